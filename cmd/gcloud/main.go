@@ -16,9 +16,26 @@ func main() {
 	config.DefaultConfDir("conf")
 	log := &logrus.Wrapper{}
 	deps := bootstrap.Instantiate(config.DefaultConfPath(), log)
+	if lvl := deps.Config.Service.LogLevel; lvl != "" {
+		err := log.SetLevel(lvl)
+		logging.LogFatalOnError(log, err, "Set log level")
+	}
 
-	httpHandler, err := httpInternal.NewHandler(deps.Guard, log, config.WebRootPath(),
-		deps.Config.Service.AllowedOrigins)
+	httpHandler, err := httpInternal.NewHandler(httpInternal.Config{
+		Guard:           deps.Guard,
+		Logger:          log,
+		BaseURL:         config.WebRootPath(),
+		AllowedOrigins:  deps.Config.Service.AllowedOrigins,
+		Manager:         deps.Manager,
+		Catalog:         deps.Catalog,
+		Prices:          deps.Prices,
+		APIKeys:         deps.APIKeys,
+		Health:          deps.Health,
+		Metrics:         deps.Metrics,
+		RateLimiter:     deps.Limiter,
+		MasterAPIKey:    deps.MasterKey,
+		ConfigOverrides: deps.Config.Overrides,
+	})
 	logging.LogFatalOnError(log, err, "Instantiate http Handler")
 
 	http.Handle("/", httpHandler)
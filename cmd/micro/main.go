@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/micro/go-micro"
+	"github.com/micro/go-micro/server"
 	"github.com/micro/go-web"
 	"github.com/tomogoma/shoppingms/pkg/api"
 	"github.com/tomogoma/shoppingms/pkg/bootstrap"
 	"github.com/tomogoma/shoppingms/pkg/config"
 	httpIntl "github.com/tomogoma/shoppingms/pkg/handler/http"
 	"github.com/tomogoma/shoppingms/pkg/handler/rpc"
+	"github.com/tomogoma/shoppingms/pkg/health"
 	"github.com/tomogoma/shoppingms/pkg/logging"
 	"github.com/tomogoma/shoppingms/pkg/logging/logrus"
 	_ "github.com/tomogoma/shoppingms/pkg/logging/standard"
@@ -22,43 +28,146 @@ func main() {
 	flag.Parse()
 	log := &logrus.Wrapper{}
 	deps := bootstrap.Instantiate(*confFile, log)
+	err := setLogLevel(log, deps.Config.Service.LogLevel)
+	logging.LogFatalOnError(log, err, "Set log level")
 
-	serverRPCQuitCh := make(chan error)
-	rpcSrv, err := rpc.NewStatusHandler(deps.Guard, log)
-	logging.LogFatalOnError(log, err, "Instantate RPC handler")
-	go serveRPC(deps.Config.Service, rpcSrv, serverRPCQuitCh)
+	ctx, stopServers := context.WithCancel(context.Background())
+	httpReqs, rpcCalls := newInFlight(), newInFlight()
 
-	serverHttpQuitCh := make(chan error)
-	httpHandler, err := httpIntl.NewHandler(deps.Guard, log, config.WebRootPath(),
-		deps.Config.Service.AllowedOrigins)
+	serverRPCQuitCh := make(chan error, 1)
+	rpcStatusSrv, err := rpc.NewStatusHandler(deps.Health, deps.Config.Overrides)
+	logging.LogFatalOnError(log, err, "Instantate RPC status handler")
+	rpcShopSrv, err := rpc.NewShoppingListsHandler(deps.Manager, deps.Catalog)
+	logging.LogFatalOnError(log, err, "Instantate RPC shopping lists handler")
+	rpcWrappers, err := rpc.Wrappers(deps.Guard, log, deps.Metrics, deps.Limiter,
+		deps.Manager, deps.APIKeys, deps.MasterKey)
+	logging.LogFatalOnError(log, err, "Instantate RPC handler wrappers")
+	rpcWrappers = append([]server.HandlerWrapper{rpcCalls.wrapper()}, rpcWrappers...)
+	rpcSrv := &readyServer{Server: server.NewServer(), health: deps.Health, log: log}
+	go serveRPC(ctx, deps.Config.Service, rpcSrv, rpcWrappers, rpcStatusSrv, rpcShopSrv, serverRPCQuitCh)
+
+	serverHttpQuitCh := make(chan error, 1)
+	httpHandler, err := httpIntl.NewHandler(httpIntl.Config{
+		Guard:           deps.Guard,
+		Logger:          log,
+		BaseURL:         config.WebRootPath(),
+		AllowedOrigins:  deps.Config.Service.AllowedOrigins,
+		Manager:         deps.Manager,
+		Catalog:         deps.Catalog,
+		Prices:          deps.Prices,
+		APIKeys:         deps.APIKeys,
+		Health:          deps.Health,
+		Metrics:         deps.Metrics,
+		RateLimiter:     deps.Limiter,
+		MasterAPIKey:    deps.MasterKey,
+		ConfigOverrides: deps.Config.Overrides,
+	})
 	logging.LogFatalOnError(log, err, "Instantiate HTTP handler")
-	go serveHttp(deps.Config.Service, httpHandler, serverHttpQuitCh)
+	go serveHttp(ctx, deps.Config.Service, httpReqs.handler(httpHandler), serverHttpQuitCh)
+
+	rldr := &reloader{
+		confFile: *confFile,
+		conf:     deps.Config,
+		log:      log,
+		http:     httpHandler,
+		limiter:  deps.Limiter,
+		master:   deps.MasterKey,
+	}
+	go rldr.watch()
 
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	var httpErr, rpcErr error
+	httpRunning, rpcRunning := true, true
 	select {
-	case err = <-serverHttpQuitCh:
-		logging.LogFatalOnError(log, err, "Serve HTTP")
-	case err = <-serverRPCQuitCh:
-		logging.LogFatalOnError(log, err, "Serve RPC")
+	case sig := <-sigCh:
+		log.Infof("Received %s, shutting down", sig)
+	case httpErr = <-serverHttpQuitCh:
+		httpRunning = false
+		logging.LogWarnOnError(log, httpErr, "Serve HTTP")
+	case rpcErr = <-serverRPCQuitCh:
+		rpcRunning = false
+		logging.LogWarnOnError(log, rpcErr, "Serve RPC")
+	}
+
+	// Deregister and stop accepting requests before draining those
+	// in progress.
+	stopServers()
+	if httpRunning {
+		err = <-serverHttpQuitCh
+		logging.LogWarnOnError(log, err, "Stop HTTP server")
+	}
+	if rpcRunning {
+		err = <-serverRPCQuitCh
+		logging.LogWarnOnError(log, err, "Stop RPC server")
+	}
+	shutdown(log, deps, deps.Config.Service.ShutdownTimeout, httpReqs, rpcCalls)
+
+	if httpErr != nil || rpcErr != nil {
+		os.Exit(1)
+	}
+	log.Infof("Shut down")
+}
+
+// flushTraces exports spans still pending in the tracer provider.
+func flushTraces(log logging.Logger, deps bootstrap.Deps) {
+	err := deps.Tracer.Shutdown(context.Background())
+	logging.LogWarnOnError(log, err, "Flush traces")
+}
+
+// readyServer only keeps the service registered while it is ready so
+// that the registry routes around unhealthy instances. Readiness is
+// re-evaluated every time the service re-registers (see
+// micro.RegisterInterval()).
+type readyServer struct {
+	server.Server
+	health *health.Health
+	log    logging.Logger
+}
+
+func (s *readyServer) Register() error {
+	rprt := s.health.Ready(context.Background())
+	if rprt.Healthy {
+		return s.Server.Register()
+	}
+	for _, r := range rprt.Checks {
+		if !r.Healthy {
+			s.log.Warnf("Not ready, %s unhealthy: %v", r.Name, r.Err)
+		}
+	}
+	if err := s.Server.Deregister(); err != nil {
+		s.log.Warnf("Deregister unready service: %v", err)
 	}
+	return nil
 }
 
-func serveRPC(conf config.Service, rpcSrv *rpc.StatusHandler, quitCh chan error) {
+// serveRPC serves all RPC handlers on srv wrapped with wrappers until ctx
+// is done, when the service is deregistered and srv is stopped.
+func serveRPC(ctx context.Context, conf config.Service, srv server.Server, wrappers []server.HandlerWrapper,
+	rpcStatusSrv *rpc.StatusHandler, rpcShopSrv *rpc.ShoppingListsHandler, quitCh chan error) {
 	service := micro.NewService(
+		micro.Server(srv),
 		micro.Name(config.CanonicalRPCName()),
 		micro.Version(conf.LoadBalanceVersion),
 		micro.RegisterInterval(conf.RegisterInterval),
+		micro.WrapHandler(wrappers...),
+		micro.Context(ctx),
 	)
-	api.RegisterStatusHandler(service.Server(), rpcSrv)
+	api.RegisterStatusHandler(service.Server(), rpcStatusSrv)
+	api.RegisterShoppingListsHandler(service.Server(), rpcShopSrv)
 	err := service.Run()
 	quitCh <- err
 }
 
-func serveHttp(conf config.Service, h http.Handler, quitCh chan error) {
+// serveHttp serves h until ctx is done, when the service is deregistered
+// and stops accepting connections.
+func serveHttp(ctx context.Context, conf config.Service, h http.Handler, quitCh chan error) {
 	srvc := web.NewService(
 		web.Handler(h),
 		web.Name(config.CanonicalWebName()),
 		web.Version(conf.LoadBalanceVersion),
 		web.RegisterInterval(conf.RegisterInterval),
+		web.Context(ctx),
 	)
 	quitCh <- srvc.Run()
 }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+	"github.com/tomogoma/shoppingms/pkg/bootstrap"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+)
+
+// reloadCheckInterval is how often the config file is checked for
+// changes.
+const reloadCheckInterval = 10 * time.Second
+
+// levelLogger is a logging.Logger whose level can be changed e.g.
+// *logrus.Wrapper.
+type levelLogger interface {
+	logging.Logger
+	SetLevel(level string) error
+}
+
+// limitSetter is implemented by *ratelimit.Limiter.
+type limitSetter interface {
+	SetLimits(apiKey, user ratelimit.Limit) error
+}
+
+// originSetter is implemented by the HTTP handler.
+type originSetter interface {
+	SetAllowedOrigins(origins []string)
+}
+
+// reloader re-reads the config file on SIGHUP or when the file changes and
+// applies the values that can be changed while running. Changes to other
+// values are logged as needing a restart.
+type reloader struct {
+	confFile string
+	// conf is the running config, it only holds the values of the config
+	// file that were applied.
+	conf    config.General
+	modTime time.Time
+
+	log     levelLogger
+	http    originSetter
+	limiter limitSetter
+	master  *apikeys.MasterKey
+}
+
+// watch reloads the config until the process exits.
+func (r *reloader) watch() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	r.modTime = r.fileModTime()
+	tkr := time.NewTicker(reloadCheckInterval)
+	defer tkr.Stop()
+	for {
+		select {
+		case <-sigCh:
+			r.log.Infof("Received SIGHUP, reloading config")
+		case <-tkr.C:
+			modTime := r.fileModTime()
+			if modTime.Equal(r.modTime) {
+				continue
+			}
+			r.log.Infof("Config file changed, reloading config")
+		}
+		r.modTime = r.fileModTime()
+		r.reload()
+	}
+}
+
+func (r *reloader) fileModTime() time.Time {
+	fi, err := os.Stat(r.confFile)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+// reload applies the config file if it is valid, otherwise the running
+// config is kept. Values that fail to apply or need a restart are left out
+// of the running config so that the next reload tries or reports them
+// again.
+func (r *reloader) reload() {
+	conf, err := config.ReadFile(r.confFile)
+	if err != nil {
+		r.log.Errorf("Reload config, keeping the running config: %v", err)
+		return
+	}
+
+	running := r.conf
+	var applied, limits, needRestart []string
+	for _, path := range config.Changed(r.conf, conf) {
+		switch path {
+		case "serviceConfig.allowedOrigins":
+			r.http.SetAllowedOrigins(conf.Service.AllowedOrigins)
+			running.Service.AllowedOrigins = conf.Service.AllowedOrigins
+		case "serviceConfig.masterAPIKey":
+			r.master.Set(conf.Service.MasterAPIKey)
+			running.Service.MasterAPIKey = conf.Service.MasterAPIKey
+		case "serviceConfig.logLevel":
+			if err := setLogLevel(r.log, conf.Service.LogLevel); err != nil {
+				r.log.Errorf("Reload config: set log level: %v", err)
+				continue
+			}
+			running.Service.LogLevel = conf.Service.LogLevel
+		case "serviceConfig.rateLimits.perAPIKey.requestsPerSecond",
+			"serviceConfig.rateLimits.perAPIKey.burst",
+			"serviceConfig.rateLimits.perUser.requestsPerSecond",
+			"serviceConfig.rateLimits.perUser.burst":
+			limits = append(limits, path)
+			continue
+		default:
+			needRestart = append(needRestart, path)
+			continue
+		}
+		applied = append(applied, path)
+	}
+	if len(limits) > 0 {
+		err := r.limiter.SetLimits(
+			bootstrap.RateLimit(conf.Service.RateLimits.PerAPIKey),
+			bootstrap.RateLimit(conf.Service.RateLimits.PerUser),
+		)
+		if err != nil {
+			r.log.Errorf("Reload config: set rate limits: %v", err)
+		} else {
+			applied = append(applied, limits...)
+			running.Service.RateLimits.PerAPIKey = conf.Service.RateLimits.PerAPIKey
+			running.Service.RateLimits.PerUser = conf.Service.RateLimits.PerUser
+		}
+	}
+
+	if len(applied) == 0 && len(needRestart) == 0 {
+		r.log.Infof("Reloaded config, nothing changed")
+	}
+	if len(applied) > 0 {
+		r.log.Infof("Reloaded config, applied changes to %s",
+			strings.Join(applied, ", "))
+	}
+	if len(needRestart) > 0 {
+		r.log.Warnf("Reloaded config, changes to %s need a restart to take effect",
+			strings.Join(needRestart, ", "))
+	}
+	r.conf = running
+}
+
+// setLogLevel sets the level of log to level, the default being info.
+func setLogLevel(log levelLogger, level string) error {
+	if level == "" {
+		level = config.LogLevelInfo
+	}
+	return log.SetLevel(level)
+}
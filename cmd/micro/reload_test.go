@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/mocks"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+)
+
+type levelLoggerMock struct {
+	mocks.Logger
+	setLevelErr error
+	level       string
+}
+
+func (lg *levelLoggerMock) SetLevel(level string) error {
+	if lg.setLevelErr != nil {
+		return lg.setLevelErr
+	}
+	lg.level = level
+	return nil
+}
+
+type limitSetterMock struct {
+	setLimitsErr error
+	user         ratelimit.Limit
+}
+
+func (lm *limitSetterMock) SetLimits(apiKey, user ratelimit.Limit) error {
+	if lm.setLimitsErr != nil {
+		return lm.setLimitsErr
+	}
+	lm.user = user
+	return nil
+}
+
+type originSetterMock struct {
+	origins []string
+}
+
+func (o *originSetterMock) SetAllowedOrigins(origins []string) {
+	o.origins = origins
+}
+
+func TestReloader_reload_failed(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "jwt.key")
+	if err := ioutil.WriteFile(keyFile, []byte("secret"), 0600); err != nil {
+		t.Fatalf("Error setting up: write key file: %v", err)
+	}
+	confFile := filepath.Join(dir, "conf.yml")
+	writeConf := func(logLevel, origin string, perUserRate float64) {
+		conf := fmt.Sprintf(`
+serviceConfig:
+  authTokenKeyFile: %s
+  allowedOrigins: [%q]
+  logLevel: %s
+  rateLimits:
+    perUser:
+      requestsPerSecond: %g
+      burst: 1
+storage:
+  backend: memory
+`, keyFile, origin, logLevel, perUserRate)
+		if err := ioutil.WriteFile(confFile, []byte(conf), 0600); err != nil {
+			t.Fatalf("Error setting up: write config file: %v", err)
+		}
+	}
+
+	writeConf(config.LogLevelInfo, "https://a.example.com", 1)
+	running, err := config.ReadFile(confFile)
+	if err != nil {
+		t.Fatalf("Error setting up: read config file: %v", err)
+	}
+	lg := &levelLoggerMock{setLevelErr: errors.New("unknown level")}
+	lm := &limitSetterMock{setLimitsErr: errors.New("invalid limit")}
+	origins := &originSetterMock{}
+	r := &reloader{
+		confFile: confFile,
+		conf:     running,
+		log:      lg,
+		http:     origins,
+		limiter:  lm,
+		master:   apikeys.NewMasterKey(""),
+	}
+
+	writeConf(config.LogLevelDebug, "https://b.example.com", 2)
+	r.reload()
+	expOrigins := []string{"https://b.example.com"}
+	if !reflect.DeepEqual(origins.origins, expOrigins) {
+		t.Errorf("Expected origins %v applied, got %v", expOrigins, origins.origins)
+	}
+	if !reflect.DeepEqual(r.conf.Service.AllowedOrigins, expOrigins) {
+		t.Errorf("Expected running origins %v, got %v", expOrigins, r.conf.Service.AllowedOrigins)
+	}
+	if r.conf.Service.LogLevel != config.LogLevelInfo {
+		t.Errorf("Expected running log level %s after failing to set it, got %s",
+			config.LogLevelInfo, r.conf.Service.LogLevel)
+	}
+	if r.conf.Service.RateLimits != running.Service.RateLimits {
+		t.Errorf("Expected running rate limits %+v after failing to set them, got %+v",
+			running.Service.RateLimits, r.conf.Service.RateLimits)
+	}
+
+	// The next reload retries what failed even though the file is the same.
+	lg.setLevelErr, lm.setLimitsErr = nil, nil
+	r.reload()
+	if lg.level != config.LogLevelDebug || r.conf.Service.LogLevel != config.LogLevelDebug {
+		t.Errorf("Expected log level %s on retry, got %s (running %s)",
+			config.LogLevelDebug, lg.level, r.conf.Service.LogLevel)
+	}
+	if lm.user.Rate != 2 || r.conf.Service.RateLimits.PerUser.RequestsPerSecond != 2 {
+		t.Errorf("Expected per user rate 2 on retry, got %+v (running %+v)",
+			lm.user, r.conf.Service.RateLimits.PerUser)
+	}
+}
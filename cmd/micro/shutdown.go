@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/bootstrap"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/logging"
+)
+
+// inFlight counts the requests being served so that shutdown can wait for
+// them to complete after the servers stop accepting new ones.
+// Use newInFlight() to instantiate.
+type inFlight struct {
+	mu       sync.Mutex
+	count    int
+	draining bool
+	drained  chan struct{}
+}
+
+func newInFlight() *inFlight {
+	return &inFlight{drained: make(chan struct{})}
+}
+
+func (f *inFlight) start() {
+	f.mu.Lock()
+	f.count++
+	f.mu.Unlock()
+}
+
+func (f *inFlight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.count--
+	f.closeIfDrained()
+}
+
+// closeIfDrained must be called with f.mu held.
+func (f *inFlight) closeIfDrained() {
+	if !f.draining || f.count > 0 {
+		return
+	}
+	select {
+	case <-f.drained:
+	default:
+		close(f.drained)
+	}
+}
+
+func (f *inFlight) isDraining() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.draining
+}
+
+// drain waits until no requests are in flight or ctx is done.
+func (f *inFlight) drain(ctx context.Context) error {
+	f.mu.Lock()
+	f.draining = true
+	f.closeIfDrained()
+	f.mu.Unlock()
+	select {
+	case <-f.drained:
+		return nil
+	case <-ctx.Done():
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return errors.Newf("%d requests still in progress: %v", f.count, ctx.Err())
+	}
+}
+
+// handler counts requests to next. Responses sent while draining close
+// the connection so that clients reconnect to another instance.
+func (f *inFlight) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.start()
+		defer f.done()
+		if f.isDraining() {
+			w.Header().Set("Connection", "close")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// wrapper counts RPC calls, it should be the outermost handler wrapper.
+func (f *inFlight) wrapper() server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			f.start()
+			defer f.done()
+			return next(ctx, req, rsp)
+		}
+	}
+}
+
+// shutdown waits up to timeout for the HTTP and RPC requests in progress
+// to complete, then flushes traces and closes the storage. The servers
+// must have stopped accepting requests.
+func shutdown(log logging.Logger, deps bootstrap.Deps, timeout time.Duration, httpReqs, rpcCalls *inFlight) {
+	if timeout == 0 {
+		timeout = config.DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for name, f := range map[string]*inFlight{"HTTP": httpReqs, "RPC": rpcCalls} {
+		wg.Add(1)
+		go func(name string, f *inFlight) {
+			defer wg.Done()
+			err := f.drain(ctx)
+			logging.LogWarnOnError(log, err, "Drain "+name+" requests")
+		}(name, f)
+	}
+	wg.Wait()
+
+	flushTraces(log, deps)
+	err := deps.Storage.Close()
+	logging.LogWarnOnError(log, err, "Close storage")
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/bootstrap"
+	"github.com/tomogoma/shoppingms/pkg/db/memory"
+	"github.com/tomogoma/shoppingms/pkg/mocks"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// closeRecorder records whether the requests in flight had drained when
+// the storage was closed.
+type closeRecorder struct {
+	*memory.Memory
+	reqs          *inFlight
+	closed        bool
+	closedDrained bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	select {
+	case <-c.reqs.drained:
+		c.closedDrained = true
+	default:
+	}
+	return c.Memory.Close()
+}
+
+func TestInFlight_drain(t *testing.T) {
+	tcs := []struct {
+		name    string
+		started int
+		// doneAfter completes the requests started this long after drain
+		// starts. Requests are left in flight if it is negative.
+		doneAfter time.Duration
+		timeout   time.Duration
+		expErr    string
+	}{
+		{name: "nothing in flight", timeout: time.Second},
+		{name: "waits for requests in flight", started: 2,
+			doneAfter: 50 * time.Millisecond, timeout: time.Second},
+		{name: "timeout", started: 3, doneAfter: -1,
+			timeout: 50 * time.Millisecond, expErr: "3 requests still in progress"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newInFlight()
+			for i := 0; i < tc.started; i++ {
+				f.start()
+			}
+			if tc.doneAfter >= 0 {
+				time.AfterFunc(tc.doneAfter, func() {
+					for i := 0; i < tc.started; i++ {
+						f.done()
+					}
+				})
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := f.drain(ctx)
+			if tc.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+					t.Fatalf("Expected error containing %q, got %v", tc.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < tc.doneAfter {
+				t.Errorf("Expected drain to wait %s for requests in flight, returned after %s",
+					tc.doneAfter, elapsed)
+			}
+			if !f.isDraining() {
+				t.Errorf("Expected draining after drain")
+			}
+		})
+	}
+}
+
+func TestInFlight_handler(t *testing.T) {
+	f := newInFlight()
+	h := f.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if conn := rec.Header().Get("Connection"); conn != "" {
+		t.Errorf("Expected no Connection header before draining, got %q", conn)
+	}
+
+	if err := f.drain(context.Background()); err != nil {
+		t.Fatalf("Error setting up: drain: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if conn := rec.Header().Get("Connection"); conn != "close" {
+		t.Errorf("Expected Connection: close while draining, got %q", conn)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	httpReqs, rpcCalls := newInFlight(), newInFlight()
+	db := &closeRecorder{Memory: memory.NewMemory(), reqs: httpReqs}
+	deps := bootstrap.Deps{Storage: db, Tracer: sdktrace.NewTracerProvider()}
+	lg := &mocks.Logger{}
+
+	httpReqs.start()
+	time.AfterFunc(50*time.Millisecond, httpReqs.done)
+
+	shutdown(lg, deps, time.Second, httpReqs, rpcCalls)
+	if !db.closed {
+		t.Fatalf("Expected storage closed")
+	}
+	if !db.closedDrained {
+		t.Errorf("Expected storage closed after the requests in flight completed")
+	}
+	if len(lg.Spinoffs) > 0 || len(lg.Logs) > 0 {
+		lg.PrintLogs(t)
+		t.Errorf("Expected nothing logged")
+	}
+}
+
+func TestShutdown_timeout(t *testing.T) {
+	httpReqs, rpcCalls := newInFlight(), newInFlight()
+	db := &closeRecorder{Memory: memory.NewMemory(), reqs: rpcCalls}
+	deps := bootstrap.Deps{Storage: db, Tracer: sdktrace.NewTracerProvider()}
+	lg := &mocks.Logger{}
+
+	rpcCalls.start()
+	shutdown(lg, deps, 50*time.Millisecond, httpReqs, rpcCalls)
+	if !db.closed {
+		t.Fatalf("Expected storage closed after the timeout")
+	}
+	if len(lg.Spinoffs) != 1 || len(lg.Spinoffs[0].Logs) != 1 ||
+		lg.Spinoffs[0].Logs[0].Level != mocks.LevelWarn {
+		lg.PrintLogs(t)
+		t.Fatalf("Expected the RPC calls still in progress logged as a warning")
+	}
+	if msg := lg.Spinoffs[0].Logs[0].Args; len(msg) != 1 ||
+		!strings.Contains(msg[0].(error).Error(), "1 requests still in progress") {
+		t.Errorf("Expected the remaining count logged, got %v", msg)
+	}
+}
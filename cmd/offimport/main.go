@@ -0,0 +1,114 @@
+// offimport loads Items, Brands, MeasuringUnits and barcodes into the
+// shared catalog from a local Open Food Facts CSV or JSONL dump
+// (optionally gzip compressed). It is safe to re-run.
+// Usage:
+//
+//	offimport -file en.openfoodfacts.org.products.csv.gz [-conf /etc/shoppingms/shoppingmsv0.conf.yml]
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/bootstrap"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/logging/logrus"
+	"github.com/tomogoma/shoppingms/pkg/off"
+)
+
+const (
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+)
+
+func main() {
+
+	confFile := flag.String("conf", config.DefaultConfPath(), "location of config file")
+	file := flag.String("file", "", "location of the Open Food Facts dump to import")
+	format := flag.String("format", "", "format of the dump: "+formatCSV+" or "+
+		formatJSONL+" (default: guessed from the file extension)")
+	batchSize := flag.Int("batch", off.DefaultBatchSize, "number of products to insert per transaction"+
+		" (max "+strconv.Itoa(off.MaxBatchSize)+")")
+	flag.Parse()
+
+	log := &logrus.Wrapper{}
+	if *file == "" {
+		log.Fatal("-file is required")
+	}
+	if *batchSize < 1 || *batchSize > off.MaxBatchSize {
+		log.Fatalf("-batch must be within 1-%d, got %d", off.MaxBatchSize, *batchSize)
+	}
+	if *format == "" {
+		*format = guessFormat(*file)
+	}
+
+	conf, err := config.ReadFile(*confFile)
+	logging.LogFatalOnError(log, err, "Read config file")
+	db := bootstrap.InstantiateStorage(log, conf, nil)
+
+	start := time.Now()
+	loader, err := off.NewLoader(db, off.WithBatchSize(*batchSize),
+		off.WithProgress(func(r off.Report) {
+			log.Infof("read %d products, loaded %d, skipped %d (%.0f/s)",
+				r.Read, r.Loaded, r.Skipped, float64(r.Read)/time.Since(start).Seconds())
+		}))
+	logging.LogFatalOnError(log, err, "Instantiate loader")
+
+	err = readDump(*file, *format, loader.Add)
+	logging.LogFatalOnError(log, err, "Read dump")
+	logging.LogFatalOnError(log, loader.Flush(), "Load final batch")
+
+	r := loader.Report()
+	log.WithFields(map[string]interface{}{
+		"read":     r.Read,
+		"loaded":   r.Loaded,
+		"skipped":  r.Skipped,
+		"batches":  r.Batches,
+		"duration": time.Since(start).String(),
+	}).Info("import complete")
+}
+
+func readDump(fName, format string, fn func(off.Product) error) error {
+	f, err := os.Open(fName)
+	if err != nil {
+		return errors.Newf("open dump: %v", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(strings.ToLower(fName), ".gz") {
+		gzr, err := gzip.NewReader(f)
+		if err != nil {
+			return errors.Newf("open gzip stream: %v", err)
+		}
+		defer gzr.Close()
+		r = gzr
+	}
+
+	switch format {
+	case formatCSV:
+		return off.ReadCSV(r, fn)
+	case formatJSONL:
+		return off.ReadJSONL(r, fn)
+	default:
+		return errors.Newf("unsupported format '%s'", format)
+	}
+}
+
+func guessFormat(fName string) string {
+	ext := filepath.Ext(strings.TrimSuffix(strings.ToLower(fName), ".gz"))
+	switch ext {
+	case ".jsonl", ".json":
+		return formatJSONL
+	default:
+		return formatCSV
+	}
+}
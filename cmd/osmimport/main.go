@@ -0,0 +1,107 @@
+// osmimport creates or updates Store and StoreBranch records from a local
+// OpenStreetMap extract (.osm.pbf or GeoJSON FeatureCollection).
+// It is safe to re-run against the same or a newer extract.
+// Usage:
+//
+//	osmimport -file kenya-latest.osm.pbf [-conf /etc/shoppingms/shoppingmsv0.conf.yml]
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/bootstrap"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/logging/logrus"
+	"github.com/tomogoma/shoppingms/pkg/osm"
+)
+
+const (
+	formatPBF     = "pbf"
+	formatGeoJSON = "geojson"
+)
+
+func main() {
+
+	confFile := flag.String("conf", config.DefaultConfPath(), "location of config file")
+	file := flag.String("file", "", "location of the .osm.pbf or .geojson extract to import")
+	format := flag.String("format", "", "format of the extract: "+formatPBF+" or "+
+		formatGeoJSON+" (default: guessed from the file extension)")
+	shops := flag.String("shops", strings.Join(osm.DefaultShopTypes, ","),
+		"comma separated values of the OSM shop tag to import")
+	radius := flag.Float64("radius", osm.DefaultMatchRadius, "distance in meters within"+
+		" which an existing branch with the same name is treated as the same branch")
+	flag.Parse()
+
+	log := &logrus.Wrapper{}
+	if *file == "" {
+		log.Fatal("-file is required")
+	}
+	if *format == "" {
+		*format = guessFormat(*file)
+	}
+
+	conf, err := config.ReadFile(*confFile)
+	logging.LogFatalOnError(log, err, "Read config file")
+	db := bootstrap.InstantiateStorage(log, conf, nil)
+
+	im, err := osm.NewImporter(db, osm.WithMatchRadius(*radius))
+	logging.LogFatalOnError(log, err, "Instantiate importer")
+
+	report := &osm.Report{}
+	skip := func(osmID string, reason error) {
+		report.Skipped++
+		log.WithField("osmID", osmID).Warnf("skipped: %v", reason)
+	}
+	importPlace := func(p osm.Place) error {
+		action, err := im.Import(p)
+		if err != nil {
+			skip(p.ID, err)
+			return nil
+		}
+		report.Add(action)
+		if total := report.Total(); total%1000 == 0 {
+			log.Infof("imported %d places", total)
+		}
+		return nil
+	}
+
+	err = readExtract(*file, *format, osm.ShopFilter(strings.Split(*shops, ",")...), importPlace, skip)
+	logging.LogFatalOnError(log, err, "Read extract")
+
+	log.WithFields(map[string]interface{}{
+		"created":   report.Created,
+		"updated":   report.Updated,
+		"unchanged": report.Unchanged,
+		"skipped":   report.Skipped,
+	}).Info("import complete")
+}
+
+func readExtract(fName, format string, keep osm.Filter, fn func(osm.Place) error, skip osm.SkipFunc) error {
+	switch format {
+	case formatPBF:
+		return osm.ReadPBFFile(fName, keep, fn, skip)
+	case formatGeoJSON:
+		f, err := os.Open(fName)
+		if err != nil {
+			return errors.Newf("open GeoJSON file: %v", err)
+		}
+		defer f.Close()
+		return osm.ReadGeoJSON(f, keep, fn, skip)
+	default:
+		return errors.Newf("unsupported format '%s'", format)
+	}
+}
+
+func guessFormat(fName string) string {
+	switch strings.ToLower(filepath.Ext(fName)) {
+	case ".pbf":
+		return formatPBF
+	default:
+		return formatGeoJSON
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	apiG "github.com/tomogoma/go-api-guard"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+	"github.com/tomogoma/shoppingms/pkg/config"
+)
+
+// apiKey is the output form of an api.Key. Key is only set when the key
+// was just created or rotated.
+type apiKey struct {
+	ID       string   `json:"ID"`
+	UserID   string   `json:"userID"`
+	Prefix   string   `json:"prefix"`
+	Key      string   `json:"key,omitempty"`
+	Scopes   []string `json:"scopes"`
+	Origins  []string `json:"origins,omitempty"`
+	Expires  string   `json:"expires,omitempty"`
+	LastUsed string   `json:"lastUsed,omitempty"`
+	Created  string   `json:"created"`
+}
+
+func toAPIKey(k api.Key) apiKey {
+	return apiKey{
+		ID:       k.ID,
+		UserID:   k.UserID,
+		Prefix:   k.Prefix,
+		Key:      string(k.Val),
+		Scopes:   k.Scopes,
+		Origins:  k.Origins,
+		Expires:  formatTimeIfSet(k.Expires),
+		LastUsed: formatTimeIfSet(k.LastUsed),
+		Created:  formatTimeIfSet(k.Created),
+	}
+}
+
+func formatTimeIfSet(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(config.TimeFormat)
+}
+
+func writeAPIKeys(e env, ks []apiKey) error {
+	t := table{header: []string{"ID", "USER", "PREFIX", "SCOPES", "ORIGINS",
+		"EXPIRES", "LAST USED", "CREATED"}}
+	for _, k := range ks {
+		t.add(k.ID, k.UserID, k.Prefix, strings.Join(k.Scopes, ","),
+			strings.Join(k.Origins, ","), k.Expires, k.LastUsed, k.Created)
+	}
+	return e.out.write(ks, t)
+}
+
+// writeNewAPIKey writes k including its value, which cannot be retrieved
+// again.
+func writeNewAPIKey(e env, k api.Key) error {
+	ak := toAPIKey(k)
+	t := table{}
+	t.add("ID:", ak.ID)
+	t.add("User:", ak.UserID)
+	t.add("Key:", ak.Key)
+	t.add("Scopes:", strings.Join(ak.Scopes, ","))
+	t.add("Origins:", strings.Join(ak.Origins, ","))
+	t.add("Expires:", ak.Expires)
+	t.add("", "Store the key now, it cannot be shown again.")
+	return e.out.write(ak, t)
+}
+
+func runAPIKeys(ctx context.Context, e env, args []string) error {
+	sub, args, err := subcommand("apikeys", args, "create", "list", "rotate", "revoke")
+	if err != nil {
+		return err
+	}
+
+	m, err := apikeys.NewManager(e.db,
+		apikeys.GuardGenerator(apiG.WithMasterKey(e.conf.Service.MasterAPIKey)))
+	if err != nil {
+		return errors.Newf("instantiate API key manager: %v", err)
+	}
+
+	fs := newFlagSet("apikeys " + sub)
+	switch sub {
+
+	case "create":
+		userID := fs.String("user", "", "ID of the client app user to create the key for")
+		scopes := fs.String("scopes", "", "comma separated scopes to grant (default: "+
+			strings.Join(apikeys.DefaultScopes, ",")+")")
+		origins := fs.String("origins", "", "comma separated origins the key may be used from (default: any)")
+		expires := fs.String("expires", "", "when the key stops being valid in "+config.TimeFormat+" (default: never)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		p := apikeys.Policy{Scopes: splitList(*scopes), Origins: splitList(*origins)}
+		if *expires != "" {
+			if p.Expires, err = time.Parse(config.TimeFormat, *expires); err != nil {
+				return errors.Newf("invalid -expires: %v", err)
+			}
+		}
+		k, err := m.Create(ctx, *userID, p)
+		if err != nil {
+			return err
+		}
+		return writeNewAPIKey(e, *k)
+
+	case "list":
+		userID := fs.String("user", "", "only list keys of this client app user")
+		offset := fs.Int64("offset", 0, "number of keys to skip")
+		count := fs.Int64("count", 100, "maximum number of keys to list")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		ks, err := m.Keys(ctx, *userID, *offset, *count)
+		if err != nil && !e.db.IsNotFoundError(err) {
+			return err
+		}
+		out := make([]apiKey, len(ks))
+		for i, k := range ks {
+			out[i] = toAPIKey(k)
+		}
+		return writeAPIKeys(e, out)
+
+	case "rotate":
+		ID := fs.String("id", "", "ID of the key to rotate")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		k, err := m.Rotate(ctx, *ID)
+		if err != nil {
+			return err
+		}
+		return writeNewAPIKey(e, *k)
+
+	default: // revoke
+		ID := fs.String("id", "", "ID of the key to revoke")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if err := m.Revoke(ctx, *ID); err != nil {
+			return err
+		}
+		return e.out.message("revoked API key " + *ID)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type catalogEntry struct {
+	ID   string `json:"ID"`
+	Name string `json:"name"`
+}
+
+type duplicateGroup struct {
+	Score   float64        `json:"score"`
+	Entries []catalogEntry `json:"entries"`
+}
+
+// runCatalog finds ("duplicates") or merges ("merge") duplicate items or
+// brands in the shared catalog.
+func runCatalog(ctx context.Context, e env, args []string) error {
+	sub, args, err := subcommand("catalog", args, "duplicates", "merge")
+	if err != nil {
+		return err
+	}
+	kind, args, err := subcommand("catalog "+sub, args, "items", "brands")
+	if err != nil {
+		return err
+	}
+	cat, err := shopping.NewCatalog(e.db)
+	if err != nil {
+		return errors.Newf("instantiate catalog: %v", err)
+	}
+
+	fs := newFlagSet("catalog " + sub + " " + kind)
+	if sub == "duplicates" {
+		threshold := fs.Float64("threshold", shopping.DefaultSimilarityThreshold,
+			"minimum name similarity (0, 1] at which entries are reported as duplicates")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		return findDuplicates(ctx, e, cat, kind, *threshold)
+	}
+
+	survivorID := fs.String("survivor", "", "ID of the entry to merge the duplicates into")
+	duplicateIDs := fs.String("duplicates", "", "comma separated IDs of the entries to merge")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var merged catalogEntry
+	if kind == "items" {
+		i, err := cat.MergeItems(ctx, *survivorID, splitList(*duplicateIDs))
+		if err != nil {
+			return err
+		}
+		merged = catalogEntry{ID: i.ID, Name: i.Name}
+	} else {
+		b, err := cat.MergeBrands(ctx, *survivorID, splitList(*duplicateIDs))
+		if err != nil {
+			return err
+		}
+		merged = catalogEntry{ID: b.ID, Name: b.Name}
+	}
+	t := table{header: []string{"ID", "NAME"}}
+	t.add(merged.ID, merged.Name)
+	return e.out.write(merged, t)
+}
+
+func findDuplicates(ctx context.Context, e env, cat *shopping.Catalog, kind string, threshold float64) error {
+	var groups []shopping.DuplicateGroup
+	var err error
+	if kind == "items" {
+		groups, err = cat.DuplicateItems(ctx, threshold)
+	} else {
+		groups, err = cat.DuplicateBrands(ctx, threshold)
+	}
+	if err != nil && !e.db.IsNotFoundError(err) {
+		return err
+	}
+
+	out := make([]duplicateGroup, len(groups))
+	t := table{header: []string{"SCORE", "IDS", "NAMES"}}
+	for i, g := range groups {
+		out[i].Score = g.Score
+		IDs := make([]string, len(g.Entries))
+		names := make([]string, len(g.Entries))
+		for j, en := range g.Entries {
+			out[i].Entries = append(out[i].Entries, catalogEntry{ID: en.ID, Name: en.Name})
+			IDs[j], names[j] = en.ID, en.Name
+		}
+		t.add(fmt.Sprintf("%.2f", g.Score), strings.Join(IDs, ","), strings.Join(names, " | "))
+	}
+	return e.out.write(out, t)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type shoppingList struct {
+	ID          string `json:"ID"`
+	UserID      string `json:"userID"`
+	Name        string `json:"name"`
+	Mode        string `json:"mode"`
+	Created     string `json:"created"`
+	LastUpdated string `json:"lastUpdated"`
+}
+
+type shoppingListItem struct {
+	ID       string  `json:"ID"`
+	Item     string  `json:"item"`
+	Brand    string  `json:"brand"`
+	Unit     string  `json:"measuringUnit"`
+	Quantity int     `json:"quantity"`
+	InList   bool    `json:"inList"`
+	InCart   bool    `json:"inCart"`
+	Price    float32 `json:"price,omitempty"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// runLists looks up the shopping lists of a user.
+func runLists(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet("lists")
+	userID := fs.String("user", "", "ID of the user whose lists to look up")
+	offset := fs.Int64("offset", 0, "number of lists to skip")
+	count := fs.Int64("count", 100, "maximum number of lists to return")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *userID == "" {
+		return errors.New("-user is required")
+	}
+
+	sls, err := e.db.ShoppingLists(ctx, *userID, *offset, *count)
+	if err != nil && !e.db.IsNotFoundError(err) {
+		return err
+	}
+	out := make([]shoppingList, len(sls))
+	t := table{header: []string{"ID", "NAME", "MODE", "CREATED", "LAST UPDATED"}}
+	for i, sl := range sls {
+		out[i] = shoppingList{ID: sl.ID, UserID: sl.UserID, Name: sl.Name,
+			Mode: sl.Mode, Created: sl.Created, LastUpdated: sl.LastUpdated}
+		t.add(sl.ID, sl.Name, sl.Mode, sl.Created, sl.LastUpdated)
+	}
+	return e.out.write(out, t)
+}
+
+// runItems looks up the items in one of a user's shopping lists.
+func runItems(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet("items")
+	userID := fs.String("user", "", "ID of the user who owns the list")
+	listID := fs.String("list", "", "ID of the shopping list")
+	offset := fs.Int64("offset", 0, "number of items to skip")
+	count := fs.Int64("count", 100, "maximum number of items to return")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *userID == "" || *listID == "" {
+		return errors.New("-user and -list are required")
+	}
+
+	sis, err := e.db.ShoppingListItems(ctx, *userID, *listID, *offset, *count)
+	if err != nil && !e.db.IsNotFoundError(err) {
+		return err
+	}
+	out := make([]shoppingListItem, len(sis))
+	t := table{header: []string{"ID", "ITEM", "BRAND", "UNIT", "QUANTITY",
+		"IN LIST", "IN CART", "PRICE"}}
+	for i, si := range sis {
+		out[i] = toShoppingListItem(si)
+		price := ""
+		if out[i].Price != 0 {
+			price = fmt.Sprintf("%.2f %s", out[i].Price, out[i].Currency)
+		}
+		t.add(si.ID, out[i].Item, out[i].Brand, out[i].Unit, strconv.Itoa(si.Quantity),
+			strconv.FormatBool(si.InList), strconv.FormatBool(si.InCart), price)
+	}
+	return e.out.write(out, t)
+}
+
+func toShoppingListItem(si shopping.ShoppingListItem) shoppingListItem {
+	return shoppingListItem{
+		ID:       si.ID,
+		Item:     si.Price.Brand.Item.Name,
+		Brand:    si.Price.Brand.Name,
+		Unit:     si.Price.Brand.MeasuringUnit.Name,
+		Quantity: si.Quantity,
+		InList:   si.InList,
+		InCart:   si.InCart,
+		Price:    si.Price.Value,
+		Currency: si.Price.Currency,
+	}
+}
@@ -0,0 +1,144 @@
+// shoppingmsctl administers a shoppingms deployment using the same config
+// file as the service: it runs and inspects DB migrations, manages API keys,
+// looks up users' shopping lists, merges duplicate catalog entries and loads
+// seed/fixture data.
+// Usage:
+//
+//	shoppingmsctl [-conf /etc/shoppingms/shoppingmsv0.conf.yml] [-output text|json] <command> [<args>]
+//
+// Commands:
+//
+//	migrate status|up
+//	apikeys create -user <userID> [-scopes a,b] [-origins a,b] [-expires RFC3339]
+//	apikeys list [-user <userID>] [-offset 0] [-count 100]
+//	apikeys rotate -id <keyID>
+//	apikeys revoke -id <keyID>
+//	lists -user <userID> [-offset 0] [-count 100]
+//	items -user <userID> -list <listID> [-offset 0] [-count 100]
+//	catalog duplicates items|brands [-threshold 0.85]
+//	catalog merge items|brands -survivor <ID> -duplicates <ID,ID>
+//	seed -file fixtures.json
+//
+// Only "migrate up" creates or migrates the DB schema, the other commands
+// fail unless it is up to date.
+//
+// Run a command with -h for its flags.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/bootstrap"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/logging/logrus"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// env is the state shared by all commands.
+type env struct {
+	conf config.General
+	db   shopping.Storage
+	out  output
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, e env, args []string) error
+}
+
+var commands = map[string]command{
+	"migrate": {usage: "run or inspect DB migrations", run: runMigrate},
+	"apikeys": {usage: "create, list, rotate and revoke API keys", run: runAPIKeys},
+	"lists":   {usage: "list a user's shopping lists", run: runLists},
+	"items":   {usage: "list the items in a user's shopping list", run: runItems},
+	"catalog": {usage: "find and merge duplicate items and brands", run: runCatalog},
+	"seed":    {usage: "load seed/fixture data from a JSON file", run: runSeed},
+}
+
+func main() {
+
+	confFile := flag.String("conf", config.DefaultConfPath(), "location of config file")
+	format := flag.String("output", formatText, "output format: "+formatText+" or "+formatJSON)
+	flag.Usage = usage
+	flag.Parse()
+
+	log := &logrus.Wrapper{}
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	out, err := newOutput(os.Stdout, *format)
+	logging.LogFatalOnError(log, err, "Set up output")
+
+	conf, err := config.ReadFile(*confFile)
+	logging.LogFatalOnError(log, err, "Read config file")
+
+	db, err := bootstrap.NewStorageWithoutMigration(conf)
+	logging.LogFatalOnError(log, err, "Instantiate storage")
+
+	e := env{conf: conf, db: db, out: out}
+	err = cmd.run(context.Background(), e, flag.Args()[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	logging.LogFatalOnError(log, err, flag.Arg(0))
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] <command> [<args>]\n\nFlags:\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s%s\n", name, commands[name].usage)
+	}
+}
+
+// newFlagSet returns a FlagSet for the (sub)command name whose parse errors
+// are returned rather than exiting.
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+// subcommand splits args into the subcommand (one of valid) and its args.
+func subcommand(cmd string, args []string, valid ...string) (string, []string, error) {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		return "", nil, errors.Newf("%s needs a subcommand: %s",
+			cmd, strings.Join(valid, ", "))
+	}
+	for _, v := range valid {
+		if args[0] == v {
+			return v, args[1:], nil
+		}
+	}
+	return "", nil, errors.Newf("unknown %s subcommand %q, expected one of: %s",
+		cmd, args[0], strings.Join(valid, ", "))
+}
+
+// splitList splits a comma separated flag value dropping empty entries.
+func splitList(s string) []string {
+	var l []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			l = append(l, v)
+		}
+	}
+	return l
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/bootstrap"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/db/memory"
+	"github.com/tomogoma/shoppingms/pkg/db/roach"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestSubcommand(t *testing.T) {
+	tt := []struct {
+		name    string
+		args    []string
+		expSub  string
+		expArgs []string
+		expErr  bool
+	}{
+		{name: "no args", expErr: true},
+		{name: "flag first", args: []string{"-id", "1"}, expErr: true},
+		{name: "unknown", args: []string{"delete"}, expErr: true},
+		{name: "no sub args", args: []string{"list"}, expSub: "list", expArgs: []string{}},
+		{
+			name: "with sub args", args: []string{"revoke", "-id", "1"},
+			expSub: "revoke", expArgs: []string{"-id", "1"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sub, args, err := subcommand("apikeys", tc.args, "list", "revoke")
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if sub != tc.expSub || !reflect.DeepEqual(args, tc.expArgs) {
+				t.Errorf("Expected (%s, %v), got (%s, %v)", tc.expSub, tc.expArgs, sub, args)
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tt := []struct {
+		in  string
+		exp []string
+	}{
+		{in: "", exp: nil},
+		{in: " , ,", exp: nil},
+		{in: "a", exp: []string{"a"}},
+		{in: " a, b ,,c ", exp: []string{"a", "b", "c"}},
+	}
+	for _, tc := range tt {
+		if got := splitList(tc.in); !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("splitList(%q): expected %v, got %v", tc.in, tc.exp, got)
+		}
+	}
+}
+
+func TestOutput_write(t *testing.T) {
+	v := []shoppingList{{ID: "1", Name: "Weekly"}}
+	tbl := table{header: []string{"ID", "NAME"}}
+	tbl.add("1", "Weekly")
+	tbl.add("22", "Party")
+
+	buf := &bytes.Buffer{}
+	out, err := newOutput(buf, formatText)
+	if err != nil {
+		t.Fatalf("newOutput(): %v", err)
+	}
+	if err := out.write(v, tbl); err != nil {
+		t.Fatalf("write(): %v", err)
+	}
+	expText := "ID  NAME\n1   Weekly\n22  Party\n"
+	if buf.String() != expText {
+		t.Errorf("Expected text:\n%s\ngot:\n%s", expText, buf.String())
+	}
+
+	buf.Reset()
+	if out, err = newOutput(buf, formatJSON); err != nil {
+		t.Fatalf("newOutput(): %v", err)
+	}
+	if err := out.write(v, tbl); err != nil {
+		t.Fatalf("write(): %v", err)
+	}
+	var got []shoppingList
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", buf.String(), err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("Expected %+v, got %+v", v, got)
+	}
+
+	if _, err := newOutput(buf, "yaml"); err == nil {
+		t.Errorf("Expected an error for an unknown format, got nil")
+	}
+}
+
+func TestRunSeed(t *testing.T) {
+	fName := filepath.Join(t.TempDir(), "fixtures.json")
+	fx := `{
+		"brands": [{"name": " Brookside ", "item": "Milk", "measuringUnit": "500ml", "barcodes": ["6161100530024"]}],
+		"storeBranches": [{"store": "Naivas", "name": "Naivas Westlands", "latitude": -1.2648, "longitude": 36.8031}],
+		"shoppingLists": [{"userID": "123", "name": "  Weekly  Shop ", "items": [
+			{"item": "Milk", "brand": "Brookside", "measuringUnit": "500ml", "quantity": 2, "inList": true},
+			{"item": " Bread ", "inCart": true}
+		]}]
+	}`
+	if err := ioutil.WriteFile(fName, []byte(fx), 0600); err != nil {
+		t.Fatalf("Error setting up: write fixtures: %v", err)
+	}
+	db := memory.NewMemory()
+	buf := &bytes.Buffer{}
+	e := env{db: db, out: output{w: buf, format: formatJSON}}
+
+	for i := 0; i < 2; i++ {
+		buf.Reset()
+		if err := runSeed(context.TODO(), e, []string{"-file", fName}); err != nil {
+			t.Fatalf("runSeed() #%d: %v", i, err)
+		}
+	}
+	var r seedReport
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("Expected a JSON report, got %q: %v", buf.String(), err)
+	}
+	expR := seedReport{Brands: 1, StoreBranches: 1, ShoppingLists: 1, ShoppingListItems: 2}
+	if r != expR {
+		t.Errorf("Expected report %+v, got %+v", expR, r)
+	}
+
+	sls, err := db.ShoppingLists(context.TODO(), "123", 0, 10)
+	if err != nil {
+		t.Fatalf("ShoppingLists(): %v", err)
+	}
+	if len(sls) != 1 || sls[0].Name != "Weekly Shop" || sls[0].Mode != shopping.ModePreparation {
+		t.Fatalf("Expected one cleaned up list in preparation mode, got %+v", sls)
+	}
+	sis, err := db.ShoppingListItems(context.TODO(), "123", sls[0].ID, 0, 10)
+	if err != nil {
+		t.Fatalf("ShoppingListItems(): %v", err)
+	}
+	got := make(map[string]shoppingListItem)
+	for _, si := range sis {
+		got[si.Price.Brand.Item.Name] = toShoppingListItem(si)
+	}
+	bread := shoppingListItem{ID: got["Bread"].ID, Item: "Bread",
+		Brand: shopping.DefaultBrandName, Unit: shopping.DefaultMeasuringUnit,
+		InList: true, InCart: true}
+	if len(sis) != 2 || got["Bread"] != bread || got["Milk"].Quantity != 2 {
+		t.Errorf("Expected milk and defaulted bread, got %+v", got)
+	}
+
+	if err := runSeed(context.TODO(), e, nil); err == nil {
+		t.Errorf("Expected an error without -file, got nil")
+	}
+}
+
+func TestRunMigrate(t *testing.T) {
+	conf := config.General{Storage: config.Storage{
+		Backend: config.StorageSQLite,
+		Path:    filepath.Join(t.TempDir(), "shoppingms.db"),
+	}}
+	db, err := bootstrap.NewStorageWithoutMigration(conf)
+	if err != nil {
+		t.Fatalf("bootstrap.NewStorageWithoutMigration(): %v", err)
+	}
+	defer db.Close()
+	buf := &bytes.Buffer{}
+	e := env{conf: conf, db: db, out: output{w: buf, format: formatJSON}}
+
+	if err := runLists(context.TODO(), e, []string{"-user", "123"}); err == nil {
+		t.Fatalf("Expected lists to fail before migrating, got nil")
+	}
+	if err := runMigrate(context.TODO(), e, []string{"status"}); err == nil {
+		t.Fatalf("Expected status to find no schema after lists, got %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := runMigrate(context.TODO(), e, []string{"up"}); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	var st migrationStatus
+	if err := json.Unmarshal(buf.Bytes(), &st); err != nil {
+		t.Fatalf("Expected a JSON status, got %q: %v", buf.String(), err)
+	}
+	expSt := migrationStatus{Version: roach.Version, Required: roach.Version, UpToDate: true}
+	if st != expSt {
+		t.Errorf("Expected status %+v, got %+v", expSt, st)
+	}
+
+	buf.Reset()
+	if err := runLists(context.TODO(), e, []string{"-user", "123"}); err != nil {
+		t.Errorf("lists after migrating: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("Expected no lists, got %s", buf.String())
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/bootstrap"
+	"github.com/tomogoma/shoppingms/pkg/db/roach"
+)
+
+type migrationStatus struct {
+	Version  int  `json:"version"`
+	Required int  `json:"required"`
+	UpToDate bool `json:"upToDate"`
+}
+
+// runMigrate reports the schema version in use ("status") or migrates the
+// DB to roach.Version ("up"). Only "up" changes the DB. The sqlite backend
+// shares roach.Version, the memory backend has no schema to migrate.
+func runMigrate(ctx context.Context, e env, args []string) error {
+	sub, args, err := subcommand("migrate", args, "status", "up")
+	if err != nil {
+		return err
+	}
+	if err := newFlagSet("migrate " + sub).Parse(args); err != nil {
+		return err
+	}
+	db, ok := e.db.(bootstrap.VersionedStorage)
+	if !ok {
+		return errors.Newf("the %s storage backend has no schema to migrate",
+			e.conf.Storage.Backend)
+	}
+	if sub == "up" {
+		// e.db never migrates, see bootstrap.NewStorageWithoutMigration().
+		s, err := bootstrap.NewStorage(e.conf, nil)
+		if err != nil {
+			return errors.Newf("instantiate storage: %v", err)
+		}
+		defer s.Close()
+		db = s.(bootstrap.VersionedStorage)
+		if err := db.InitDBIfNot(); err != nil {
+			return errors.Newf("migrate: %v", err)
+		}
+	}
+	v, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return errors.Newf("get schema version: %v", err)
+	}
+	st := migrationStatus{Version: v, Required: roach.Version, UpToDate: v == roach.Version}
+	t := table{header: []string{"VERSION", "REQUIRED", "UP TO DATE"}}
+	t.add(strconv.Itoa(st.Version), strconv.Itoa(st.Required), strconv.FormatBool(st.UpToDate))
+	return e.out.write(st, t)
+}
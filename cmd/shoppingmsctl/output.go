@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// table is the human readable form of a command's result.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(cols ...string) {
+	t.rows = append(t.rows, cols)
+}
+
+// output writes command results either as aligned text tables or as JSON.
+type output struct {
+	w      io.Writer
+	format string
+}
+
+func newOutput(w io.Writer, format string) (output, error) {
+	switch format {
+	case formatText, formatJSON:
+		return output{w: w, format: format}, nil
+	default:
+		return output{}, errors.Newf("unknown output format %q, expected %s or %s",
+			format, formatText, formatJSON)
+	}
+}
+
+// write writes v as indented JSON or t as a text table depending on the
+// output format.
+func (o output) write(v interface{}, t table) error {
+	if o.format == formatJSON {
+		enc := json.NewEncoder(o.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+	if len(t.header) > 0 {
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	}
+	for _, r := range t.rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	return tw.Flush()
+}
+
+// message writes a one line status message as {"message": msg} in JSON.
+func (o output) message(msg string) error {
+	return o.write(map[string]string{"message": msg}, table{rows: [][]string{{msg}}})
+}
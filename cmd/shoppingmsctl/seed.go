@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/dev"
+	"github.com/tomogoma/shoppingms/pkg/osm"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// fixtures is the format of the file loaded by the seed command e.g.
+//
+//	{
+//	  "brands": [{"name": "Brookside", "item": "Milk", "measuringUnit": "500ml", "barcodes": ["6161100530024"]}],
+//	  "storeBranches": [{"store": "Naivas", "name": "Naivas Westlands", "latitude": -1.2648, "longitude": 36.8031}],
+//	  "shoppingLists": [{"userID": "123", "name": "Weekly", "items": [
+//	    {"item": "Milk", "brand": "Brookside", "measuringUnit": "500ml", "quantity": 2, "inList": true}
+//	  ]}]
+//	}
+type fixtures struct {
+	Brands        []fixtureBrand        `json:"brands"`
+	StoreBranches []fixtureStoreBranch  `json:"storeBranches"`
+	ShoppingLists []fixtureShoppingList `json:"shoppingLists"`
+}
+
+type fixtureBrand struct {
+	Name          string   `json:"name"`
+	Item          string   `json:"item"`
+	MeasuringUnit string   `json:"measuringUnit"`
+	Barcodes      []string `json:"barcodes"`
+}
+
+func (b fixtureBrand) brand() shopping.Brand {
+	return shopping.Brand{
+		Name:          b.Name,
+		Item:          shopping.Item{Name: b.Item},
+		MeasuringUnit: shopping.MeasuringUnit{Name: b.MeasuringUnit},
+		Barcodes:      b.Barcodes,
+	}
+}
+
+// fixtureStoreBranch is matched against existing branches the same way
+// osmimport does: by OSMID if set, otherwise by name and location.
+type fixtureStoreBranch struct {
+	Store     string  `json:"store"`
+	Name      string  `json:"name"`
+	OSMID     string  `json:"osmID"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type fixtureShoppingList struct {
+	UserID string                    `json:"userID"`
+	Name   string                    `json:"name"`
+	Mode   string                    `json:"mode"`
+	Items  []fixtureShoppingListItem `json:"items"`
+}
+
+type fixtureShoppingListItem struct {
+	Item          string `json:"item"`
+	Brand         string `json:"brand"`
+	MeasuringUnit string `json:"measuringUnit"`
+	Quantity      int    `json:"quantity"`
+	InList        bool   `json:"inList"`
+	InCart        bool   `json:"inCart"`
+}
+
+type seedReport struct {
+	Brands            int `json:"brands"`
+	StoreBranches     int `json:"storeBranches"`
+	ShoppingLists     int `json:"shoppingLists"`
+	ShoppingListItems int `json:"shoppingListItems"`
+}
+
+// runSeed loads the fixtures in a JSON file through the same managers as
+// the service so that names are cleaned and defaulted alike. Existing
+// records are reused or updated so that the same file can be loaded
+// repeatedly.
+func runSeed(ctx context.Context, e env, args []string) error {
+	fs := newFlagSet("seed")
+	file := fs.String("file", "", "location of the JSON fixtures file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return errors.Newf("open fixtures: %v", err)
+	}
+	defer f.Close()
+	var fx fixtures
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fx); err != nil {
+		return errors.Newf("decode fixtures: %v", err)
+	}
+
+	r := seedReport{}
+
+	cat, err := shopping.NewCatalog(e.db)
+	if err != nil {
+		return errors.Newf("instantiate catalog: %v", err)
+	}
+	if len(fx.Brands) > 0 {
+		brands := make([]shopping.Brand, len(fx.Brands))
+		for i, b := range fx.Brands {
+			brands[i] = b.brand()
+		}
+		if _, err := cat.UpsertBrands(ctx, brands); err != nil {
+			return errors.Newf("upsert brands: %v", err)
+		}
+		r.Brands = len(brands)
+	}
+
+	im, err := osm.NewImporter(e.db)
+	if err != nil {
+		return errors.Newf("instantiate store branch importer: %v", err)
+	}
+	for _, sb := range fx.StoreBranches {
+		p := osm.Place{
+			ID:       sb.OSMID,
+			Tags:     map[string]string{"brand": sb.Store, "name": sb.Name},
+			Location: shopping.Location{Latitude: sb.Latitude, Longitude: sb.Longitude},
+		}
+		if _, err := im.Import(p); err != nil {
+			return errors.Newf("store branch %s: %v", sb.Name, err)
+		}
+		r.StoreBranches++
+	}
+
+	if len(fx.ShoppingLists) == 0 {
+		return writeSeedReport(e, r)
+	}
+	s, err := newListSeeder(e.db)
+	if err != nil {
+		return err
+	}
+	for _, fsl := range fx.ShoppingLists {
+		if err := s.seed(ctx, fsl); err != nil {
+			return errors.Newf("shopping list %s: %v", fsl.Name, err)
+		}
+		r.ShoppingLists++
+		r.ShoppingListItems += len(fsl.Items)
+	}
+	return writeSeedReport(e, r)
+}
+
+func writeSeedReport(e env, r seedReport) error {
+	t := table{header: []string{"BRANDS", "STORE BRANCHES", "SHOPPING LISTS", "SHOPPING LIST ITEMS"}}
+	t.add(strconv.Itoa(r.Brands), strconv.Itoa(r.StoreBranches),
+		strconv.Itoa(r.ShoppingLists), strconv.Itoa(r.ShoppingListItems))
+	return e.out.write(r, t)
+}
+
+// listSeeder adds shopping lists through a shopping.Manager on behalf of
+// their users. The users' JWTs are signed with a key that only lives as
+// long as the process.
+type listSeeder struct {
+	tokens  *dev.Tokens
+	manager *shopping.Manager
+}
+
+func newListSeeder(db shopping.Storage) (*listSeeder, error) {
+	jwter, err := dev.NewJWTHandler()
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := dev.NewTokens(jwter)
+	if err != nil {
+		return nil, errors.Newf("instantiate tokens: %v", err)
+	}
+	prices, err := shopping.NewPrices(db, jwter)
+	if err != nil {
+		return nil, errors.Newf("instantiate prices: %v", err)
+	}
+	m, err := shopping.NewManager(db, jwter, prices)
+	if err != nil {
+		return nil, errors.Newf("instantiate shopping list manager: %v", err)
+	}
+	return &listSeeder{tokens: tokens, manager: m}, nil
+}
+
+func (s *listSeeder) seed(ctx context.Context, fsl fixtureShoppingList) error {
+	JWT, err := s.tokens.Issue(fsl.UserID)
+	if err != nil {
+		return err
+	}
+	sl, err := s.manager.InsertShoppingList(ctx, JWT, fsl.Name, fsl.Mode)
+	if err != nil {
+		return err
+	}
+	for _, item := range fsl.Items {
+		_, err := s.manager.UpsertShoppingListItem(ctx, JWT, shopping.ShoppingListItemUpsert{
+			ShoppingListID: sl.ID,
+			ItemName:       item.Item,
+			BrandName:      item.Brand,
+			MeasuringUnit:  item.MeasuringUnit,
+			Quantity:       item.Quantity,
+			InList:         item.InList,
+			InCart:         item.InCart,
+		})
+		if err != nil {
+			return errors.Newf("upsert item %s: %v", item.Item, err)
+		}
+	}
+	return nil
+}
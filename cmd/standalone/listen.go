@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/config"
+)
+
+// systemdFirstFD is the first file descriptor passed by systemd socket
+// activation, see sd_listen_fds(3).
+const systemdFirstFD = 3
+
+// listen returns the socket passed by systemd socket activation if any,
+// otherwise it listens on addr which is host:port or a unix domain socket
+// path prefixed with config.UnixAddressPrefix. It also returns a
+// description of the address for logging.
+func listen(addr string) (net.Listener, string, error) {
+	l, err := systemdListener(systemdFirstFD)
+	if err != nil {
+		return nil, "", errors.Newf("systemd socket activation: %v", err)
+	}
+	if l != nil {
+		return l, "systemd socket " + l.Addr().String(), nil
+	}
+
+	if strings.HasPrefix(addr, config.UnixAddressPrefix) {
+		sock := strings.TrimPrefix(addr, config.UnixAddressPrefix)
+		if err := removeStaleSocket(sock); err != nil {
+			return nil, "", err
+		}
+		l, err := net.Listen("unix", sock)
+		return l, addr, err
+	}
+
+	if addr == "" {
+		addr = config.DefaultStandaloneAddress
+	}
+	l, err = net.Listen("tcp", addr)
+	return l, addr, err
+}
+
+// systemdListener returns the socket passed as file descriptor fd by
+// systemd socket activation, or nil if the process was not socket
+// activated.
+func systemdListener(fd uintptr) (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil {
+		return nil, errors.Newf("parse LISTEN_FDS: %v", err)
+	}
+	if n != 1 {
+		return nil, errors.Newf("expected 1 socket, got %d", n)
+	}
+	// Keep the socket from being inherited by child processes.
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(fd, "systemd socket")
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// removeStaleSocket removes the unix domain socket left at path by a
+// previous run that did not exit cleanly. It does not touch other files.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return errors.Newf("%s exists and is not a socket", path)
+	}
+	return os.Remove(path)
+}
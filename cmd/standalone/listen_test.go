@@ -0,0 +1,135 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestSystemdListener(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	tt := []struct {
+		name        string
+		listenPID   string
+		listenFDs   string
+		expListener bool
+		expErr      bool
+	}{
+		{name: "not socket activated"},
+		{name: "activated for another process", listenPID: strconv.Itoa(os.Getpid() + 1),
+			listenFDs: "1"},
+		{name: "bad LISTEN_FDS", listenPID: pid, listenFDs: "one", expErr: true},
+		{name: "too many sockets", listenPID: pid, listenFDs: "2", expErr: true},
+		{name: "socket activated", listenPID: pid, listenFDs: "1", expListener: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("LISTEN_PID", tc.listenPID)
+			t.Setenv("LISTEN_FDS", tc.listenFDs)
+			t.Setenv("LISTEN_FDNAMES", "http")
+
+			passed, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Error setting up: listen: %v", err)
+			}
+			defer passed.Close()
+			f, err := passed.(*net.TCPListener).File()
+			if err != nil {
+				t.Fatalf("Error setting up: listener file: %v", err)
+			}
+			defer f.Close()
+
+			l, err := systemdListener(f.Fd())
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if !tc.expListener {
+				if l != nil {
+					l.Close()
+					t.Fatalf("Expected no listener, got one on %s", l.Addr())
+				}
+				return
+			}
+			if l == nil {
+				t.Fatalf("Expected a listener, got nil")
+			}
+			defer l.Close()
+			if l.Addr().String() != passed.Addr().String() {
+				t.Errorf("Expected listener on %s, got %s", passed.Addr(), l.Addr())
+			}
+			for _, env := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+				if v, ok := os.LookupEnv(env); ok {
+					t.Errorf("Expected %s to be unset, got %q", env, v)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveStaleSocket(t *testing.T) {
+	tt := []struct {
+		name      string
+		setup     func(t *testing.T, path string)
+		expErr    bool
+		expExists bool
+	}{
+		{name: "missing", setup: func(*testing.T, string) {}},
+		{
+			name: "stale socket",
+			setup: func(t *testing.T, path string) {
+				l, err := net.Listen("unix", path)
+				if err != nil {
+					t.Fatalf("Error setting up: listen: %v", err)
+				}
+				// Leave the socket behind as a crashed process would.
+				l.(*net.UnixListener).SetUnlinkOnClose(false)
+				l.Close()
+			},
+		},
+		{
+			name: "regular file",
+			setup: func(t *testing.T, path string) {
+				if err := ioutil.WriteFile(path, []byte("data"), 0600); err != nil {
+					t.Fatalf("Error setting up: write file: %v", err)
+				}
+			},
+			expErr:    true,
+			expExists: true,
+		},
+		{
+			name: "directory",
+			setup: func(t *testing.T, path string) {
+				if err := os.Mkdir(path, 0700); err != nil {
+					t.Fatalf("Error setting up: mkdir: %v", err)
+				}
+			},
+			expErr:    true,
+			expExists: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "s.sock")
+			tc.setup(t, path)
+			err := removeStaleSocket(path)
+			if tc.expErr && err == nil {
+				t.Errorf("Expected an error, got nil")
+			}
+			if !tc.expErr && err != nil {
+				t.Errorf("Got error: %v", err)
+			}
+			if _, err := os.Lstat(path); os.IsNotExist(err) == tc.expExists {
+				t.Errorf("Expected %s to exist: %t", path, tc.expExists)
+			}
+		})
+	}
+}
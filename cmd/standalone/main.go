@@ -0,0 +1,137 @@
+// Command standalone serves the HTTP API without a micro registry or App
+// Engine e.g. on a plain VM or behind a reverse proxy. See README.MD for
+// configuration.
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/bootstrap"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/dev"
+	httpIntl "github.com/tomogoma/shoppingms/pkg/handler/http"
+	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/logging/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	idleTimeout       = 2 * time.Minute
+)
+
+func main() {
+
+	confFile := flag.String("conf", config.DefaultConfPath(), "location of config file")
+	devMode := flag.Bool("dev", false, "run in developer mode with in-memory"+
+		" demo data, a fixed API key and JWTs issued at /dev/tokens, ignoring -conf")
+	flag.Parse()
+	log := &logrus.Wrapper{}
+	var deps bootstrap.Deps
+	if *devMode {
+		deps = bootstrap.InstantiateDev(log)
+	} else {
+		deps = bootstrap.Instantiate(*confFile, log)
+	}
+	if lvl := deps.Config.Service.LogLevel; lvl != "" {
+		err := log.SetLevel(lvl)
+		logging.LogFatalOnError(log, err, "Set log level")
+	}
+
+	h, err := httpIntl.NewHandler(httpIntl.Config{
+		Guard:           deps.Guard,
+		Logger:          log,
+		BaseURL:         config.WebRootPath(),
+		AllowedOrigins:  deps.Config.Service.AllowedOrigins,
+		Manager:         deps.Manager,
+		Catalog:         deps.Catalog,
+		Prices:          deps.Prices,
+		APIKeys:         deps.APIKeys,
+		Health:          deps.Health,
+		Metrics:         deps.Metrics,
+		RateLimiter:     deps.Limiter,
+		MasterAPIKey:    deps.MasterKey,
+		ConfigOverrides: deps.Config.Overrides,
+		DevTokens:       devTokens(deps),
+	})
+	logging.LogFatalOnError(log, err, "Instantiate HTTP handler")
+
+	conf := deps.Config.Standalone
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	srv.TLSConfig, err = tlsConfig(conf)
+	logging.LogFatalOnError(log, err, "Configure TLS")
+	if conf.H2C {
+		srv.Handler = h2c.NewHandler(h, &http2.Server{IdleTimeout: idleTimeout})
+	}
+
+	l, addr, err := listen(conf.Address)
+	logging.LogFatalOnError(log, err, "Listen")
+
+	quitCh := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			quitCh <- srv.ServeTLS(l, "", "")
+			return
+		}
+		quitCh <- srv.Serve(l)
+	}()
+	log.Infof("Serving HTTP on %s (TLS: %t, client certificates required: %t, h2c: %t)",
+		addr, srv.TLSConfig != nil, conf.ClientCAFile != "", conf.H2C)
+	if *devMode {
+		log.Warnf("Developer mode: use API key %s, get JWTs from POST %s/dev/tokens",
+			dev.APIKey, config.WebRootPath())
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	select {
+	case sig := <-sigCh:
+		log.Infof("Received %s, shutting down", sig)
+	case err = <-quitCh:
+		logging.LogWarnOnError(log, err, "Serve HTTP")
+	}
+	shutdown(log, deps, srv)
+	if err != nil {
+		os.Exit(1)
+	}
+	log.Infof("Shut down")
+}
+
+// devTokens returns deps.DevTokens as an http.DevTokenIssuer, which is nil
+// rather than a nil *dev.Tokens outside developer mode.
+func devTokens(deps bootstrap.Deps) httpIntl.DevTokenIssuer {
+	if deps.DevTokens == nil {
+		return nil
+	}
+	return deps.DevTokens
+}
+
+// shutdown stops srv accepting connections and waits up to
+// config.Service.ShutdownTimeout for requests in progress to complete, then
+// flushes traces and closes the storage.
+func shutdown(log logging.Logger, deps bootstrap.Deps, srv *http.Server) {
+	timeout := deps.Config.Service.ShutdownTimeout
+	if timeout == 0 {
+		timeout = config.DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	logging.LogWarnOnError(log, err, "Drain HTTP requests")
+
+	err = deps.Tracer.Shutdown(context.Background())
+	logging.LogWarnOnError(log, err, "Flush traces")
+	err = deps.Storage.Close()
+	logging.LogWarnOnError(log, err, "Close storage")
+}
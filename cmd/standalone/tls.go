@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/config"
+)
+
+// tlsConfig returns the TLS config for the certificate and key files in
+// conf, requiring client certificates signed by conf.ClientCAFile if set.
+// It returns nil if TLS is not configured. HTTP/2 is negotiated over TLS.
+func tlsConfig(conf config.Standalone) (*tls.Config, error) {
+	if conf.TLSCertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(conf.TLSCertFile, conf.TLSKeyFile)
+	if err != nil {
+		return nil, errors.Newf("load certificate: %v", err)
+	}
+	tlsConf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if conf.ClientCAFile == "" {
+		return tlsConf, nil
+	}
+	caPEM, err := ioutil.ReadFile(conf.ClientCAFile)
+	if err != nil {
+		return nil, errors.Newf("read client CA file: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.Newf("no certificates found in client CA file %s",
+			conf.ClientCAFile)
+	}
+	tlsConf.ClientCAs = pool
+	tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConf, nil
+}
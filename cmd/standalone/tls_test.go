@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/config"
+)
+
+// writeCert generates a certificate signed by parent (self signed if nil)
+// and writes its PEM encoded certificate and key into dir.
+func writeCert(t *testing.T, dir, name string, isCA bool, parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey) (certFile, keyFile string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error setting up: generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Error setting up: create certificate: %v", err)
+	}
+	if cert, err = x509.ParseCertificate(der); err != nil {
+		t.Fatalf("Error setting up: parse certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error setting up: marshal key: %v", err)
+	}
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile, cert, key
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		t.Fatalf("Error setting up: write %s: %v", file, err)
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	caFile, _, ca, caKey := writeCert(t, dir, "ca", true, nil, nil)
+	certFile, keyFile, _, _ := writeCert(t, dir, "server", false, ca, caKey)
+	_, otherKeyFile, _, _ := writeCert(t, dir, "other", false, ca, caKey)
+	emptyFile := filepath.Join(dir, "empty.pem")
+	if err := ioutil.WriteFile(emptyFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("Error setting up: write empty CA file: %v", err)
+	}
+
+	tt := []struct {
+		name          string
+		conf          config.Standalone
+		expNil        bool
+		expClientAuth tls.ClientAuthType
+		expErr        bool
+	}{
+		{name: "TLS not configured", expNil: true},
+		{
+			name:          "server certificate",
+			conf:          config.Standalone{TLSCertFile: certFile, TLSKeyFile: keyFile},
+			expClientAuth: tls.NoClientCert,
+		},
+		{
+			name: "client CA",
+			conf: config.Standalone{TLSCertFile: certFile, TLSKeyFile: keyFile,
+				ClientCAFile: caFile},
+			expClientAuth: tls.RequireAndVerifyClientCert,
+		},
+		{
+			name:   "mismatched key",
+			conf:   config.Standalone{TLSCertFile: certFile, TLSKeyFile: otherKeyFile},
+			expErr: true,
+		},
+		{
+			name:   "missing certificate",
+			conf:   config.Standalone{TLSCertFile: filepath.Join(dir, "none.crt"), TLSKeyFile: keyFile},
+			expErr: true,
+		},
+		{
+			name: "missing client CA",
+			conf: config.Standalone{TLSCertFile: certFile, TLSKeyFile: keyFile,
+				ClientCAFile: filepath.Join(dir, "none.pem")},
+			expErr: true,
+		},
+		{
+			name: "client CA without certificates",
+			conf: config.Standalone{TLSCertFile: certFile, TLSKeyFile: keyFile,
+				ClientCAFile: emptyFile},
+			expErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tlsConf, err := tlsConfig(tc.conf)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if tc.expNil {
+				if tlsConf != nil {
+					t.Errorf("Expected nil config, got %+v", tlsConf)
+				}
+				return
+			}
+			if tlsConf == nil {
+				t.Fatalf("Expected a config, got nil")
+			}
+			if tlsConf.MinVersion != tls.VersionTLS12 {
+				t.Errorf("Expected min version %x, got %x", tls.VersionTLS12, tlsConf.MinVersion)
+			}
+			if tlsConf.ClientAuth != tc.expClientAuth {
+				t.Errorf("Expected client auth %v, got %v", tc.expClientAuth, tlsConf.ClientAuth)
+			}
+			if len(tlsConf.NextProtos) == 0 || tlsConf.NextProtos[0] != "h2" {
+				t.Errorf("Expected h2 to be negotiated first, got %v", tlsConf.NextProtos)
+			}
+		})
+	}
+}
+
+func TestTLSConfig_clientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	caFile, _, ca, caKey := writeCert(t, dir, "ca", true, nil, nil)
+	certFile, keyFile, _, _ := writeCert(t, dir, "server", false, ca, caKey)
+	clientCertFile, clientKeyFile, _, _ := writeCert(t, dir, "client", false, ca, caKey)
+	_, _, untrusted, untrustedKey := writeCert(t, dir, "untrusted-ca", true, nil, nil)
+	strangerCertFile, strangerKeyFile, _, _ := writeCert(t, dir, "stranger", false,
+		untrusted, untrustedKey)
+
+	srvConf, err := tlsConfig(config.Standalone{TLSCertFile: certFile,
+		TLSKeyFile: keyFile, ClientCAFile: caFile})
+	if err != nil {
+		t.Fatalf("tlsConfig(): %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	tt := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		expErr   bool
+	}{
+		{name: "no client certificate", expErr: true},
+		{name: "untrusted client certificate", certFile: strangerCertFile,
+			keyFile: strangerKeyFile, expErr: true},
+		{name: "trusted client certificate", certFile: clientCertFile,
+			keyFile: clientKeyFile},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := tls.Listen("tcp", "127.0.0.1:0", srvConf)
+			if err != nil {
+				t.Fatalf("Error setting up: listen: %v", err)
+			}
+			defer l.Close()
+			srvErr := make(chan error, 1)
+			go func() {
+				conn, err := l.Accept()
+				if err != nil {
+					srvErr <- err
+					return
+				}
+				defer conn.Close()
+				srvErr <- conn.(*tls.Conn).Handshake()
+			}()
+
+			cliConf := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+			if tc.certFile != "" {
+				cert, err := tls.LoadX509KeyPair(tc.certFile, tc.keyFile)
+				if err != nil {
+					t.Fatalf("Error setting up: load client certificate: %v", err)
+				}
+				cliConf.Certificates = []tls.Certificate{cert}
+			}
+			if conn, err := tls.Dial("tcp", l.Addr().String(), cliConf); err == nil {
+				// Complete the handshake; TLS 1.3 clients only learn of
+				// rejection on first read.
+				conn.Read(make([]byte, 1))
+				conn.Close()
+			}
+			err = <-srvErr
+			if tc.expErr && err == nil {
+				t.Errorf("Expected the server to reject the handshake, got nil")
+			}
+			if !tc.expErr && err != nil {
+				t.Errorf("Expected the server to accept the handshake, got %v", err)
+			}
+		})
+	}
+}
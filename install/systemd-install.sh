@@ -14,6 +14,7 @@ WantedBy=multi-user.target
 
 [Service]
 ExecStart=${INSTALL_FILE}
+ExecReload=/bin/kill -HUP \$MAINPID
 SyslogIdentifier=${CANONICAL_NAME}
 Restart=always
 RestartSec=10
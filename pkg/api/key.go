@@ -3,9 +3,19 @@ package api
 import "time"
 
 type Key struct {
-	ID          string
-	UserID      string
-	Val         []byte
+	ID     string
+	UserID string
+	// Prefix of Val, the only part of the key stored in plain text.
+	Prefix string
+	Val    []byte
+	// Scopes the key grants access to e.g. lists:read.
+	Scopes []string
+	// Origins the key may be used from, any if empty.
+	Origins []string
+	// Expires is when the key stops being valid, never if zero.
+	Expires time.Time
+	// LastUsed is when the key was last used, never if zero.
+	LastUsed    time.Time
 	Created     time.Time
 	LastUpdated time.Time
 }
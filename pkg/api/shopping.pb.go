@@ -0,0 +1,1283 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: github.com/tomogoma/shoppingms/pkg/api/shopping.proto
+
+package api
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	client "github.com/micro/go-micro/client"
+	server "github.com/micro/go-micro/server"
+	context "golang.org/x/net/context"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type ShoppingList struct {
+	ID          string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	UserID      string `protobuf:"bytes,2,opt,name=userID" json:"userID,omitempty"`
+	Name        string `protobuf:"bytes,3,opt,name=name" json:"name,omitempty"`
+	Mode        string `protobuf:"bytes,4,opt,name=mode" json:"mode,omitempty"`
+	Created     string `protobuf:"bytes,5,opt,name=created" json:"created,omitempty"`
+	LastUpdated string `protobuf:"bytes,6,opt,name=lastUpdated" json:"lastUpdated,omitempty"`
+}
+
+func (m *ShoppingList) Reset()                    { *m = ShoppingList{} }
+func (m *ShoppingList) String() string            { return proto.CompactTextString(m) }
+func (*ShoppingList) ProtoMessage()               {}
+func (*ShoppingList) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{0} }
+
+func (m *ShoppingList) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *ShoppingList) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *ShoppingList) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ShoppingList) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+func (m *ShoppingList) GetCreated() string {
+	if m != nil {
+		return m.Created
+	}
+	return ""
+}
+
+func (m *ShoppingList) GetLastUpdated() string {
+	if m != nil {
+		return m.LastUpdated
+	}
+	return ""
+}
+
+type MeasuringUnit struct {
+	ID   string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *MeasuringUnit) Reset()                    { *m = MeasuringUnit{} }
+func (m *MeasuringUnit) String() string            { return proto.CompactTextString(m) }
+func (*MeasuringUnit) ProtoMessage()               {}
+func (*MeasuringUnit) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{1} }
+
+func (m *MeasuringUnit) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *MeasuringUnit) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type Item struct {
+	ID   string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *Item) Reset()                    { *m = Item{} }
+func (m *Item) String() string            { return proto.CompactTextString(m) }
+func (*Item) ProtoMessage()               {}
+func (*Item) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{2} }
+
+func (m *Item) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *Item) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type Brand struct {
+	ID            string         `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	Name          string         `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	MeasuringUnit *MeasuringUnit `protobuf:"bytes,3,opt,name=measuringUnit" json:"measuringUnit,omitempty"`
+	Item          *Item          `protobuf:"bytes,4,opt,name=item" json:"item,omitempty"`
+	Barcodes      []string       `protobuf:"bytes,5,rep,name=barcodes" json:"barcodes,omitempty"`
+}
+
+func (m *Brand) Reset()                    { *m = Brand{} }
+func (m *Brand) String() string            { return proto.CompactTextString(m) }
+func (*Brand) ProtoMessage()               {}
+func (*Brand) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{3} }
+
+func (m *Brand) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *Brand) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Brand) GetMeasuringUnit() *MeasuringUnit {
+	if m != nil {
+		return m.MeasuringUnit
+	}
+	return nil
+}
+
+func (m *Brand) GetItem() *Item {
+	if m != nil {
+		return m.Item
+	}
+	return nil
+}
+
+func (m *Brand) GetBarcodes() []string {
+	if m != nil {
+		return m.Barcodes
+	}
+	return nil
+}
+
+type Store struct {
+	ID   string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *Store) Reset()                    { *m = Store{} }
+func (m *Store) String() string            { return proto.CompactTextString(m) }
+func (*Store) ProtoMessage()               {}
+func (*Store) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{4} }
+
+func (m *Store) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *Store) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type Location struct {
+	Latitude  float64 `protobuf:"fixed64,1,opt,name=latitude" json:"latitude,omitempty"`
+	Longitude float64 `protobuf:"fixed64,2,opt,name=longitude" json:"longitude,omitempty"`
+}
+
+func (m *Location) Reset()                    { *m = Location{} }
+func (m *Location) String() string            { return proto.CompactTextString(m) }
+func (*Location) ProtoMessage()               {}
+func (*Location) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{5} }
+
+func (m *Location) GetLatitude() float64 {
+	if m != nil {
+		return m.Latitude
+	}
+	return 0
+}
+
+func (m *Location) GetLongitude() float64 {
+	if m != nil {
+		return m.Longitude
+	}
+	return 0
+}
+
+type StoreBranch struct {
+	ID       string    `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	Name     string    `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Store    *Store    `protobuf:"bytes,3,opt,name=store" json:"store,omitempty"`
+	Location *Location `protobuf:"bytes,4,opt,name=location" json:"location,omitempty"`
+	OSMID    string    `protobuf:"bytes,5,opt,name=OSMID" json:"OSMID,omitempty"`
+}
+
+func (m *StoreBranch) Reset()                    { *m = StoreBranch{} }
+func (m *StoreBranch) String() string            { return proto.CompactTextString(m) }
+func (*StoreBranch) ProtoMessage()               {}
+func (*StoreBranch) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{6} }
+
+func (m *StoreBranch) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *StoreBranch) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *StoreBranch) GetStore() *Store {
+	if m != nil {
+		return m.Store
+	}
+	return nil
+}
+
+func (m *StoreBranch) GetLocation() *Location {
+	if m != nil {
+		return m.Location
+	}
+	return nil
+}
+
+func (m *StoreBranch) GetOSMID() string {
+	if m != nil {
+		return m.OSMID
+	}
+	return ""
+}
+
+type Price struct {
+	ID            string       `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	Value         float32      `protobuf:"fixed32,2,opt,name=value" json:"value,omitempty"`
+	Currency      string       `protobuf:"bytes,3,opt,name=currency" json:"currency,omitempty"`
+	Brand         *Brand       `protobuf:"bytes,4,opt,name=brand" json:"brand,omitempty"`
+	AtStoreBranch *StoreBranch `protobuf:"bytes,5,opt,name=atStoreBranch" json:"atStoreBranch,omitempty"`
+	Status        string       `protobuf:"bytes,6,opt,name=status" json:"status,omitempty"`
+	OutlierScore  float64      `protobuf:"fixed64,7,opt,name=outlierScore" json:"outlierScore,omitempty"`
+	Created       string       `protobuf:"bytes,8,opt,name=created" json:"created,omitempty"`
+}
+
+func (m *Price) Reset()                    { *m = Price{} }
+func (m *Price) String() string            { return proto.CompactTextString(m) }
+func (*Price) ProtoMessage()               {}
+func (*Price) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{7} }
+
+func (m *Price) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *Price) GetValue() float32 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+func (m *Price) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+func (m *Price) GetBrand() *Brand {
+	if m != nil {
+		return m.Brand
+	}
+	return nil
+}
+
+func (m *Price) GetAtStoreBranch() *StoreBranch {
+	if m != nil {
+		return m.AtStoreBranch
+	}
+	return nil
+}
+
+func (m *Price) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *Price) GetOutlierScore() float64 {
+	if m != nil {
+		return m.OutlierScore
+	}
+	return 0
+}
+
+func (m *Price) GetCreated() string {
+	if m != nil {
+		return m.Created
+	}
+	return ""
+}
+
+type ShoppingListItem struct {
+	ID           string        `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	Quantity     int64         `protobuf:"varint,2,opt,name=quantity" json:"quantity,omitempty"`
+	InList       bool          `protobuf:"varint,3,opt,name=inList" json:"inList,omitempty"`
+	InCart       bool          `protobuf:"varint,4,opt,name=inCart" json:"inCart,omitempty"`
+	ShoppingList *ShoppingList `protobuf:"bytes,5,opt,name=shoppingList" json:"shoppingList,omitempty"`
+	Price        *Price        `protobuf:"bytes,6,opt,name=price" json:"price,omitempty"`
+}
+
+func (m *ShoppingListItem) Reset()                    { *m = ShoppingListItem{} }
+func (m *ShoppingListItem) String() string            { return proto.CompactTextString(m) }
+func (*ShoppingListItem) ProtoMessage()               {}
+func (*ShoppingListItem) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{8} }
+
+func (m *ShoppingListItem) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *ShoppingListItem) GetQuantity() int64 {
+	if m != nil {
+		return m.Quantity
+	}
+	return 0
+}
+
+func (m *ShoppingListItem) GetInList() bool {
+	if m != nil {
+		return m.InList
+	}
+	return false
+}
+
+func (m *ShoppingListItem) GetInCart() bool {
+	if m != nil {
+		return m.InCart
+	}
+	return false
+}
+
+func (m *ShoppingListItem) GetShoppingList() *ShoppingList {
+	if m != nil {
+		return m.ShoppingList
+	}
+	return nil
+}
+
+func (m *ShoppingListItem) GetPrice() *Price {
+	if m != nil {
+		return m.Price
+	}
+	return nil
+}
+
+type CatalogEntry struct {
+	ID   string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *CatalogEntry) Reset()                    { *m = CatalogEntry{} }
+func (m *CatalogEntry) String() string            { return proto.CompactTextString(m) }
+func (*CatalogEntry) ProtoMessage()               {}
+func (*CatalogEntry) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{9} }
+
+func (m *CatalogEntry) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *CatalogEntry) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type DuplicateGroup struct {
+	Entries []*CatalogEntry `protobuf:"bytes,1,rep,name=entries" json:"entries,omitempty"`
+	Score   float64         `protobuf:"fixed64,2,opt,name=score" json:"score,omitempty"`
+}
+
+func (m *DuplicateGroup) Reset()                    { *m = DuplicateGroup{} }
+func (m *DuplicateGroup) String() string            { return proto.CompactTextString(m) }
+func (*DuplicateGroup) ProtoMessage()               {}
+func (*DuplicateGroup) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{10} }
+
+func (m *DuplicateGroup) GetEntries() []*CatalogEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+func (m *DuplicateGroup) GetScore() float64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
+}
+
+type StringUpdate struct {
+	Updating bool   `protobuf:"varint,1,opt,name=updating" json:"updating,omitempty"`
+	NewVal   string `protobuf:"bytes,2,opt,name=newVal" json:"newVal,omitempty"`
+}
+
+func (m *StringUpdate) Reset()                    { *m = StringUpdate{} }
+func (m *StringUpdate) String() string            { return proto.CompactTextString(m) }
+func (*StringUpdate) ProtoMessage()               {}
+func (*StringUpdate) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{11} }
+
+func (m *StringUpdate) GetUpdating() bool {
+	if m != nil {
+		return m.Updating
+	}
+	return false
+}
+
+func (m *StringUpdate) GetNewVal() string {
+	if m != nil {
+		return m.NewVal
+	}
+	return ""
+}
+
+type Empty struct {
+}
+
+func (m *Empty) Reset()                    { *m = Empty{} }
+func (m *Empty) String() string            { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()               {}
+func (*Empty) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{12} }
+
+type InsertShoppingListRequest struct {
+	APIKey string `protobuf:"bytes,1,opt,name=APIKey" json:"APIKey,omitempty"`
+	JWT    string `protobuf:"bytes,2,opt,name=JWT" json:"JWT,omitempty"`
+	Name   string `protobuf:"bytes,3,opt,name=name" json:"name,omitempty"`
+	Mode   string `protobuf:"bytes,4,opt,name=mode" json:"mode,omitempty"`
+}
+
+func (m *InsertShoppingListRequest) Reset()                    { *m = InsertShoppingListRequest{} }
+func (m *InsertShoppingListRequest) String() string            { return proto.CompactTextString(m) }
+func (*InsertShoppingListRequest) ProtoMessage()               {}
+func (*InsertShoppingListRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{13} }
+
+func (m *InsertShoppingListRequest) GetAPIKey() string {
+	if m != nil {
+		return m.APIKey
+	}
+	return ""
+}
+
+func (m *InsertShoppingListRequest) GetJWT() string {
+	if m != nil {
+		return m.JWT
+	}
+	return ""
+}
+
+func (m *InsertShoppingListRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *InsertShoppingListRequest) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+type UpdateShoppingListRequest struct {
+	APIKey         string        `protobuf:"bytes,1,opt,name=APIKey" json:"APIKey,omitempty"`
+	JWT            string        `protobuf:"bytes,2,opt,name=JWT" json:"JWT,omitempty"`
+	ShoppingListID string        `protobuf:"bytes,3,opt,name=shoppingListID" json:"shoppingListID,omitempty"`
+	Name           *StringUpdate `protobuf:"bytes,4,opt,name=name" json:"name,omitempty"`
+	Mode           *StringUpdate `protobuf:"bytes,5,opt,name=mode" json:"mode,omitempty"`
+}
+
+func (m *UpdateShoppingListRequest) Reset()                    { *m = UpdateShoppingListRequest{} }
+func (m *UpdateShoppingListRequest) String() string            { return proto.CompactTextString(m) }
+func (*UpdateShoppingListRequest) ProtoMessage()               {}
+func (*UpdateShoppingListRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{14} }
+
+func (m *UpdateShoppingListRequest) GetAPIKey() string {
+	if m != nil {
+		return m.APIKey
+	}
+	return ""
+}
+
+func (m *UpdateShoppingListRequest) GetJWT() string {
+	if m != nil {
+		return m.JWT
+	}
+	return ""
+}
+
+func (m *UpdateShoppingListRequest) GetShoppingListID() string {
+	if m != nil {
+		return m.ShoppingListID
+	}
+	return ""
+}
+
+func (m *UpdateShoppingListRequest) GetName() *StringUpdate {
+	if m != nil {
+		return m.Name
+	}
+	return nil
+}
+
+func (m *UpdateShoppingListRequest) GetMode() *StringUpdate {
+	if m != nil {
+		return m.Mode
+	}
+	return nil
+}
+
+type GetShoppingListsRequest struct {
+	APIKey string `protobuf:"bytes,1,opt,name=APIKey" json:"APIKey,omitempty"`
+	JWT    string `protobuf:"bytes,2,opt,name=JWT" json:"JWT,omitempty"`
+	Offset int64  `protobuf:"varint,3,opt,name=offset" json:"offset,omitempty"`
+	Count  int64  `protobuf:"varint,4,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *GetShoppingListsRequest) Reset()                    { *m = GetShoppingListsRequest{} }
+func (m *GetShoppingListsRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetShoppingListsRequest) ProtoMessage()               {}
+func (*GetShoppingListsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{15} }
+
+func (m *GetShoppingListsRequest) GetAPIKey() string {
+	if m != nil {
+		return m.APIKey
+	}
+	return ""
+}
+
+func (m *GetShoppingListsRequest) GetJWT() string {
+	if m != nil {
+		return m.JWT
+	}
+	return ""
+}
+
+func (m *GetShoppingListsRequest) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *GetShoppingListsRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type ShoppingListsResponse struct {
+	ShoppingLists []*ShoppingList `protobuf:"bytes,1,rep,name=shoppingLists" json:"shoppingLists,omitempty"`
+}
+
+func (m *ShoppingListsResponse) Reset()                    { *m = ShoppingListsResponse{} }
+func (m *ShoppingListsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ShoppingListsResponse) ProtoMessage()               {}
+func (*ShoppingListsResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{16} }
+
+func (m *ShoppingListsResponse) GetShoppingLists() []*ShoppingList {
+	if m != nil {
+		return m.ShoppingLists
+	}
+	return nil
+}
+
+type UpsertShoppingListItemRequest struct {
+	APIKey         string  `protobuf:"bytes,1,opt,name=APIKey" json:"APIKey,omitempty"`
+	JWT            string  `protobuf:"bytes,2,opt,name=JWT" json:"JWT,omitempty"`
+	ShoppingListID string  `protobuf:"bytes,3,opt,name=shoppingListID" json:"shoppingListID,omitempty"`
+	ItemName       string  `protobuf:"bytes,4,opt,name=itemName" json:"itemName,omitempty"`
+	BrandName      string  `protobuf:"bytes,5,opt,name=brandName" json:"brandName,omitempty"`
+	MeasuringUnit  string  `protobuf:"bytes,6,opt,name=measuringUnit" json:"measuringUnit,omitempty"`
+	Quantity       int64   `protobuf:"varint,7,opt,name=quantity" json:"quantity,omitempty"`
+	InList         bool    `protobuf:"varint,8,opt,name=inList" json:"inList,omitempty"`
+	InCart         bool    `protobuf:"varint,9,opt,name=inCart" json:"inCart,omitempty"`
+	UnitPrice      float32 `protobuf:"fixed32,10,opt,name=unitPrice" json:"unitPrice,omitempty"`
+	Currency       string  `protobuf:"bytes,11,opt,name=currency" json:"currency,omitempty"`
+	StoreBranchID  string  `protobuf:"bytes,12,opt,name=storeBranchID" json:"storeBranchID,omitempty"`
+}
+
+func (m *UpsertShoppingListItemRequest) Reset()                    { *m = UpsertShoppingListItemRequest{} }
+func (m *UpsertShoppingListItemRequest) String() string            { return proto.CompactTextString(m) }
+func (*UpsertShoppingListItemRequest) ProtoMessage()               {}
+func (*UpsertShoppingListItemRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{17} }
+
+func (m *UpsertShoppingListItemRequest) GetAPIKey() string {
+	if m != nil {
+		return m.APIKey
+	}
+	return ""
+}
+
+func (m *UpsertShoppingListItemRequest) GetJWT() string {
+	if m != nil {
+		return m.JWT
+	}
+	return ""
+}
+
+func (m *UpsertShoppingListItemRequest) GetShoppingListID() string {
+	if m != nil {
+		return m.ShoppingListID
+	}
+	return ""
+}
+
+func (m *UpsertShoppingListItemRequest) GetItemName() string {
+	if m != nil {
+		return m.ItemName
+	}
+	return ""
+}
+
+func (m *UpsertShoppingListItemRequest) GetBrandName() string {
+	if m != nil {
+		return m.BrandName
+	}
+	return ""
+}
+
+func (m *UpsertShoppingListItemRequest) GetMeasuringUnit() string {
+	if m != nil {
+		return m.MeasuringUnit
+	}
+	return ""
+}
+
+func (m *UpsertShoppingListItemRequest) GetQuantity() int64 {
+	if m != nil {
+		return m.Quantity
+	}
+	return 0
+}
+
+func (m *UpsertShoppingListItemRequest) GetInList() bool {
+	if m != nil {
+		return m.InList
+	}
+	return false
+}
+
+func (m *UpsertShoppingListItemRequest) GetInCart() bool {
+	if m != nil {
+		return m.InCart
+	}
+	return false
+}
+
+func (m *UpsertShoppingListItemRequest) GetUnitPrice() float32 {
+	if m != nil {
+		return m.UnitPrice
+	}
+	return 0
+}
+
+func (m *UpsertShoppingListItemRequest) GetCurrency() string {
+	if m != nil {
+		return m.Currency
+	}
+	return ""
+}
+
+func (m *UpsertShoppingListItemRequest) GetStoreBranchID() string {
+	if m != nil {
+		return m.StoreBranchID
+	}
+	return ""
+}
+
+type DeleteShoppingListItemRequest struct {
+	APIKey string `protobuf:"bytes,1,opt,name=APIKey" json:"APIKey,omitempty"`
+	JWT    string `protobuf:"bytes,2,opt,name=JWT" json:"JWT,omitempty"`
+	ID     string `protobuf:"bytes,3,opt,name=ID" json:"ID,omitempty"`
+}
+
+func (m *DeleteShoppingListItemRequest) Reset()                    { *m = DeleteShoppingListItemRequest{} }
+func (m *DeleteShoppingListItemRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteShoppingListItemRequest) ProtoMessage()               {}
+func (*DeleteShoppingListItemRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{18} }
+
+func (m *DeleteShoppingListItemRequest) GetAPIKey() string {
+	if m != nil {
+		return m.APIKey
+	}
+	return ""
+}
+
+func (m *DeleteShoppingListItemRequest) GetJWT() string {
+	if m != nil {
+		return m.JWT
+	}
+	return ""
+}
+
+func (m *DeleteShoppingListItemRequest) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+type GetShoppingListItemsRequest struct {
+	APIKey         string `protobuf:"bytes,1,opt,name=APIKey" json:"APIKey,omitempty"`
+	JWT            string `protobuf:"bytes,2,opt,name=JWT" json:"JWT,omitempty"`
+	ShoppingListID string `protobuf:"bytes,3,opt,name=shoppingListID" json:"shoppingListID,omitempty"`
+	Offset         int64  `protobuf:"varint,4,opt,name=offset" json:"offset,omitempty"`
+	Count          int64  `protobuf:"varint,5,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *GetShoppingListItemsRequest) Reset()                    { *m = GetShoppingListItemsRequest{} }
+func (m *GetShoppingListItemsRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetShoppingListItemsRequest) ProtoMessage()               {}
+func (*GetShoppingListItemsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{19} }
+
+func (m *GetShoppingListItemsRequest) GetAPIKey() string {
+	if m != nil {
+		return m.APIKey
+	}
+	return ""
+}
+
+func (m *GetShoppingListItemsRequest) GetJWT() string {
+	if m != nil {
+		return m.JWT
+	}
+	return ""
+}
+
+func (m *GetShoppingListItemsRequest) GetShoppingListID() string {
+	if m != nil {
+		return m.ShoppingListID
+	}
+	return ""
+}
+
+func (m *GetShoppingListItemsRequest) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *GetShoppingListItemsRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type SearchShoppingItemsRequest struct {
+	APIKey        string `protobuf:"bytes,1,opt,name=APIKey" json:"APIKey,omitempty"`
+	JWT           string `protobuf:"bytes,2,opt,name=JWT" json:"JWT,omitempty"`
+	ItemName      string `protobuf:"bytes,3,opt,name=itemName" json:"itemName,omitempty"`
+	BrandName     string `protobuf:"bytes,4,opt,name=brandName" json:"brandName,omitempty"`
+	MeasuringUnit string `protobuf:"bytes,5,opt,name=measuringUnit" json:"measuringUnit,omitempty"`
+	Offset        int64  `protobuf:"varint,6,opt,name=offset" json:"offset,omitempty"`
+	Count         int64  `protobuf:"varint,7,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *SearchShoppingItemsRequest) Reset()                    { *m = SearchShoppingItemsRequest{} }
+func (m *SearchShoppingItemsRequest) String() string            { return proto.CompactTextString(m) }
+func (*SearchShoppingItemsRequest) ProtoMessage()               {}
+func (*SearchShoppingItemsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{20} }
+
+func (m *SearchShoppingItemsRequest) GetAPIKey() string {
+	if m != nil {
+		return m.APIKey
+	}
+	return ""
+}
+
+func (m *SearchShoppingItemsRequest) GetJWT() string {
+	if m != nil {
+		return m.JWT
+	}
+	return ""
+}
+
+func (m *SearchShoppingItemsRequest) GetItemName() string {
+	if m != nil {
+		return m.ItemName
+	}
+	return ""
+}
+
+func (m *SearchShoppingItemsRequest) GetBrandName() string {
+	if m != nil {
+		return m.BrandName
+	}
+	return ""
+}
+
+func (m *SearchShoppingItemsRequest) GetMeasuringUnit() string {
+	if m != nil {
+		return m.MeasuringUnit
+	}
+	return ""
+}
+
+func (m *SearchShoppingItemsRequest) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *SearchShoppingItemsRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type ShoppingListItemsResponse struct {
+	Items []*ShoppingListItem `protobuf:"bytes,1,rep,name=items" json:"items,omitempty"`
+}
+
+func (m *ShoppingListItemsResponse) Reset()                    { *m = ShoppingListItemsResponse{} }
+func (m *ShoppingListItemsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ShoppingListItemsResponse) ProtoMessage()               {}
+func (*ShoppingListItemsResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{21} }
+
+func (m *ShoppingListItemsResponse) GetItems() []*ShoppingListItem {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+type DuplicatesRequest struct {
+	APIKey    string  `protobuf:"bytes,1,opt,name=APIKey" json:"APIKey,omitempty"`
+	Threshold float64 `protobuf:"fixed64,2,opt,name=threshold" json:"threshold,omitempty"`
+}
+
+func (m *DuplicatesRequest) Reset()                    { *m = DuplicatesRequest{} }
+func (m *DuplicatesRequest) String() string            { return proto.CompactTextString(m) }
+func (*DuplicatesRequest) ProtoMessage()               {}
+func (*DuplicatesRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{22} }
+
+func (m *DuplicatesRequest) GetAPIKey() string {
+	if m != nil {
+		return m.APIKey
+	}
+	return ""
+}
+
+func (m *DuplicatesRequest) GetThreshold() float64 {
+	if m != nil {
+		return m.Threshold
+	}
+	return 0
+}
+
+type DuplicateGroupsResponse struct {
+	DuplicateGroups []*DuplicateGroup `protobuf:"bytes,1,rep,name=duplicateGroups" json:"duplicateGroups,omitempty"`
+}
+
+func (m *DuplicateGroupsResponse) Reset()                    { *m = DuplicateGroupsResponse{} }
+func (m *DuplicateGroupsResponse) String() string            { return proto.CompactTextString(m) }
+func (*DuplicateGroupsResponse) ProtoMessage()               {}
+func (*DuplicateGroupsResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{23} }
+
+func (m *DuplicateGroupsResponse) GetDuplicateGroups() []*DuplicateGroup {
+	if m != nil {
+		return m.DuplicateGroups
+	}
+	return nil
+}
+
+type MergeRequest struct {
+	APIKey       string   `protobuf:"bytes,1,opt,name=APIKey" json:"APIKey,omitempty"`
+	SurvivorID   string   `protobuf:"bytes,2,opt,name=survivorID" json:"survivorID,omitempty"`
+	DuplicateIDs []string `protobuf:"bytes,3,rep,name=duplicateIDs" json:"duplicateIDs,omitempty"`
+}
+
+func (m *MergeRequest) Reset()                    { *m = MergeRequest{} }
+func (m *MergeRequest) String() string            { return proto.CompactTextString(m) }
+func (*MergeRequest) ProtoMessage()               {}
+func (*MergeRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{24} }
+
+func (m *MergeRequest) GetAPIKey() string {
+	if m != nil {
+		return m.APIKey
+	}
+	return ""
+}
+
+func (m *MergeRequest) GetSurvivorID() string {
+	if m != nil {
+		return m.SurvivorID
+	}
+	return ""
+}
+
+func (m *MergeRequest) GetDuplicateIDs() []string {
+	if m != nil {
+		return m.DuplicateIDs
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*ShoppingList)(nil), "api.ShoppingList")
+	proto.RegisterType((*MeasuringUnit)(nil), "api.MeasuringUnit")
+	proto.RegisterType((*Item)(nil), "api.Item")
+	proto.RegisterType((*Brand)(nil), "api.Brand")
+	proto.RegisterType((*Store)(nil), "api.Store")
+	proto.RegisterType((*Location)(nil), "api.Location")
+	proto.RegisterType((*StoreBranch)(nil), "api.StoreBranch")
+	proto.RegisterType((*Price)(nil), "api.Price")
+	proto.RegisterType((*ShoppingListItem)(nil), "api.ShoppingListItem")
+	proto.RegisterType((*CatalogEntry)(nil), "api.CatalogEntry")
+	proto.RegisterType((*DuplicateGroup)(nil), "api.DuplicateGroup")
+	proto.RegisterType((*StringUpdate)(nil), "api.StringUpdate")
+	proto.RegisterType((*Empty)(nil), "api.Empty")
+	proto.RegisterType((*InsertShoppingListRequest)(nil), "api.InsertShoppingListRequest")
+	proto.RegisterType((*UpdateShoppingListRequest)(nil), "api.UpdateShoppingListRequest")
+	proto.RegisterType((*GetShoppingListsRequest)(nil), "api.GetShoppingListsRequest")
+	proto.RegisterType((*ShoppingListsResponse)(nil), "api.ShoppingListsResponse")
+	proto.RegisterType((*UpsertShoppingListItemRequest)(nil), "api.UpsertShoppingListItemRequest")
+	proto.RegisterType((*DeleteShoppingListItemRequest)(nil), "api.DeleteShoppingListItemRequest")
+	proto.RegisterType((*GetShoppingListItemsRequest)(nil), "api.GetShoppingListItemsRequest")
+	proto.RegisterType((*SearchShoppingItemsRequest)(nil), "api.SearchShoppingItemsRequest")
+	proto.RegisterType((*ShoppingListItemsResponse)(nil), "api.ShoppingListItemsResponse")
+	proto.RegisterType((*DuplicatesRequest)(nil), "api.DuplicatesRequest")
+	proto.RegisterType((*DuplicateGroupsResponse)(nil), "api.DuplicateGroupsResponse")
+	proto.RegisterType((*MergeRequest)(nil), "api.MergeRequest")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ client.Option
+var _ server.Option
+
+// Client API for ShoppingLists service
+
+type ShoppingListsClient interface {
+	InsertShoppingList(ctx context.Context, in *InsertShoppingListRequest, opts ...client.CallOption) (*ShoppingList, error)
+	UpdateShoppingList(ctx context.Context, in *UpdateShoppingListRequest, opts ...client.CallOption) (*ShoppingList, error)
+	GetShoppingLists(ctx context.Context, in *GetShoppingListsRequest, opts ...client.CallOption) (*ShoppingListsResponse, error)
+	UpsertShoppingListItem(ctx context.Context, in *UpsertShoppingListItemRequest, opts ...client.CallOption) (*ShoppingListItem, error)
+	DeleteShoppingListItem(ctx context.Context, in *DeleteShoppingListItemRequest, opts ...client.CallOption) (*Empty, error)
+	GetShoppingListItems(ctx context.Context, in *GetShoppingListItemsRequest, opts ...client.CallOption) (*ShoppingListItemsResponse, error)
+	SearchShoppingItems(ctx context.Context, in *SearchShoppingItemsRequest, opts ...client.CallOption) (*ShoppingListItemsResponse, error)
+	DuplicateItems(ctx context.Context, in *DuplicatesRequest, opts ...client.CallOption) (*DuplicateGroupsResponse, error)
+	DuplicateBrands(ctx context.Context, in *DuplicatesRequest, opts ...client.CallOption) (*DuplicateGroupsResponse, error)
+	MergeItems(ctx context.Context, in *MergeRequest, opts ...client.CallOption) (*Item, error)
+	MergeBrands(ctx context.Context, in *MergeRequest, opts ...client.CallOption) (*Brand, error)
+}
+
+type shoppingListsClient struct {
+	c           client.Client
+	serviceName string
+}
+
+func NewShoppingListsClient(serviceName string, c client.Client) ShoppingListsClient {
+	if c == nil {
+		c = client.NewClient()
+	}
+	if len(serviceName) == 0 {
+		serviceName = "api"
+	}
+	return &shoppingListsClient{
+		c:           c,
+		serviceName: serviceName,
+	}
+}
+
+func (c *shoppingListsClient) InsertShoppingList(ctx context.Context, in *InsertShoppingListRequest, opts ...client.CallOption) (*ShoppingList, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.InsertShoppingList", in)
+	out := new(ShoppingList)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoppingListsClient) UpdateShoppingList(ctx context.Context, in *UpdateShoppingListRequest, opts ...client.CallOption) (*ShoppingList, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.UpdateShoppingList", in)
+	out := new(ShoppingList)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoppingListsClient) GetShoppingLists(ctx context.Context, in *GetShoppingListsRequest, opts ...client.CallOption) (*ShoppingListsResponse, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.GetShoppingLists", in)
+	out := new(ShoppingListsResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoppingListsClient) UpsertShoppingListItem(ctx context.Context, in *UpsertShoppingListItemRequest, opts ...client.CallOption) (*ShoppingListItem, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.UpsertShoppingListItem", in)
+	out := new(ShoppingListItem)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoppingListsClient) DeleteShoppingListItem(ctx context.Context, in *DeleteShoppingListItemRequest, opts ...client.CallOption) (*Empty, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.DeleteShoppingListItem", in)
+	out := new(Empty)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoppingListsClient) GetShoppingListItems(ctx context.Context, in *GetShoppingListItemsRequest, opts ...client.CallOption) (*ShoppingListItemsResponse, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.GetShoppingListItems", in)
+	out := new(ShoppingListItemsResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoppingListsClient) SearchShoppingItems(ctx context.Context, in *SearchShoppingItemsRequest, opts ...client.CallOption) (*ShoppingListItemsResponse, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.SearchShoppingItems", in)
+	out := new(ShoppingListItemsResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoppingListsClient) DuplicateItems(ctx context.Context, in *DuplicatesRequest, opts ...client.CallOption) (*DuplicateGroupsResponse, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.DuplicateItems", in)
+	out := new(DuplicateGroupsResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoppingListsClient) DuplicateBrands(ctx context.Context, in *DuplicatesRequest, opts ...client.CallOption) (*DuplicateGroupsResponse, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.DuplicateBrands", in)
+	out := new(DuplicateGroupsResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoppingListsClient) MergeItems(ctx context.Context, in *MergeRequest, opts ...client.CallOption) (*Item, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.MergeItems", in)
+	out := new(Item)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shoppingListsClient) MergeBrands(ctx context.Context, in *MergeRequest, opts ...client.CallOption) (*Brand, error) {
+	req := c.c.NewRequest(c.serviceName, "ShoppingLists.MergeBrands", in)
+	out := new(Brand)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for ShoppingLists service
+
+type ShoppingListsHandler interface {
+	InsertShoppingList(context.Context, *InsertShoppingListRequest, *ShoppingList) error
+	UpdateShoppingList(context.Context, *UpdateShoppingListRequest, *ShoppingList) error
+	GetShoppingLists(context.Context, *GetShoppingListsRequest, *ShoppingListsResponse) error
+	UpsertShoppingListItem(context.Context, *UpsertShoppingListItemRequest, *ShoppingListItem) error
+	DeleteShoppingListItem(context.Context, *DeleteShoppingListItemRequest, *Empty) error
+	GetShoppingListItems(context.Context, *GetShoppingListItemsRequest, *ShoppingListItemsResponse) error
+	SearchShoppingItems(context.Context, *SearchShoppingItemsRequest, *ShoppingListItemsResponse) error
+	DuplicateItems(context.Context, *DuplicatesRequest, *DuplicateGroupsResponse) error
+	DuplicateBrands(context.Context, *DuplicatesRequest, *DuplicateGroupsResponse) error
+	MergeItems(context.Context, *MergeRequest, *Item) error
+	MergeBrands(context.Context, *MergeRequest, *Brand) error
+}
+
+func RegisterShoppingListsHandler(s server.Server, hdlr ShoppingListsHandler, opts ...server.HandlerOption) {
+	s.Handle(s.NewHandler(&ShoppingLists{hdlr}, opts...))
+}
+
+type ShoppingLists struct {
+	ShoppingListsHandler
+}
+
+func (h *ShoppingLists) InsertShoppingList(ctx context.Context, in *InsertShoppingListRequest, out *ShoppingList) error {
+	return h.ShoppingListsHandler.InsertShoppingList(ctx, in, out)
+}
+
+func (h *ShoppingLists) UpdateShoppingList(ctx context.Context, in *UpdateShoppingListRequest, out *ShoppingList) error {
+	return h.ShoppingListsHandler.UpdateShoppingList(ctx, in, out)
+}
+
+func (h *ShoppingLists) GetShoppingLists(ctx context.Context, in *GetShoppingListsRequest, out *ShoppingListsResponse) error {
+	return h.ShoppingListsHandler.GetShoppingLists(ctx, in, out)
+}
+
+func (h *ShoppingLists) UpsertShoppingListItem(ctx context.Context, in *UpsertShoppingListItemRequest, out *ShoppingListItem) error {
+	return h.ShoppingListsHandler.UpsertShoppingListItem(ctx, in, out)
+}
+
+func (h *ShoppingLists) DeleteShoppingListItem(ctx context.Context, in *DeleteShoppingListItemRequest, out *Empty) error {
+	return h.ShoppingListsHandler.DeleteShoppingListItem(ctx, in, out)
+}
+
+func (h *ShoppingLists) GetShoppingListItems(ctx context.Context, in *GetShoppingListItemsRequest, out *ShoppingListItemsResponse) error {
+	return h.ShoppingListsHandler.GetShoppingListItems(ctx, in, out)
+}
+
+func (h *ShoppingLists) SearchShoppingItems(ctx context.Context, in *SearchShoppingItemsRequest, out *ShoppingListItemsResponse) error {
+	return h.ShoppingListsHandler.SearchShoppingItems(ctx, in, out)
+}
+
+func (h *ShoppingLists) DuplicateItems(ctx context.Context, in *DuplicatesRequest, out *DuplicateGroupsResponse) error {
+	return h.ShoppingListsHandler.DuplicateItems(ctx, in, out)
+}
+
+func (h *ShoppingLists) DuplicateBrands(ctx context.Context, in *DuplicatesRequest, out *DuplicateGroupsResponse) error {
+	return h.ShoppingListsHandler.DuplicateBrands(ctx, in, out)
+}
+
+func (h *ShoppingLists) MergeItems(ctx context.Context, in *MergeRequest, out *Item) error {
+	return h.ShoppingListsHandler.MergeItems(ctx, in, out)
+}
+
+func (h *ShoppingLists) MergeBrands(ctx context.Context, in *MergeRequest, out *Brand) error {
+	return h.ShoppingListsHandler.MergeBrands(ctx, in, out)
+}
+
+func init() { proto.RegisterFile("github.com/tomogoma/shoppingms/pkg/api/shopping.proto", fileDescriptor1) }
+
+var fileDescriptor1 = []byte{
+	// 1231 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x57, 0xcf, 0x6e, 0xdb, 0xc6,
+	0x13, 0x16, 0x25, 0x51, 0x7f, 0x46, 0x52, 0xe2, 0x6c, 0x1c, 0x87, 0xd6, 0xcf, 0xf6, 0x4f, 0x58,
+	0x24, 0x45, 0x5a, 0x17, 0x36, 0xe0, 0x20, 0x6d, 0x2f, 0x3d, 0x34, 0x51, 0x90, 0x30, 0x8d, 0x53,
+	0x83, 0xaa, 0xdb, 0xb4, 0x37, 0x9a, 0xda, 0x48, 0x6c, 0x25, 0x92, 0xe6, 0x2e, 0x5d, 0xf8, 0x3d,
+	0x7a, 0x2a, 0x50, 0xa0, 0xef, 0xd1, 0x53, 0x4f, 0x7d, 0x81, 0xbe, 0x4c, 0x0f, 0x05, 0x8a, 0xfd,
+	0x43, 0x72, 0x29, 0x51, 0xb6, 0x9a, 0x20, 0x37, 0xce, 0xec, 0x70, 0xf8, 0xcd, 0xb7, 0x33, 0xdf,
+	0x2e, 0xe1, 0xd1, 0xc4, 0x67, 0xd3, 0xe4, 0xec, 0xc0, 0x0b, 0xe7, 0x87, 0x2c, 0x9c, 0x87, 0x93,
+	0x70, 0xee, 0x1e, 0xd2, 0x69, 0x18, 0x45, 0x7e, 0x30, 0x99, 0xd3, 0xc3, 0xe8, 0xc7, 0xc9, 0xa1,
+	0x1b, 0xf9, 0x99, 0xeb, 0x20, 0x8a, 0x43, 0x16, 0xa2, 0x9a, 0x1b, 0xf9, 0xf8, 0x17, 0x03, 0xba,
+	0x23, 0xe5, 0x7f, 0xe9, 0x53, 0x86, 0x6e, 0x40, 0xd5, 0x1e, 0x5a, 0xc6, 0xc0, 0x78, 0xd0, 0x76,
+	0xaa, 0xf6, 0x10, 0x6d, 0x41, 0x23, 0xa1, 0x24, 0xb6, 0x87, 0x56, 0x55, 0xf8, 0x94, 0x85, 0x10,
+	0xd4, 0x03, 0x77, 0x4e, 0xac, 0x9a, 0xf0, 0x8a, 0x67, 0xee, 0x9b, 0x87, 0x63, 0x62, 0xd5, 0xa5,
+	0x8f, 0x3f, 0x23, 0x0b, 0x9a, 0x5e, 0x4c, 0x5c, 0x46, 0xc6, 0x96, 0x29, 0xdc, 0xa9, 0x89, 0x06,
+	0xd0, 0x99, 0xb9, 0x94, 0x9d, 0x46, 0x63, 0xb1, 0xda, 0x10, 0xab, 0xba, 0x0b, 0x3f, 0x84, 0xde,
+	0x31, 0x71, 0x69, 0x12, 0xfb, 0xc1, 0xe4, 0x34, 0xf0, 0x97, 0xc1, 0xa5, 0x20, 0xaa, 0x39, 0x08,
+	0xfc, 0x11, 0xd4, 0x6d, 0x46, 0xe6, 0x6b, 0xc5, 0xfe, 0x66, 0x80, 0xf9, 0x38, 0x76, 0x83, 0xf1,
+	0x3a, 0xd1, 0xe8, 0x33, 0xe8, 0xcd, 0x75, 0x38, 0xa2, 0xf6, 0xce, 0x11, 0x3a, 0x70, 0x23, 0xff,
+	0xa0, 0x00, 0xd4, 0x29, 0x06, 0xa2, 0x5d, 0xa8, 0xfb, 0x8c, 0xcc, 0x05, 0x31, 0x9d, 0xa3, 0xb6,
+	0x78, 0x81, 0x83, 0x74, 0x84, 0x1b, 0xf5, 0xa1, 0x75, 0xe6, 0xc6, 0x5e, 0x38, 0x26, 0xd4, 0x32,
+	0x07, 0xb5, 0x07, 0x6d, 0x27, 0xb3, 0xf1, 0x3e, 0x98, 0x23, 0x16, 0xc6, 0x64, 0xad, 0x7a, 0x86,
+	0xd0, 0x7a, 0x19, 0x7a, 0x2e, 0xf3, 0xc3, 0x80, 0x27, 0x9d, 0xb9, 0xcc, 0x67, 0xc9, 0x98, 0x88,
+	0xb7, 0x0c, 0x27, 0xb3, 0xd1, 0x0e, 0xb4, 0x67, 0x61, 0x30, 0x91, 0x8b, 0x55, 0xb1, 0x98, 0x3b,
+	0xf0, 0xcf, 0x06, 0x74, 0xc4, 0x37, 0x39, 0x35, 0xde, 0x74, 0x2d, 0x6e, 0x06, 0x60, 0x52, 0xfe,
+	0x8a, 0xe2, 0x04, 0x44, 0x89, 0x22, 0x89, 0x23, 0x17, 0xd0, 0x87, 0xd0, 0x9a, 0x29, 0x6c, 0x8a,
+	0x87, 0x9e, 0x08, 0x4a, 0x01, 0x3b, 0xd9, 0x32, 0xda, 0x04, 0xf3, 0xab, 0xd1, 0xb1, 0x3d, 0x54,
+	0x1d, 0x23, 0x0d, 0xfc, 0xb7, 0x01, 0xe6, 0x49, 0xec, 0x7b, 0xcb, 0x54, 0x6c, 0x82, 0x79, 0xe1,
+	0xce, 0x12, 0x89, 0xa8, 0xea, 0x48, 0x83, 0x13, 0xe0, 0x25, 0x71, 0x4c, 0x02, 0xef, 0x52, 0x75,
+	0x69, 0x66, 0x73, 0xb8, 0x67, 0x7c, 0xdf, 0xad, 0xba, 0x06, 0x57, 0x74, 0x82, 0x23, 0x17, 0xd0,
+	0x27, 0xd0, 0x73, 0x99, 0xc6, 0x82, 0xc0, 0xd2, 0x39, 0xda, 0xc8, 0x0b, 0x93, 0x7e, 0xa7, 0x18,
+	0xc6, 0xe7, 0x85, 0x32, 0x97, 0x25, 0x54, 0x35, 0xb4, 0xb2, 0x10, 0x86, 0x6e, 0x98, 0xb0, 0x99,
+	0x4f, 0xe2, 0x91, 0xc7, 0x79, 0x6a, 0x0a, 0xd6, 0x0b, 0x3e, 0x7d, 0x56, 0x5a, 0x85, 0x59, 0xc1,
+	0x7f, 0x1a, 0xb0, 0xa1, 0x8f, 0x69, 0x69, 0x87, 0xf7, 0xa1, 0x75, 0x9e, 0xb8, 0x01, 0xf3, 0xd9,
+	0xa5, 0x60, 0xa2, 0xe6, 0x64, 0x36, 0x87, 0xe5, 0x07, 0xfc, 0x4d, 0x41, 0x45, 0xcb, 0x51, 0x96,
+	0xf4, 0x3f, 0x71, 0x63, 0x66, 0xd5, 0x53, 0x3f, 0xb7, 0xd0, 0x23, 0xe8, 0x52, 0xed, 0x7b, 0xaa,
+	0xfa, 0x5b, 0xb2, 0x7a, 0x6d, 0xc1, 0x29, 0x84, 0x71, 0x5e, 0x23, 0xbe, 0x45, 0x56, 0x43, 0xe3,
+	0x55, 0x6c, 0x9a, 0x23, 0x17, 0xf0, 0x11, 0x74, 0x9f, 0xb8, 0xcc, 0x9d, 0x85, 0x93, 0xa7, 0x01,
+	0x8b, 0x2f, 0xd7, 0x6a, 0xeb, 0x11, 0xdc, 0x18, 0x26, 0xd1, 0xcc, 0xf7, 0x5c, 0x46, 0x9e, 0xc5,
+	0x61, 0x12, 0xa1, 0x7d, 0x68, 0x92, 0x80, 0xc5, 0x3e, 0xa1, 0x96, 0x31, 0xa8, 0x65, 0xc8, 0xf4,
+	0xcc, 0x4e, 0x1a, 0xc1, 0xdb, 0x83, 0x0a, 0xce, 0x65, 0xa7, 0x4b, 0x03, 0x3f, 0x86, 0xee, 0x88,
+	0x89, 0x09, 0x15, 0x6a, 0xc3, 0xd9, 0x4b, 0xf8, 0x93, 0x1f, 0x4c, 0x04, 0x9c, 0x96, 0x93, 0xd9,
+	0x9c, 0xa5, 0x80, 0xfc, 0xf4, 0x8d, 0x3b, 0x4b, 0x45, 0x50, 0x5a, 0xb8, 0x09, 0xe6, 0xd3, 0x79,
+	0xc4, 0x2e, 0xf1, 0x1c, 0xb6, 0xed, 0x80, 0x92, 0x98, 0x15, 0xb8, 0x21, 0xe7, 0x09, 0x91, 0x1c,
+	0x7f, 0x71, 0x62, 0x7f, 0x49, 0x2e, 0x55, 0x99, 0xca, 0x42, 0x1b, 0x50, 0x7b, 0xf1, 0xed, 0xd7,
+	0x2a, 0x25, 0x7f, 0x5c, 0x57, 0x54, 0xf1, 0xef, 0x06, 0x6c, 0x4b, 0xd8, 0xef, 0xf6, 0xbd, 0x0f,
+	0xe0, 0x86, 0xbe, 0x7d, 0xf6, 0x50, 0x7d, 0x79, 0xc1, 0x8b, 0xee, 0x2b, 0x5c, 0x75, 0xbd, 0x0b,
+	0x34, 0xf2, 0x14, 0xd4, 0xfb, 0x0a, 0xaa, 0xb9, 0x32, 0x4c, 0xa0, 0x3f, 0x87, 0xbb, 0xcf, 0x48,
+	0x81, 0x29, 0xfa, 0xdf, 0xa1, 0x6f, 0x41, 0x23, 0x7c, 0xf3, 0x86, 0x12, 0xd9, 0xd0, 0x35, 0x47,
+	0x59, 0x7c, 0xb3, 0xbd, 0x30, 0x09, 0x64, 0x3f, 0xd7, 0x1c, 0x69, 0xe0, 0x13, 0xb8, 0xb3, 0xf0,
+	0x3d, 0x1a, 0x85, 0x01, 0x25, 0xe8, 0x53, 0xe8, 0xe9, 0xb5, 0x16, 0xdb, 0xa9, 0x40, 0x6e, 0x31,
+	0x0e, 0xff, 0x53, 0x85, 0xdd, 0xd3, 0x68, 0x71, 0xcb, 0x85, 0xa8, 0xbf, 0xb7, 0x6d, 0xe8, 0x43,
+	0x8b, 0x9f, 0x17, 0xaf, 0xd2, 0xad, 0x68, 0x3b, 0x99, 0xcd, 0x25, 0x5d, 0x08, 0x97, 0x58, 0x94,
+	0xba, 0x99, 0x3b, 0xd0, 0xbd, 0xc5, 0xa3, 0x4b, 0x8a, 0x53, 0xd1, 0x59, 0x10, 0x90, 0xe6, 0x4a,
+	0x01, 0x69, 0xad, 0x10, 0x90, 0x76, 0x41, 0x40, 0x76, 0xa0, 0x9d, 0x04, 0x3e, 0x13, 0xb3, 0x6f,
+	0x81, 0xd0, 0xe5, 0xdc, 0x51, 0xd0, 0xe6, 0xce, 0x82, 0x36, 0xdf, 0x83, 0x1e, 0xcd, 0x05, 0xd5,
+	0x1e, 0x5a, 0x5d, 0x89, 0xb5, 0xe0, 0xc4, 0xdf, 0xc1, 0xee, 0x90, 0xcc, 0x08, 0x23, 0xef, 0x4e,
+	0xbf, 0x94, 0xa0, 0x5a, 0x2a, 0x41, 0xf8, 0x57, 0x03, 0xfe, 0xb7, 0xd0, 0xa0, 0x3c, 0x31, 0x7d,
+	0x7f, 0x1b, 0x9b, 0x37, 0x73, 0xbd, 0xbc, 0x99, 0x4d, 0xbd, 0x99, 0xff, 0x32, 0xa0, 0x3f, 0x22,
+	0x6e, 0xec, 0x4d, 0x53, 0x88, 0x6f, 0x09, 0x4f, 0xef, 0xa7, 0xda, 0x55, 0xfd, 0x54, 0xbf, 0xb6,
+	0x9f, 0xcc, 0xb2, 0x7e, 0xca, 0xcb, 0x6a, 0x94, 0x97, 0xd5, 0xd4, 0xcb, 0x7a, 0x0e, 0xdb, 0x25,
+	0x94, 0xab, 0x39, 0xdd, 0x07, 0x93, 0x43, 0x4b, 0xe7, 0xf3, 0xce, 0xd2, 0x7c, 0x8a, 0xad, 0x97,
+	0x31, 0xd8, 0x86, 0x5b, 0xd9, 0x79, 0x71, 0x2d, 0x2d, 0x3b, 0xd0, 0x66, 0xd3, 0x98, 0xd0, 0x69,
+	0x38, 0x1b, 0xa7, 0x77, 0xa1, 0xcc, 0x81, 0x5f, 0xc3, 0xdd, 0xe2, 0xd1, 0x93, 0x43, 0xfa, 0x1c,
+	0x6e, 0x8e, 0x8b, 0x4b, 0x0a, 0xdc, 0x6d, 0x01, 0xae, 0xf8, 0x9a, 0xb3, 0x18, 0x8b, 0x7f, 0x80,
+	0xee, 0x31, 0x89, 0x27, 0xe4, 0x3a, 0x7c, 0x7b, 0x00, 0x34, 0x89, 0x2f, 0xfc, 0x8b, 0x30, 0xbf,
+	0x84, 0x6b, 0x1e, 0x7e, 0xb1, 0xc8, 0x52, 0xdb, 0x43, 0x6a, 0xd5, 0xc4, 0x05, 0xb2, 0xe0, 0x3b,
+	0xfa, 0xa3, 0x01, 0xbd, 0x82, 0xfe, 0x21, 0x1b, 0xd0, 0xf2, 0x81, 0x85, 0xf6, 0xe4, 0xcd, 0x74,
+	0xd5, 0x49, 0xd6, 0x5f, 0x96, 0x45, 0x5c, 0xe1, 0xa9, 0x96, 0xcf, 0x22, 0x95, 0x6a, 0xe5, 0x21,
+	0x55, 0x9e, 0xea, 0x15, 0x6c, 0x2c, 0x9e, 0x0c, 0x68, 0x47, 0x04, 0xae, 0x38, 0x30, 0xfa, 0xfd,
+	0xa5, 0x34, 0xd9, 0x06, 0xe1, 0x0a, 0x3a, 0x85, 0xad, 0x72, 0x8d, 0x46, 0x58, 0xc1, 0xbb, 0x42,
+	0xc0, 0xfb, 0xe5, 0x4d, 0x86, 0x2b, 0xe8, 0x05, 0x6c, 0x95, 0x6b, 0x8f, 0x4a, 0x7b, 0xa5, 0x30,
+	0xf5, 0xe5, 0xa5, 0x48, 0xde, 0x1b, 0x2a, 0xe8, 0x7b, 0xd8, 0x2c, 0xd3, 0x1a, 0x34, 0x28, 0x2b,
+	0x5b, 0x9f, 0xf3, 0xfe, 0x5e, 0x29, 0x3c, 0xbd, 0xfc, 0xd7, 0x70, 0xbb, 0x44, 0x27, 0xd0, 0xff,
+	0xe5, 0x8b, 0x2b, 0x15, 0x64, 0x8d, 0xcc, 0xcf, 0xb5, 0x1b, 0x99, 0x4c, 0xba, 0x55, 0x6c, 0xfa,
+	0x2c, 0xd7, 0x4e, 0xc9, 0x30, 0xe8, 0x99, 0x6c, 0xb8, 0x99, 0x2d, 0x8a, 0x0b, 0xf8, 0xdb, 0xa7,
+	0xfa, 0x18, 0x40, 0x4c, 0x94, 0x04, 0x74, 0x4b, 0xfd, 0x96, 0xe5, 0x23, 0xd6, 0xcf, 0x7f, 0xbc,
+	0x70, 0x05, 0x1d, 0x40, 0x47, 0x2c, 0xaa, 0x8f, 0x96, 0x84, 0x6b, 0x7f, 0x05, 0xb8, 0x72, 0xd6,
+	0x10, 0x7f, 0xcd, 0x0f, 0xff, 0x1d, 0x00, 0x54, 0xd0, 0x01, 0xea, 0x6e, 0x0f, 0x00, 0x00,
+}
@@ -6,10 +6,39 @@ Package api is a generated protocol buffer package.
 
 It is generated from these files:
 	github.com/tomogoma/shoppingms/pkg/api/status.proto
+	github.com/tomogoma/shoppingms/pkg/api/shopping.proto
 
 It has these top-level messages:
 	Request
 	Response
+	ConfigStatus
+	ConfigOverride
+	DependencyHealth
+	ShoppingList
+	MeasuringUnit
+	Item
+	Brand
+	Store
+	Location
+	StoreBranch
+	Price
+	ShoppingListItem
+	CatalogEntry
+	DuplicateGroup
+	StringUpdate
+	Empty
+	InsertShoppingListRequest
+	UpdateShoppingListRequest
+	GetShoppingListsRequest
+	ShoppingListsResponse
+	UpsertShoppingListItemRequest
+	DeleteShoppingListItemRequest
+	GetShoppingListItemsRequest
+	SearchShoppingItemsRequest
+	ShoppingListItemsResponse
+	DuplicatesRequest
+	DuplicateGroupsResponse
+	MergeRequest
 */
 package api
 
@@ -51,10 +80,14 @@ func (m *Request) GetAPIKey() string {
 }
 
 type Response struct {
-	Name          string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	Version       string `protobuf:"bytes,2,opt,name=version" json:"version,omitempty"`
-	Description   string `protobuf:"bytes,3,opt,name=description" json:"description,omitempty"`
-	CanonicalName string `protobuf:"bytes,4,opt,name=canonicalName" json:"canonicalName,omitempty"`
+	Name          string              `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Version       string              `protobuf:"bytes,2,opt,name=version" json:"version,omitempty"`
+	Description   string              `protobuf:"bytes,3,opt,name=description" json:"description,omitempty"`
+	CanonicalName string              `protobuf:"bytes,4,opt,name=canonicalName" json:"canonicalName,omitempty"`
+	Live          bool                `protobuf:"varint,5,opt,name=live" json:"live,omitempty"`
+	Ready         bool                `protobuf:"varint,6,opt,name=ready" json:"ready,omitempty"`
+	Dependencies  []*DependencyHealth `protobuf:"bytes,7,rep,name=dependencies" json:"dependencies,omitempty"`
+	Config        *ConfigStatus       `protobuf:"bytes,8,opt,name=config" json:"config,omitempty"`
 }
 
 func (m *Response) Reset()                    { *m = Response{} }
@@ -90,9 +123,136 @@ func (m *Response) GetCanonicalName() string {
 	return ""
 }
 
+func (m *Response) GetLive() bool {
+	if m != nil {
+		return m.Live
+	}
+	return false
+}
+
+func (m *Response) GetReady() bool {
+	if m != nil {
+		return m.Ready
+	}
+	return false
+}
+
+func (m *Response) GetDependencies() []*DependencyHealth {
+	if m != nil {
+		return m.Dependencies
+	}
+	return nil
+}
+
+func (m *Response) GetConfig() *ConfigStatus {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+type ConfigStatus struct {
+	Precedence string            `protobuf:"bytes,1,opt,name=precedence" json:"precedence,omitempty"`
+	Overrides  []*ConfigOverride `protobuf:"bytes,2,rep,name=overrides" json:"overrides,omitempty"`
+}
+
+func (m *ConfigStatus) Reset()                    { *m = ConfigStatus{} }
+func (m *ConfigStatus) String() string            { return proto.CompactTextString(m) }
+func (*ConfigStatus) ProtoMessage()               {}
+func (*ConfigStatus) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *ConfigStatus) GetPrecedence() string {
+	if m != nil {
+		return m.Precedence
+	}
+	return ""
+}
+
+func (m *ConfigStatus) GetOverrides() []*ConfigOverride {
+	if m != nil {
+		return m.Overrides
+	}
+	return nil
+}
+
+type ConfigOverride struct {
+	Field string `protobuf:"bytes,1,opt,name=field" json:"field,omitempty"`
+	Env   string `protobuf:"bytes,2,opt,name=env" json:"env,omitempty"`
+	Value string `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *ConfigOverride) Reset()                    { *m = ConfigOverride{} }
+func (m *ConfigOverride) String() string            { return proto.CompactTextString(m) }
+func (*ConfigOverride) ProtoMessage()               {}
+func (*ConfigOverride) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *ConfigOverride) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *ConfigOverride) GetEnv() string {
+	if m != nil {
+		return m.Env
+	}
+	return ""
+}
+
+func (m *ConfigOverride) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type DependencyHealth struct {
+	Name          string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Healthy       bool   `protobuf:"varint,2,opt,name=healthy" json:"healthy,omitempty"`
+	LatencyMillis int64  `protobuf:"varint,3,opt,name=latencyMillis" json:"latencyMillis,omitempty"`
+	Error         string `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *DependencyHealth) Reset()                    { *m = DependencyHealth{} }
+func (m *DependencyHealth) String() string            { return proto.CompactTextString(m) }
+func (*DependencyHealth) ProtoMessage()               {}
+func (*DependencyHealth) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *DependencyHealth) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *DependencyHealth) GetHealthy() bool {
+	if m != nil {
+		return m.Healthy
+	}
+	return false
+}
+
+func (m *DependencyHealth) GetLatencyMillis() int64 {
+	if m != nil {
+		return m.LatencyMillis
+	}
+	return 0
+}
+
+func (m *DependencyHealth) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Request)(nil), "api.Request")
 	proto.RegisterType((*Response)(nil), "api.Response")
+	proto.RegisterType((*ConfigStatus)(nil), "api.ConfigStatus")
+	proto.RegisterType((*ConfigOverride)(nil), "api.ConfigOverride")
+	proto.RegisterType((*DependencyHealth)(nil), "api.DependencyHealth")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -155,19 +315,31 @@ func (h *Status) Check(ctx context.Context, in *Request, out *Response) error {
 func init() { proto.RegisterFile("github.com/tomogoma/shoppingms/pkg/api/status.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 221 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x8f, 0xc1, 0x4a, 0xc3, 0x40,
-	0x10, 0x86, 0x8d, 0xad, 0xa9, 0x8e, 0xf6, 0x32, 0x07, 0x09, 0x9e, 0x6a, 0x10, 0xf1, 0x94, 0x15,
-	0x7d, 0x02, 0xf1, 0x24, 0x82, 0x48, 0x7c, 0x82, 0xe9, 0x76, 0x48, 0x97, 0xba, 0x3b, 0x6b, 0x66,
-	0x23, 0x78, 0xf3, 0xd1, 0x25, 0x6b, 0x04, 0x7b, 0xdb, 0xff, 0xfb, 0x7f, 0x96, 0x6f, 0xc0, 0x74,
-	0x2e, 0x6d, 0x87, 0x75, 0x63, 0xc5, 0x9b, 0x24, 0x5e, 0x3a, 0xf1, 0x64, 0x94, 0x79, 0xe3, 0xd5,
-	0xc4, 0x5d, 0x67, 0x28, 0x3a, 0xa3, 0x89, 0xd2, 0xa0, 0x4d, 0xec, 0x25, 0x09, 0xce, 0x28, 0xba,
-	0xfa, 0x12, 0x16, 0x2d, 0x7f, 0x0c, 0xac, 0x09, 0xcf, 0xa1, 0x7c, 0x78, 0x7d, 0x7a, 0xe6, 0xaf,
-	0xaa, 0x58, 0x15, 0x37, 0x27, 0xed, 0x94, 0xea, 0xef, 0x02, 0x8e, 0x5b, 0xd6, 0x28, 0x41, 0x19,
-	0x11, 0xe6, 0x81, 0x3c, 0x4f, 0x93, 0xfc, 0xc6, 0x0a, 0x16, 0x9f, 0xdc, 0xab, 0x93, 0x50, 0x1d,
-	0x66, 0xfc, 0x17, 0x71, 0x05, 0xa7, 0x1b, 0x56, 0xdb, 0xbb, 0x98, 0xc6, 0x76, 0x96, 0xdb, 0xff,
-	0x08, 0xaf, 0x60, 0x69, 0x29, 0x48, 0x70, 0x96, 0xde, 0x5f, 0xc6, 0x8f, 0xe7, 0x79, 0xb3, 0x0f,
-	0xef, 0x6e, 0xa1, 0x7c, 0xcb, 0xea, 0x78, 0x0d, 0x47, 0x8f, 0x5b, 0xb6, 0x3b, 0x3c, 0x6b, 0x28,
-	0xba, 0x66, 0x72, 0xbf, 0x58, 0x4e, 0xe9, 0xd7, 0xb2, 0x3e, 0x58, 0x97, 0xf9, 0xc6, 0xfb, 0x9f,
-	0x00, 0x00, 0x00, 0xff, 0xff, 0xc8, 0x0c, 0xbf, 0x4b, 0x16, 0x01, 0x00, 0x00,
+	// 415 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x52, 0xcb, 0x8e, 0xd3, 0x30,
+	0x14, 0xa5, 0xcd, 0x34, 0xed, 0xdc, 0x76, 0xd0, 0x60, 0x1e, 0x8a, 0x58, 0xa0, 0x10, 0x21, 0x54,
+	0x36, 0x0d, 0x74, 0x56, 0x2c, 0xd1, 0xb0, 0x00, 0x21, 0x06, 0x64, 0xbe, 0xc0, 0xe3, 0xdc, 0x49,
+	0xac, 0x71, 0x6c, 0x63, 0xbb, 0x91, 0x2a, 0xf1, 0x11, 0x7c, 0x32, 0xb2, 0xe3, 0x8a, 0x16, 0x89,
+	0x9d, 0xcf, 0x23, 0xc7, 0xf1, 0xb9, 0x17, 0xae, 0x5a, 0xe1, 0xbb, 0xdd, 0xed, 0x86, 0xeb, 0xbe,
+	0xf6, 0xba, 0xd7, 0xad, 0xee, 0x59, 0xed, 0x3a, 0x6d, 0x8c, 0x50, 0x6d, 0xef, 0x6a, 0x73, 0xdf,
+	0xd6, 0xcc, 0x88, 0xda, 0x79, 0xe6, 0x77, 0x6e, 0x63, 0xac, 0xf6, 0x9a, 0x64, 0xcc, 0x88, 0xea,
+	0x25, 0xcc, 0x29, 0xfe, 0xdc, 0xa1, 0xf3, 0xe4, 0x19, 0xe4, 0x1f, 0xbe, 0x7f, 0xfe, 0x82, 0xfb,
+	0x62, 0x52, 0x4e, 0xd6, 0xe7, 0x34, 0xa1, 0xea, 0xf7, 0x14, 0x16, 0x14, 0x9d, 0xd1, 0xca, 0x21,
+	0x21, 0x70, 0xa6, 0x58, 0x8f, 0xc9, 0x12, 0xcf, 0xa4, 0x80, 0xf9, 0x80, 0xd6, 0x09, 0xad, 0x8a,
+	0x69, 0xa4, 0x0f, 0x90, 0x94, 0xb0, 0x6c, 0xd0, 0x71, 0x2b, 0x8c, 0x0f, 0x6a, 0x16, 0xd5, 0x63,
+	0x8a, 0xbc, 0x82, 0x0b, 0xce, 0x94, 0x56, 0x82, 0x33, 0x79, 0x13, 0x82, 0xcf, 0xa2, 0xe7, 0x94,
+	0x0c, 0xb7, 0x4a, 0x31, 0x60, 0x31, 0x2b, 0x27, 0xeb, 0x05, 0x8d, 0x67, 0xf2, 0x04, 0x66, 0x16,
+	0x59, 0xb3, 0x2f, 0xf2, 0x48, 0x8e, 0x80, 0xbc, 0x87, 0x55, 0x83, 0x06, 0x55, 0x83, 0x8a, 0x0b,
+	0x74, 0xc5, 0xbc, 0xcc, 0xd6, 0xcb, 0xed, 0xd3, 0x0d, 0x33, 0x62, 0xf3, 0xf1, 0x20, 0xec, 0x3f,
+	0x21, 0x93, 0xbe, 0xa3, 0x27, 0x56, 0xf2, 0x06, 0x72, 0xae, 0xd5, 0x9d, 0x68, 0x8b, 0x45, 0x39,
+	0x59, 0x2f, 0xb7, 0x8f, 0xe2, 0x47, 0xd7, 0x91, 0xfa, 0x11, 0x8b, 0xa3, 0xc9, 0x50, 0x31, 0x58,
+	0x1d, 0xf3, 0xe4, 0x05, 0x80, 0xb1, 0xc8, 0x31, 0x44, 0x1d, 0xba, 0x39, 0x62, 0xc8, 0x3b, 0x38,
+	0xd7, 0x03, 0x5a, 0x2b, 0x1a, 0x74, 0xc5, 0x34, 0xfe, 0xd2, 0xe3, 0xa3, 0xf4, 0x6f, 0x49, 0xa3,
+	0x7f, 0x5d, 0xd5, 0x0d, 0x3c, 0x3c, 0x15, 0xc3, 0x83, 0xef, 0x04, 0xca, 0x26, 0xe5, 0x8f, 0x80,
+	0x5c, 0x42, 0x86, 0x6a, 0x48, 0xc5, 0x87, 0x63, 0xf0, 0x0d, 0x4c, 0xee, 0x30, 0xd5, 0x3d, 0x82,
+	0xea, 0x17, 0x5c, 0xfe, 0xfb, 0xfe, 0xff, 0x0d, 0xb3, 0x8b, 0xea, 0x3e, 0x66, 0x2e, 0xe8, 0x01,
+	0x86, 0x51, 0x49, 0xe6, 0xc3, 0xe7, 0x5f, 0x85, 0x94, 0xc2, 0xc5, 0xfc, 0x8c, 0x9e, 0x92, 0xe1,
+	0x76, 0xb4, 0x56, 0xdb, 0x34, 0xc8, 0x11, 0x6c, 0xdf, 0x42, 0x9e, 0xaa, 0x7a, 0x0d, 0xb3, 0xeb,
+	0x0e, 0xf9, 0x3d, 0x59, 0xc5, 0x02, 0xd2, 0xf2, 0x3d, 0xbf, 0x48, 0x68, 0x5c, 0xb3, 0xea, 0xc1,
+	0x6d, 0x1e, 0x97, 0xf4, 0xea, 0xcf, 0x00, 0xe2, 0xe9, 0x6f, 0x8c, 0xdb, 0x02, 0x00, 0x00,
 }
@@ -0,0 +1,238 @@
+package apikeys
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	apiG "github.com/tomogoma/go-api-guard"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"go.opentelemetry.io/otel"
+)
+
+// DB persists API keys.
+type DB interface {
+	IsNotFoundError(error) bool
+	// InsertAPIKeyWithPolicy inserts an API key for userID granted scopes,
+	// usable from origins (any if empty) until expires (never if zero).
+	InsertAPIKeyWithPolicy(ctx context.Context, userID string, key []byte, scopes, origins []string, expires time.Time) (*api.Key, error)
+	// APIKeyByUserIDVal returns the api.Key of userID with value key if it
+	// has not expired.
+	APIKeyByUserIDVal(userID string, key []byte) (apiG.Key, error)
+	APIKeys(ctx context.Context, userID string, offset, count int64) ([]api.Key, error)
+	APIKeyByID(ctx context.Context, ID string) (*api.Key, error)
+	// TouchAPIKey records that the API key with ID was just used.
+	TouchAPIKey(ctx context.Context, ID string) error
+	DeleteAPIKey(ctx context.Context, ID string) error
+}
+
+// Generator generates API keys in the format validated by the Guard and
+// persists them in the KeyStore it was created with e.g. *api.Guard from
+// go-api-guard.
+type Generator interface {
+	NewAPIKey(userID string) (apiG.Key, error)
+}
+
+// NewGenerator returns a Generator that persists keys in ks.
+type NewGenerator func(ks apiG.KeyStore) (Generator, error)
+
+// GuardGenerator returns a NewGenerator of go-api-guard Guards created
+// with opts.
+func GuardGenerator(opts ...apiG.Option) NewGenerator {
+	return func(ks apiG.KeyStore) (Generator, error) {
+		return apiG.NewGuard(ks, opts...)
+	}
+}
+
+var tracer = otel.Tracer("github.com/tomogoma/shoppingms/pkg/apikeys")
+
+// Manager creates, lists, rotates and revokes the API keys of client apps.
+// Use NewManager() to instantiate.
+type Manager struct {
+	errors.ErrToHTTP
+
+	db     DB
+	newGen NewGenerator
+}
+
+func NewManager(db DB, newGen NewGenerator) (*Manager, error) {
+	if db == nil {
+		return nil, errors.New("DB was nil")
+	}
+	if newGen == nil {
+		return nil, errors.New("NewGenerator was nil")
+	}
+	return &Manager{db: db, newGen: newGen}, nil
+}
+
+// Create creates a new API key restricted by p for the client app user
+// userID. The key's Val is only ever returned by Create and Rotate since
+// only its hash is stored.
+func (m *Manager) Create(ctx context.Context, userID string, p Policy) (*api.Key, error) {
+	ctx, span := tracer.Start(ctx, "Manager.Create")
+	defer span.End()
+
+	if err := validateUserID(userID); err != nil {
+		return nil, err
+	}
+	p, err := validatePolicy(p, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return m.newKey(ctx, userID, p)
+}
+
+// Keys returns API keys of the client app user userID, or of all client
+// apps if userID is empty. Keys are returned without their Val.
+func (m *Manager) Keys(ctx context.Context, userID string, offset, count int64) ([]api.Key, error) {
+	ctx, span := tracer.Start(ctx, "Manager.Keys")
+	defer span.End()
+
+	if userID != "" {
+		if err := validateUserID(userID); err != nil {
+			return nil, err
+		}
+	}
+	if offset < 0 {
+		return nil, errors.NewClient("offset must not be less than 0")
+	}
+	if count < 1 {
+		return nil, errors.NewClient("count must be greater than 0")
+	}
+	ks, err := m.db.APIKeys(ctx, userID, offset, count)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ks {
+		ks[i].Val = nil
+	}
+	return ks, nil
+}
+
+// Rotate replaces the API key with ID by a new key for the same client app
+// user and with the same scopes, origins and expiry. The replaced key is
+// revoked once the new one is created.
+func (m *Manager) Rotate(ctx context.Context, ID string) (*api.Key, error) {
+	ctx, span := tracer.Start(ctx, "Manager.Rotate")
+	defer span.End()
+
+	if ID == "" {
+		return nil, errors.NewClient("API key ID was empty")
+	}
+	old, err := m.db.APIKeyByID(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	k, err := m.newKey(ctx, old.UserID, Policy{
+		Scopes:  old.Scopes,
+		Origins: old.Origins,
+		Expires: old.Expires,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := m.db.DeleteAPIKey(ctx, ID); err != nil {
+		return nil, errors.Newf("revoke rotated key (new key %s was created): %v",
+			k.ID, err)
+	}
+	return k, nil
+}
+
+// Revoke revokes the API key with ID. Requests bearing it are rejected
+// from then on.
+func (m *Manager) Revoke(ctx context.Context, ID string) error {
+	ctx, span := tracer.Start(ctx, "Manager.Revoke")
+	defer span.End()
+
+	if ID == "" {
+		return errors.NewClient("API key ID was empty")
+	}
+	return m.db.DeleteAPIKey(ctx, ID)
+}
+
+// Authorize returns an error unless key, which the Guard validated as
+// belonging to the client app user userID, grants scope and may be used
+// from origin. Use ScopeAny for endpoints any valid key may call.
+// The key's last use is recorded on success.
+func (m *Manager) Authorize(ctx context.Context, userID string, key []byte, scope, origin string) error {
+	ctx, span := tracer.Start(ctx, "Manager.Authorize")
+	defer span.End()
+
+	kI, err := m.db.APIKeyByUserIDVal(userID, key)
+	if err != nil {
+		if m.db.IsNotFoundError(err) {
+			return errors.NewUnauthorized("invalid API key")
+		}
+		return errors.Newf("get API key: %v", err)
+	}
+	k, err := toKey(kI)
+	if err != nil {
+		return err
+	}
+	if !k.Expires.IsZero() && !time.Now().Before(k.Expires) {
+		return errors.NewUnauthorized("API key expired")
+	}
+	if !originAllowed(k.Origins, origin) {
+		return errors.NewForbiddenf("API key may not be used from origin %q", origin)
+	}
+	if !HasScope(k.Scopes, scope) {
+		return errors.NewForbiddenf("API key lacks the %s scope", scope)
+	}
+	if err := m.db.TouchAPIKey(ctx, k.ID); err != nil {
+		// Auditing is best effort, it should not fail the request.
+		span.RecordError(err)
+	}
+	return nil
+}
+
+// newKey generates a key for userID that is inserted already restricted
+// by p so that it is never usable beyond p.
+func (m *Manager) newKey(ctx context.Context, userID string, p Policy) (*api.Key, error) {
+	gen, err := m.newGen(policyKeyStore{DB: m.db, ctx: ctx, policy: p})
+	if err != nil {
+		return nil, errors.Newf("instantiate API key generator: %v", err)
+	}
+	kI, err := gen.NewAPIKey(userID)
+	if err != nil {
+		return nil, errors.Newf("generate API key: %v", err)
+	}
+	return toKey(kI)
+}
+
+// policyKeyStore is the KeyStore of a Generator that inserts keys
+// restricted by policy.
+type policyKeyStore struct {
+	DB
+	ctx    context.Context
+	policy Policy
+}
+
+func (s policyKeyStore) InsertAPIKey(userID string, key []byte) (apiG.Key, error) {
+	k, err := s.InsertAPIKeyWithPolicy(s.ctx, userID, key, s.policy.Scopes,
+		s.policy.Origins, s.policy.Expires)
+	if err != nil {
+		return nil, err
+	}
+	return *k, nil
+}
+
+func toKey(kI apiG.Key) (*api.Key, error) {
+	k, ok := kI.(api.Key)
+	if !ok {
+		return nil, errors.Newf("expected API key of type %T, got %T", api.Key{}, kI)
+	}
+	return &k, nil
+}
+
+// validateUserID checks that userID is a valid client app user ID, which
+// are numeric.
+func validateUserID(userID string) error {
+	if userID == "" {
+		return errors.NewClient("user ID was empty")
+	}
+	if _, err := strconv.ParseInt(userID, 10, 64); err != nil {
+		return errors.NewClientf("user ID must be numeric, got %q", userID)
+	}
+	return nil
+}
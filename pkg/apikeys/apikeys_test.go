@@ -0,0 +1,371 @@
+package apikeys_test
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	apiG "github.com/tomogoma/go-api-guard"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+)
+
+// keyStore is an in-memory DB and a NewGenerator of keys "val<ID>".
+type keyStore struct {
+	errors.NotFoundErrCheck
+	keys   []api.Key
+	nextID int
+	genErr error
+}
+
+func (s *keyStore) newGenerator(ks apiG.KeyStore) (apikeys.Generator, error) {
+	return generator{s: s, ks: ks}, nil
+}
+
+type generator struct {
+	s  *keyStore
+	ks apiG.KeyStore
+}
+
+func (g generator) NewAPIKey(userID string) (apiG.Key, error) {
+	if g.s.genErr != nil {
+		return nil, g.s.genErr
+	}
+	return g.ks.InsertAPIKey(userID, []byte("val"+strconv.Itoa(g.s.nextID+1)))
+}
+
+func (s *keyStore) InsertAPIKeyWithPolicy(ctx context.Context, userID string, key []byte, scopes, origins []string, expires time.Time) (*api.Key, error) {
+	s.nextID++
+	k := api.Key{ID: strconv.Itoa(s.nextID), UserID: userID, Val: key, Scopes: scopes,
+		Origins: origins, Expires: expires}
+	s.keys = append(s.keys, k)
+	return &k, nil
+}
+
+func (s *keyStore) APIKeyByUserIDVal(userID string, key []byte) (apiG.Key, error) {
+	for _, k := range s.keys {
+		if k.UserID == userID && string(k.Val) == string(key) {
+			return k, nil
+		}
+	}
+	return nil, errors.NewNotFound("API key not found")
+}
+
+func (s *keyStore) TouchAPIKey(ctx context.Context, ID string) error {
+	for i := range s.keys {
+		if s.keys[i].ID == ID {
+			s.keys[i].LastUsed = time.Now()
+			return nil
+		}
+	}
+	return errors.NewNotFound("API key not found")
+}
+
+func (s *keyStore) APIKeys(ctx context.Context, userID string, offset, count int64) ([]api.Key, error) {
+	var ks []api.Key
+	for _, k := range s.keys {
+		if userID == "" || k.UserID == userID {
+			ks = append(ks, k)
+		}
+	}
+	if len(ks) == 0 {
+		return nil, errors.NewNotFound("no API keys found")
+	}
+	return ks, nil
+}
+
+func (s *keyStore) APIKeyByID(ctx context.Context, ID string) (*api.Key, error) {
+	for _, k := range s.keys {
+		if k.ID == ID {
+			return &k, nil
+		}
+	}
+	return nil, errors.NewNotFound("API key not found")
+}
+
+func (s *keyStore) DeleteAPIKey(ctx context.Context, ID string) error {
+	for i, k := range s.keys {
+		if k.ID == ID {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			return nil
+		}
+	}
+	return errors.NewNotFound("API key not found")
+}
+
+func TestNewManager(t *testing.T) {
+	s := &keyStore{}
+	if _, err := apikeys.NewManager(nil, s.newGenerator); err == nil {
+		t.Errorf("Expected an error for nil DB, got nil")
+	}
+	if _, err := apikeys.NewManager(s, nil); err == nil {
+		t.Errorf("Expected an error for nil NewGenerator, got nil")
+	}
+	if m, err := apikeys.NewManager(s, apikeys.GuardGenerator()); err != nil || m == nil {
+		t.Errorf("Expected a manager, got %v, %v", m, err)
+	}
+}
+
+func TestManager_Create(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+	tt := []struct {
+		name      string
+		userID    string
+		policy    apikeys.Policy
+		genErr    error
+		expScopes []string
+		expClErr  bool
+		expErr    bool
+	}{
+		{name: "valid", userID: "123", expScopes: apikeys.DefaultScopes},
+		{
+			name:   "valid with policy",
+			userID: "123",
+			policy: apikeys.Policy{
+				Scopes:  []string{apikeys.ScopeListsRead},
+				Origins: []string{"https://Example.com/"},
+				Expires: expires,
+			},
+			expScopes: []string{apikeys.ScopeListsRead},
+		},
+		{name: "empty user ID", userID: "", expClErr: true},
+		{name: "non numeric user ID", userID: "abc", expClErr: true},
+		{name: "unknown scope", userID: "123", expClErr: true,
+			policy: apikeys.Policy{Scopes: []string{"everything"}}},
+		{name: "bad origin", userID: "123", expClErr: true,
+			policy: apikeys.Policy{Origins: []string{"example.com"}}},
+		{name: "origin with path", userID: "123", expClErr: true,
+			policy: apikeys.Policy{Origins: []string{"https://example.com/app"}}},
+		{name: "expired", userID: "123", expClErr: true,
+			policy: apikeys.Policy{Expires: time.Now().Add(-time.Hour)}},
+		{name: "generator error", userID: "123", genErr: errors.New("db down"), expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &keyStore{genErr: tc.genErr}
+			m := newManager(t, s)
+			k, err := m.Create(context.TODO(), tc.userID, tc.policy)
+			if tc.expClErr {
+				if !(errors.ClErrCheck{}).IsClientError(err) {
+					t.Fatalf("Expected a client error, got %v", err)
+				}
+				return
+			}
+			if tc.expErr {
+				if err == nil || (errors.ClErrCheck{}).IsClientError(err) {
+					t.Fatalf("Expected an internal error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if k.UserID != tc.userID || len(k.Val) == 0 {
+				t.Errorf("Expected a key with a value for %s, got %+v", tc.userID, k)
+			}
+			if !reflect.DeepEqual(k.Scopes, tc.expScopes) {
+				t.Errorf("Expected scopes %v, got %v", tc.expScopes, k.Scopes)
+			}
+			if len(tc.policy.Origins) > 0 && k.Origins[0] != "https://example.com" {
+				t.Errorf("Expected normalized origin https://example.com, got %v", k.Origins)
+			}
+			if !k.Expires.Equal(tc.policy.Expires) {
+				t.Errorf("Expected expiry %v, got %v", tc.policy.Expires, k.Expires)
+			}
+			// The key must be stored restricted from the start.
+			if len(s.keys) != 1 || !reflect.DeepEqual(s.keys[0].Scopes, k.Scopes) ||
+				!reflect.DeepEqual(s.keys[0].Origins, k.Origins) || !s.keys[0].Expires.Equal(k.Expires) {
+				t.Errorf("Expected the key inserted with its policy, got %+v", s.keys)
+			}
+		})
+	}
+}
+
+func TestManager_Keys(t *testing.T) {
+	s := &keyStore{}
+	m := newManager(t, s)
+	for _, usrID := range []string{"123", "123", "456"} {
+		if _, err := m.Create(context.TODO(), usrID, apikeys.Policy{}); err != nil {
+			t.Fatalf("Error setting up: create key: %v", err)
+		}
+	}
+	tt := []struct {
+		name        string
+		userID      string
+		offset      int64
+		count       int64
+		expCount    int
+		expClErr    bool
+		expNotFound bool
+	}{
+		{name: "all", count: 10, expCount: 3},
+		{name: "one user", userID: "123", count: 10, expCount: 2},
+		{name: "none", userID: "789", count: 10, expNotFound: true},
+		{name: "bad user ID", userID: "abc", count: 10, expClErr: true},
+		{name: "bad offset", offset: -1, count: 10, expClErr: true},
+		{name: "bad count", count: 0, expClErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, err := m.Keys(context.TODO(), tc.userID, tc.offset, tc.count)
+			if tc.expClErr {
+				if !(errors.ClErrCheck{}).IsClientError(err) {
+					t.Fatalf("Expected a client error, got %v", err)
+				}
+				return
+			}
+			if tc.expNotFound {
+				if !(errors.NotFoundErrCheck{}).IsNotFoundError(err) {
+					t.Fatalf("Expected a not found error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if len(ks) != tc.expCount {
+				t.Fatalf("Expected %d keys, got %d", tc.expCount, len(ks))
+			}
+			for _, k := range ks {
+				if k.Val != nil {
+					t.Errorf("Expected keys without values, got %+v", k)
+				}
+			}
+		})
+	}
+	if len(s.keys[0].Val) == 0 {
+		t.Errorf("Keys() cleared stored key values")
+	}
+}
+
+func TestManager_Rotate(t *testing.T) {
+	s := &keyStore{}
+	m := newManager(t, s)
+	old, err := m.Create(context.TODO(), "123", apikeys.Policy{
+		Scopes:  []string{apikeys.ScopeCatalogWrite},
+		Origins: []string{"https://example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: create key: %v", err)
+	}
+
+	k, err := m.Rotate(context.TODO(), old.ID)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if k.ID == old.ID || k.UserID != old.UserID || string(k.Val) == string(old.Val) {
+		t.Errorf("Expected a new key for %s, got %+v (old %+v)", old.UserID, k, old)
+	}
+	if !reflect.DeepEqual(k.Scopes, old.Scopes) || !reflect.DeepEqual(k.Origins, old.Origins) {
+		t.Errorf("Expected the policy of the old key, got %+v (old %+v)", k, old)
+	}
+	if _, err := s.APIKeyByID(context.TODO(), old.ID); !s.IsNotFoundError(err) {
+		t.Errorf("Expected old key revoked, got %v", err)
+	}
+
+	if _, err := m.Rotate(context.TODO(), old.ID); !(errors.NotFoundErrCheck{}).IsNotFoundError(err) {
+		t.Errorf("Expected not found error rotating revoked key, got %v", err)
+	}
+	if _, err := m.Rotate(context.TODO(), ""); !(errors.ClErrCheck{}).IsClientError(err) {
+		t.Errorf("Expected client error for empty ID, got %v", err)
+	}
+}
+
+func TestManager_Revoke(t *testing.T) {
+	s := &keyStore{}
+	m := newManager(t, s)
+	k, err := m.Create(context.TODO(), "123", apikeys.Policy{})
+	if err != nil {
+		t.Fatalf("Error setting up: create key: %v", err)
+	}
+	if err := m.Revoke(context.TODO(), k.ID); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if err := m.Revoke(context.TODO(), k.ID); !(errors.NotFoundErrCheck{}).IsNotFoundError(err) {
+		t.Errorf("Expected not found error revoking again, got %v", err)
+	}
+	if err := m.Revoke(context.TODO(), ""); !(errors.ClErrCheck{}).IsClientError(err) {
+		t.Errorf("Expected client error for empty ID, got %v", err)
+	}
+}
+
+func TestManager_Authorize(t *testing.T) {
+	s := &keyStore{}
+	m := newManager(t, s)
+	lists, err := m.Create(context.TODO(), "123", apikeys.Policy{
+		Scopes:  []string{apikeys.ScopeListsRead},
+		Origins: []string{"https://example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: create key: %v", err)
+	}
+	admin, err := m.Create(context.TODO(), "456", apikeys.Policy{
+		Scopes: []string{apikeys.ScopeAdmin},
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: create key: %v", err)
+	}
+	expired, err := m.Create(context.TODO(), "789", apikeys.Policy{})
+	if err != nil {
+		t.Fatalf("Error setting up: create key: %v", err)
+	}
+	s.keys[2].Expires = time.Now().Add(-time.Minute)
+
+	tt := []struct {
+		name         string
+		key          *api.Key
+		scope        string
+		origin       string
+		expUnauth    bool
+		expForbidden bool
+	}{
+		{name: "in scope", key: lists, scope: apikeys.ScopeListsRead, origin: "https://EXAMPLE.com"},
+		{name: "any scope", key: lists, scope: "", origin: "https://example.com"},
+		{name: "out of scope", key: lists, scope: apikeys.ScopeListsWrite,
+			origin: "https://example.com", expForbidden: true},
+		{name: "wrong origin", key: lists, scope: apikeys.ScopeListsRead,
+			origin: "https://evil.com", expForbidden: true},
+		{name: "missing origin", key: lists, scope: apikeys.ScopeListsRead,
+			expForbidden: true},
+		{name: "admin any origin", key: admin, scope: apikeys.ScopeCatalogWrite,
+			origin: "https://evil.com"},
+		{name: "expired", key: expired, scope: apikeys.ScopeListsRead, expUnauth: true},
+		{name: "unknown key", key: &api.Key{ID: "9", UserID: "123", Val: []byte("none")},
+			scope: apikeys.ScopeListsRead, expUnauth: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := m.Authorize(context.TODO(), tc.key.UserID, tc.key.Val, tc.scope, tc.origin)
+			if tc.expUnauth {
+				if !(errors.AuthErrCheck{}).IsUnauthorizedError(err) {
+					t.Fatalf("Expected an unauthorized error, got %v", err)
+				}
+				return
+			}
+			if tc.expForbidden {
+				if !(errors.AuthErrCheck{}).IsForbiddenError(err) {
+					t.Fatalf("Expected a forbidden error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			k, _ := s.APIKeyByID(context.TODO(), tc.key.ID)
+			if k.LastUsed.IsZero() {
+				t.Errorf("Expected last use recorded")
+			}
+		})
+	}
+}
+
+func newManager(t *testing.T, s *keyStore) *apikeys.Manager {
+	m, err := apikeys.NewManager(s, s.newGenerator)
+	if err != nil {
+		t.Fatalf("Error setting up: new manager: %v", err)
+	}
+	return m
+}
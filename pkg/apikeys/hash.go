@@ -0,0 +1,58 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+const (
+	// PrefixLen is the number of leading characters of an API key that are
+	// stored in plain text to look the key up by.
+	PrefixLen = 8
+	saltLen   = 16
+)
+
+// Hash is the form in which an API key is stored at rest. Only Prefix is
+// derived from the key in a reversible way; it does not suffice to use the
+// key.
+type Hash struct {
+	Prefix string
+	Salt   []byte
+	Sum    []byte
+}
+
+// HashKey salts and hashes key for storage.
+func HashKey(key []byte) (Hash, error) {
+	if len(key) <= PrefixLen {
+		return Hash{}, errors.Newf("API key must be longer than %d bytes", PrefixLen)
+	}
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return Hash{}, errors.Newf("generate salt: %v", err)
+	}
+	return Hash{Prefix: Prefix(key), Salt: salt, Sum: sum(salt, key)}, nil
+}
+
+// Prefix returns the non-secret prefix of key to look its Hash up by.
+func Prefix(key []byte) string {
+	if len(key) < PrefixLen {
+		return string(key)
+	}
+	return string(key[:PrefixLen])
+}
+
+// Matches reports, in constant time, whether key is the key h was
+// created from.
+func (h Hash) Matches(key []byte) bool {
+	return subtle.ConstantTimeCompare(h.Sum, sum(h.Salt, key)) == 1
+}
+
+func sum(salt, key []byte) []byte {
+	hash := sha256.New()
+	hash.Write(salt)
+	hash.Write(key)
+	return hash.Sum(nil)
+}
@@ -0,0 +1,42 @@
+package apikeys_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+)
+
+func TestHashKey(t *testing.T) {
+	key := []byte(strings.Repeat("axui", 14))
+
+	h, err := apikeys.HashKey(key)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if h.Prefix != "axuiaxui" {
+		t.Errorf("Expected prefix axuiaxui, got %s", h.Prefix)
+	}
+	if bytes.Contains(h.Sum, key) || bytes.Contains(h.Salt, key) {
+		t.Errorf("Expected key not to be stored in plain text, got %+v", h)
+	}
+	if !h.Matches(key) {
+		t.Errorf("Expected hash to match its key")
+	}
+	if h.Matches(append(key, 'x')) || h.Matches(key[1:]) || h.Matches(nil) {
+		t.Errorf("Expected hash not to match other keys")
+	}
+
+	h2, err := apikeys.HashKey(key)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if bytes.Equal(h.Salt, h2.Salt) || bytes.Equal(h.Sum, h2.Sum) {
+		t.Errorf("Expected hashes of the same key to be salted differently")
+	}
+
+	if _, err := apikeys.HashKey([]byte("short")); err == nil {
+		t.Errorf("Expected an error for a short key, got nil")
+	}
+}
@@ -0,0 +1,34 @@
+package apikeys
+
+import (
+	"crypto/subtle"
+	"sync/atomic"
+)
+
+// MasterKey is the master API key from config, which grants every scope
+// and is not stored. It can be replaced while in use e.g. when config is
+// reloaded. A nil or empty MasterKey matches no key.
+// Use NewMasterKey() to instantiate.
+type MasterKey struct {
+	val atomic.Value
+}
+
+func NewMasterKey(key string) *MasterKey {
+	mk := &MasterKey{}
+	mk.Set(key)
+	return mk
+}
+
+// Set replaces the master key, disabling it if key is empty.
+func (mk *MasterKey) Set(key string) {
+	mk.val.Store([]byte(key))
+}
+
+// Matches reports whether key is the master key.
+func (mk *MasterKey) Matches(key []byte) bool {
+	if mk == nil {
+		return false
+	}
+	master, _ := mk.val.Load().([]byte)
+	return len(master) > 0 && subtle.ConstantTimeCompare(key, master) == 1
+}
@@ -0,0 +1,32 @@
+package apikeys_test
+
+import (
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+)
+
+func TestMasterKey_Matches(t *testing.T) {
+	var nilKey *apikeys.MasterKey
+	if nilKey.Matches([]byte("")) {
+		t.Errorf("Expected nil master key to match nothing")
+	}
+
+	mk := apikeys.NewMasterKey("")
+	if mk.Matches([]byte("")) {
+		t.Errorf("Expected empty master key to match nothing")
+	}
+
+	mk.Set("master")
+	if !mk.Matches([]byte("master")) {
+		t.Errorf("Expected master key to match")
+	}
+	if mk.Matches([]byte("other")) {
+		t.Errorf("Expected other key not to match")
+	}
+
+	mk.Set("rotated")
+	if mk.Matches([]byte("master")) || !mk.Matches([]byte("rotated")) {
+		t.Errorf("Expected only the replacement master key to match")
+	}
+}
@@ -0,0 +1,110 @@
+package apikeys
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// Scopes API keys can grant. ScopeAdmin grants every scope and every key
+// grants ScopeAny.
+const (
+	ScopeAny          = ""
+	ScopeListsRead    = "lists:read"
+	ScopeListsWrite   = "lists:write"
+	ScopePricesRead   = "prices:read"
+	ScopePricesWrite  = "prices:write"
+	ScopeCatalogWrite = "catalog:write"
+	ScopeAdmin        = "admin"
+)
+
+// DefaultScopes are granted to keys created without scopes, including the
+// keys that existed before scopes were introduced. They cover everything a
+// client app could do on behalf of its users.
+var DefaultScopes = []string{ScopeListsRead, ScopeListsWrite, ScopePricesRead, ScopePricesWrite}
+
+var allScopes = []string{ScopeListsRead, ScopeListsWrite, ScopePricesRead,
+	ScopePricesWrite, ScopeCatalogWrite, ScopeAdmin}
+
+// Policy restricts what an API key may be used for.
+type Policy struct {
+	// Scopes granted, DefaultScopes if empty.
+	Scopes []string
+	// Origins the key may be used from, any if empty. Requests bearing a
+	// key with Origins must declare one of them in their Origin.
+	Origins []string
+	// Expires is when the key stops being valid, never if zero.
+	Expires time.Time
+}
+
+// HasScope reports whether scopes grant scope.
+func HasScope(scopes []string, scope string) bool {
+	if scope == ScopeAny {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePolicy checks p and returns it with defaults applied.
+func validatePolicy(p Policy, now time.Time) (Policy, error) {
+	if len(p.Scopes) == 0 {
+		p.Scopes = DefaultScopes
+	}
+	for _, s := range p.Scopes {
+		if !isScope(s) {
+			return p, errors.NewClientf("unknown scope %q, expected one of %s",
+				s, strings.Join(allScopes, ", "))
+		}
+	}
+	for i, o := range p.Origins {
+		origin, err := validOrigin(o)
+		if err != nil {
+			return p, err
+		}
+		p.Origins[i] = origin
+	}
+	if !p.Expires.IsZero() && !p.Expires.After(now) {
+		return p, errors.NewClient("expiry must be in the future")
+	}
+	return p, nil
+}
+
+func isScope(s string) bool {
+	for _, scope := range allScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// validOrigin returns o as it appears in Origin headers i.e.
+// scheme://host[:port] or an error if o is not an origin.
+func validOrigin(o string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(o))
+	if err != nil || u.Scheme == "" || u.Host == "" ||
+		strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", errors.NewClientf("origin must be of the form scheme://host[:port], got %q", o)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+func originAllowed(origins []string, origin string) bool {
+	if len(origins) == 0 {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, o := range origins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
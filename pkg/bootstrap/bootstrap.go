@@ -1,37 +1,133 @@
 package bootstrap
 
 import (
+	"context"
 	"io/ioutil"
+	"time"
 
+	jwtLib "github.com/dgrijalva/jwt-go"
 	"github.com/tomogoma/go-api-guard"
 	"github.com/tomogoma/jwt"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
 	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/db/memory"
 	"github.com/tomogoma/shoppingms/pkg/db/roach"
+	"github.com/tomogoma/shoppingms/pkg/db/sqlite"
+	"github.com/tomogoma/shoppingms/pkg/dev"
+	"github.com/tomogoma/shoppingms/pkg/health"
 	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/metrics"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
 	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+	"github.com/tomogoma/shoppingms/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 type Deps struct {
-	Config config.General
-	Guard  *api.Guard
-	Roach  *roach.Roach
-	JWTEr  *jwt.Handler
+	Config  config.General
+	Guard   *api.Guard
+	Storage shopping.Storage
+	JWTEr   *jwt.Handler
+	Manager *shopping.Manager
+	Catalog *shopping.Catalog
+	Prices  *shopping.Prices
+	APIKeys *apikeys.Manager
+	Health  *health.Health
+	Metrics *metrics.Metrics
+	Tracer  *sdktrace.TracerProvider
+	Limiter *ratelimit.Limiter
+	// MasterKey is conf.Service.MasterAPIKey, checked by the HTTP and RPC
+	// handlers ahead of Guard so that it can be replaced on config reload.
+	MasterKey *apikeys.MasterKey
+	// DevTokens issues JWTs for any user in developer mode, it is nil
+	// otherwise. See InstantiateDev().
+	DevTokens *dev.Tokens
 }
 
-func InstantiateRoach(lg logging.Logger, conf crdb.Config) *roach.Roach {
-	var opts []roach.Option
+// NewRoach returns a *roach.Roach for the DB in conf without connecting
+// to it, see InstantiateRoach().
+func NewRoach(conf crdb.Config, opts ...roach.Option) *roach.Roach {
 	if dsn := conf.FormatDSN(); dsn != "" {
 		opts = append(opts, roach.WithDSN(dsn))
 	}
 	if dbn := conf.DBName; dbn != "" {
 		opts = append(opts, roach.WithDBName(dbn))
 	}
-	rdb := roach.NewRoach(opts...)
+	return roach.NewRoach(opts...)
+}
+
+func InstantiateRoach(lg logging.Logger, conf crdb.Config, opts ...roach.Option) *roach.Roach {
+	rdb := NewRoach(conf, opts...)
 	err := rdb.InitDBIfNot()
 	logging.LogWarnOnError(lg, err, "Initiate Cockroach DB connection")
 	return rdb
 }
 
+// NewSQLite returns a *sqlite.SQLite for the DB file in conf without
+// opening it.
+func NewSQLite(conf config.Storage, opts ...sqlite.Option) *sqlite.SQLite {
+	if conf.Path != "" {
+		opts = append(opts, sqlite.WithPath(conf.Path))
+	}
+	return sqlite.NewSQLite(opts...)
+}
+
+// VersionedStorage is a storage backend with a versioned DB schema i.e.
+// *roach.Roach and *sqlite.SQLite, both at roach.Version when up to date.
+type VersionedStorage interface {
+	shopping.Storage
+	InitDBIfNot() error
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+// NewStorage returns the storage backend selected in conf.Storage without
+// connecting to it, see InstantiateStorage(). obs, if not nil, receives the
+// DB queries of the cockroach and sqlite backends.
+func NewStorage(conf config.General, obs roach.Observer) (shopping.Storage, error) {
+	return newStorage(conf, []roach.Option{roach.WithObserver(obs)},
+		[]sqlite.Option{sqlite.WithObserver(obs)})
+}
+
+// NewStorageWithoutMigration is like NewStorage() but the DB schema is
+// never created or migrated, see roach.WithoutMigration(). The memory
+// backend has no schema.
+func NewStorageWithoutMigration(conf config.General) (shopping.Storage, error) {
+	return newStorage(conf, []roach.Option{roach.WithoutMigration()},
+		[]sqlite.Option{sqlite.WithoutMigration()})
+}
+
+func newStorage(conf config.General, roachOpts []roach.Option, sqliteOpts []sqlite.Option) (shopping.Storage, error) {
+	switch conf.Storage.Backend {
+	case "", config.StorageCockroach:
+		return NewRoach(conf.Database, roachOpts...), nil
+	case config.StorageSQLite:
+		return NewSQLite(conf.Storage, sqliteOpts...), nil
+	case config.StorageMemory:
+		return memory.NewMemory(), nil
+	default:
+		return nil, errors.Newf("unknown backend %q", conf.Storage.Backend)
+	}
+}
+
+// InstantiateStorage returns the storage backend selected in conf.Storage,
+// setting up its DB if it has one, see NewStorage().
+func InstantiateStorage(lg logging.Logger, conf config.General, obs roach.Observer) shopping.Storage {
+	db, err := NewStorage(conf, obs)
+	logging.LogFatalOnError(lg, err, "Instantiate storage")
+	vdb, ok := db.(VersionedStorage)
+	if !ok {
+		lg.Warnf("Using the memory storage backend, data will be lost on exit")
+		return db
+	}
+	err = vdb.InitDBIfNot()
+	logging.LogWarnOnError(lg, err, "Initiate DB")
+	return db
+}
+
 func InstantiateJWTHandler(lg logging.Logger, tknKyF string) *jwt.Handler {
 	JWTKey, err := ioutil.ReadFile(tknKyF)
 	logging.LogFatalOnError(lg, err, "Read JWT key file")
@@ -40,16 +136,157 @@ func InstantiateJWTHandler(lg logging.Logger, tknKyF string) *jwt.Handler {
 	return jwter
 }
 
-func Instantiate(confFile string, lg logging.Logger) Deps {
+// SchemaCheck sets up db if InstantiateStorage() could not e.g. because
+// the DB was unreachable at startup, then checks that its schema is at
+// roach.Version.
+func SchemaCheck(db VersionedStorage) health.Check {
+	return func(ctx context.Context) error {
+		if err := db.InitDBIfNot(); err != nil {
+			return err
+		}
+		v, err := db.SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if v != roach.Version {
+			return errors.Newf("need db version '%d', found '%d'",
+				roach.Version, v)
+		}
+		return nil
+	}
+}
+
+// InstantiateHealth checks storage connectivity, the DB schema version if
+// db is a VersionedStorage and that the JWT key can sign and verify tokens.
+func InstantiateHealth(lg logging.Logger, db shopping.Storage, jwter *jwt.Handler) *health.Health {
+	checks := []health.Option{health.WithCheck("database", db.Ping)}
+	if vdb, ok := db.(VersionedStorage); ok {
+		checks = append(checks, health.WithCheck("schema", SchemaCheck(vdb)))
+	}
+	checks = append(checks,
+		health.WithCheck("jwt", func(context.Context) error {
+			clm := jwtLib.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix()}
+			JWT, err := jwter.Generate(clm)
+			if err != nil {
+				return errors.Newf("generate: %v", err)
+			}
+			if _, err := jwter.Validate(JWT, &jwtLib.StandardClaims{}); err != nil {
+				return errors.Newf("validate: %v", err)
+			}
+			return nil
+		}),
+	)
+	h, err := health.NewHealth(checks...)
+	logging.LogFatalOnError(lg, err, "Instantiate health checks")
+	return h
+}
+
+// InstantiateTracer sets the global TracerProvider and the W3C trace
+// context propagator used by the HTTP and RPC handlers.
+func InstantiateTracer(lg logging.Logger, conf config.Tracing) *sdktrace.TracerProvider {
+	tp, err := tracing.NewProvider(conf)
+	logging.LogFatalOnError(lg, err, "Instantiate tracer provider")
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return tp
+}
 
+// InstantiateRateLimiter limits requests as configured in conf, keeping
+// token buckets in memory unless conf selects the database (db) which is
+// shared by all instances.
+func InstantiateRateLimiter(lg logging.Logger, conf config.RateLimits, db ratelimit.Store) *ratelimit.Limiter {
+	var store ratelimit.Store
+	switch conf.Store {
+	case "", config.RateLimitStoreMemory:
+		store = ratelimit.NewMemory()
+	case config.RateLimitStoreDatabase:
+		store = db
+	default:
+		err := errors.Newf("unknown store %q", conf.Store)
+		logging.LogFatalOnError(lg, err, "Instantiate rate limit store")
+	}
+	lm, err := ratelimit.NewLimiter(store,
+		ratelimit.WithAPIKeyLimit(RateLimit(conf.PerAPIKey)),
+		ratelimit.WithUserLimit(RateLimit(conf.PerUser)),
+	)
+	logging.LogFatalOnError(lg, err, "Instantiate rate limiter")
+	return lm
+}
+
+// RateLimit converts a configured rate limit to a ratelimit.Limit.
+func RateLimit(conf config.RateLimit) ratelimit.Limit {
+	return ratelimit.Limit{Rate: conf.RequestsPerSecond, Burst: conf.Burst}
+}
+
+func Instantiate(confFile string, lg logging.Logger) Deps {
 	conf, err := config.ReadFile(confFile)
 	logging.LogFatalOnError(lg, err, "Read config file")
-
-	rdb := InstantiateRoach(lg, conf.Database)
 	tg := InstantiateJWTHandler(lg, conf.Service.AuthTokenKeyFile)
+	return instantiate(lg, conf, tg)
+}
+
+// InstantiateDev instantiates the service in developer mode, see
+// dev.Config(), for trying out the API without an authentication
+// micro-service or a database. JWTs are signed with a key generated on
+// start up and issued by Deps.DevTokens. The storage is seeded with demo
+// data, see dev.Seed().
+func InstantiateDev(lg logging.Logger) Deps {
+	tg, err := dev.NewJWTHandler()
+	logging.LogFatalOnError(lg, err, "Instantiate dev JWT handler")
+	deps := instantiate(lg, dev.Config(), tg)
+
+	deps.DevTokens, err = dev.NewTokens(tg)
+	logging.LogFatalOnError(lg, err, "Instantiate dev tokens")
+
+	r, err := dev.Seed(context.Background(), deps.Storage)
+	logging.LogFatalOnError(lg, err, "Seed demo data")
+	lg.Infof("Seeded %d brands, %d store branches and %d prices",
+		r.Brands, r.StoreBranches, r.Prices)
+	return deps
+}
 
-	g, err := api.NewGuard(rdb, api.WithMasterKey(conf.Service.MasterAPIKey))
+func instantiate(lg logging.Logger, conf config.General, tg *jwt.Handler) Deps {
+
+	tp := InstantiateTracer(lg, conf.Tracing)
+
+	mtrcs, err := metrics.NewMetrics()
+	logging.LogFatalOnError(lg, err, "Instantiate metrics")
+
+	db := InstantiateStorage(lg, conf, mtrcs)
+
+	g, err := api.NewGuard(db)
 	logging.LogFatalOnError(lg, err, "Instantate API access guard")
 
-	return Deps{Config: conf, Guard: g, Roach: rdb, JWTEr: tg}
+	prices, err := shopping.NewPrices(db, tg)
+	logging.LogFatalOnError(lg, err, "Instantiate prices")
+
+	m, err := shopping.NewManager(db, tg, prices)
+	logging.LogFatalOnError(lg, err, "Instantiate shopping manager")
+
+	cat, err := shopping.NewCatalog(db)
+	logging.LogFatalOnError(lg, err, "Instantiate catalog")
+
+	keys, err := apikeys.NewManager(db, apikeys.GuardGenerator())
+	logging.LogFatalOnError(lg, err, "Instantiate API key manager")
+
+	hc := InstantiateHealth(lg, db, tg)
+
+	lm := InstantiateRateLimiter(lg, conf.Service.RateLimits, db)
+
+	return Deps{
+		Config:  conf,
+		Guard:   g,
+		Storage: db,
+		JWTEr:   tg,
+		Manager: m,
+		Catalog: cat,
+		Prices:  prices,
+		APIKeys: keys,
+		Health:  hc,
+		Metrics: mtrcs,
+		Tracer:  tp,
+		Limiter: lm,
+
+		MasterKey: apikeys.NewMasterKey(conf.Service.MasterAPIKey),
+	}
 }
@@ -0,0 +1,81 @@
+package bootstrap_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/bootstrap"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/db/roach"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// versionedStorage is unreachable until up is set.
+type versionedStorage struct {
+	shopping.Storage
+	up     bool
+	isInit bool
+}
+
+func (s *versionedStorage) InitDBIfNot() error {
+	if !s.up {
+		return errors.New("connection refused")
+	}
+	s.isInit = true
+	return nil
+}
+
+func (s *versionedStorage) SchemaVersion(ctx context.Context) (int, error) {
+	if !s.up {
+		return -1, errors.New("connection refused")
+	}
+	if !s.isInit {
+		return -1, errors.New("no version yet")
+	}
+	return roach.Version, nil
+}
+
+func TestSchemaCheck_unreachableAtStartup(t *testing.T) {
+	db := &versionedStorage{}
+	if err := db.InitDBIfNot(); err == nil {
+		t.Fatalf("Error setting up: expected startup InitDBIfNot() to fail")
+	}
+	check := bootstrap.SchemaCheck(db)
+	if err := check(context.TODO()); err == nil {
+		t.Errorf("Expected an error while the DB is unreachable, got nil")
+	}
+	db.up = true
+	if err := check(context.TODO()); err != nil {
+		t.Errorf("Expected ready once the DB is reachable, got %v", err)
+	}
+	if !db.isInit {
+		t.Errorf("Expected the check to set up the DB")
+	}
+}
+
+func TestSchemaCheck_newSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shoppingms-bootstrap")
+	if err != nil {
+		t.Fatalf("Error setting up: temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	conf := config.General{Storage: config.Storage{
+		Backend: config.StorageSQLite,
+		Path:    filepath.Join(dir, "shoppingms.db"),
+	}}
+	db, err := bootstrap.NewStorage(conf, nil)
+	if err != nil {
+		t.Fatalf("bootstrap.NewStorage(): %v", err)
+	}
+	vdb, ok := db.(bootstrap.VersionedStorage)
+	if !ok {
+		t.Fatalf("Expected a bootstrap.VersionedStorage, got %T", db)
+	}
+	if err := bootstrap.SchemaCheck(vdb)(context.TODO()); err != nil {
+		t.Errorf("Expected the check to set up a new DB, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Changed returns the YAML paths of the values that differ between a and
+// b e.g. serviceConfig.rateLimits.perUser.burst. Lists are compared as a
+// whole and reported by the path of the list. Overrides are not compared.
+func Changed(a, b General) []string {
+	var paths []string
+	changed(&paths, reflect.ValueOf(a), reflect.ValueOf(b), "")
+	return paths
+}
+
+func changed(paths *[]string, a, b reflect.Value, path string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		field := tag
+		if path != "" {
+			field = path + "." + tag
+		}
+		af, bf := a.Field(i), b.Field(i)
+		if af.Kind() == reflect.Struct {
+			changed(paths, af, bf, field)
+			continue
+		}
+		if !reflect.DeepEqual(af.Interface(), bf.Interface()) {
+			*paths = append(*paths, field)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/config"
+)
+
+func TestChanged(t *testing.T) {
+	base := func() config.General {
+		c := config.General{}
+		c.Service.RegisterInterval = 5 * time.Second
+		c.Service.AllowedOrigins = []string{"https://a.example"}
+		c.Service.RateLimits.PerUser = config.RateLimit{RequestsPerSecond: 5, Burst: 20}
+		c.Database.Password = "s3cret"
+		return c
+	}
+	tcs := []struct {
+		name     string
+		modify   func(c *config.General)
+		expPaths []string
+	}{
+		{name: "unchanged", modify: func(*config.General) {}},
+		{
+			name: "overrides ignored",
+			modify: func(c *config.General) {
+				c.Overrides = []config.Override{{Field: "database.password"}}
+			},
+		},
+		{
+			name: "changed",
+			modify: func(c *config.General) {
+				c.Service.AllowedOrigins = append(c.Service.AllowedOrigins, "https://b.example")
+				c.Service.RateLimits.PerUser.Burst = 10
+				c.Service.LogLevel = config.LogLevelDebug
+				c.Database.Password = "n3w"
+				ratio := 0.5
+				c.Tracing.SampleRatio = &ratio
+			},
+			expPaths: []string{
+				"serviceConfig.allowedOrigins",
+				"serviceConfig.rateLimits.perUser.burst",
+				"serviceConfig.logLevel",
+				"database.password",
+				"tracing.sampleRatio",
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := base()
+			tc.modify(&c)
+			paths := config.Changed(base(), c)
+			if !reflect.DeepEqual(paths, tc.expPaths) {
+				t.Errorf("Paths mismatch:\nexpect:\t%q\ngot:\t%q", tc.expPaths, paths)
+			}
+		})
+	}
+}
@@ -31,6 +31,7 @@ var (
 	defaultInstallDir       = path.Join("/usr", "local", "bin")
 	defaultSysDUnitFilePath = path.Join("/etc", "systemd", "system", DefaultSysDUnitName())
 	sysDConfDir             = path.Join("/etc", Name)
+	sysDDataDir             = path.Join("/var", "lib", Name)
 	defaultConfDir          = sysDConfDir
 )
 
@@ -93,6 +94,12 @@ func DefaultDocsDir() string {
 	return path.Join(defaultConfDir, DocsPath)
 }
 
+// DefaultSQLitePath is the database file of the sqlite storage backend
+// unless configured otherwise.
+func DefaultSQLitePath() string {
+	return path.Join(sysDDataDir, CanonicalName()+".db")
+}
+
 func DefaultConfPath() string {
 	return path.Join(defaultConfDir, CanonicalName()+".conf.yml")
 }
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+const (
+	// EnvPrefix prefixes the environment variables that override config
+	// file values. The rest of the variable name is the value's YAML path
+	// in upper snake case e.g. SHOPPINGMS_DATABASE_PASSWORD for
+	// database.password.
+	EnvPrefix = "SHOPPINGMS_"
+	// EnvFileSuffix is appended to a variable's name to read the value
+	// from the file the variable names instead e.g.
+	// SHOPPINGMS_DATABASE_PASSWORD_FILE=/run/secrets/db_password.
+	EnvFileSuffix = "_FILE"
+
+	// Precedence describes which source a config value is taken from when
+	// it is set in more than one.
+	Precedence = "environment variable (" + EnvPrefix + "<FIELD> or " + EnvPrefix +
+		"<FIELD>" + EnvFileSuffix + ") > config file > default"
+
+	// RedactedValue replaces the values of secrets in Overrides.
+	RedactedValue = "[redacted]"
+)
+
+// secretFields are the YAML paths of values that must never be reported.
+var secretFields = map[string]bool{
+	"serviceConfig.masterAPIKey": true,
+	"database.password":          true,
+}
+
+// Override is a config value set from the environment.
+type Override struct {
+	// Field is the YAML path of the value e.g. database.password.
+	Field string
+	// Env is the variable the value was read from, the EnvFileSuffix
+	// variant if the value was read from a file.
+	Env string
+	// Value is the effective value, RedactedValue for secrets.
+	Value string
+}
+
+// EnvName returns the name of the environment variable that overrides the
+// value at the YAML path field e.g. SHOPPINGMS_SERVICE_CONFIG_MASTER_API_KEY
+// for serviceConfig.masterAPIKey.
+func EnvName(field string) string {
+	segs := strings.Split(field, ".")
+	for i, seg := range segs {
+		segs[i] = upperSnakeCase(seg)
+	}
+	return EnvPrefix + strings.Join(segs, "_")
+}
+
+// ApplyEnv overrides values in conf with those set in the environment as
+// reported by lookupEnv (e.g. os.LookupEnv) and records them in
+// conf.Overrides. Lists are comma separated. It is an error to set both a
+// variable and its EnvFileSuffix variant.
+func ApplyEnv(conf *General, lookupEnv func(key string) (string, bool)) error {
+	return applyEnv(reflect.ValueOf(conf).Elem(), "", lookupEnv, &conf.Overrides)
+}
+
+func applyEnv(v reflect.Value, path string, lookupEnv func(string) (string, bool), ovs *[]Override) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		field := tag
+		if path != "" {
+			field = path + "." + tag
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			if err := applyEnv(fv, field, lookupEnv, ovs); err != nil {
+				return err
+			}
+			continue
+		}
+
+		env := EnvName(field)
+		val, isSet := lookupEnv(env)
+		fileVal, isFileSet := lookupEnv(env + EnvFileSuffix)
+		if isSet && isFileSet {
+			return errors.Newf("only one of %s and %s%s may be set",
+				env, env, EnvFileSuffix)
+		}
+		if isFileSet {
+			env = env + EnvFileSuffix
+			content, err := ioutil.ReadFile(fileVal)
+			if err != nil {
+				return errors.Newf("read %s: %v", env, err)
+			}
+			val = strings.TrimRight(string(content), "\r\n")
+		} else if !isSet {
+			continue
+		}
+
+		if err := setValue(fv, val); err != nil {
+			return errors.Newf("%s: %v", env, err)
+		}
+		if secretFields[field] {
+			val = RedactedValue
+		}
+		*ovs = append(*ovs, Override{Field: field, Env: env, Value: val})
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, val string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), val); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return errors.Newf("unsupported list type %s", v.Type())
+		}
+		var l []string
+		for _, s := range strings.Split(val, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				l = append(l, s)
+			}
+		}
+		v.Set(reflect.ValueOf(l))
+	default:
+		return errors.Newf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// upperSnakeCase converts a camelCase name to UPPER_SNAKE_CASE keeping
+// acronyms together e.g. masterAPIKey to MASTER_API_KEY.
+func upperSnakeCase(s string) string {
+	rs := []rune(s)
+	out := make([]rune, 0, len(rs)+4)
+	for i, r := range rs {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := rs[i-1]
+			nextIsLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && nextIsLower) {
+				out = append(out, '_')
+			}
+		}
+		out = append(out, unicode.ToUpper(r))
+	}
+	return string(out)
+}
@@ -0,0 +1,139 @@
+package config_test
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/config"
+)
+
+func TestEnvName(t *testing.T) {
+	tcs := []struct {
+		field  string
+		expEnv string
+	}{
+		{field: "database.password", expEnv: "SHOPPINGMS_DATABASE_PASSWORD"},
+		{field: "database.dbName", expEnv: "SHOPPINGMS_DATABASE_DB_NAME"},
+		{field: "serviceConfig.masterAPIKey", expEnv: "SHOPPINGMS_SERVICE_CONFIG_MASTER_API_KEY"},
+		{field: "serviceConfig.rateLimits.perAPIKey.burst", expEnv: "SHOPPINGMS_SERVICE_CONFIG_RATE_LIMITS_PER_API_KEY_BURST"},
+		{field: "tracing.otlpEndpoint", expEnv: "SHOPPINGMS_TRACING_OTLP_ENDPOINT"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.field, func(t *testing.T) {
+			if env := config.EnvName(tc.field); env != tc.expEnv {
+				t.Errorf("expected %s, got %s", tc.expEnv, env)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	secretFile := writeFile(t, dir, "password", "s3cret\n")
+
+	tcs := []struct {
+		name         string
+		env          map[string]string
+		expConf      config.General
+		expOverrides []config.Override
+		expErr       bool
+	}{
+		{
+			name:    "no overrides",
+			env:     map[string]string{},
+			expConf: config.General{Service: config.Service{LoadBalanceVersion: "file"}},
+		},
+		{
+			name: "all kinds",
+			env: map[string]string{
+				"SHOPPINGMS_SERVICE_CONFIG_LOAD_BALANCE_VERSION":                     "env",
+				"SHOPPINGMS_SERVICE_CONFIG_REGISTER_INTERVAL":                        "10s",
+				"SHOPPINGMS_SERVICE_CONFIG_ALLOWED_ORIGINS":                          "https://a.example, https://b.example",
+				"SHOPPINGMS_SERVICE_CONFIG_RATE_LIMITS_PER_USER_REQUESTS_PER_SECOND": "2.5",
+				"SHOPPINGMS_DATABASE_PORT":                                           "26258",
+				"SHOPPINGMS_TRACING_INSECURE":                                        "true",
+				"SHOPPINGMS_TRACING_SAMPLE_RATIO":                                    "0",
+			},
+			expConf: func() config.General {
+				c := config.General{}
+				c.Service.LoadBalanceVersion = "env"
+				c.Service.RegisterInterval = 10 * time.Second
+				c.Service.AllowedOrigins = []string{"https://a.example", "https://b.example"}
+				c.Service.RateLimits.PerUser.RequestsPerSecond = 2.5
+				c.Database.Port = 26258
+				c.Tracing.Insecure = true
+				c.Tracing.SampleRatio = new(float64)
+				return c
+			}(),
+		},
+		{
+			name: "secrets redacted",
+			env: map[string]string{
+				"SHOPPINGMS_SERVICE_CONFIG_MASTER_API_KEY": "master",
+				"SHOPPINGMS_DATABASE_PASSWORD_FILE":        secretFile,
+			},
+			expConf: func() config.General {
+				c := config.General{}
+				c.Service.LoadBalanceVersion = "file"
+				c.Service.MasterAPIKey = "master"
+				c.Database.Password = "s3cret"
+				return c
+			}(),
+			expOverrides: []config.Override{
+				{Field: "serviceConfig.masterAPIKey", Env: "SHOPPINGMS_SERVICE_CONFIG_MASTER_API_KEY", Value: config.RedactedValue},
+				{Field: "database.password", Env: "SHOPPINGMS_DATABASE_PASSWORD_FILE", Value: config.RedactedValue},
+			},
+		},
+		{
+			name: "both value and file",
+			env: map[string]string{
+				"SHOPPINGMS_DATABASE_PASSWORD":      "s3cret",
+				"SHOPPINGMS_DATABASE_PASSWORD_FILE": secretFile,
+			},
+			expErr: true,
+		},
+		{
+			name:   "missing file",
+			env:    map[string]string{"SHOPPINGMS_DATABASE_PASSWORD_FILE": path.Join(dir, "none")},
+			expErr: true,
+		},
+		{
+			name:   "bad value",
+			env:    map[string]string{"SHOPPINGMS_DATABASE_PORT": "port"},
+			expErr: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := config.General{Service: config.Service{LoadBalanceVersion: "file"}}
+			err := config.ApplyEnv(&conf, func(key string) (string, bool) {
+				v, ok := tc.env[key]
+				return v, ok
+			})
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if tc.expOverrides != nil && !reflect.DeepEqual(conf.Overrides, tc.expOverrides) {
+				t.Errorf("Overrides mismatch:\nexpect:\t%+v\ngot:\t%+v", tc.expOverrides, conf.Overrides)
+			}
+			if len(conf.Overrides) != len(tc.env) {
+				t.Errorf("Expected %d overrides, got %d", len(tc.env), len(conf.Overrides))
+			}
+			conf.Overrides = nil
+			if !reflect.DeepEqual(conf, tc.expConf) {
+				t.Errorf("Config mismatch:\nexpect:\t%+v\ngot:\t%+v", tc.expConf, conf)
+			}
+		})
+	}
+}
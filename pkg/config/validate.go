@@ -0,0 +1,307 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/go-typed-errors"
+	"gopkg.in/yaml.v2"
+)
+
+// SSL modes accepted in crdb.Config.SSLMode.
+const (
+	SSLModeDisable    = "disable"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
+)
+
+// Log levels accepted in Service.LogLevel.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// Storage backends accepted in Storage.Backend.
+const (
+	StorageCockroach = "cockroach"
+	StorageSQLite    = "sqlite"
+	StorageMemory    = "memory"
+)
+
+// Rate limit stores accepted in RateLimits.Store.
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStoreDatabase = "database"
+)
+
+// ValidationError lists every problem found in a config, each prefixed
+// with the YAML path of the offending value, or with the file and line of
+// values that could not be parsed.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e ValidationError) Error() string {
+	return "invalid config:\n\t" + strings.Join(e.Problems, "\n\t")
+}
+
+type problems []string
+
+func (ps *problems) add(path, format string, args ...interface{}) {
+	*ps = append(*ps, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (ps problems) err() error {
+	if len(ps) == 0 {
+		return nil
+	}
+	sort.Strings(ps)
+	return ValidationError{Problems: ps}
+}
+
+// Validate checks that values in c are usable, including that the files
+// they refer to exist, and returns a ValidationError listing all problems
+// found.
+func (c General) Validate() error {
+	ps := problems{}
+	c.validate(&ps)
+	return ps.err()
+}
+
+func (c General) validate(ps *problems) {
+
+	s := c.Service
+	if s.RegisterInterval < 0 {
+		ps.add("serviceConfig.registerInterval", "must not be negative, got %s", s.RegisterInterval)
+	}
+	if s.ShutdownTimeout < 0 {
+		ps.add("serviceConfig.shutdownTimeout", "must not be negative, got %s", s.ShutdownTimeout)
+	}
+	if s.AuthTokenKeyFile == "" {
+		ps.add("serviceConfig.authTokenKeyFile", "is required")
+	} else {
+		validateFile(ps, "serviceConfig.authTokenKeyFile", s.AuthTokenKeyFile)
+	}
+	for i, o := range s.AllowedOrigins {
+		if err := validateAllowedOrigin(o); err != nil {
+			ps.add(fmt.Sprintf("serviceConfig.allowedOrigins[%d]", i), "%v", err)
+		}
+	}
+	switch s.RateLimits.Store {
+	case "", RateLimitStoreMemory, RateLimitStoreDatabase:
+	default:
+		ps.add("serviceConfig.rateLimits.store", "must be one of %s or %s, got %q",
+			RateLimitStoreMemory, RateLimitStoreDatabase, s.RateLimits.Store)
+	}
+	validateRateLimit(ps, "serviceConfig.rateLimits.perAPIKey", s.RateLimits.PerAPIKey)
+	validateRateLimit(ps, "serviceConfig.rateLimits.perUser", s.RateLimits.PerUser)
+	switch s.LogLevel {
+	case "", LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+	default:
+		ps.add("serviceConfig.logLevel", "must be one of %s, %s, %s or %s, got %q",
+			LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError, s.LogLevel)
+	}
+
+	switch c.Storage.Backend {
+	case "", StorageCockroach:
+		validateDatabase(ps, c.Database)
+	case StorageSQLite:
+		if p := c.Storage.Path; p != "" {
+			if fi, err := os.Stat(p); err == nil && fi.IsDir() {
+				ps.add("storage.path", "%s is a directory", p)
+			}
+		}
+	case StorageMemory:
+	default:
+		ps.add("storage.backend", "must be one of %s, %s or %s, got %q",
+			StorageCockroach, StorageSQLite, StorageMemory, c.Storage.Backend)
+	}
+
+	if r := c.Tracing.SampleRatio; r != nil && (*r < 0 || *r > 1) {
+		ps.add("tracing.sampleRatio", "must be within 0-1, got %g", *r)
+	}
+
+	sa := c.Standalone
+	if err := validateAddress(sa.Address); err != nil {
+		ps.add("standalone.address", "%v", err)
+	}
+	if (sa.TLSCertFile == "") != (sa.TLSKeyFile == "") {
+		ps.add("standalone.tlsKeyFile", "tlsCertFile and tlsKeyFile must be set together")
+	}
+	if sa.ClientCAFile != "" && sa.TLSCertFile == "" {
+		ps.add("standalone.clientCAFile", "requires tlsCertFile and tlsKeyFile")
+	}
+	if sa.H2C && sa.TLSCertFile != "" {
+		ps.add("standalone.h2c", "must not be set with tlsCertFile, HTTP/2 is always served over TLS")
+	}
+	for path, f := range map[string]string{
+		"standalone.tlsCertFile":  sa.TLSCertFile,
+		"standalone.tlsKeyFile":   sa.TLSKeyFile,
+		"standalone.clientCAFile": sa.ClientCAFile,
+	} {
+		if f != "" {
+			validateFile(ps, path, f)
+		}
+	}
+}
+
+// validateDatabase checks the database config, which is only used by the
+// cockroach storage backend.
+func validateDatabase(ps *problems, db crdb.Config) {
+	if db.Port < 0 || db.Port > 65535 {
+		ps.add("database.port", "must be within 0-65535, got %d", db.Port)
+	}
+	if db.ConnectTimeout < 0 {
+		ps.add("database.connectTimeout", "must not be negative, got %d", db.ConnectTimeout)
+	}
+	switch db.SSLMode {
+	case "", SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull:
+		if db.SSLMode == SSLModeVerifyCA || db.SSLMode == SSLModeVerifyFull {
+			if db.SSLRootCert == "" {
+				ps.add("database.sslRootCert", "is required when sslMode is %s", db.SSLMode)
+			}
+		}
+		if (db.SSLCert == "") != (db.SSLKey == "") {
+			ps.add("database.sslKey", "sslCert and sslKey must be set together")
+		}
+		for path, f := range map[string]string{
+			"database.sslRootCert": db.SSLRootCert,
+			"database.sslCert":     db.SSLCert,
+			"database.sslKey":      db.SSLKey,
+		} {
+			if f != "" {
+				validateFile(ps, path, f)
+			}
+		}
+	case SSLModeDisable:
+	default:
+		ps.add("database.sslMode", "must be one of %s, %s, %s or %s, got %q",
+			SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull, db.SSLMode)
+	}
+}
+
+// validateAddress accepts the values documented for standalone.address:
+// "", host:port or unix:/path/to/socket.
+func validateAddress(addr string) error {
+	if addr == "" {
+		return nil
+	}
+	if strings.HasPrefix(addr, UnixAddressPrefix) {
+		if strings.TrimPrefix(addr, UnixAddressPrefix) == "" {
+			return errors.Newf("%q is missing the socket path", addr)
+		}
+		return nil
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 0 || p > 65535 {
+		return errors.Newf("%q has an invalid port", addr)
+	}
+	return nil
+}
+
+func validateRateLimit(ps *problems, path string, l RateLimit) {
+	if l.RequestsPerSecond < 0 {
+		ps.add(path+".requestsPerSecond", "must not be negative, got %g", l.RequestsPerSecond)
+	}
+	if l.Burst < 0 {
+		ps.add(path+".burst", "must not be negative, got %d", l.Burst)
+	}
+	if l.RequestsPerSecond > 0 && l.Burst == 0 {
+		ps.add(path+".burst", "must be at least 1 when requestsPerSecond is set")
+	}
+}
+
+// validateFile checks that fName is a readable regular file.
+func validateFile(ps *problems, path, fName string) {
+	f, err := os.Open(fName)
+	if err != nil {
+		ps.add(path, "%v", err)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		ps.add(path, "%v", err)
+		return
+	}
+	if fi.IsDir() {
+		ps.add(path, "%s is a directory", fName)
+	}
+}
+
+// validateAllowedOrigin accepts the values documented for
+// serviceConfig.allowedOrigins: "*", "null", "" or scheme://host[:port].
+func validateAllowedOrigin(o string) error {
+	if o == "*" || o == "null" || o == "" {
+		return nil
+	}
+	u, err := url.Parse(o)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return errors.Newf("%q must be of the form scheme://host[:port]", o)
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return errors.Newf("%q must only have a scheme, host and port", o)
+	}
+	return nil
+}
+
+// unknownKeys returns a problem for every key in the YAML document confD
+// that does not map to a field of General.
+func unknownKeys(ps *problems, confD []byte) {
+	var raw interface{}
+	if err := yaml.Unmarshal(confD, &raw); err != nil {
+		return
+	}
+	findUnknownKeys(ps, raw, reflect.TypeOf(General{}), "")
+}
+
+func findUnknownKeys(ps *problems, raw interface{}, t reflect.Type, path string) {
+	m, ok := raw.(map[interface{}]interface{})
+	if !ok || t.Kind() != reflect.Struct {
+		return
+	}
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag != "" && tag != "-" {
+			fields[tag] = t.Field(i).Type
+		}
+	}
+	for k, v := range m {
+		key := fmt.Sprint(k)
+		field := key
+		if path != "" {
+			field = path + "." + key
+		}
+		ft, ok := fields[key]
+		if ok {
+			findUnknownKeys(ps, v, ft, field)
+			continue
+		}
+		for known := range fields {
+			if strings.EqualFold(known, key) {
+				ps.add(field, "unknown key, did you mean %s?", known)
+				ok = true
+			}
+		}
+		if !ok {
+			ps.add(field, "unknown key")
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/config"
+)
+
+func TestGeneral_Validate(t *testing.T) {
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	keyFile := writeFile(t, dir, "jwt.key", "key")
+	certFile := writeFile(t, dir, "node.crt", "cert")
+
+	valid := func() config.General {
+		c := config.General{}
+		c.Service.RegisterInterval = 5 * time.Second
+		c.Service.AuthTokenKeyFile = keyFile
+		c.Service.AllowedOrigins = []string{"*", "https://a.example:8443"}
+		c.Service.RateLimits.PerAPIKey = config.RateLimit{RequestsPerSecond: 5, Burst: 10}
+		c.Database.Port = 26257
+		c.Database.SSLMode = config.SSLModeVerifyCA
+		c.Database.SSLRootCert = certFile
+		ratio := 0.5
+		c.Tracing.SampleRatio = &ratio
+		c.Service.LogLevel = config.LogLevelDebug
+		c.Standalone.Address = "localhost:8443"
+		return c
+	}
+
+	tcs := []struct {
+		name        string
+		modify      func(c *config.General)
+		expProblems []string
+	}{
+		{name: "valid", modify: func(*config.General) {}},
+		{
+			name: "ssl disabled ignores missing certs",
+			modify: func(c *config.General) {
+				c.Database.SSLMode = config.SSLModeDisable
+				c.Database.SSLRootCert = path.Join(dir, "none")
+			},
+		},
+		{
+			name: "all problems",
+			modify: func(c *config.General) {
+				c.Service.RegisterInterval = -time.Second
+				c.Service.ShutdownTimeout = -time.Second
+				c.Service.AuthTokenKeyFile = dir
+				c.Service.AllowedOrigins = []string{"https://a.example", "a.example", "https://a.example/path"}
+				c.Service.RateLimits.Store = "redis"
+				c.Service.RateLimits.PerUser = config.RateLimit{RequestsPerSecond: 1}
+				c.Service.LogLevel = "verbose"
+				c.Database.Port = 70000
+				c.Database.SSLMode = config.SSLModeVerifyFull
+				c.Database.SSLRootCert = ""
+				c.Database.SSLCert = certFile
+				ratio := 2.0
+				c.Tracing.SampleRatio = &ratio
+			},
+			expProblems: []string{
+				"database.port",
+				"database.sslKey",
+				"database.sslRootCert",
+				"serviceConfig.allowedOrigins[1]",
+				"serviceConfig.allowedOrigins[2]",
+				"serviceConfig.authTokenKeyFile",
+				"serviceConfig.logLevel",
+				"serviceConfig.rateLimits.perUser.burst",
+				"serviceConfig.rateLimits.store",
+				"serviceConfig.registerInterval",
+				"serviceConfig.shutdownTimeout",
+				"tracing.sampleRatio",
+			},
+		},
+		{
+			name: "missing files",
+			modify: func(c *config.General) {
+				c.Service.AuthTokenKeyFile = path.Join(dir, "none")
+				c.Database.SSLRootCert = path.Join(dir, "none")
+			},
+			expProblems: []string{"database.sslRootCert", "serviceConfig.authTokenKeyFile"},
+		},
+		{
+			name: "missing auth token key file",
+			modify: func(c *config.General) {
+				c.Service.AuthTokenKeyFile = ""
+			},
+			expProblems: []string{"serviceConfig.authTokenKeyFile"},
+		},
+		{
+			name: "standalone unix socket with TLS",
+			modify: func(c *config.General) {
+				c.Standalone.Address = "unix:/run/shoppingms.sock"
+				c.Standalone.TLSCertFile = certFile
+				c.Standalone.TLSKeyFile = certFile
+				c.Standalone.ClientCAFile = certFile
+			},
+		},
+		{
+			name: "standalone problems",
+			modify: func(c *config.General) {
+				c.Standalone.Address = "localhost"
+				c.Standalone.TLSCertFile = certFile
+				c.Standalone.ClientCAFile = path.Join(dir, "none")
+				c.Standalone.H2C = true
+			},
+			expProblems: []string{
+				"standalone.address",
+				"standalone.clientCAFile",
+				"standalone.h2c",
+				"standalone.tlsKeyFile",
+			},
+		},
+		{
+			name:        "standalone bad port",
+			modify:      func(c *config.General) { c.Standalone.Address = ":http8080" },
+			expProblems: []string{"standalone.address"},
+		},
+		{
+			name:        "standalone socket path missing",
+			modify:      func(c *config.General) { c.Standalone.Address = "unix:" },
+			expProblems: []string{"standalone.address"},
+		},
+		{
+			name: "memory storage ignores database",
+			modify: func(c *config.General) {
+				c.Storage.Backend = config.StorageMemory
+				c.Database.SSLRootCert = path.Join(dir, "none")
+			},
+		},
+		{
+			name: "sqlite storage ignores database",
+			modify: func(c *config.General) {
+				c.Storage.Backend = config.StorageSQLite
+				c.Storage.Path = path.Join(dir, "shoppingms.db")
+				c.Database.SSLRootCert = path.Join(dir, "none")
+			},
+		},
+		{
+			name: "sqlite path is a directory",
+			modify: func(c *config.General) {
+				c.Storage.Backend = config.StorageSQLite
+				c.Storage.Path = dir
+			},
+			expProblems: []string{"storage.path"},
+		},
+		{
+			name:        "unknown storage backend",
+			modify:      func(c *config.General) { c.Storage.Backend = "postgres" },
+			expProblems: []string{"storage.backend"},
+		},
+		{
+			name: "unknown ssl mode",
+			modify: func(c *config.General) {
+				c.Database.SSLMode = "prefer"
+			},
+			expProblems: []string{"database.sslMode"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := valid()
+			tc.modify(&c)
+			err := c.Validate()
+			assertProblems(t, err, tc.expProblems)
+		})
+	}
+}
+
+func TestReadFile_validation(t *testing.T) {
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	keyFile := writeFile(t, dir, "jwt.key", "key")
+
+	tcs := []struct {
+		name        string
+		yaml        string
+		expProblems []string
+	}{
+		{
+			name: "valid",
+			yaml: `
+serviceConfig:
+  registerInterval: 5s
+  authTokenKeyFile: ` + keyFile + `
+database:
+  sslMode: disable
+`,
+		},
+		{
+			name: "unknown keys and bad values",
+			yaml: `
+serviceConfig:
+  registerInterval: 5 seconds
+  authTokenKeyFile: ` + keyFile + `
+  masterApiKey: abc
+  rateLimits:
+    perApiKey:
+      burst: 3
+database:
+  sslMode: disable
+  dbname: shoppingms
+tracing:
+  endpoint: localhost:4318
+`,
+			expProblems: []string{
+				"", // registerInterval could not be parsed.
+				"database.dbname",
+				"serviceConfig.masterApiKey",
+				"serviceConfig.rateLimits.perApiKey",
+				"tracing.endpoint",
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			fName := writeFile(t, dir, "conf.yml", tc.yaml)
+			_, err := config.ReadFile(fName)
+			assertProblems(t, err, tc.expProblems)
+		})
+	}
+}
+
+// assertProblems checks that err is a config.ValidationError whose
+// problems are about the YAML paths in expPaths, in order. An empty path
+// matches any problem.
+func assertProblems(t *testing.T, err error, expPaths []string) {
+	if len(expPaths) == 0 {
+		if err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+		return
+	}
+	vErr, ok := err.(config.ValidationError)
+	if !ok {
+		t.Fatalf("Expected a config.ValidationError, got %v", err)
+	}
+	paths := make([]string, len(vErr.Problems))
+	for i, p := range vErr.Problems {
+		for j := range expPaths {
+			if expPaths[j] != "" && len(p) > len(expPaths[j]) &&
+				p[:len(expPaths[j])+1] == expPaths[j]+":" {
+				paths[i] = expPaths[j]
+			}
+		}
+	}
+	if !reflect.DeepEqual(paths, expPaths) {
+		t.Errorf("Problems mismatch:\nexpect:\t%q\ngot:\t%q", expPaths, vErr.Problems)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "shoppingms-config")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	fName := path.Join(dir, name)
+	if err := ioutil.WriteFile(fName, []byte(content), 0600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return fName
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"io/ioutil"
+	"os"
 	"time"
 
 	"github.com/tomogoma/crdb"
@@ -9,28 +10,107 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	// DefaultShutdownTimeout is used when Service.ShutdownTimeout is not
+	// set.
+	DefaultShutdownTimeout = 30 * time.Second
+	// DefaultStandaloneAddress is used when Standalone.Address is not set.
+	DefaultStandaloneAddress = ":8080"
+	// UnixAddressPrefix prefixes a Standalone.Address that is the path to
+	// a unix domain socket e.g. unix:/run/shoppingms.sock.
+	UnixAddressPrefix = "unix:"
+)
+
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond,omitempty" yaml:"requestsPerSecond"`
+	Burst             int     `json:"burst,omitempty" yaml:"burst"`
+}
+
+type RateLimits struct {
+	Store     string    `json:"store,omitempty" yaml:"store"`
+	PerAPIKey RateLimit `json:"perAPIKey,omitempty" yaml:"perAPIKey"`
+	PerUser   RateLimit `json:"perUser,omitempty" yaml:"perUser"`
+}
+
 type Service struct {
 	RegisterInterval   time.Duration `json:"registerInterval,omitempty" yaml:"registerInterval"`
 	LoadBalanceVersion string        `json:"loadBalanceVersion,omitempty" yaml:"loadBalanceVersion"`
 	MasterAPIKey       string        `json:"masterAPIKey,omitempty" yaml:"masterAPIKey"`
 	AllowedOrigins     []string      `json:"allowedOrigins" yaml:"allowedOrigins"`
 	AuthTokenKeyFile   string        `json:"authTokenKeyFile" yaml:"authTokenKeyFile"`
+	RateLimits         RateLimits    `json:"rateLimits,omitempty" yaml:"rateLimits"`
+	LogLevel           string        `json:"logLevel,omitempty" yaml:"logLevel"`
+	ShutdownTimeout    time.Duration `json:"shutdownTimeout,omitempty" yaml:"shutdownTimeout"`
+}
+
+// Standalone configures the HTTP server of cmd/standalone.
+type Standalone struct {
+	Address      string `json:"address,omitempty" yaml:"address"`
+	TLSCertFile  string `json:"tlsCertFile,omitempty" yaml:"tlsCertFile"`
+	TLSKeyFile   string `json:"tlsKeyFile,omitempty" yaml:"tlsKeyFile"`
+	ClientCAFile string `json:"clientCAFile,omitempty" yaml:"clientCAFile"`
+	H2C          bool   `json:"h2c,omitempty" yaml:"h2c"`
+}
+
+// Storage selects where the service persists its data.
+type Storage struct {
+	Backend string `json:"backend,omitempty" yaml:"backend"`
+	// Path is the database file of the sqlite backend, DefaultSQLitePath()
+	// if empty.
+	Path string `json:"path,omitempty" yaml:"path"`
+}
+
+type Tracing struct {
+	OTLPEndpoint string `json:"otlpEndpoint,omitempty" yaml:"otlpEndpoint"`
+	Insecure     bool   `json:"insecure,omitempty" yaml:"insecure"`
+	// SampleRatio is the fraction of new traces to sample, 0 to sample
+	// none. tracing.DefaultSampleRatio is used if nil.
+	SampleRatio *float64 `json:"sampleRatio,omitempty" yaml:"sampleRatio"`
 }
 
 type General struct {
-	Service  Service     `json:"serviceConfig,omitempty" yaml:"serviceConfig"`
-	Database crdb.Config `json:"database,omitempty" yaml:"database"`
+	Service    Service     `json:"serviceConfig,omitempty" yaml:"serviceConfig"`
+	Storage    Storage     `json:"storage,omitempty" yaml:"storage"`
+	Database   crdb.Config `json:"database,omitempty" yaml:"database"`
+	Tracing    Tracing     `json:"tracing,omitempty" yaml:"tracing"`
+	Standalone Standalone  `json:"standalone,omitempty" yaml:"standalone"`
+	// Overrides are the values set from the environment, see ApplyEnv().
+	Overrides []Override `json:"-" yaml:"-"`
 }
 
+// ReadFile reads the YAML config file fName, applies overrides from the
+// environment (see ApplyEnv()) then validates the result (see
+// General.Validate()). fName may be missing if the environment sets at
+// least one value. Keys in fName that are not config fields and values
+// that cannot be parsed are reported along with other problems in a
+// ValidationError.
 func ReadFile(fName string) (conf General, err error) {
-	confD, err := ioutil.ReadFile(fName)
-	if err != nil {
-		return
+	confD, readErr := ioutil.ReadFile(fName)
+	if readErr != nil && !os.IsNotExist(readErr) {
+		return conf, readErr
+	}
+	ps := problems{}
+	if readErr == nil {
+		if err = yaml.Unmarshal(confD, &conf); err != nil {
+			tErr, ok := err.(*yaml.TypeError)
+			if !ok {
+				err = errors.Newf("unmarshal conf file (%s) contents: %v",
+					fName, err)
+				return
+			}
+			for _, e := range tErr.Errors {
+				ps = append(ps, fName+": "+e)
+			}
+		}
+		unknownKeys(&ps, confD)
 	}
-	if err = yaml.Unmarshal(confD, &conf); err != nil {
-		err = errors.Newf("unmarshal conf file (%s) contents: %v",
-			fName, err)
+	if err = ApplyEnv(&conf, os.LookupEnv); err != nil {
+		err = errors.Newf("apply environment overrides: %v", err)
 		return
 	}
-	return
+	if readErr != nil && len(conf.Overrides) == 0 {
+		return conf, readErr
+	}
+	conf.validate(&ps)
+	return conf, ps.err()
 }
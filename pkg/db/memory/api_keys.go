@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apiG "github.com/tomogoma/go-api-guard"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+)
+
+// minAPIKeyLen is the minimum length of API keys accepted for storage.
+const minAPIKeyLen = 56
+
+type apiKeyRow struct {
+	ID       string
+	userID   string
+	hash     apikeys.Hash
+	scopes   string
+	origins  string
+	expires  time.Time
+	lastUsed time.Time
+	created  time.Time
+	updated  time.Time
+}
+
+// InsertAPIKey inserts an API key with apikeys.DefaultScopes for the
+// userID, see InsertAPIKeyWithPolicy.
+func (m *Memory) InsertAPIKey(userID string, key []byte) (apiG.Key, error) {
+	k, err := m.InsertAPIKeyWithPolicy(context.Background(), userID, key,
+		apikeys.DefaultScopes, nil, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return *k, nil
+}
+
+// InsertAPIKeyWithPolicy inserts an API key for the userID granted scopes,
+// usable from origins (any if empty) until expires (never if zero). Only a
+// salted hash of key and its prefix are stored, the returned Key carries
+// key itself so that it can be shown to the user this once.
+func (m *Memory) InsertAPIKeyWithPolicy(ctx context.Context, userID string, key []byte, scopes, origins []string, expires time.Time) (*api.Key, error) {
+	if len(key) < minAPIKeyLen {
+		return nil, errors.Newf("API key must be at least %d bytes long", minAPIKeyLen)
+	}
+	if _, err := strconv.ParseInt(userID, 10, 64); err != nil {
+		return nil, errors.Newf("user ID must be an integer, got '%s'", userID)
+	}
+	h, err := apikeys.HashKey(key)
+	if err != nil {
+		return nil, errors.Newf("hash API key: %v", err)
+	}
+	var k api.Key
+	m.update(func(s *state, now time.Time) error {
+		row := apiKeyRow{
+			ID:      s.nextID(),
+			userID:  userID,
+			hash:    h,
+			scopes:  joinList(scopes),
+			origins: joinList(origins),
+			expires: expires,
+			created: now,
+			updated: now,
+		}
+		s.apiKeys[row.ID] = row
+		k = row.key()
+		return nil
+	})
+	k.Val = key
+	return &k, nil
+}
+
+// APIKeyByUserIDVal returns API keys for the provided userID/key combination
+// unless expired. Candidates are looked up by the key's prefix and
+// verified against their hashes in constant time.
+func (m *Memory) APIKeyByUserIDVal(userID string, key []byte) (apiG.Key, error) {
+	ks := m.queryAPIKeys(func(k apiKeyRow, now time.Time) bool {
+		return k.userID == userID && k.hash.Prefix == apikeys.Prefix(key) &&
+			(k.expires.IsZero() || k.expires.After(now)) && k.hash.Matches(key)
+	})
+	if len(ks) == 0 {
+		return nil, errors.NewNotFound("API key not found")
+	}
+	ks[0].Val = key
+	return ks[0], nil
+}
+
+// APIKeys returns API keys, oldest first, of userID or of all users if
+// userID is empty. Keys are returned without their Val which is not stored.
+func (m *Memory) APIKeys(ctx context.Context, userID string, offset, count int64) ([]api.Key, error) {
+	ks := m.queryAPIKeys(func(k apiKeyRow, _ time.Time) bool {
+		return userID == "" || k.userID == userID
+	})
+	from, to := page(len(ks), offset, count)
+	ks = ks[from:to]
+	if len(ks) == 0 {
+		return nil, errors.NewNotFound("no API keys found")
+	}
+	return ks, nil
+}
+
+// APIKeyByID returns the API key with ID, without its Val.
+func (m *Memory) APIKeyByID(ctx context.Context, ID string) (*api.Key, error) {
+	ks := m.queryAPIKeys(func(k apiKeyRow, _ time.Time) bool {
+		return k.ID == ID
+	})
+	if len(ks) == 0 {
+		return nil, errors.NewNotFound("API key not found")
+	}
+	return &ks[0], nil
+}
+
+// UpdateAPIKeyPolicy sets the scopes, origins and expiry of the API key
+// with ID. A zero expires means the key never expires.
+func (m *Memory) UpdateAPIKeyPolicy(ctx context.Context, ID string, scopes, origins []string, expires time.Time) (*api.Key, error) {
+	var k api.Key
+	err := m.update(func(s *state, now time.Time) error {
+		row, ok := s.apiKeys[ID]
+		if !ok {
+			return errors.NewNotFound("API key not found")
+		}
+		row.scopes = joinList(scopes)
+		row.origins = joinList(origins)
+		row.expires = expires
+		row.updated = now
+		s.apiKeys[ID] = row
+		k = row.key()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// TouchAPIKey records that the API key with ID was just used. Uses are
+// recorded at most once per minute per key, as with Roach.
+func (m *Memory) TouchAPIKey(ctx context.Context, ID string) error {
+	return m.update(func(s *state, now time.Time) error {
+		row, ok := s.apiKeys[ID]
+		if ok && (row.lastUsed.IsZero() || row.lastUsed.Before(now.Add(-time.Minute))) {
+			row.lastUsed = now
+			s.apiKeys[ID] = row
+		}
+		return nil
+	})
+}
+
+// DeleteAPIKey deletes the API key with ID so that it is no longer valid.
+func (m *Memory) DeleteAPIKey(ctx context.Context, ID string) error {
+	return m.update(func(s *state, _ time.Time) error {
+		if _, ok := s.apiKeys[ID]; !ok {
+			return checkAffected(0, 1)
+		}
+		delete(s.apiKeys, ID)
+		return nil
+	})
+}
+
+// queryAPIKeys returns the API keys that satisfy include, oldest first.
+func (m *Memory) queryAPIKeys(include func(k apiKeyRow, now time.Time) bool) []api.Key {
+	var ks []api.Key
+	m.view(func(s *state, now time.Time) error {
+		for _, row := range s.apiKeys {
+			if include(row, now) {
+				ks = append(ks, row.key())
+			}
+		}
+		return nil
+	})
+	sort.Slice(ks, func(i, j int) bool { return lessID(ks[i].ID, ks[j].ID) })
+	return ks
+}
+
+func (k apiKeyRow) key() api.Key {
+	return api.Key{
+		ID:          k.ID,
+		UserID:      k.userID,
+		Prefix:      k.hash.Prefix,
+		Scopes:      splitList(k.scopes),
+		Origins:     splitList(k.origins),
+		Expires:     k.expires,
+		LastUsed:    k.lastUsed,
+		Created:     k.created,
+		LastUpdated: k.updated,
+	}
+}
+
+// joinList encodes a list of values without spaces such as scopes, as
+// Roach stores them, see splitList().
+func joinList(vals []string) string {
+	return strings.Join(vals, " ")
+}
+
+func splitList(list string) []string {
+	return strings.Fields(list)
+}
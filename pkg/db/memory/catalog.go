@@ -0,0 +1,294 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// Entity types of aliases.
+const (
+	aliasItems  = "items"
+	aliasBrands = "brands"
+)
+
+// namedRow is a row of items or measuring units.
+type namedRow struct {
+	ID       string
+	name     string
+	normName string
+}
+
+type brandKey struct {
+	normName string
+	itemID   string
+	unitID   string
+}
+
+type brandRow struct {
+	ID string
+	brandKey
+	name string
+}
+
+type aliasKey struct {
+	entityType string
+	normName   string
+}
+
+// UpsertBrands inserts brands, their items, measuring units and barcodes in
+// a single transaction, reusing any that already exist (matched by
+// normalized name or an alias left behind by a merge). Existing barcodes
+// are re-assigned to the brand they appear with in brands. It returns
+// brands with all IDs assigned.
+func (m *Memory) UpsertBrands(ctx context.Context, brands []shopping.Brand) ([]shopping.Brand, error) {
+	if len(brands) == 0 {
+		return nil, nil
+	}
+	out := make([]shopping.Brand, len(brands))
+	copy(out, brands)
+	err := m.update(func(s *state, _ time.Time) error {
+		for i := range out {
+			out[i].Item.Name = s.resolveAlias(aliasItems, out[i].Item.Name)
+			out[i].Name = s.resolveAlias(aliasBrands, out[i].Name)
+			out[i].Item.ID = upsertNamed(s, s.items, s.itemIDByNorm, out[i].Item.Name)
+			out[i].MeasuringUnit.ID = upsertNamed(s, s.units, s.unitIDByNorm, out[i].MeasuringUnit.Name)
+		}
+		for i := range out {
+			k := brandKey{
+				normName: shopping.NormalizeName(out[i].Name),
+				itemID:   out[i].Item.ID,
+				unitID:   out[i].MeasuringUnit.ID,
+			}
+			if k.normName == "" || k.itemID == "" || k.unitID == "" {
+				return errors.Newf("upsert brands: brand '%s' needs a name,"+
+					" item and measuring unit", out[i].Name)
+			}
+			ID, ok := s.brandIDByKey[k]
+			if !ok {
+				ID = s.nextID()
+				s.brands[ID] = brandRow{ID: ID, brandKey: k, name: shopping.CleanName(out[i].Name)}
+				s.brandIDByKey[k] = ID
+			}
+			out[i].ID = ID
+		}
+		for i := range out {
+			for _, code := range out[i].Barcodes {
+				if code = strings.TrimSpace(code); code != "" {
+					s.barcodes[code] = out[i].ID
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// upsertNamed inserts name into rows (items or measuring units) if no row
+// has the same normalized name and returns the row's ID, or "" if name
+// normalizes to "".
+func upsertNamed(s *state, rows map[string]namedRow, IDByNorm map[string]string, name string) string {
+	norm := shopping.NormalizeName(name)
+	if norm == "" {
+		return ""
+	}
+	if ID, ok := IDByNorm[norm]; ok {
+		return ID
+	}
+	ID := s.nextID()
+	rows[ID] = namedRow{ID: ID, name: shopping.CleanName(name), normName: norm}
+	IDByNorm[norm] = ID
+	return ID
+}
+
+// Items returns all items in the catalog.
+func (m *Memory) Items(ctx context.Context) ([]shopping.Item, error) {
+	var items []shopping.Item
+	m.view(func(s *state, _ time.Time) error {
+		for _, i := range s.items {
+			items = append(items, shopping.Item{ID: i.ID, Name: i.name})
+		}
+		return nil
+	})
+	if len(items) == 0 {
+		return nil, errors.NewNotFound("no items found")
+	}
+	sort.Slice(items, func(i, j int) bool { return lessID(items[i].ID, items[j].ID) })
+	return items, nil
+}
+
+// Brands returns all brands in the catalog together with their item and
+// measuring unit. Barcodes are not included.
+func (m *Memory) Brands(ctx context.Context) ([]shopping.Brand, error) {
+	var brands []shopping.Brand
+	m.view(func(s *state, _ time.Time) error {
+		for ID := range s.brands {
+			brands = append(brands, s.brand(ID))
+		}
+		return nil
+	})
+	if len(brands) == 0 {
+		return nil, errors.NewNotFound("no brands found")
+	}
+	sort.Slice(brands, func(i, j int) bool { return lessID(brands[i].ID, brands[j].ID) })
+	return brands, nil
+}
+
+// MergeItems moves the brands of the items with duplicateIDs to the item
+// with survivorID, records the duplicates' names as aliases of the
+// survivor and deletes the duplicates, all in one transaction.
+// A brand that the survivor already has (same name and measuring unit) is
+// merged into the survivor's brand.
+func (m *Memory) MergeItems(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Item, error) {
+	var survivor namedRow
+	err := m.update(func(s *state, now time.Time) error {
+		var ok bool
+		if survivor, ok = s.items[survivorID]; !ok {
+			return errors.NewNotFoundf("item %s not found", survivorID)
+		}
+		for _, dupID := range duplicateIDs {
+			dup, ok := s.items[dupID]
+			if !ok {
+				return errors.NewNotFoundf("item %s not found", dupID)
+			}
+			for _, b := range s.brandsOfItem(dup.ID) {
+				k := brandKey{normName: b.normName, itemID: survivor.ID, unitID: b.unitID}
+				if existingID, ok := s.brandIDByKey[k]; ok {
+					s.mergeBrandInto(s.brands[existingID], b, now)
+					continue
+				}
+				delete(s.brandIDByKey, b.brandKey)
+				b.brandKey = k
+				s.brands[b.ID] = b
+				s.brandIDByKey[k] = b.ID
+			}
+			if dup.normName != survivor.normName {
+				s.addAlias(aliasItems, dup.normName, dup.name, survivor.name)
+			}
+			delete(s.items, dup.ID)
+			delete(s.itemIDByNorm, dup.normName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &shopping.Item{ID: survivor.ID, Name: survivor.name}, nil
+}
+
+// MergeBrands moves the prices, shopping list items and barcodes of the
+// brands with duplicateIDs to the brand with survivorID, records the
+// duplicates' names as aliases of the survivor and deletes the duplicates,
+// all in one transaction.
+// It returns a client error if a duplicate is not of the same item and
+// measuring unit as the survivor.
+func (m *Memory) MergeBrands(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Brand, error) {
+	var out shopping.Brand
+	err := m.update(func(s *state, now time.Time) error {
+		survivor, ok := s.brands[survivorID]
+		if !ok {
+			return errors.NewNotFoundf("brand %s not found", survivorID)
+		}
+		for _, dupID := range duplicateIDs {
+			dup, ok := s.brands[dupID]
+			if !ok {
+				return errors.NewNotFoundf("brand %s not found", dupID)
+			}
+			if dup.itemID != survivor.itemID || dup.unitID != survivor.unitID {
+				return errors.NewClientf("brand %s is not of the same item and"+
+					" measuring unit as brand %s", dup.ID, survivor.ID)
+			}
+			s.mergeBrandInto(survivor, dup, now)
+		}
+		out = s.brand(survivor.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (s *state) mergeBrandInto(survivor, dup brandRow, now time.Time) {
+	// A shopping list holds one item per brand, drop the duplicate's item
+	// from lists that already have the survivor.
+	for ID, row := range s.listItems {
+		if row.brandID != dup.ID {
+			continue
+		}
+		if _, ok := s.listItemByBrand(row.listID, survivor.ID); ok {
+			delete(s.listItems, ID)
+			continue
+		}
+		row.brandID = survivor.ID
+		row.updated = now
+		s.listItems[ID] = row
+	}
+	for ID, p := range s.prices {
+		if p.brandID == dup.ID {
+			p.brandID = survivor.ID
+			s.prices[ID] = p
+		}
+	}
+	for code, brandID := range s.barcodes {
+		if brandID == dup.ID {
+			s.barcodes[code] = survivor.ID
+		}
+	}
+	if dup.normName != survivor.normName {
+		s.addAlias(aliasBrands, dup.normName, dup.name, survivor.name)
+	}
+	delete(s.brands, dup.ID)
+	delete(s.brandIDByKey, dup.brandKey)
+}
+
+// addAlias records aliasNorm as an alias of canonicalName. Aliases that
+// pointed at the merged name (prevName) are re-pointed to canonicalName.
+func (s *state) addAlias(entityType, aliasNorm, prevName, canonicalName string) {
+	s.aliases[aliasKey{entityType: entityType, normName: aliasNorm}] = canonicalName
+	for k, canon := range s.aliases {
+		if k.entityType == entityType && canon == prevName {
+			s.aliases[k] = canonicalName
+		}
+	}
+}
+
+// resolveAlias returns the canonical name of name if it is a known alias
+// of entityType, otherwise name.
+func (s *state) resolveAlias(entityType, name string) string {
+	norm := shopping.NormalizeName(name)
+	if canon, ok := s.aliases[aliasKey{entityType: entityType, normName: norm}]; ok {
+		return canon
+	}
+	return name
+}
+
+// brandsOfItem returns the brands of the item with itemID ordered by ID.
+func (s *state) brandsOfItem(itemID string) []brandRow {
+	var brands []brandRow
+	for _, b := range s.brands {
+		if b.itemID == itemID {
+			brands = append(brands, b)
+		}
+	}
+	sort.Slice(brands, func(i, j int) bool { return lessID(brands[i].ID, brands[j].ID) })
+	return brands
+}
+
+// brand joins the brand with ID with its item and measuring unit.
+func (s *state) brand(ID string) shopping.Brand {
+	b := s.brands[ID]
+	return shopping.Brand{
+		ID:            b.ID,
+		Name:          b.name,
+		Item:          shopping.Item{ID: b.itemID, Name: s.items[b.itemID].name},
+		MeasuringUnit: shopping.MeasuringUnit{ID: b.unitID, Name: s.units[b.unitID].name},
+	}
+}
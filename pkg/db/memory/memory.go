@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+)
+
+// Memory is a shopping.Storage that keeps everything in memory, it suits
+// development and tests but loses all data when the process exits.
+// Every call runs as a serializable transaction with the same constraints
+// as the Roach tables; a call that fails leaves no changes behind.
+// Use NewMemory() to instantiate.
+type Memory struct {
+	errors.NotFoundErrCheck
+
+	mutex  sync.RWMutex
+	state  *state
+	closed bool
+
+	rateLimits *ratelimit.Memory
+}
+
+// state holds the rows of every table keyed by their primary key. Rows are
+// values so that clone() yields an independent copy of the state.
+type state struct {
+	lastID int64
+
+	apiKeys       map[string]apiKeyRow
+	stores        map[string]storeRow
+	storeBranches map[string]storeBranchRow
+	items         map[string]namedRow
+	units         map[string]namedRow
+	brands        map[string]brandRow
+	barcodes      map[string]string // brand ID by code
+	aliases       map[aliasKey]string
+	shoppingLists map[string]shoppingListRow
+	prices        map[string]priceRow
+	verdicts      map[string]verdictsRow // by ID of the casting price
+	listItems     map[string]listItemRow
+
+	// indexes on unique columns.
+	itemIDByNorm  map[string]string
+	unitIDByNorm  map[string]string
+	brandIDByKey  map[brandKey]string
+	storeIDByName map[string]string
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		state: &state{
+			apiKeys:       make(map[string]apiKeyRow),
+			stores:        make(map[string]storeRow),
+			storeBranches: make(map[string]storeBranchRow),
+			items:         make(map[string]namedRow),
+			units:         make(map[string]namedRow),
+			brands:        make(map[string]brandRow),
+			barcodes:      make(map[string]string),
+			aliases:       make(map[aliasKey]string),
+			shoppingLists: make(map[string]shoppingListRow),
+			prices:        make(map[string]priceRow),
+			verdicts:      make(map[string]verdictsRow),
+			listItems:     make(map[string]listItemRow),
+			itemIDByNorm:  make(map[string]string),
+			unitIDByNorm:  make(map[string]string),
+			brandIDByKey:  make(map[brandKey]string),
+			storeIDByName: make(map[string]string),
+		},
+		rateLimits: ratelimit.NewMemory(),
+	}
+}
+
+// Ping reports whether the Memory is still open.
+func (m *Memory) Ping(ctx context.Context) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if m.closed {
+		return errors.New("memory storage is closed")
+	}
+	return nil
+}
+
+// Close marks the Memory closed, see Ping(). Data is kept until the
+// Memory is garbage collected.
+func (m *Memory) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.closed = true
+	return nil
+}
+
+// TakeRateLimitToken takes a token from the token bucket identified by key.
+func (m *Memory) TakeRateLimitToken(ctx context.Context, key string, l ratelimit.Limit) (ratelimit.Result, error) {
+	return m.rateLimits.TakeRateLimitToken(ctx, key, l)
+}
+
+// update runs fn on a copy of the state which replaces the state only if
+// fn returns nil. now is the transaction timestamp, the equivalent of
+// CURRENT_TIMESTAMP.
+func (m *Memory) update(fn func(s *state, now time.Time) error) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	s := m.state.clone()
+	if err := fn(s, time.Now()); err != nil {
+		return err
+	}
+	m.state = s
+	return nil
+}
+
+// view runs fn on the state, which fn must not modify.
+func (m *Memory) view(fn func(s *state, now time.Time) error) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return fn(m.state, time.Now())
+}
+
+// nextID returns a new ID, IDs increase with every call.
+func (s *state) nextID() string {
+	s.lastID++
+	return strconv.FormatInt(s.lastID, 10)
+}
+
+func (s *state) clone() *state {
+	c := *s
+	c.apiKeys = cloneMap(s.apiKeys).(map[string]apiKeyRow)
+	c.stores = cloneMap(s.stores).(map[string]storeRow)
+	c.storeBranches = cloneMap(s.storeBranches).(map[string]storeBranchRow)
+	c.items = cloneMap(s.items).(map[string]namedRow)
+	c.units = cloneMap(s.units).(map[string]namedRow)
+	c.brands = cloneMap(s.brands).(map[string]brandRow)
+	c.barcodes = cloneMap(s.barcodes).(map[string]string)
+	c.aliases = cloneMap(s.aliases).(map[aliasKey]string)
+	c.shoppingLists = cloneMap(s.shoppingLists).(map[string]shoppingListRow)
+	c.prices = cloneMap(s.prices).(map[string]priceRow)
+	c.verdicts = cloneMap(s.verdicts).(map[string]verdictsRow)
+	c.listItems = cloneMap(s.listItems).(map[string]listItemRow)
+	c.itemIDByNorm = cloneMap(s.itemIDByNorm).(map[string]string)
+	c.unitIDByNorm = cloneMap(s.unitIDByNorm).(map[string]string)
+	c.brandIDByKey = cloneMap(s.brandIDByKey).(map[brandKey]string)
+	c.storeIDByName = cloneMap(s.storeIDByName).(map[string]string)
+	return &c
+}
+
+// cloneMap returns a shallow copy of the map m.
+func cloneMap(m interface{}) interface{} {
+	v := reflect.ValueOf(m)
+	c := reflect.MakeMapWithSize(v.Type(), v.Len())
+	for it := v.MapRange(); it.Next(); {
+		c.SetMapIndex(it.Key(), it.Value())
+	}
+	return c.Interface()
+}
+
+// lessID orders IDs as the numbers they are.
+func lessID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// page returns the bounds of the slice of n results selected by offset and
+// count, the equivalent of LIMIT count OFFSET offset.
+func page(n int, offset, count int64) (from, to int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if count < 0 {
+		count = 0
+	}
+	if offset > int64(n) {
+		offset = int64(n)
+	}
+	to64 := offset + count
+	if to64 > int64(n) || to64 < offset {
+		to64 = int64(n)
+	}
+	return int(offset), int(to64)
+}
+
+// checkAffected mirrors the errors of roach's checkRowsAffected().
+func checkAffected(affected, expAffected int) error {
+	if affected == 0 {
+		return errors.NewNotFound("none found for update")
+	}
+	if affected != expAffected {
+		return errors.Newf("expected %d affected rows but got %d",
+			expAffected, affected)
+	}
+	return nil
+}
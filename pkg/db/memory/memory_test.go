@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/db/memory"
+	"github.com/tomogoma/shoppingms/pkg/db/storagetest"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestMemory(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) (shopping.Storage, func()) {
+		return memory.NewMemory(), func() {}
+	})
+}
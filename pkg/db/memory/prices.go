@@ -0,0 +1,316 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type priceRow struct {
+	ID             string
+	value          float32
+	currency       string
+	brandID        string
+	storeBranchID  string
+	userID         string
+	status         string
+	outlierScore   float64
+	confirmations  int64
+	contradictions int64
+	created        time.Time
+}
+
+// verdictsRow holds the IDs of the prices a price confirmed and
+// contradicted. The slices are never modified once stored.
+type verdictsRow struct {
+	confirmed    []string
+	contradicted []string
+}
+
+// InsertPrice inserts p and records v in one transaction and returns p
+// with its ID, creation date, brand and store branch details assigned.
+// p.Brand.ID must reference an existing brand, p.AtStoreBranch.ID must
+// reference an existing store branch or be empty.
+func (m *Memory) InsertPrice(ctx context.Context, p shopping.Price, v shopping.Verdicts) (*shopping.Price, error) {
+	var out shopping.Price
+	err := m.update(func(s *state, now time.Time) error {
+		if p.Value < 0 {
+			return errors.Newf("price value must not be negative, got %f", p.Value)
+		}
+		if len(p.Currency) != 3 {
+			return errors.Newf("currency must be 3 characters long, got '%s'", p.Currency)
+		}
+		if _, ok := s.brands[p.Brand.ID]; !ok {
+			return errors.Newf("brand %s does not exist", p.Brand.ID)
+		}
+		if _, ok := s.storeBranches[p.AtStoreBranch.ID]; p.AtStoreBranch.ID != "" && !ok {
+			return errors.Newf("store branch %s does not exist", p.AtStoreBranch.ID)
+		}
+		if p.SubmittedBy == "" || p.Status == "" {
+			return errors.New("a price needs a submitter and status")
+		}
+		row := priceRow{
+			ID:            s.nextID(),
+			value:         p.Value,
+			currency:      p.Currency,
+			brandID:       p.Brand.ID,
+			storeBranchID: p.AtStoreBranch.ID,
+			userID:        p.SubmittedBy,
+			status:        p.Status,
+			outlierScore:  p.OutlierScore,
+			created:       now,
+		}
+		s.prices[row.ID] = row
+		if err := s.recordVerdicts(row.ID, v); err != nil {
+			return err
+		}
+		out = s.price(s.prices[row.ID])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PriceByID returns the price with ID.
+func (m *Memory) PriceByID(ctx context.Context, ID string) (*shopping.Price, error) {
+	var out shopping.Price
+	err := m.view(func(s *state, _ time.Time) error {
+		p, ok := s.prices[ID]
+		if !ok {
+			return errors.NewNotFoundf("price %s not found", ID)
+		}
+		out = s.price(p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RecentApprovedPrices returns the latest (up to limit) approved prices of
+// brandID in currency that were observed since, latest first. Prices
+// observed at any store branch are included if storeBranchID is empty.
+func (m *Memory) RecentApprovedPrices(ctx context.Context, brandID, storeBranchID, currency string, since time.Time, limit int) ([]shopping.Price, error) {
+	return m.queryPrices(func(p priceRow) bool {
+		return p.brandID == brandID && p.currency == currency &&
+			p.status == shopping.PriceStatusApproved && !p.created.Before(since) &&
+			(storeBranchID == "" || p.storeBranchID == storeBranchID)
+	}, func(a, b priceRow) bool {
+		if !a.created.Equal(b.created) {
+			return a.created.After(b.created)
+		}
+		return lessID(b.ID, a.ID)
+	}, 0, int64(limit))
+}
+
+// PricesByStatus returns prices with status, oldest first.
+func (m *Memory) PricesByStatus(ctx context.Context, status string, offset, count int64) ([]shopping.Price, error) {
+	return m.queryPrices(func(p priceRow) bool {
+		return p.status == status
+	}, func(a, b priceRow) bool {
+		if !a.created.Equal(b.created) {
+			return a.created.Before(b.created)
+		}
+		return lessID(a.ID, b.ID)
+	}, offset, count)
+}
+
+// UpdatePriceStatus sets the status of the price with priceID, reverses
+// the verdicts it previously cast and records v in one transaction and
+// returns the updated price. A price that already has status is returned
+// unchanged and v is not recorded so that concurrent updates to the same
+// status record v only once.
+func (m *Memory) UpdatePriceStatus(ctx context.Context, priceID, status string, v shopping.Verdicts) (*shopping.Price, error) {
+	var out shopping.Price
+	err := m.update(func(s *state, _ time.Time) error {
+		p, ok := s.prices[priceID]
+		if !ok {
+			return checkAffected(0, 1)
+		}
+		if status == "" {
+			return errors.New("a price needs a status")
+		}
+		if p.status != status {
+			p.status = status
+			s.prices[priceID] = p
+			s.revokeVerdicts(priceID)
+			if err := s.recordVerdicts(priceID, v); err != nil {
+				return err
+			}
+		}
+		out = s.price(s.prices[priceID])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// recordVerdicts increments the confirmations of prices with v.Confirmed
+// and the contradictions of prices with v.Contradicted and stores v as
+// cast by the price with priceID so that revokeVerdicts can reverse them.
+func (s *state) recordVerdicts(priceID string, v shopping.Verdicts) error {
+	var row verdictsRow
+	for _, col := range []struct {
+		name   string
+		IDs    []string
+		inc    func(*priceRow)
+		stored *[]string
+	}{
+		{name: "confirmations", IDs: v.Confirmed, stored: &row.confirmed,
+			inc: func(p *priceRow) { p.confirmations++ }},
+		{name: "contradictions", IDs: v.Contradicted, stored: &row.contradicted,
+			inc: func(p *priceRow) { p.contradictions++ }},
+	} {
+		if len(col.IDs) == 0 {
+			continue
+		}
+		updated := make(map[string]bool)
+		for _, ID := range col.IDs {
+			p, ok := s.prices[ID]
+			if !ok || updated[ID] {
+				continue
+			}
+			col.inc(&p)
+			s.prices[ID] = p
+			updated[ID] = true
+			*col.stored = append(*col.stored, ID)
+		}
+		if err := checkAffected(len(updated), len(col.IDs)); err != nil {
+			return errors.Newf("update %s: %v", col.name, err)
+		}
+	}
+	if len(row.confirmed) > 0 || len(row.contradicted) > 0 {
+		s.verdicts[priceID] = row
+	}
+	return nil
+}
+
+// revokeVerdicts reverses the verdicts recorded by recordVerdicts for the
+// price with priceID.
+func (s *state) revokeVerdicts(priceID string) {
+	row := s.verdicts[priceID]
+	for _, ID := range row.confirmed {
+		if p, ok := s.prices[ID]; ok {
+			p.confirmations--
+			s.prices[ID] = p
+		}
+	}
+	for _, ID := range row.contradicted {
+		if p, ok := s.prices[ID]; ok {
+			p.contradictions--
+			s.prices[ID] = p
+		}
+	}
+	delete(s.verdicts, priceID)
+}
+
+// Contributors returns the price submission tallies of the users with
+// userIDs. Users who never submitted a price are left out.
+func (m *Memory) Contributors(ctx context.Context, userIDs []string) ([]shopping.Contributor, error) {
+	include := make(map[string]bool)
+	for _, ID := range userIDs {
+		include[ID] = true
+	}
+	cs := m.contributors(func(c shopping.Contributor) bool { return include[c.UserID] })
+	if len(cs) == 0 {
+		return nil, errors.NewNotFound("no contributors found")
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].UserID < cs[j].UserID })
+	return cs, nil
+}
+
+// ContributorsByTrust returns contributors ordered by shopping.TrustScore,
+// most trusted first.
+func (m *Memory) ContributorsByTrust(ctx context.Context, offset, count int64) ([]shopping.Contributor, error) {
+	cs := m.contributors(func(shopping.Contributor) bool { return true })
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].Trust != cs[j].Trust {
+			return cs[i].Trust > cs[j].Trust
+		}
+		if cs[i].Submissions != cs[j].Submissions {
+			return cs[i].Submissions > cs[j].Submissions
+		}
+		return cs[i].UserID < cs[j].UserID
+	})
+	from, to := page(len(cs), offset, count)
+	cs = cs[from:to]
+	if len(cs) == 0 {
+		return nil, errors.NewNotFound("no contributors found")
+	}
+	return cs, nil
+}
+
+// contributors tallies the prices submitted by each user, returning the
+// tallies that satisfy include.
+func (m *Memory) contributors(include func(shopping.Contributor) bool) []shopping.Contributor {
+	byUser := make(map[string]*shopping.Contributor)
+	m.view(func(s *state, _ time.Time) error {
+		for _, p := range s.prices {
+			c, ok := byUser[p.userID]
+			if !ok {
+				c = &shopping.Contributor{UserID: p.userID}
+				byUser[p.userID] = c
+			}
+			c.Submissions++
+			c.Confirmations += p.confirmations
+			c.Contradictions += p.contradictions
+		}
+		return nil
+	})
+	var cs []shopping.Contributor
+	for _, c := range byUser {
+		c.Trust = shopping.TrustScore(c.Confirmations, c.Contradictions)
+		if include(*c) {
+			cs = append(cs, *c)
+		}
+	}
+	return cs
+}
+
+// queryPrices returns the prices that satisfy include ordered by less.
+func (m *Memory) queryPrices(include func(priceRow) bool, less func(a, b priceRow) bool, offset, count int64) ([]shopping.Price, error) {
+	var ps []shopping.Price
+	m.view(func(s *state, _ time.Time) error {
+		var rows []priceRow
+		for _, p := range s.prices {
+			if include(p) {
+				rows = append(rows, p)
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool { return less(rows[i], rows[j]) })
+		from, to := page(len(rows), offset, count)
+		for _, p := range rows[from:to] {
+			ps = append(ps, s.price(p))
+		}
+		return nil
+	})
+	if len(ps) == 0 {
+		return nil, errors.NewNotFound("no prices found")
+	}
+	return ps, nil
+}
+
+// price joins p with its brand and store branch.
+func (s *state) price(p priceRow) shopping.Price {
+	return shopping.Price{
+		ID:             p.ID,
+		Value:          p.value,
+		Currency:       p.currency,
+		Brand:          s.brand(p.brandID),
+		AtStoreBranch:  s.storeBranchRef(p.storeBranchID),
+		SubmittedBy:    p.userID,
+		Status:         p.status,
+		OutlierScore:   p.outlierScore,
+		Confirmations:  p.confirmations,
+		Contradictions: p.contradictions,
+		Created:        p.created,
+	}
+}
@@ -0,0 +1,331 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type shoppingListRow struct {
+	ID      string
+	userID  string
+	name    string
+	mode    string
+	created time.Time
+	updated time.Time
+}
+
+type listItemRow struct {
+	ID       string
+	listID   string
+	brandID  string
+	priceID  string
+	quantity int
+	inList   bool
+	inCart   bool
+	created  time.Time
+	updated  time.Time
+}
+
+// UpsertShoppingList inserts sl if sl.UserID has no shopping list named
+// sl.Name and returns the stored shopping list. An existing shopping list
+// is returned unchanged.
+func (m *Memory) UpsertShoppingList(ctx context.Context, sl shopping.ShoppingList) (*shopping.ShoppingList, error) {
+	var stored shoppingListRow
+	err := m.update(func(s *state, now time.Time) error {
+		if sl.UserID == "" || sl.Name == "" {
+			return errors.New("a shopping list needs a user ID and name")
+		}
+		if existing, ok := s.shoppingListByName(sl.UserID, sl.Name); ok {
+			stored = existing
+			return nil
+		}
+		stored = shoppingListRow{
+			ID:      s.nextID(),
+			userID:  sl.UserID,
+			name:    sl.Name,
+			mode:    sl.Mode,
+			created: now,
+			updated: now,
+		}
+		s.shoppingLists[stored.ID] = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := stored.shoppingList()
+	return &out, nil
+}
+
+// UpdateShoppingList updates the name and/or mode of the shopping list
+// with ID owned by userID.
+func (m *Memory) UpdateShoppingList(ctx context.Context, userID, ID string, name, mode crdb.StringUpdate) (*shopping.ShoppingList, error) {
+	if !name.Updating && !mode.Updating {
+		return nil, errors.NewClient("nothing to update")
+	}
+	var sl shoppingListRow
+	err := m.update(func(s *state, now time.Time) error {
+		var ok bool
+		sl, ok = s.shoppingLists[ID]
+		if !ok || sl.userID != userID {
+			return errors.NewNotFoundf("shopping list %s not found", ID)
+		}
+		if name.Updating {
+			if name.NewVal == "" {
+				return errors.New("a shopping list needs a name")
+			}
+			if other, ok := s.shoppingListByName(userID, name.NewVal); ok && other.ID != ID {
+				return errors.Newf("shopping list named '%s' already exists", name.NewVal)
+			}
+			sl.name = name.NewVal
+		}
+		if mode.Updating {
+			sl.mode = mode.NewVal
+		}
+		sl.updated = now
+		s.shoppingLists[ID] = sl
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := sl.shoppingList()
+	return &out, nil
+}
+
+// ShoppingLists returns the shopping lists owned by userID, most recently
+// updated first.
+func (m *Memory) ShoppingLists(ctx context.Context, userID string, offset, count int64) ([]shopping.ShoppingList, error) {
+	var sls []shopping.ShoppingList
+	m.view(func(s *state, _ time.Time) error {
+		var rows []shoppingListRow
+		for _, sl := range s.shoppingLists {
+			if sl.userID == userID {
+				rows = append(rows, sl)
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if !rows[i].updated.Equal(rows[j].updated) {
+				return rows[i].updated.After(rows[j].updated)
+			}
+			return lessID(rows[i].ID, rows[j].ID)
+		})
+		from, to := page(len(rows), offset, count)
+		for _, sl := range rows[from:to] {
+			sls = append(sls, sl.shoppingList())
+		}
+		return nil
+	})
+	if len(sls) == 0 {
+		return nil, errors.NewNotFound("no shopping lists found")
+	}
+	return sls, nil
+}
+
+// ShoppingList returns the shopping list with ID owned by userID.
+func (m *Memory) ShoppingList(ctx context.Context, userID, ID string) (*shopping.ShoppingList, error) {
+	var sl shoppingListRow
+	err := m.view(func(s *state, _ time.Time) error {
+		var ok bool
+		sl, ok = s.shoppingLists[ID]
+		if !ok || sl.userID != userID {
+			return errors.NewNotFoundf("shopping list %s not found", ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := sl.shoppingList()
+	return &out, nil
+}
+
+// UpsertShoppingListItem inserts item into the shopping list with
+// item.ShoppingList.ID owned by userID or updates the item of the same
+// brand (item.Price.Brand.ID) if the shopping list already has one.
+// item.Price.ID may be empty in which case an existing item's price is
+// kept.
+func (m *Memory) UpsertShoppingListItem(ctx context.Context, userID string, item shopping.ShoppingListItem) (*shopping.ShoppingListItem, error) {
+	var out shopping.ShoppingListItem
+	err := m.update(func(s *state, now time.Time) error {
+		sl, ok := s.shoppingLists[item.ShoppingList.ID]
+		if !ok || sl.userID != userID {
+			return errors.NewNotFoundf("shopping list %s not found", item.ShoppingList.ID)
+		}
+		if _, ok := s.brands[item.Price.Brand.ID]; !ok {
+			return errors.Newf("brand %s does not exist", item.Price.Brand.ID)
+		}
+		if _, ok := s.prices[item.Price.ID]; item.Price.ID != "" && !ok {
+			return errors.Newf("price %s does not exist", item.Price.ID)
+		}
+		if item.Quantity < 0 {
+			return errors.Newf("quantity must not be negative, got %d", item.Quantity)
+		}
+		row, ok := s.listItemByBrand(sl.ID, item.Price.Brand.ID)
+		if !ok {
+			row = listItemRow{
+				ID:      s.nextID(),
+				listID:  sl.ID,
+				brandID: item.Price.Brand.ID,
+				created: now,
+			}
+		}
+		if item.Price.ID != "" {
+			row.priceID = item.Price.ID
+		}
+		row.quantity = item.Quantity
+		row.inList = item.InList
+		row.inCart = item.InCart
+		row.updated = now
+		s.listItems[row.ID] = row
+		out = s.shoppingListItem(row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteShoppingListItem deletes the shopping list item with ID from a
+// shopping list owned by userID.
+func (m *Memory) DeleteShoppingListItem(ctx context.Context, userID, ID string) error {
+	return m.update(func(s *state, _ time.Time) error {
+		row, ok := s.listItems[ID]
+		if !ok || s.shoppingLists[row.listID].userID != userID {
+			return checkAffected(0, 1)
+		}
+		delete(s.listItems, ID)
+		return nil
+	})
+}
+
+// ShoppingListItems returns the items in the shopping list with
+// shoppingListID owned by userID in the order they were added.
+func (m *Memory) ShoppingListItems(ctx context.Context, userID, shoppingListID string, offset, count int64) ([]shopping.ShoppingListItem, error) {
+	var items []shopping.ShoppingListItem
+	m.view(func(s *state, _ time.Time) error {
+		if s.shoppingLists[shoppingListID].userID != userID {
+			return nil
+		}
+		var rows []listItemRow
+		for _, row := range s.listItems {
+			if row.listID == shoppingListID {
+				rows = append(rows, row)
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if !rows[i].created.Equal(rows[j].created) {
+				return rows[i].created.Before(rows[j].created)
+			}
+			return lessID(rows[i].ID, rows[j].ID)
+		})
+		from, to := page(len(rows), offset, count)
+		for _, row := range rows[from:to] {
+			items = append(items, s.shoppingListItem(row))
+		}
+		return nil
+	})
+	if len(items) == 0 {
+		return nil, errors.NewNotFound("no shopping list items found")
+	}
+	return items, nil
+}
+
+// SearchShoppingListItems returns items in any shopping list owned by
+// userID whose item, brand and measuring unit names contain those in q,
+// ignoring case. The most recently updated items are returned first.
+func (m *Memory) SearchShoppingListItems(ctx context.Context, userID string, q shopping.ItemSearch, offset, count int64) ([]shopping.ShoppingListItem, error) {
+	var items []shopping.ShoppingListItem
+	m.view(func(s *state, _ time.Time) error {
+		var rows []listItemRow
+		for _, row := range s.listItems {
+			if s.shoppingLists[row.listID].userID != userID {
+				continue
+			}
+			b := s.brands[row.brandID]
+			if containsFold(s.items[b.itemID].name, q.ItemName) &&
+				containsFold(b.name, q.BrandName) &&
+				containsFold(s.units[b.unitID].name, q.MeasuringUnit) {
+				rows = append(rows, row)
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if !rows[i].updated.Equal(rows[j].updated) {
+				return rows[i].updated.After(rows[j].updated)
+			}
+			return lessID(rows[i].ID, rows[j].ID)
+		})
+		from, to := page(len(rows), offset, count)
+		for _, row := range rows[from:to] {
+			items = append(items, s.shoppingListItem(row))
+		}
+		return nil
+	})
+	if len(items) == 0 {
+		return nil, errors.NewNotFound("no shopping list items found")
+	}
+	return items, nil
+}
+
+func (s *state) shoppingListByName(userID, name string) (shoppingListRow, bool) {
+	for _, sl := range s.shoppingLists {
+		if sl.userID == userID && sl.name == name {
+			return sl, true
+		}
+	}
+	return shoppingListRow{}, false
+}
+
+func (s *state) listItemByBrand(listID, brandID string) (listItemRow, bool) {
+	for _, row := range s.listItems {
+		if row.listID == listID && row.brandID == brandID {
+			return row, true
+		}
+	}
+	return listItemRow{}, false
+}
+
+func (sl shoppingListRow) shoppingList() shopping.ShoppingList {
+	return shopping.ShoppingList{
+		ID:          sl.ID,
+		UserID:      sl.userID,
+		Name:        sl.name,
+		Mode:        sl.mode,
+		Created:     sl.created.Format(config.TimeFormat),
+		LastUpdated: sl.updated.Format(config.TimeFormat),
+	}
+}
+
+// shoppingListItem joins row with its shopping list, brand and price.
+func (s *state) shoppingListItem(row listItemRow) shopping.ShoppingListItem {
+	i := shopping.ShoppingListItem{
+		ID:           row.ID,
+		Quantity:     row.quantity,
+		InList:       row.inList,
+		InCart:       row.inCart,
+		ShoppingList: s.shoppingLists[row.listID].shoppingList(),
+	}
+	i.Price.Brand = s.brand(row.brandID)
+	if p, ok := s.prices[row.priceID]; ok {
+		i.Price.ID = p.ID
+		i.Price.Value = p.value
+		i.Price.Currency = p.currency
+		i.Price.Status = p.status
+		i.Price.Created = p.created
+		i.Price.AtStoreBranch = s.storeBranchRef(p.storeBranchID)
+	}
+	return i
+}
+
+// containsFold reports whether s contains substr, ignoring case.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type storeRow struct {
+	ID   string
+	name string
+}
+
+type storeBranchRow struct {
+	ID       string
+	storeID  string
+	name     string
+	location shopping.Location
+	osmID    string
+}
+
+// UpsertStore inserts a store with name if one does not already exist and
+// returns the stored value.
+func (m *Memory) UpsertStore(name string) (*shopping.Store, error) {
+	var out shopping.Store
+	err := m.update(func(s *state, _ time.Time) error {
+		if name == "" {
+			return errors.New("a store needs a name")
+		}
+		ID, ok := s.storeIDByName[name]
+		if !ok {
+			ID = s.nextID()
+			s.stores[ID] = storeRow{ID: ID, name: name}
+			s.storeIDByName[name] = ID
+		}
+		out = shopping.Store{ID: ID, Name: name}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// InsertStoreBranch inserts sb and returns it with its ID assigned.
+// sb.Store.ID must reference an existing store.
+func (m *Memory) InsertStoreBranch(sb shopping.StoreBranch) (*shopping.StoreBranch, error) {
+	err := m.update(func(s *state, _ time.Time) error {
+		if _, ok := s.stores[sb.Store.ID]; !ok {
+			return errors.Newf("store %s does not exist", sb.Store.ID)
+		}
+		row := storeBranchRow{
+			storeID:  sb.Store.ID,
+			name:     sb.Name,
+			location: sb.Location,
+			osmID:    sb.OSMID,
+		}
+		if err := s.checkStoreBranch(row); err != nil {
+			return err
+		}
+		row.ID = s.nextID()
+		s.storeBranches[row.ID] = row
+		sb.ID = row.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sb, nil
+}
+
+// UpdateStoreBranch overwrites the name, location and OSMID of the store
+// branch with sb.ID.
+func (m *Memory) UpdateStoreBranch(sb shopping.StoreBranch) error {
+	return m.update(func(s *state, _ time.Time) error {
+		row, ok := s.storeBranches[sb.ID]
+		if !ok {
+			return checkAffected(0, 1)
+		}
+		row.name = sb.Name
+		row.location = sb.Location
+		row.osmID = sb.OSMID
+		if err := s.checkStoreBranch(row); err != nil {
+			return err
+		}
+		s.storeBranches[row.ID] = row
+		return nil
+	})
+}
+
+// StoreBranchByOSMID returns the store branch that was imported from the
+// OpenStreetMap element osmID.
+func (m *Memory) StoreBranchByOSMID(osmID string) (*shopping.StoreBranch, error) {
+	sbs, err := m.queryStoreBranches(func(sb storeBranchRow) bool {
+		return sb.osmID != "" && sb.osmID == osmID
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sbs[0], nil
+}
+
+// StoreBranchesWithin returns all branches of the store with storeID that
+// lie inside the box bounded by the south-west (sw) and north-east (ne)
+// corners.
+func (m *Memory) StoreBranchesWithin(storeID string, sw, ne shopping.Location) ([]shopping.StoreBranch, error) {
+	return m.queryStoreBranches(func(sb storeBranchRow) bool {
+		l := sb.location
+		return sb.storeID == storeID &&
+			l.Latitude >= sw.Latitude && l.Latitude <= ne.Latitude &&
+			l.Longitude >= sw.Longitude && l.Longitude <= ne.Longitude
+	})
+}
+
+func (m *Memory) queryStoreBranches(include func(storeBranchRow) bool) ([]shopping.StoreBranch, error) {
+	var sbs []shopping.StoreBranch
+	m.view(func(s *state, _ time.Time) error {
+		for _, row := range s.storeBranches {
+			if !include(row) {
+				continue
+			}
+			sb := s.storeBranchRef(row.ID)
+			sb.Location = row.location
+			sb.OSMID = row.osmID
+			sbs = append(sbs, sb)
+		}
+		return nil
+	})
+	if len(sbs) == 0 {
+		return nil, errors.NewNotFound("no store branches found")
+	}
+	sort.Slice(sbs, func(i, j int) bool { return lessID(sbs[i].ID, sbs[j].ID) })
+	return sbs, nil
+}
+
+// checkStoreBranch enforces the constraints of the store branches table
+// on sb.
+func (s *state) checkStoreBranch(sb storeBranchRow) error {
+	if sb.name == "" {
+		return errors.New("a store branch needs a name")
+	}
+	if l := sb.location; l.Latitude < -90 || l.Latitude > 90 ||
+		l.Longitude < -180 || l.Longitude > 180 {
+		return errors.Newf("location %+v is out of range", l)
+	}
+	if sb.osmID == "" {
+		return nil
+	}
+	for _, other := range s.storeBranches {
+		if other.osmID == sb.osmID && other.ID != sb.ID {
+			return errors.Newf("store branch %s already has OSM ID '%s'",
+				other.ID, sb.osmID)
+		}
+	}
+	return nil
+}
+
+// storeBranchRef returns the ID and name of the store branch with ID and
+// of its store, or a zero StoreBranch if ID is empty.
+func (s *state) storeBranchRef(ID string) shopping.StoreBranch {
+	row, ok := s.storeBranches[ID]
+	if !ok {
+		return shopping.StoreBranch{}
+	}
+	return shopping.StoreBranch{
+		ID:    row.ID,
+		Name:  row.name,
+		Store: shopping.Store{ID: row.storeID, Name: s.stores[row.storeID].name},
+	}
+}
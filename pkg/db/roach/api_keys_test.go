@@ -2,13 +2,16 @@ package roach_test
 
 import (
 	"bytes"
+	"context"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/tomogoma/crdb"
 	apiH "github.com/tomogoma/go-api-guard"
 	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
 	"github.com/tomogoma/shoppingms/pkg/db/roach"
 )
 
@@ -66,6 +69,11 @@ func TestRoach_InsertAPIKey(t *testing.T) {
 				t.Errorf("API key mismatch, expect %s, got %s",
 					tc.key, ret.Val)
 			}
+			if ret.Prefix != apikeys.Prefix(tc.key) {
+				t.Errorf("API key prefix mismatch, expect %s, got %s",
+					apikeys.Prefix(tc.key), ret.Prefix)
+			}
+			assertNotStoredInPlainText(t, conf, tc.key)
 			return
 		})
 	}
@@ -85,6 +93,12 @@ func TestRoach_APIKeyByUserIDVal(t *testing.T) {
 	}{
 		{name: "found", userID: usrID, key: expKey.Value(), expNotFound: false},
 		{name: "not found key", userID: usrID, key: []byte{}, expNotFound: true},
+		{
+			name:        "not found same prefix",
+			userID:      usrID,
+			key:         append([]byte(apikeys.Prefix(expKey.Value())), "other"...),
+			expNotFound: true,
+		},
 		{name: "not found userID", userID: "345", key: expKey.Value(), expNotFound: true},
 		{name: "not found all", userID: "345", key: []byte{}, expNotFound: true},
 	}
@@ -108,6 +122,83 @@ func TestRoach_APIKeyByUserIDVal(t *testing.T) {
 	}
 }
 
+func TestRoach_APIKeys(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	k1 := insertAPIKey(t, r, "123")
+	k2 := insertAPIKey(t, r, "123")
+	k3 := insertAPIKey(t, r, "456")
+	tt := []struct {
+		name        string
+		userID      string
+		offset      int64
+		expKeys     []apiH.Key
+		expNotFound bool
+	}{
+		{name: "all users", expKeys: []apiH.Key{k1, k2, k3}},
+		{name: "one user", userID: "123", expKeys: []apiH.Key{k1, k2}},
+		{name: "offset", userID: "123", offset: 1, expKeys: []apiH.Key{k2}},
+		{name: "none", userID: "789", expNotFound: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, err := r.APIKeys(context.Background(), tc.userID, tc.offset, 10)
+			if tc.expNotFound {
+				if !r.IsNotFoundError(err) {
+					t.Fatalf("Expected not found error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if len(ks) != len(tc.expKeys) {
+				t.Fatalf("Expected %d keys, got %d", len(tc.expKeys), len(ks))
+			}
+			for i := range ks {
+				if len(ks[i].Val) > 0 {
+					t.Errorf("Expected key values not to be returned, got %s", ks[i].Val)
+				}
+				ks[i].Val = tc.expKeys[i].Value()
+				if !reflect.DeepEqual(tc.expKeys[i], ks[i]) {
+					t.Errorf("API Key mismatch:\nExpect:\t%+v\nGot:\t%+v",
+						tc.expKeys[i], ks[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRoach_DeleteAPIKey(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	k := insertAPIKey(t, r, "123").(api.Key)
+
+	got, err := r.APIKeyByID(context.Background(), k.ID)
+	if err != nil {
+		t.Fatalf("Get by ID: got error: %v", err)
+	}
+	got.Val = k.Val
+	if !reflect.DeepEqual(k, *got) {
+		t.Errorf("API Key mismatch:\nExpect:\t%+v\nGot:\t%+v", k, *got)
+	}
+
+	if err := r.DeleteAPIKey(context.Background(), k.ID); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if _, err := r.APIKeyByUserIDVal(k.UserID, k.Val); !r.IsNotFoundError(err) {
+		t.Errorf("Expected deleted key not to be found by value, got %v", err)
+	}
+	if _, err := r.APIKeyByID(context.Background(), k.ID); !r.IsNotFoundError(err) {
+		t.Errorf("Expected deleted key not to be found by ID, got %v", err)
+	}
+	if err := r.DeleteAPIKey(context.Background(), k.ID); !r.IsNotFoundError(err) {
+		t.Errorf("Expected not found error deleting again, got %v", err)
+	}
+}
+
 func insertAPIKey(t *testing.T, r *roach.Roach, usrID string) apiH.Key {
 	k, err := r.InsertAPIKey(usrID, bytes.Repeat([]byte("x"), 56))
 	if err != nil {
@@ -115,3 +206,135 @@ func insertAPIKey(t *testing.T, r *roach.Roach, usrID string) apiH.Key {
 	}
 	return k
 }
+
+func TestRoach_UpdateAPIKeyPolicy(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	k := insertAPIKey(t, r, "123").(api.Key)
+	if !reflect.DeepEqual(k.Scopes, apikeys.DefaultScopes) {
+		t.Errorf("Expected new key to have the default scopes, got %v", k.Scopes)
+	}
+
+	scopes := []string{apikeys.ScopeListsRead, apikeys.ScopeAdmin}
+	origins := []string{"https://example.com"}
+	expires := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	upd, err := r.UpdateAPIKeyPolicy(context.Background(), k.ID, scopes, origins, expires)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if !reflect.DeepEqual(upd.Scopes, scopes) || !reflect.DeepEqual(upd.Origins, origins) ||
+		!upd.Expires.Equal(expires) {
+		t.Errorf("Expected policy %v %v %v, got %+v", scopes, origins, expires, upd)
+	}
+	if _, err := r.APIKeyByUserIDVal(k.UserID, k.Val); err != nil {
+		t.Errorf("Expected key not yet expired to be found, got %v", err)
+	}
+
+	expired := time.Now().Add(-time.Hour)
+	if _, err := r.UpdateAPIKeyPolicy(context.Background(), k.ID, scopes, nil, expired); err != nil {
+		t.Fatalf("Expire: got error: %v", err)
+	}
+	if _, err := r.APIKeyByUserIDVal(k.UserID, k.Val); !r.IsNotFoundError(err) {
+		t.Errorf("Expected expired key not to be found, got %v", err)
+	}
+
+	if _, err := r.UpdateAPIKeyPolicy(context.Background(), "999", scopes, nil, time.Time{}); !r.IsNotFoundError(err) {
+		t.Errorf("Expected not found error for unknown key, got %v", err)
+	}
+}
+
+func TestRoach_TouchAPIKey(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	k := insertAPIKey(t, r, "123").(api.Key)
+	if !k.LastUsed.IsZero() {
+		t.Errorf("Expected new key not to have been used, got %v", k.LastUsed)
+	}
+
+	if err := r.TouchAPIKey(context.Background(), k.ID); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	touched, err := r.APIKeyByID(context.Background(), k.ID)
+	if err != nil {
+		t.Fatalf("Get by ID: got error: %v", err)
+	}
+	if touched.LastUsed.IsZero() {
+		t.Fatalf("Expected last use recorded")
+	}
+
+	if err := r.TouchAPIKey(context.Background(), k.ID); err != nil {
+		t.Fatalf("Touch again: got error: %v", err)
+	}
+	again, err := r.APIKeyByID(context.Background(), k.ID)
+	if err != nil {
+		t.Fatalf("Get by ID: got error: %v", err)
+	}
+	if !again.LastUsed.Equal(touched.LastUsed) {
+		t.Errorf("Expected last use within a minute not to be rerecorded, got %v then %v",
+			touched.LastUsed, again.LastUsed)
+	}
+}
+
+func TestRoach_migrateAPIKeys(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	if err := newRoach(t, conf).InitDBIfNot(); err != nil {
+		t.Fatalf("Error setting up: init db: %v", err)
+	}
+	rdb := getDB(t, conf)
+	defer rdb.Close()
+	key := bytes.Repeat([]byte("y"), 56)
+	for _, q := range []string{
+		`DROP TABLE ` + roach.TblAPIKeys,
+		`CREATE TABLE ` + roach.TblAPIKeys + ` (
+			` + roach.ColID + ` SERIAL PRIMARY KEY NOT NULL,
+			` + roach.ColUserID + ` INTEGER NOT NULL,
+			` + roach.ColKey + ` VARCHAR(256) NOT NULL,
+			` + roach.ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			` + roach.ColUpdateDate + ` TIMESTAMPTZ NOT NULL
+		)`,
+		`INSERT INTO ` + roach.TblAPIKeys + ` (` +
+			roach.ColDesc(roach.ColUserID, roach.ColKey, roach.ColUpdateDate) + `)
+			VALUES (123, '` + string(key) + `', CURRENT_TIMESTAMP)`,
+		`UPDATE ` + roach.TblConfigurations + ` SET ` + roach.ColValue + `='0'
+			WHERE ` + roach.ColKey + `='db.version'`,
+	} {
+		if _, err := rdb.Exec(q); err != nil {
+			t.Fatalf("Error setting up: version 0 API keys: %v", err)
+		}
+	}
+
+	r := newRoach(t, conf)
+	if err := r.InitDBIfNot(); err != nil {
+		t.Fatalf("Migrate: got error: %v", err)
+	}
+	k, err := r.APIKeyByUserIDVal("123", key)
+	if err != nil {
+		t.Fatalf("Expected migrated key to be valid, got %v", err)
+	}
+	if scopes := k.(api.Key).Scopes; !reflect.DeepEqual(scopes, apikeys.DefaultScopes) {
+		t.Errorf("Expected migrated key to have the default scopes, got %v", scopes)
+	}
+	assertNotStoredInPlainText(t, conf, key)
+	if v, err := r.SchemaVersion(context.Background()); err != nil || v != roach.Version {
+		t.Errorf("Expected schema version %d, got %d (error %v)", roach.Version, v, err)
+	}
+}
+
+func assertNotStoredInPlainText(t *testing.T, conf crdb.Config, key []byte) {
+	rdb := getDB(t, conf)
+	defer rdb.Close()
+	var count int
+	q := `
+	SELECT COUNT(*)
+		FROM ` + roach.TblAPIKeys + `
+		WHERE ` + roach.ColKeyHash + `=$1 OR ` + roach.ColKeySalt + `=$1`
+	if err := rdb.QueryRow(q, key).Scan(&count); err != nil {
+		t.Fatalf("Check for plain text API keys: %v", err)
+	}
+	if count > 0 {
+		t.Errorf("Expected API key not to be stored in plain text, found %d", count)
+	}
+}
@@ -0,0 +1,205 @@
+package roach
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type brandKey struct {
+	normName string
+	itemID   string
+	unitID   string
+}
+
+// UpsertBrands inserts brands, their items, measuring units and barcodes in
+// a single transaction, reusing any that already exist (matched by
+// normalized name or an alias left behind by a merge). Existing barcodes
+// are re-assigned to the brand they appear with in brands. It returns
+// brands with all IDs assigned.
+func (r *Roach) UpsertBrands(ctx context.Context, brands []shopping.Brand) ([]shopping.Brand, error) {
+	if len(brands) == 0 {
+		return nil, nil
+	}
+	out := make([]shopping.Brand, len(brands))
+	copy(out, brands)
+	err := r.executeTx(ctx, "UpsertBrands", func(tx *sql.Tx) error {
+
+		itemNames := make([]string, 0, len(out))
+		unitNames := make([]string, 0, len(out))
+		brandNames := make([]string, 0, len(out))
+		for _, b := range out {
+			itemNames = append(itemNames, b.Item.Name)
+			unitNames = append(unitNames, b.MeasuringUnit.Name)
+			brandNames = append(brandNames, b.Name)
+		}
+		itemNames, err := resolveAliases(tx, TblItems, itemNames)
+		if err != nil {
+			return errors.Newf("resolve item aliases: %v", err)
+		}
+		brandNames, err = resolveAliases(tx, TblBrands, brandNames)
+		if err != nil {
+			return errors.Newf("resolve brand aliases: %v", err)
+		}
+		for i := range out {
+			out[i].Item.Name = itemNames[i]
+			out[i].Name = brandNames[i]
+		}
+
+		itemIDs, err := upsertNamed(tx, TblItems, itemNames)
+		if err != nil {
+			return errors.Newf("upsert items: %v", err)
+		}
+		unitIDs, err := upsertNamed(tx, TblMeasuringUnits, unitNames)
+		if err != nil {
+			return errors.Newf("upsert measuring units: %v", err)
+		}
+
+		keys := make([]brandKey, len(out))
+		for i := range out {
+			out[i].Item.ID = itemIDs[shopping.NormalizeName(out[i].Item.Name)]
+			out[i].MeasuringUnit.ID = unitIDs[shopping.NormalizeName(out[i].MeasuringUnit.Name)]
+			keys[i] = brandKey{
+				normName: shopping.NormalizeName(out[i].Name),
+				itemID:   out[i].Item.ID,
+				unitID:   out[i].MeasuringUnit.ID,
+			}
+		}
+		brandIDs, err := upsertBrandRows(tx, out, keys)
+		if err != nil {
+			return errors.Newf("upsert brands: %v", err)
+		}
+
+		barcodes := make(map[string]string)
+		for i := range out {
+			out[i].ID = brandIDs[keys[i]]
+			for _, code := range out[i].Barcodes {
+				if code = strings.TrimSpace(code); code != "" {
+					barcodes[code] = out[i].ID
+				}
+			}
+		}
+		if err := upsertBarcodes(tx, barcodes); err != nil {
+			return errors.Newf("upsert barcodes: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// upsertNamed inserts names into tbl (which must have the name and
+// normName columns) if not exists and returns the IDs mapped by normalized
+// name.
+func upsertNamed(tx *sql.Tx, tbl string, names []string) (map[string]string, error) {
+	distinct := make(map[string]string)
+	var args []interface{}
+	for _, name := range names {
+		norm := shopping.NormalizeName(name)
+		if _, ok := distinct[norm]; ok || norm == "" {
+			continue
+		}
+		distinct[norm] = ""
+		args = append(args, shopping.CleanName(name), norm)
+	}
+	if len(args) == 0 {
+		return distinct, nil
+	}
+	insCols := ColDesc(ColName, ColNormName, ColUpdateDate)
+	q := `
+		INSERT INTO ` + tbl + ` (` + insCols + `)
+			VALUES ` + rowPlaceholders(len(distinct), 2, "CURRENT_TIMESTAMP") + `
+			ON CONFLICT (` + ColNormName + `)
+			DO UPDATE SET ` + ColUpdateDate + ` = CURRENT_TIMESTAMP
+			RETURNING ` + ColDesc(ColID, ColNormName)
+	rows, err := tx.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ID, norm string
+		if err := rows.Scan(&ID, &norm); err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		distinct[norm] = ID
+	}
+	return distinct, rows.Err()
+}
+
+func upsertBrandRows(tx *sql.Tx, brands []shopping.Brand, keys []brandKey) (map[brandKey]string, error) {
+	IDs := make(map[brandKey]string)
+	var args []interface{}
+	for i, k := range keys {
+		if _, ok := IDs[k]; ok {
+			continue
+		}
+		if k.normName == "" || k.itemID == "" || k.unitID == "" {
+			return nil, errors.Newf("brand '%s' needs a name, item and measuring unit",
+				brands[i].Name)
+		}
+		IDs[k] = ""
+		args = append(args, shopping.CleanName(brands[i].Name), k.normName, k.itemID, k.unitID)
+	}
+	insCols := ColDesc(ColName, ColNormName, ColItemID, ColMeasUnitID, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblBrands + ` (` + insCols + `)
+			VALUES ` + rowPlaceholders(len(IDs), 4, "CURRENT_TIMESTAMP") + `
+			ON CONFLICT (` + ColDesc(ColNormName, ColItemID, ColMeasUnitID) + `)
+			DO UPDATE SET ` + ColUpdateDate + ` = CURRENT_TIMESTAMP
+			RETURNING ` + ColDesc(ColID, ColNormName, ColItemID, ColMeasUnitID)
+	rows, err := tx.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ID string
+		k := brandKey{}
+		if err := rows.Scan(&ID, &k.normName, &k.itemID, &k.unitID); err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		IDs[k] = ID
+	}
+	return IDs, rows.Err()
+}
+
+func upsertBarcodes(tx *sql.Tx, brandIDByCode map[string]string) error {
+	if len(brandIDByCode) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, 2*len(brandIDByCode))
+	for code, brandID := range brandIDByCode {
+		args = append(args, code, brandID)
+	}
+	insCols := ColDesc(ColCode, ColBrandID, ColUpdateDate)
+	updCols := ColDesc(ColBrandID, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblBarcodes + ` (` + insCols + `)
+			VALUES ` + rowPlaceholders(len(brandIDByCode), 2, "CURRENT_TIMESTAMP") + `
+			ON CONFLICT (` + ColCode + `)
+			DO UPDATE SET (` + updCols + `) = (excluded.` + ColBrandID + `, CURRENT_TIMESTAMP)`
+	_, err := tx.Exec(q, args...)
+	return err
+}
+
+// rowPlaceholders returns numRows comma separated value lists each
+// containing numCols sequential placeholders followed by extra e.g.
+// rowPlaceholders(2, 2, "NOW()") yields "($1, $2, NOW()), ($3, $4, NOW())".
+func rowPlaceholders(numRows, numCols int, extra ...string) string {
+	rows := make([]string, numRows)
+	for i := range rows {
+		cols := make([]string, 0, numCols+len(extra))
+		for j := 1; j <= numCols; j++ {
+			cols = append(cols, "$"+strconv.Itoa(i*numCols+j))
+		}
+		rows[i] = "(" + ColDesc(append(cols, extra...)...) + ")"
+	}
+	return strings.Join(rows, ", ")
+}
@@ -0,0 +1,346 @@
+package roach
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type brandRow struct {
+	shopping.Brand
+	normName string
+}
+
+// Items returns all items in the catalog.
+func (r *Roach) Items(ctx context.Context) ([]shopping.Item, error) {
+	if err := r.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer r.observeQuery(ctx, "Items")()
+	q := `SELECT ` + ColDesc(ColID, ColName) + ` FROM ` + TblItems
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []shopping.Item
+	for rows.Next() {
+		i := shopping.Item{}
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Newf("iterate result set: %v", err)
+	}
+	if len(items) == 0 {
+		return nil, errors.NewNotFound("no items found")
+	}
+	return items, nil
+}
+
+// Brands returns all brands in the catalog together with their item and
+// measuring unit. Barcodes are not included.
+func (r *Roach) Brands(ctx context.Context) ([]shopping.Brand, error) {
+	if err := r.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer r.observeQuery(ctx, "Brands")()
+	rows, err := r.db.QueryContext(ctx, selectBrandsQ)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var brands []shopping.Brand
+	for rows.Next() {
+		b, err := scanBrand(rows)
+		if err != nil {
+			return nil, err
+		}
+		brands = append(brands, b.Brand)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Newf("iterate result set: %v", err)
+	}
+	if len(brands) == 0 {
+		return nil, errors.NewNotFound("no brands found")
+	}
+	return brands, nil
+}
+
+// MergeItems moves the brands of the items with duplicateIDs to the item
+// with survivorID, records the duplicates' names as aliases of the
+// survivor and deletes the duplicates, all in one transaction.
+// A brand that the survivor already has (same name and measuring unit) is
+// merged into the survivor's brand.
+func (r *Roach) MergeItems(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Item, error) {
+	var survivor shopping.Item
+	err := r.executeTx(ctx, "MergeItems", func(tx *sql.Tx) error {
+
+		survivorNorm, err := itemByID(tx, survivorID, &survivor)
+		if err != nil {
+			return err
+		}
+
+		for _, dupID := range duplicateIDs {
+			var dup shopping.Item
+			dupNorm, err := itemByID(tx, dupID, &dup)
+			if err != nil {
+				return err
+			}
+			dupBrands, err := brandRowsWhere(tx, `b.`+ColItemID+`=$1`, dup.ID)
+			if err != nil {
+				return err
+			}
+			for _, b := range dupBrands {
+				var existing brandRow
+				err := scanBrandRow(tx.QueryRow(selectBrandsQ+`
+					WHERE b.`+ColItemID+`=$1 AND b.`+ColNormName+`=$2
+						AND b.`+ColMeasUnitID+`=$3`,
+					survivor.ID, b.normName, b.MeasuringUnit.ID), &existing)
+				if err == nil {
+					if err := mergeBrandInto(tx, existing, b); err != nil {
+						return err
+					}
+					continue
+				}
+				if err != sql.ErrNoRows {
+					return errors.Newf("find matching survivor brand: %v", err)
+				}
+				q := `UPDATE ` + TblBrands + `
+					SET (` + ColDesc(ColItemID, ColUpdateDate) + `) = ($1, CURRENT_TIMESTAMP)
+					WHERE ` + ColID + `=$2`
+				if _, err := tx.Exec(q, survivor.ID, b.ID); err != nil {
+					return errors.Newf("move brand %s: %v", b.ID, err)
+				}
+			}
+			if dupNorm != survivorNorm {
+				if err := addAlias(tx, TblItems, dupNorm, dup.Name, survivor.Name); err != nil {
+					return err
+				}
+			}
+			q := `DELETE FROM ` + TblItems + ` WHERE ` + ColID + `=$1`
+			if _, err := tx.Exec(q, dup.ID); err != nil {
+				return errors.Newf("delete item %s: %v", dup.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &survivor, nil
+}
+
+// MergeBrands moves the prices, shopping list items and barcodes of the
+// brands with duplicateIDs to the brand with survivorID, records the
+// duplicates' names as aliases of the survivor and deletes the duplicates,
+// all in one transaction.
+// It returns a client error if a duplicate is not of the same item and
+// measuring unit as the survivor.
+func (r *Roach) MergeBrands(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Brand, error) {
+	var survivor brandRow
+	err := r.executeTx(ctx, "MergeBrands", func(tx *sql.Tx) error {
+		if err := brandByID(tx, survivorID, &survivor); err != nil {
+			return err
+		}
+		for _, dupID := range duplicateIDs {
+			var dup brandRow
+			if err := brandByID(tx, dupID, &dup); err != nil {
+				return err
+			}
+			if dup.Item.ID != survivor.Item.ID || dup.MeasuringUnit.ID != survivor.MeasuringUnit.ID {
+				return errors.NewClientf("brand %s is not of the same item and"+
+					" measuring unit as brand %s", dup.ID, survivor.ID)
+			}
+			if err := mergeBrandInto(tx, survivor, dup); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &survivor.Brand, nil
+}
+
+func mergeBrandInto(tx *sql.Tx, survivor, dup brandRow) error {
+	// A shopping list holds one item per brand, drop the duplicate's item
+	// from lists that already have the survivor.
+	q := `
+		DELETE FROM ` + TblShopListItems + `
+			WHERE ` + ColBrandID + `=$1 AND ` + ColShopListID + ` IN (
+				SELECT ` + ColShopListID + ` FROM ` + TblShopListItems + `
+					WHERE ` + ColBrandID + `=$2
+			)`
+	if _, err := tx.Exec(q, dup.ID, survivor.ID); err != nil {
+		return errors.Newf("delete shopping list items clashing with brand %s: %v",
+			survivor.ID, err)
+	}
+	updCols := ColDesc(ColBrandID, ColUpdateDate)
+	for _, tbl := range []string{TblPrices, TblShopListItems, TblBarcodes} {
+		q = `UPDATE ` + tbl + `
+			SET (` + updCols + `) = ($1, CURRENT_TIMESTAMP)
+			WHERE ` + ColBrandID + `=$2`
+		if _, err := tx.Exec(q, survivor.ID, dup.ID); err != nil {
+			return errors.Newf("re-point %s from brand %s: %v", tbl, dup.ID, err)
+		}
+	}
+	if dup.normName != survivor.normName {
+		if err := addAlias(tx, TblBrands, dup.normName, dup.Name, survivor.Name); err != nil {
+			return err
+		}
+	}
+	q = `DELETE FROM ` + TblBrands + ` WHERE ` + ColID + `=$1`
+	if _, err := tx.Exec(q, dup.ID); err != nil {
+		return errors.Newf("delete brand %s: %v", dup.ID, err)
+	}
+	return nil
+}
+
+// addAlias records aliasNorm as an alias of canonicalName. Aliases that
+// pointed at the merged name (prevName) are re-pointed to canonicalName.
+func addAlias(tx *sql.Tx, entityType, aliasNorm, prevName, canonicalName string) error {
+	insCols := ColDesc(ColEntityType, ColNormName, ColCanonName, ColUpdateDate)
+	updCols := ColDesc(ColCanonName, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblAliases + ` (` + insCols + `)
+			VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+			ON CONFLICT (` + ColDesc(ColEntityType, ColNormName) + `)
+			DO UPDATE SET (` + updCols + `) = ($3, CURRENT_TIMESTAMP)`
+	if _, err := tx.Exec(q, entityType, aliasNorm, canonicalName); err != nil {
+		return errors.Newf("insert alias: %v", err)
+	}
+	q = `
+		UPDATE ` + TblAliases + `
+			SET (` + updCols + `) = ($1, CURRENT_TIMESTAMP)
+			WHERE ` + ColEntityType + `=$2 AND ` + ColCanonName + `=$3`
+	if _, err := tx.Exec(q, canonicalName, entityType, prevName); err != nil {
+		return errors.Newf("re-point aliases: %v", err)
+	}
+	return nil
+}
+
+// resolveAliases returns names with every name that is a known alias of
+// entityType replaced by its canonical name.
+func resolveAliases(tx *sql.Tx, entityType string, names []string) ([]string, error) {
+	args := []interface{}{entityType}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		norm := shopping.NormalizeName(name)
+		if norm == "" || seen[norm] {
+			continue
+		}
+		seen[norm] = true
+		args = append(args, norm)
+	}
+	if len(args) == 1 {
+		return names, nil
+	}
+	q := `
+		SELECT ` + ColDesc(ColNormName, ColCanonName) + `
+			FROM ` + TblAliases + `
+			WHERE ` + ColEntityType + `=$1
+				AND ` + ColNormName + ` IN (` + placeholders(2, len(args)-1) + `)`
+	rows, err := tx.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	canonical := make(map[string]string)
+	for rows.Next() {
+		var norm, canon string
+		if err := rows.Scan(&norm, &canon); err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		canonical[norm] = canon
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Newf("iterate result set: %v", err)
+	}
+	resolved := make([]string, len(names))
+	for i, name := range names {
+		resolved[i] = name
+		if canon, ok := canonical[shopping.NormalizeName(name)]; ok {
+			resolved[i] = canon
+		}
+	}
+	return resolved, nil
+}
+
+func itemByID(qr queryRower, ID string, into *shopping.Item) (normName string, err error) {
+	q := `SELECT ` + ColDesc(ColID, ColName, ColNormName) + ` FROM ` + TblItems + ` WHERE ` + ColID + `=$1`
+	err = qr.QueryRow(q, ID).Scan(&into.ID, &into.Name, &normName)
+	if err == sql.ErrNoRows {
+		return "", errors.NewNotFoundf("item %s not found", ID)
+	}
+	return normName, err
+}
+
+func brandByID(qr queryRower, ID string, into *brandRow) error {
+	err := scanBrandRow(qr.QueryRow(selectBrandsQ+` WHERE b.`+ColID+`=$1`, ID), into)
+	if err == sql.ErrNoRows {
+		return errors.NewNotFoundf("brand %s not found", ID)
+	}
+	return err
+}
+
+func brandRowsWhere(tx *sql.Tx, where string, args ...interface{}) ([]brandRow, error) {
+	rows, err := tx.Query(selectBrandsQ+` WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var brands []brandRow
+	for rows.Next() {
+		b, err := scanBrand(rows)
+		if err != nil {
+			return nil, err
+		}
+		brands = append(brands, b)
+	}
+	return brands, rows.Err()
+}
+
+var selectBrandsQ = `
+	SELECT b.` + ColID + `, b.` + ColName + `, b.` + ColNormName + `,
+			i.` + ColID + `, i.` + ColName + `,
+			mu.` + ColID + `, mu.` + ColName + `
+		FROM ` + TblBrands + ` AS b
+		INNER JOIN ` + TblItems + ` AS i ON i.` + ColID + `=b.` + ColItemID + `
+		INNER JOIN ` + TblMeasuringUnits + ` AS mu ON mu.` + ColID + `=b.` + ColMeasUnitID
+
+func scanBrandRow(row *sql.Row, into *brandRow) error {
+	return row.Scan(&into.ID, &into.Name, &into.normName, &into.Item.ID,
+		&into.Item.Name, &into.MeasuringUnit.ID, &into.MeasuringUnit.Name)
+}
+
+func scanBrand(rows *sql.Rows) (brandRow, error) {
+	b := brandRow{}
+	err := rows.Scan(&b.ID, &b.Name, &b.normName, &b.Item.ID, &b.Item.Name,
+		&b.MeasuringUnit.ID, &b.MeasuringUnit.Name)
+	if err != nil {
+		return b, errors.Newf("scan result set row: %v", err)
+	}
+	return b, nil
+}
+
+// placeholders returns n comma separated sequential placeholders starting
+// from $from e.g. placeholders(2, 3) yields "$2, $3, $4".
+func placeholders(from, n int) string {
+	phs := make([]string, n)
+	for i := range phs {
+		phs[i] = "$" + strconv.Itoa(from+i)
+	}
+	return strings.Join(phs, ", ")
+}
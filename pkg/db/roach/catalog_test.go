@@ -0,0 +1,102 @@
+package roach_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestRoach_UpsertBrands(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	brands := []shopping.Brand{
+		{
+			Name:          "Colgate",
+			Item:          shopping.Item{Name: "Toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+			Barcodes:      []string{"8718951065545"},
+		},
+		{
+			Name:          " colgate ",
+			Item:          shopping.Item{Name: "toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ML"},
+			Barcodes:      []string{"8718951065546"},
+		},
+	}
+	first, err := r.UpsertBrands(context.Background(), brands)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(first) != len(brands) {
+		t.Fatalf("Expected %d brands, got %d", len(brands), len(first))
+	}
+	if first[0].ID == "" || first[0].Item.ID == "" || first[0].MeasuringUnit.ID == "" {
+		t.Fatalf("IDs were not assigned: %+v", first[0])
+	}
+	if first[1].ID != first[0].ID {
+		t.Errorf("Expected names differing in case and space to share a brand ID")
+	}
+	second, err := r.UpsertBrands(context.Background(), brands[:1])
+	if err != nil {
+		t.Fatalf("Got error on repeat upsert: %v", err)
+	}
+	if second[0].ID != first[0].ID {
+		t.Errorf("Expected repeat upsert to yield brand ID %s, got %s",
+			first[0].ID, second[0].ID)
+	}
+	_, err = r.UpsertBrands(context.Background(), []shopping.Brand{{Name: "No item"}})
+	if err == nil {
+		t.Errorf("Expected an error for a brand without item, got nil")
+	}
+}
+
+func TestRoach_MergeBrands(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	brands, err := r.UpsertBrands(context.Background(), []shopping.Brand{
+		{
+			Name:          "Colgate",
+			Item:          shopping.Item{Name: "Toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+		},
+		{
+			Name:          "Colgate Ltd",
+			Item:          shopping.Item{Name: "Toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+			Barcodes:      []string{"8718951065545"},
+		},
+		{
+			Name:          "Colgate Ltd",
+			Item:          shopping.Item{Name: "Toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "50 ml"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: upsert brands: %v", err)
+	}
+	survivor, dup, otherUnit := brands[0], brands[1], brands[2]
+
+	if _, err := r.MergeBrands(context.Background(), survivor.ID, []string{otherUnit.ID}); err == nil {
+		t.Fatalf("Expected an error merging brands of different units, got nil")
+	}
+
+	merged, err := r.MergeBrands(context.Background(), survivor.ID, []string{dup.ID})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if merged.ID != survivor.ID {
+		t.Errorf("Expected survivor ID %s, got %s", survivor.ID, merged.ID)
+	}
+
+	// The merged name should now resolve to the survivor.
+	again, err := r.UpsertBrands(context.Background(), []shopping.Brand{dup})
+	if err != nil {
+		t.Fatalf("Got error re-inserting merged brand: %v", err)
+	}
+	if again[0].ID != survivor.ID {
+		t.Errorf("Expected alias to resolve to brand %s, got %s", survivor.ID, again[0].ID)
+	}
+}
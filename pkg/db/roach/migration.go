@@ -3,7 +3,10 @@ package roach
 import (
 	"errors"
 	"fmt"
+	"strings"
+
 	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
 )
 
 func (r *Roach) migrate(fromVersion, toVersion int) error {
@@ -14,13 +17,126 @@ func (r *Roach) migrate(fromVersion, toVersion int) error {
 		return fmt.Errorf("connect to db: %v", err)
 	}
 
-	// TODO supported migration logic here e.g.
-	//		if fromVersion == 0 && toVersion == 1 {
-	//			if err := r.migrate0To1(); err != nil { // implement r.migrate0To1()
-	//				return err
-	//			}
-	//			return r.setRunningVersionCurrent()
-	//		}
+	// migrations[v] migrates from version v to v+1.
+	migrations := []func() error{r.migrate0To1, r.migrate1To2, r.migrate2To3}
+	if fromVersion < 0 || fromVersion >= toVersion || toVersion > len(migrations) {
+		return errors.New("not supported")
+	}
+	for v := fromVersion; v < toVersion; v++ {
+		if err := migrations[v](); err != nil {
+			return fmt.Errorf("to version %d: %v", v+1, err)
+		}
+	}
+	return r.setRunningVersionCurrent()
+}
+
+// migrate0To1 replaces the plain text API keys of version 0 by their
+// salted hashes. Each step is skipped if already done so that the
+// migration can be repeated if interrupted.
+func (r *Roach) migrate0To1() error {
+	for _, q := range []string{
+		`ALTER TABLE ` + TblAPIKeys + ` ADD COLUMN IF NOT EXISTS ` + ColKeyPrefix + ` VARCHAR(16)`,
+		`ALTER TABLE ` + TblAPIKeys + ` ADD COLUMN IF NOT EXISTS ` + ColKeySalt + ` BYTEA`,
+		`ALTER TABLE ` + TblAPIKeys + ` ADD COLUMN IF NOT EXISTS ` + ColKeyHash + ` BYTEA`,
+	} {
+		if _, err := r.db.Exec(q); err != nil {
+			return fmt.Errorf("add API key hash columns: %v", err)
+		}
+	}
+
+	hasPlainText, err := r.hasColumn(TblAPIKeys, ColKey)
+	if err != nil {
+		return fmt.Errorf("check for plain text API keys: %v", err)
+	}
+	if !hasPlainText {
+		return nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT ` + ColDesc(ColID, ColKey) + `
+			FROM ` + TblAPIKeys + `
+			WHERE ` + ColKeyHash + ` IS NULL`)
+	if err != nil {
+		return fmt.Errorf("get plain text API keys: %v", err)
+	}
+	hashes := make(map[string]apikeys.Hash)
+	for rows.Next() {
+		var ID string
+		var key []byte
+		if err := rows.Scan(&ID, &key); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan result set row: %v", err)
+		}
+		if hashes[ID], err = apikeys.HashKey(key); err != nil {
+			rows.Close()
+			return fmt.Errorf("hash API key %s: %v", ID, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate result set: %v", err)
+	}
+	rows.Close()
+
+	updCols := ColDesc(ColKeyPrefix, ColKeySalt, ColKeyHash)
+	q := `
+		UPDATE ` + TblAPIKeys + `
+			SET (` + updCols + `) = ($1, $2, $3)
+			WHERE ` + ColID + `=$4`
+	for ID, h := range hashes {
+		res, err := r.db.Exec(q, h.Prefix, h.Salt, h.Sum, ID)
+		if err := checkRowsAffected(res, err, 1); err != nil {
+			return fmt.Errorf("store hash of API key %s: %v", ID, err)
+		}
+	}
+
+	for _, q := range []string{
+		`CREATE INDEX IF NOT EXISTS ` + IdxAPIKeysPrefix + ` ON ` + TblAPIKeys +
+			` (` + ColDesc(ColUserID, ColKeyPrefix) + `)`,
+		`ALTER TABLE ` + TblAPIKeys + ` DROP COLUMN IF EXISTS ` + ColKey,
+	} {
+		if _, err := r.db.Exec(q); err != nil {
+			return fmt.Errorf("finalize API key hash columns: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrate1To2 adds the scopes, origins, expiry and last use of API keys.
+// Existing keys are granted apikeys.DefaultScopes.
+func (r *Roach) migrate1To2() error {
+	for _, q := range []string{
+		`ALTER TABLE ` + TblAPIKeys + ` ADD COLUMN IF NOT EXISTS ` + ColScopes +
+			` STRING NOT NULL DEFAULT '` + joinList(apikeys.DefaultScopes) + `'`,
+		`ALTER TABLE ` + TblAPIKeys + ` ADD COLUMN IF NOT EXISTS ` + ColOrigins +
+			` STRING NOT NULL DEFAULT ''`,
+		`ALTER TABLE ` + TblAPIKeys + ` ADD COLUMN IF NOT EXISTS ` + ColExpiryDate + ` TIMESTAMPTZ`,
+		`ALTER TABLE ` + TblAPIKeys + ` ADD COLUMN IF NOT EXISTS ` + ColLastUsed + ` TIMESTAMPTZ`,
+	} {
+		if _, err := r.db.Exec(q); err != nil {
+			return fmt.Errorf("add API key policy columns: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrate2To3 adds the price verdicts table. Verdicts cast before version
+// 3 were not recorded and are not reversed if their price is rejected.
+func (r *Roach) migrate2To3() error {
+	if _, err := r.db.Exec(TblDescPriceVerdicts); err != nil {
+		return fmt.Errorf("create price verdicts table: %v", err)
+	}
+	return nil
+}
 
-	return errors.New("not supported")
+// hasColumn reports whether table has col. Unquoted identifiers, as used
+// throughout this package, are stored in lower case.
+func (r *Roach) hasColumn(table, col string) (bool, error) {
+	q := `
+	SELECT COUNT(*)
+		FROM information_schema.columns
+		WHERE table_name=$1 AND column_name=$2`
+	var count int
+	err := r.db.QueryRow(q, strings.ToLower(table), strings.ToLower(col)).Scan(&count)
+	return count > 0, err
 }
@@ -0,0 +1,140 @@
+package roach_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/db/roach"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestRoach_prices(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	brand := insertBrand(t, r)
+	sb := insertStoreBranch(t, r, "node/1", shopping.Location{Latitude: -1.28, Longitude: 36.82})
+
+	approved := insertPrice(t, r, brand, sb.ID, 200, shopping.PriceStatusApproved)
+	insertPrice(t, r, brand, "", 210, shopping.PriceStatusApproved)
+	pending := insertPrice(t, r, brand, sb.ID, 20000, shopping.PriceStatusPending)
+
+	if approved.Brand.Item.Name != brand.Item.Name || approved.AtStoreBranch.Store.Name == "" {
+		t.Errorf("Expected brand and store branch details, got %+v", approved)
+	}
+
+	since := time.Now().Add(-time.Hour)
+	recent, err := r.RecentApprovedPrices(context.Background(), brand.ID, sb.ID, "KES", since, 10)
+	if err != nil {
+		t.Fatalf("RecentApprovedPrices() at branch: %v", err)
+	}
+	if len(recent) != 1 || recent[0].ID != approved.ID {
+		t.Errorf("Expected only approved price %s at branch, got %+v", approved.ID, recent)
+	}
+	recent, err = r.RecentApprovedPrices(context.Background(), brand.ID, "", "KES", since, 10)
+	if err != nil {
+		t.Fatalf("RecentApprovedPrices() at any branch: %v", err)
+	}
+	if len(recent) != 2 {
+		t.Errorf("Expected 2 approved prices at any branch, got %+v", recent)
+	}
+
+	queue, err := r.PricesByStatus(context.Background(), shopping.PriceStatusPending, 0, 10)
+	if err != nil {
+		t.Fatalf("PricesByStatus(): %v", err)
+	}
+	if len(queue) != 1 || queue[0].ID != pending.ID {
+		t.Errorf("Expected only price %s pending, got %+v", pending.ID, queue)
+	}
+
+	rejected, err := r.UpdatePriceStatus(context.Background(), pending.ID,
+		shopping.PriceStatusRejected, shopping.Verdicts{})
+	if err != nil {
+		t.Fatalf("UpdatePriceStatus(): %v", err)
+	}
+	if rejected.Status != shopping.PriceStatusRejected {
+		t.Errorf("Expected status %s, got %s", shopping.PriceStatusRejected, rejected.Status)
+	}
+	_, err = r.PricesByStatus(context.Background(), shopping.PriceStatusPending, 0, 10)
+	if !r.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error on empty queue, got %v", err)
+	}
+	_, err = r.UpdatePriceStatus(context.Background(), "999999",
+		shopping.PriceStatusApproved, shopping.Verdicts{})
+	if !r.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for missing price, got %v", err)
+	}
+}
+
+func TestRoach_contributors(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	brand := insertBrand(t, r)
+	p1 := insertPrice(t, r, brand, "", 200, shopping.PriceStatusApproved)
+	p2 := insertPrice(t, r, brand, "", 300, shopping.PriceStatusApproved)
+	p3 := insertPrice(t, r, brand, "", 250, shopping.PriceStatusPending)
+
+	_, err := r.UpdatePriceStatus(context.Background(), p3.ID, shopping.PriceStatusApproved,
+		shopping.Verdicts{Confirmed: []string{p1.ID, p2.ID}, Contradicted: []string{p1.ID}})
+	if err != nil {
+		t.Fatalf("UpdatePriceStatus() with verdicts: %v", err)
+	}
+	got, err := r.PriceByID(context.Background(), p1.ID)
+	if err != nil {
+		t.Fatalf("PriceByID(): %v", err)
+	}
+	if got.Confirmations != 1 || got.Contradictions != 1 {
+		t.Errorf("Expected 1 confirmation and 1 contradiction, got %+v", got)
+	}
+
+	cs, err := r.Contributors(context.Background(), []string{"usr1", "none"})
+	if err != nil {
+		t.Fatalf("Contributors(): %v", err)
+	}
+	exp := shopping.Contributor{
+		UserID:         "usr1",
+		Submissions:    3,
+		Confirmations:  2,
+		Contradictions: 1,
+		Trust:          shopping.TrustScore(2, 1),
+	}
+	if len(cs) != 1 || cs[0] != exp {
+		t.Errorf("Expected %+v, got %+v", exp, cs)
+	}
+	cs, err = r.ContributorsByTrust(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatalf("ContributorsByTrust(): %v", err)
+	}
+	if len(cs) != 1 || cs[0] != exp {
+		t.Errorf("Expected %+v, got %+v", exp, cs)
+	}
+}
+
+func insertBrand(t *testing.T, r *roach.Roach) shopping.Brand {
+	brands, err := r.UpsertBrands(context.Background(), []shopping.Brand{{
+		Name:          "Colgate",
+		Item:          shopping.Item{Name: "Toothpaste"},
+		MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+	}})
+	if err != nil {
+		t.Fatalf("Error setting up: upsert brand: %v", err)
+	}
+	return brands[0]
+}
+
+func insertPrice(t *testing.T, r *roach.Roach, b shopping.Brand, storeBranchID string, value float32, status string) *shopping.Price {
+	p, err := r.InsertPrice(context.Background(), shopping.Price{
+		Value:         value,
+		Currency:      "KES",
+		Brand:         b,
+		AtStoreBranch: shopping.StoreBranch{ID: storeBranchID},
+		SubmittedBy:   "usr1",
+		Status:        status,
+	}, shopping.Verdicts{})
+	if err != nil {
+		t.Fatalf("Error setting up: insert price: %v", err)
+	}
+	return p
+}
@@ -0,0 +1,31 @@
+package roach_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+)
+
+func TestRoach_TakeRateLimitToken(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	l := ratelimit.Limit{Rate: 0.001, Burst: 2}
+	for i, expAllowed := range []bool{true, true, false} {
+		res, err := r.TakeRateLimitToken(context.Background(), "apiKey:app1", l)
+		if err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+		if res.Allowed != expAllowed {
+			t.Errorf("Request %d: expected allowed %t, got %+v", i, expAllowed, res)
+		}
+	}
+	res, err := r.TakeRateLimitToken(context.Background(), "apiKey:app2", l)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if !res.Allowed || res.Remaining != 1 {
+		t.Errorf("Expected a separate bucket per key, got %+v", res)
+	}
+}
@@ -7,13 +7,20 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cockroachdb/cockroach-go/crdb"
 	crdbH "github.com/tomogoma/crdb"
 	"github.com/tomogoma/go-typed-errors"
 	"github.com/tomogoma/shoppingms/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/tomogoma/shoppingms/pkg/db/roach")
+
 // Roach is a cockroach db store.
 // Use NewRoach() to instantiate.
 type Roach struct {
@@ -22,9 +29,12 @@ type Roach struct {
 	dbName           string
 	db               *sql.DB
 	compatibilityErr error
+	observer         Observer
 
 	isDBInitMutex sync.Mutex
 	isDBInit      bool
+	// noMigration is set by WithoutMigration().
+	noMigration bool
 }
 
 const (
@@ -38,6 +48,7 @@ func NewRoach(opts ...Option) *Roach {
 		isDBInit:      false,
 		isDBInitMutex: sync.Mutex{},
 		dbName:        config.CanonicalName(),
+		observer:      noopObserver{},
 	}
 	for _, f := range opts {
 		f(r)
@@ -55,13 +66,94 @@ func (r *Roach) InitDBIfNot() error {
 	return r.instantiate()
 }
 
+// Close closes the DB connection pool once queries in progress finish.
+// The Roach must not be used afterwards.
+func (r *Roach) Close() error {
+	if r.db == nil {
+		return nil
+	}
+	return r.db.Close()
+}
+
 // ExecuteTx prepares a transaction (with retries) for execution in fn.
 // It commits the changes if fn returns nil, otherwise changes are rolled back.
 func (r *Roach) ExecuteTx(fn func(*sql.Tx) error) error {
+	return r.executeTx(context.Background(), "ExecuteTx", fn)
+}
+
+// executeTx is ExecuteTx() reporting the transaction to the Observer as op
+// and tracing it as a child of the span in ctx, if any.
+func (r *Roach) executeTx(ctx context.Context, op string, fn func(*sql.Tx) error) error {
 	if err := r.InitDBIfNot(); err != nil {
 		return err
 	}
-	return crdb.ExecuteTx(context.Background(), r.db, nil, fn)
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	attempts := 0
+	start := time.Now()
+	err := crdb.ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		attempts++
+		return fn(tx)
+	})
+	if attempts > 0 {
+		r.observer.ObserveTx(op, time.Since(start), attempts-1)
+		span.SetAttributes(attribute.Int("db.transaction.retries", attempts-1))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// observeQuery reports the time elapsed until the returned func is called
+// to the Observer as a query made by op, tracing it as a child of the span
+// in ctx, if any e.g.
+//     defer r.observeQuery(ctx, "ShoppingLists")()
+func (r *Roach) observeQuery(ctx context.Context, op string) func() {
+	_, span := startSpan(ctx, op)
+	start := time.Now()
+	return func() {
+		r.observer.ObserveQuery(op, time.Since(start))
+		span.End()
+	}
+}
+
+// startSpan starts a span for op only if ctx already carries one so that
+// calls made outside a request (e.g. by importers) do not start new traces.
+func startSpan(ctx context.Context, op string) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.SpanContext().IsValid() {
+		return ctx, parent
+	}
+	return tracer.Start(ctx, "roach."+op, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "cockroachdb")))
+}
+
+// Ping checks that the DB can be reached. Unlike InitDBIfNot() it does not
+// fail if the DB schema is incompatible, see SchemaVersion() for that.
+func (r *Roach) Ping(ctx context.Context) error {
+	var err error
+	r.db, err = crdbH.TryConnect(r.dsn, r.db)
+	if err != nil {
+		return errors.Newf("connect to db: %v", err)
+	}
+	if err := r.db.PingContext(ctx); err != nil {
+		return errors.Newf("ping db: %v", err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the version of the DB schema in use, which should
+// equal Version once the DB is initialized.
+func (r *Roach) SchemaVersion(ctx context.Context) (int, error) {
+	var err error
+	r.db, err = crdbH.TryConnect(r.dsn, r.db)
+	if err != nil {
+		return -1, errors.Newf("connect to db: %v", err)
+	}
+	return r.runningVersion(ctx)
 }
 
 // ColDesc returns a string containing cols in the given order separated by ",".
@@ -85,6 +177,16 @@ func (r *Roach) instantiate() error {
 	if r.isDBInit {
 		return nil
 	}
+	if r.noMigration {
+		if _, err := r.validateRunningVersion(); err != nil {
+			if r.IsNotFoundError(err) {
+				return errors.Newf("db not set up: need db version '%d'", Version)
+			}
+			return errors.Newf("check db version: %v", err)
+		}
+		r.isDBInit = true
+		return nil
+	}
 	if err := crdbH.InstantiateDB(r.db, r.dbName, AllTableDescs...); err != nil {
 		return errors.Newf("instantiating db: %v", err)
 	}
@@ -107,10 +209,23 @@ func (r *Roach) instantiate() error {
 }
 
 func (r *Roach) validateRunningVersion() (int, error) {
+	runningVersion, err := r.runningVersion(context.Background())
+	if err != nil {
+		return -1, err
+	}
+	if runningVersion != Version {
+		r.compatibilityErr = errors.Newf("db incompatible: need db"+
+			" version '%d', found '%d'", Version, runningVersion)
+		return runningVersion, r.compatibilityErr
+	}
+	return runningVersion, nil
+}
+
+func (r *Roach) runningVersion(ctx context.Context) (int, error) {
 	var runningVersion int
 	q := `SELECT ` + ColValue + ` FROM ` + TblConfigurations + ` WHERE ` + ColKey + `=$1`
 	var confB []byte
-	if err := r.db.QueryRow(q, keyDBVersion).Scan(&confB); err != nil {
+	if err := r.db.QueryRowContext(ctx, q, keyDBVersion).Scan(&confB); err != nil {
 		if err == sql.ErrNoRows {
 			return -1, errors.NewNotFoundf("config not found")
 		}
@@ -119,11 +234,6 @@ func (r *Roach) validateRunningVersion() (int, error) {
 	if err := json.Unmarshal(confB, &runningVersion); err != nil {
 		return -1, errors.Newf("Unmarshalling config: %v", err)
 	}
-	if runningVersion != Version {
-		r.compatibilityErr = errors.Newf("db incompatible: need db"+
-			" version '%d', found '%d'", Version, runningVersion)
-		return runningVersion, r.compatibilityErr
-	}
 	return runningVersion, nil
 }
 
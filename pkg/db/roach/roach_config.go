@@ -1,5 +1,7 @@
 package roach
 
+import "time"
+
 const TimeFormat = ""
 
 // Option allows extra configuration for instantiating Roach. Use the With...
@@ -20,3 +22,39 @@ func WithDBName(db string) Option {
 		r.dbName = db
 	}
 }
+
+// WithoutMigration stops Roach from creating or migrating the DB schema
+// in InitDBIfNot() and the Execute/Query methods, which fail instead unless
+// the schema is already at Version. It is for tools that must not change
+// the schema as a side effect of reading the DB.
+func WithoutMigration() Option {
+	return func(r *Roach) {
+		r.noMigration = true
+	}
+}
+
+// Observer receives the durations of DB queries and transactions, e.g. to
+// expose them as metrics.
+type Observer interface {
+	// ObserveQuery receives the duration of a query made outside a
+	// transaction by the Roach method op.
+	ObserveQuery(op string, d time.Duration)
+	// ObserveTx receives the duration of a transaction run by the Roach
+	// method op and the number of times it was retried.
+	ObserveTx(op string, d time.Duration, retries int)
+}
+
+// WithObserver sets the Observer to report DB queries and transactions to.
+// They are not reported by default or if o is nil.
+func WithObserver(o Observer) Option {
+	return func(r *Roach) {
+		if o != nil {
+			r.observer = o
+		}
+	}
+}
+
+type noopObserver struct{}
+
+func (noopObserver) ObserveQuery(string, time.Duration)   {}
+func (noopObserver) ObserveTx(string, time.Duration, int) {}
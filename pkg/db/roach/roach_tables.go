@@ -2,11 +2,23 @@ package roach
 
 const (
 	// Database definition version
-	Version = 0
+	Version = 3
 
 	// Table names
 	TblConfigurations = "configurations"
 	TblAPIKeys        = "apiKeys"
+	TblStores         = "stores"
+	TblStoreBranches  = "storeBranches"
+	TblItems          = "items"
+	TblMeasuringUnits = "measuringUnits"
+	TblBrands         = "brands"
+	TblBarcodes       = "barcodes"
+	TblAliases        = "aliases"
+	TblShoppingLists  = "shoppingLists"
+	TblPrices         = "prices"
+	TblPriceVerdicts  = "priceVerdicts"
+	TblShopListItems  = "shoppingListItems"
+	TblRateLimits     = "rateLimitBuckets"
 
 	// DB Table Columns
 	ColID         = "ID"
@@ -15,6 +27,43 @@ const (
 	ColUserID     = "userID"
 	ColKey        = "key"
 	ColValue      = "value"
+	ColName       = "name"
+	ColStoreID    = "storeID"
+	ColLatitude   = "latitude"
+	ColLongitude  = "longitude"
+	ColOSMID      = "osmID"
+	ColNormName   = "normName"
+	ColItemID     = "itemID"
+	ColMeasUnitID = "measuringUnitID"
+	ColBrandID    = "brandID"
+	ColCode       = "code"
+	ColEntityType = "entityType"
+	ColCanonName  = "canonicalName"
+	ColMode       = "mode"
+	ColCurrency   = "currency"
+	ColStoreBrID  = "storeBranchID"
+	ColShopListID = "shoppingListID"
+	ColPriceID    = "priceID"
+	ColQuantity   = "quantity"
+	ColInList     = "inList"
+	ColInCart     = "inCart"
+	ColStatus     = "status"
+	ColOutlierSc  = "outlierScore"
+	ColConfirms   = "confirmations"
+	ColContradics = "contradictions"
+	ColTokens     = "tokens"
+	ColKeyPrefix  = "keyPrefix"
+	ColKeySalt    = "keySalt"
+	ColKeyHash    = "keyHash"
+	ColScopes     = "scopes"
+	ColOrigins    = "origins"
+	ColExpiryDate = "expiryDate"
+	ColLastUsed   = "lastUsedDate"
+	ColJudgedID   = "judgedPriceID"
+	ColConfirming = "confirming"
+
+	// Index names
+	IdxAPIKeysPrefix = "apiKeysUserIDKeyPrefixIdx"
 
 	// CREATE TABLE DESCRIPTIONS
 	TblDescConfigurations = `
@@ -29,8 +78,150 @@ const (
 	CREATE TABLE IF NOT EXISTS ` + TblAPIKeys + ` (
 		` + ColID + ` SERIAL PRIMARY KEY NOT NULL CHECK (` + ColID + `>0),
 		` + ColUserID + ` INTEGER NOT NULL,
-		` + ColKey + ` VARCHAR(256) NOT NULL CHECK ( LENGTH(` + ColKey + `) >= 56 ),
+		` + ColKeyPrefix + ` VARCHAR(16) NOT NULL CHECK (` + ColKeyPrefix + ` != ''),
+		` + ColKeySalt + ` BYTEA NOT NULL,
+		` + ColKeyHash + ` BYTEA NOT NULL,
+		` + ColScopes + ` STRING NOT NULL,
+		` + ColOrigins + ` STRING NOT NULL DEFAULT '',
+		` + ColExpiryDate + ` TIMESTAMPTZ,
+		` + ColLastUsed + ` TIMESTAMPTZ,
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL,
+		INDEX ` + IdxAPIKeysPrefix + ` (` + ColUserID + `, ` + ColKeyPrefix + `)
+	);
+	`
+	TblDescStores = `
+	CREATE TABLE IF NOT EXISTS ` + TblStores + ` (
+		` + ColID + ` SERIAL PRIMARY KEY NOT NULL CHECK (` + ColID + `>0),
+		` + ColName + ` VARCHAR(256) UNIQUE NOT NULL CHECK (` + ColName + ` != ''),
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL
+	);
+	`
+	TblDescStoreBranches = `
+	CREATE TABLE IF NOT EXISTS ` + TblStoreBranches + ` (
+		` + ColID + ` SERIAL PRIMARY KEY NOT NULL CHECK (` + ColID + `>0),
+		` + ColStoreID + ` INTEGER NOT NULL REFERENCES ` + TblStores + ` (` + ColID + `),
+		` + ColName + ` VARCHAR(256) NOT NULL CHECK (` + ColName + ` != ''),
+		` + ColLatitude + ` FLOAT NOT NULL CHECK (` + ColLatitude + ` BETWEEN -90 AND 90),
+		` + ColLongitude + ` FLOAT NOT NULL CHECK (` + ColLongitude + ` BETWEEN -180 AND 180),
+		` + ColOSMID + ` VARCHAR(64) UNIQUE,
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL,
+		INDEX (` + ColStoreID + `, ` + ColLatitude + `, ` + ColLongitude + `)
+	);
+	`
+	TblDescItems = `
+	CREATE TABLE IF NOT EXISTS ` + TblItems + ` (
+		` + ColID + ` SERIAL PRIMARY KEY NOT NULL CHECK (` + ColID + `>0),
+		` + ColName + ` VARCHAR(256) NOT NULL CHECK (` + ColName + ` != ''),
+		` + ColNormName + ` VARCHAR(256) UNIQUE NOT NULL CHECK (` + ColNormName + ` != ''),
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL
+	);
+	`
+	TblDescMeasuringUnits = `
+	CREATE TABLE IF NOT EXISTS ` + TblMeasuringUnits + ` (
+		` + ColID + ` SERIAL PRIMARY KEY NOT NULL CHECK (` + ColID + `>0),
+		` + ColName + ` VARCHAR(256) NOT NULL CHECK (` + ColName + ` != ''),
+		` + ColNormName + ` VARCHAR(256) UNIQUE NOT NULL CHECK (` + ColNormName + ` != ''),
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL
+	);
+	`
+	TblDescBrands = `
+	CREATE TABLE IF NOT EXISTS ` + TblBrands + ` (
+		` + ColID + ` SERIAL PRIMARY KEY NOT NULL CHECK (` + ColID + `>0),
+		` + ColName + ` VARCHAR(256) NOT NULL CHECK (` + ColName + ` != ''),
+		` + ColNormName + ` VARCHAR(256) NOT NULL CHECK (` + ColNormName + ` != ''),
+		` + ColItemID + ` INTEGER NOT NULL REFERENCES ` + TblItems + ` (` + ColID + `),
+		` + ColMeasUnitID + ` INTEGER NOT NULL REFERENCES ` + TblMeasuringUnits + ` (` + ColID + `),
 		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL,
+		UNIQUE (` + ColNormName + `, ` + ColItemID + `, ` + ColMeasUnitID + `)
+	);
+	`
+	TblDescBarcodes = `
+	CREATE TABLE IF NOT EXISTS ` + TblBarcodes + ` (
+		` + ColCode + ` VARCHAR(64) PRIMARY KEY NOT NULL CHECK (` + ColCode + ` != ''),
+		` + ColBrandID + ` INTEGER NOT NULL REFERENCES ` + TblBrands + ` (` + ColID + `),
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL,
+		INDEX (` + ColBrandID + `)
+	);
+	`
+	TblDescAliases = `
+	CREATE TABLE IF NOT EXISTS ` + TblAliases + ` (
+		` + ColEntityType + ` VARCHAR(32) NOT NULL CHECK (` + ColEntityType + ` != ''),
+		` + ColNormName + ` VARCHAR(256) NOT NULL CHECK (` + ColNormName + ` != ''),
+		` + ColCanonName + ` VARCHAR(256) NOT NULL CHECK (` + ColCanonName + ` != ''),
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (` + ColEntityType + `, ` + ColNormName + `)
+	);
+	`
+	TblDescShoppingLists = `
+	CREATE TABLE IF NOT EXISTS ` + TblShoppingLists + ` (
+		` + ColID + ` SERIAL PRIMARY KEY NOT NULL CHECK (` + ColID + `>0),
+		` + ColUserID + ` VARCHAR(256) NOT NULL CHECK (` + ColUserID + ` != ''),
+		` + ColName + ` VARCHAR(256) NOT NULL CHECK (` + ColName + ` != ''),
+		` + ColMode + ` VARCHAR(32) NOT NULL,
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL,
+		UNIQUE (` + ColUserID + `, ` + ColName + `)
+	);
+	`
+	TblDescPrices = `
+	CREATE TABLE IF NOT EXISTS ` + TblPrices + ` (
+		` + ColID + ` SERIAL PRIMARY KEY NOT NULL CHECK (` + ColID + `>0),
+		` + ColValue + ` FLOAT NOT NULL CHECK (` + ColValue + ` >= 0),
+		` + ColCurrency + ` VARCHAR(3) NOT NULL CHECK (LENGTH(` + ColCurrency + `) = 3),
+		` + ColBrandID + ` INTEGER NOT NULL REFERENCES ` + TblBrands + ` (` + ColID + `),
+		` + ColStoreBrID + ` INTEGER REFERENCES ` + TblStoreBranches + ` (` + ColID + `),
+		` + ColUserID + ` VARCHAR(256) NOT NULL CHECK (` + ColUserID + ` != ''),
+		` + ColStatus + ` VARCHAR(16) NOT NULL CHECK (` + ColStatus + ` != ''),
+		` + ColOutlierSc + ` FLOAT NOT NULL DEFAULT 0,
+		` + ColConfirms + ` INTEGER NOT NULL DEFAULT 0 CHECK (` + ColConfirms + ` >= 0),
+		` + ColContradics + ` INTEGER NOT NULL DEFAULT 0 CHECK (` + ColContradics + ` >= 0),
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL,
+		INDEX (` + ColBrandID + `, ` + ColStatus + `, ` + ColCreateDate + `),
+		INDEX (` + ColStatus + `, ` + ColCreateDate + `),
+		INDEX (` + ColUserID + `)
+	);
+	`
+	// TblDescPriceVerdicts records the prices each approved price
+	// confirmed (confirming) or contradicted so that the verdicts can be
+	// reversed if it is later rejected.
+	TblDescPriceVerdicts = `
+	CREATE TABLE IF NOT EXISTS ` + TblPriceVerdicts + ` (
+		` + ColPriceID + ` INTEGER NOT NULL REFERENCES ` + TblPrices + ` (` + ColID + `),
+		` + ColJudgedID + ` INTEGER NOT NULL REFERENCES ` + TblPrices + ` (` + ColID + `),
+		` + ColConfirming + ` BOOL NOT NULL,
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (` + ColPriceID + `, ` + ColJudgedID + `, ` + ColConfirming + `)
+	);
+	`
+	TblDescShopListItems = `
+	CREATE TABLE IF NOT EXISTS ` + TblShopListItems + ` (
+		` + ColID + ` SERIAL PRIMARY KEY NOT NULL CHECK (` + ColID + `>0),
+		` + ColShopListID + ` INTEGER NOT NULL REFERENCES ` + TblShoppingLists + ` (` + ColID + `),
+		` + ColBrandID + ` INTEGER NOT NULL REFERENCES ` + TblBrands + ` (` + ColID + `),
+		` + ColPriceID + ` INTEGER REFERENCES ` + TblPrices + ` (` + ColID + `),
+		` + ColQuantity + ` INTEGER NOT NULL CHECK (` + ColQuantity + ` >= 0),
+		` + ColInList + ` BOOL NOT NULL,
+		` + ColInCart + ` BOOL NOT NULL,
+		` + ColCreateDate + ` TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL,
+		UNIQUE (` + ColShopListID + `, ` + ColBrandID + `),
+		INDEX (` + ColBrandID + `),
+		INDEX (` + ColPriceID + `)
+	);
+	`
+	TblDescRateLimits = `
+	CREATE TABLE IF NOT EXISTS ` + TblRateLimits + ` (
+		` + ColKey + ` VARCHAR(320) PRIMARY KEY NOT NULL CHECK (` + ColKey + ` != ''),
+		` + ColTokens + ` FLOAT NOT NULL,
 		` + ColUpdateDate + ` TIMESTAMPTZ NOT NULL
 	);
 	`
@@ -41,6 +232,18 @@ const (
 var AllTableDescs = []string{
 	TblDescConfigurations,
 	TblDescAPIKeys,
+	TblDescStores,
+	TblDescStoreBranches,
+	TblDescItems,
+	TblDescMeasuringUnits,
+	TblDescBrands,
+	TblDescBarcodes,
+	TblDescAliases,
+	TblDescShoppingLists,
+	TblDescPrices,
+	TblDescPriceVerdicts,
+	TblDescShopListItems,
+	TblDescRateLimits,
 }
 
 // AllTableNames lists all table names in order of dependency
@@ -48,4 +251,16 @@ var AllTableDescs = []string{
 var AllTableNames = []string{
 	TblConfigurations,
 	TblAPIKeys,
+	TblStores,
+	TblStoreBranches,
+	TblItems,
+	TblMeasuringUnits,
+	TblBrands,
+	TblBarcodes,
+	TblAliases,
+	TblShoppingLists,
+	TblPrices,
+	TblPriceVerdicts,
+	TblShopListItems,
+	TblRateLimits,
 }
@@ -1,12 +1,15 @@
 package roach_test
 
 import (
+	"context"
 	"database/sql"
 	"strconv"
 	"testing"
 
 	"github.com/tomogoma/crdb"
 	"github.com/tomogoma/shoppingms/pkg/db/roach"
+	"github.com/tomogoma/shoppingms/pkg/db/storagetest"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
 	"github.com/tomogoma/shoppingms/pkg/config"
 	"flag"
 	"sync/atomic"
@@ -103,9 +106,9 @@ func TestRoach_InitDBIfNot(t *testing.T) {
 			expErr:     false,
 		},
 		{
-			name:       "db version smaller",
+			name:       "db version smaller than migratable",
 			hasVersion: true,
-			version:    []byte(strconv.Itoa(roach.Version - 1)),
+			version:    []byte(strconv.Itoa(-1)),
 			expErr:     true,
 		},
 		{
@@ -166,6 +169,34 @@ func TestRoach_InitDBIfNot(t *testing.T) {
 	}
 }
 
+func TestRoach_SchemaVersion(t *testing.T) {
+
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	if err := r.InitDBIfNot(); err != nil {
+		t.Fatalf("Error setting up: init db: %v", err)
+	}
+
+	if err := r.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: got error: %v", err)
+	}
+	v, err := r.SchemaVersion(context.Background())
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if v != roach.Version {
+		t.Errorf("Expected schema version %d, got %d", roach.Version, v)
+	}
+}
+
+func TestRoach_storage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) (shopping.Storage, func()) {
+		conf, tearDown := setup(t)
+		return newRoach(t, conf), tearDown
+	})
+}
+
 func newRoach(t *testing.T, conf crdb.Config) *roach.Roach {
 	r := roach.NewRoach(
 		roach.WithDBName(conf.DBName),
@@ -0,0 +1,150 @@
+package roach_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestRoach_shoppingLists(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+
+	sl, err := r.UpsertShoppingList(context.Background(), shopping.ShoppingList{
+		UserID: "usr1",
+		Name:   "Groceries",
+		Mode:   shopping.ModePreparation,
+	})
+	if err != nil {
+		t.Fatalf("UpsertShoppingList(): %v", err)
+	}
+	if sl.ID == "" || sl.Created == "" {
+		t.Errorf("Expected ID and created date, got %+v", sl)
+	}
+	dup, err := r.UpsertShoppingList(context.Background(), shopping.ShoppingList{
+		UserID: "usr1",
+		Name:   "Groceries",
+		Mode:   shopping.ModeShopping,
+	})
+	if err != nil {
+		t.Fatalf("UpsertShoppingList() duplicate: %v", err)
+	}
+	if dup.ID != sl.ID || dup.Mode != shopping.ModePreparation {
+		t.Errorf("Expected existing list %+v, got %+v", sl, dup)
+	}
+
+	upd, err := r.UpdateShoppingList(context.Background(), "usr1", sl.ID, crdb.StringUpdate{},
+		crdb.StringUpdate{Updating: true, NewVal: shopping.ModeShopping})
+	if err != nil {
+		t.Fatalf("UpdateShoppingList(): %v", err)
+	}
+	if upd.Name != sl.Name || upd.Mode != shopping.ModeShopping {
+		t.Errorf("Expected only mode updated, got %+v", upd)
+	}
+	_, err = r.UpdateShoppingList(context.Background(), "usr2", sl.ID, crdb.StringUpdate{},
+		crdb.StringUpdate{Updating: true, NewVal: shopping.ModeShopping})
+	if !r.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for another user's list, got %v", err)
+	}
+
+	sls, err := r.ShoppingLists(context.Background(), "usr1", 0, 10)
+	if err != nil {
+		t.Fatalf("ShoppingLists(): %v", err)
+	}
+	if len(sls) != 1 || sls[0].ID != sl.ID {
+		t.Errorf("Expected only list %s, got %+v", sl.ID, sls)
+	}
+	_, err = r.ShoppingLists(context.Background(), "usr2", 0, 10)
+	if !r.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for user without lists, got %v", err)
+	}
+}
+
+func TestRoach_shoppingListItems(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	brand := insertBrand(t, r)
+	price := insertPrice(t, r, brand, "", 200, shopping.PriceStatusApproved)
+	sl, err := r.UpsertShoppingList(context.Background(), shopping.ShoppingList{
+		UserID: "usr1",
+		Name:   "Groceries",
+		Mode:   shopping.ModePreparation,
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: upsert shopping list: %v", err)
+	}
+
+	item, err := r.UpsertShoppingListItem(context.Background(), "usr1", shopping.ShoppingListItem{
+		Quantity:     2,
+		InList:       true,
+		ShoppingList: *sl,
+		Price:        *price,
+	})
+	if err != nil {
+		t.Fatalf("UpsertShoppingListItem(): %v", err)
+	}
+	if item.Price.ID != price.ID || item.Price.Brand.Item.Name != brand.Item.Name {
+		t.Errorf("Expected price %s of %+v, got %+v", price.ID, brand, item.Price)
+	}
+
+	// Upserting the same brand without a price keeps the item's price.
+	upd, err := r.UpsertShoppingListItem(context.Background(), "usr1", shopping.ShoppingListItem{
+		Quantity:     3,
+		InList:       true,
+		InCart:       true,
+		ShoppingList: *sl,
+		Price:        shopping.Price{Brand: brand},
+	})
+	if err != nil {
+		t.Fatalf("UpsertShoppingListItem() update: %v", err)
+	}
+	if upd.ID != item.ID || upd.Quantity != 3 || !upd.InCart || upd.Price.ID != price.ID {
+		t.Errorf("Expected item %s updated with price kept, got %+v", item.ID, upd)
+	}
+
+	_, err = r.UpsertShoppingListItem(context.Background(), "usr2", shopping.ShoppingListItem{
+		Quantity:     1,
+		ShoppingList: *sl,
+		Price:        shopping.Price{Brand: brand},
+	})
+	if !r.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for another user's list, got %v", err)
+	}
+
+	items, err := r.ShoppingListItems(context.Background(), "usr1", sl.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("ShoppingListItems(): %v", err)
+	}
+	if len(items) != 1 || items[0].ID != item.ID {
+		t.Errorf("Expected only item %s, got %+v", item.ID, items)
+	}
+
+	found, err := r.SearchShoppingListItems(context.Background(), "usr1",
+		shopping.ItemSearch{ItemName: "tooth"}, 0, 10)
+	if err != nil {
+		t.Fatalf("SearchShoppingListItems(): %v", err)
+	}
+	if len(found) != 1 || found[0].ID != item.ID {
+		t.Errorf("Expected only item %s found, got %+v", item.ID, found)
+	}
+	_, err = r.SearchShoppingListItems(context.Background(), "usr1",
+		shopping.ItemSearch{ItemName: "%"}, 0, 10)
+	if !r.IsNotFoundError(err) {
+		t.Errorf("Expected wildcards to be matched literally, got %v", err)
+	}
+
+	if err := r.DeleteShoppingListItem(context.Background(), "usr2", item.ID); !r.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error deleting another user's item, got %v", err)
+	}
+	if err := r.DeleteShoppingListItem(context.Background(), "usr1", item.ID); err != nil {
+		t.Fatalf("DeleteShoppingListItem(): %v", err)
+	}
+	_, err = r.ShoppingListItems(context.Background(), "usr1", sl.ID, 0, 10)
+	if !r.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error on empty list, got %v", err)
+	}
+}
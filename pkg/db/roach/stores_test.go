@@ -0,0 +1,82 @@
+package roach_test
+
+import (
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/db/roach"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestRoach_UpsertStore(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	first, err := r.UpsertStore("Naivas")
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if first.ID == "" {
+		t.Fatalf("ID was not assigned")
+	}
+	second, err := r.UpsertStore("Naivas")
+	if err != nil {
+		t.Fatalf("Got error on repeat upsert: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("Expected repeat upsert to yield ID %s, got %s", first.ID, second.ID)
+	}
+	if _, err := r.UpsertStore(""); err == nil {
+		t.Errorf("Expected an error for empty name, got nil")
+	}
+}
+
+func TestRoach_StoreBranchesWithin(t *testing.T) {
+	conf, tearDown := setup(t)
+	defer tearDown()
+	r := newRoach(t, conf)
+	near := insertStoreBranch(t, r, "node/1", shopping.Location{Latitude: -1.2636, Longitude: 36.8035})
+	insertStoreBranch(t, r, "node/2", shopping.Location{Latitude: -1.3000, Longitude: 36.8500})
+	sw, ne := shopping.BoundingBox(near.Location, 200)
+	tt := []struct {
+		name        string
+		storeID     string
+		expNotFound bool
+	}{
+		{name: "found", storeID: near.Store.ID, expNotFound: false},
+		{name: "other store", storeID: "9999", expNotFound: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sbs, err := r.StoreBranchesWithin(tc.storeID, sw, ne)
+			if tc.expNotFound {
+				if !r.IsNotFoundError(err) {
+					t.Fatalf("Expected not found error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if len(sbs) != 1 || sbs[0].ID != near.ID {
+				t.Errorf("Expected only branch %s, got %+v", near.ID, sbs)
+			}
+		})
+	}
+}
+
+func insertStoreBranch(t *testing.T, r *roach.Roach, osmID string, loc shopping.Location) *shopping.StoreBranch {
+	s, err := r.UpsertStore("Naivas")
+	if err != nil {
+		t.Fatalf("Error setting up: upsert store: %v", err)
+	}
+	sb, err := r.InsertStoreBranch(shopping.StoreBranch{
+		Name:     "Naivas " + osmID,
+		Store:    *s,
+		Location: loc,
+		OSMID:    osmID,
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: insert store branch: %v", err)
+	}
+	return sb
+}
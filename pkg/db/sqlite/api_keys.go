@@ -0,0 +1,254 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	apiG "github.com/tomogoma/go-api-guard"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+)
+
+// minAPIKeyLen is the minimum length of API keys accepted for storage.
+const minAPIKeyLen = 56
+
+// InsertAPIKey inserts an API key with apikeys.DefaultScopes for the
+// userID, see InsertAPIKeyWithPolicy.
+func (s *SQLite) InsertAPIKey(userID string, key []byte) (apiG.Key, error) {
+	k, err := s.InsertAPIKeyWithPolicy(context.Background(), userID, key,
+		apikeys.DefaultScopes, nil, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return *k, nil
+}
+
+// InsertAPIKeyWithPolicy inserts an API key for the userID granted scopes,
+// usable from origins (any if empty) until expires (never if zero). Only a
+// salted hash of key and its prefix are stored, the returned Key carries
+// key itself so that it can be shown to the user this once.
+func (s *SQLite) InsertAPIKeyWithPolicy(ctx context.Context, userID string, key []byte, scopes, origins []string, expires time.Time) (*api.Key, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "InsertAPIKeyWithPolicy")()
+	if len(key) < minAPIKeyLen {
+		return nil, errors.Newf("API key must be at least %d bytes long", minAPIKeyLen)
+	}
+	h, err := apikeys.HashKey(key)
+	if err != nil {
+		return nil, errors.Newf("hash API key: %v", err)
+	}
+	insCols := ColDesc(ColUserID, ColKeyPrefix, ColKeySalt, ColKeyHash, ColScopes,
+		ColOrigins, ColExpiryDate, ColCreateDate, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblAPIKeys + ` (` + insCols + `)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+			RETURNING ` + apiKeyCols
+	var expiry *time.Time
+	if !expires.IsZero() {
+		expires = expires.UTC()
+		expiry = &expires
+	}
+	k, err := scanAPIKey(s.db.QueryRowContext(ctx, q, userID, h.Prefix, h.Salt, h.Sum,
+		joinList(scopes), joinList(origins), expiry, now()))
+	if err != nil {
+		return nil, err
+	}
+	k.Val = key
+	return k, nil
+}
+
+// APIKeyByUserIDVal returns API keys for the provided userID/key combination
+// unless expired. Candidates are looked up by the key's prefix and
+// verified against their hashes in constant time.
+func (s *SQLite) APIKeyByUserIDVal(userID string, key []byte) (apiG.Key, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(context.Background(), "APIKeyByUserIDVal")()
+	q := `
+	SELECT ` + ColDesc(apiKeyCols, ColKeySalt, ColKeyHash) + `
+		FROM ` + TblAPIKeys + `
+		WHERE ` + ColUserID + `=$1 AND ` + ColKeyPrefix + `=$2
+			AND (` + ColExpiryDate + ` IS NULL OR ` + ColExpiryDate + ` > $3)`
+	rows, err := s.db.Query(q, userID, apikeys.Prefix(key), now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var found *api.Key
+	for rows.Next() {
+		h := apikeys.Hash{}
+		k, err := scanAPIKey(rows, &h.Salt, &h.Sum)
+		if err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		if h.Matches(key) && found == nil {
+			k.Val = key
+			found = k
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Newf("iterate result set: %v", err)
+	}
+	if found == nil {
+		return nil, errors.NewNotFound("API key not found")
+	}
+	return *found, nil
+}
+
+// APIKeys returns API keys, oldest first, of userID or of all users if
+// userID is empty. Keys are returned without their Val which is not stored.
+func (s *SQLite) APIKeys(ctx context.Context, userID string, offset, count int64) ([]api.Key, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "APIKeys")()
+	where := ""
+	args := []interface{}{count, offset}
+	if userID != "" {
+		where = ` WHERE ` + ColUserID + `=$3`
+		args = append(args, userID)
+	}
+	q := `
+	SELECT ` + apiKeyCols + `
+		FROM ` + TblAPIKeys + where + `
+		ORDER BY ` + ColID + `
+		LIMIT $1 OFFSET $2`
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ks []api.Key
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		ks = append(ks, *k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Newf("iterate result set: %v", err)
+	}
+	if len(ks) == 0 {
+		return nil, errors.NewNotFound("no API keys found")
+	}
+	return ks, nil
+}
+
+// APIKeyByID returns the API key with ID, without its Val.
+func (s *SQLite) APIKeyByID(ctx context.Context, ID string) (*api.Key, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "APIKeyByID")()
+	q := `
+	SELECT ` + apiKeyCols + `
+		FROM ` + TblAPIKeys + `
+		WHERE ` + ColID + `=$1`
+	k, err := scanAPIKey(s.db.QueryRowContext(ctx, q, ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NewNotFound("API key not found")
+		}
+		return nil, err
+	}
+	return k, nil
+}
+
+// UpdateAPIKeyPolicy sets the scopes, origins and expiry of the API key
+// with ID. A zero expires means the key never expires.
+func (s *SQLite) UpdateAPIKeyPolicy(ctx context.Context, ID string, scopes, origins []string, expires time.Time) (*api.Key, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "UpdateAPIKeyPolicy")()
+	updCols := ColDesc(ColScopes, ColOrigins, ColExpiryDate, ColUpdateDate)
+	q := `
+	UPDATE ` + TblAPIKeys + `
+		SET (` + updCols + `) = ($1, $2, $3, $4)
+		WHERE ` + ColID + `=$5
+		RETURNING ` + apiKeyCols
+	var expiry *time.Time
+	if !expires.IsZero() {
+		expires = expires.UTC()
+		expiry = &expires
+	}
+	k, err := scanAPIKey(s.db.QueryRowContext(ctx, q, joinList(scopes),
+		joinList(origins), expiry, now(), ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NewNotFound("API key not found")
+		}
+		return nil, err
+	}
+	return k, nil
+}
+
+// TouchAPIKey records that the API key with ID was just used. Uses are
+// recorded at most once per minute per key to spare the DB a write per
+// request.
+func (s *SQLite) TouchAPIKey(ctx context.Context, ID string) error {
+	if err := s.InitDBIfNot(); err != nil {
+		return err
+	}
+	defer s.observeQuery(ctx, "TouchAPIKey")()
+	q := `
+	UPDATE ` + TblAPIKeys + `
+		SET ` + ColLastUsed + `=$1
+		WHERE ` + ColID + `=$2 AND (` + ColLastUsed + ` IS NULL
+			OR ` + ColLastUsed + ` < $3)`
+	at := now()
+	_, err := s.db.ExecContext(ctx, q, at, ID, at.Add(-time.Minute))
+	return err
+}
+
+// DeleteAPIKey deletes the API key with ID so that it is no longer valid.
+func (s *SQLite) DeleteAPIKey(ctx context.Context, ID string) error {
+	if err := s.InitDBIfNot(); err != nil {
+		return err
+	}
+	defer s.observeQuery(ctx, "DeleteAPIKey")()
+	q := `DELETE FROM ` + TblAPIKeys + ` WHERE ` + ColID + `=$1`
+	res, err := s.db.ExecContext(ctx, q, ID)
+	return checkRowsAffected(res, err, 1)
+}
+
+var apiKeyCols = ColDesc(ColID, ColUserID, ColKeyPrefix, ColScopes, ColOrigins,
+	ColExpiryDate, ColLastUsed, ColCreateDate, ColUpdateDate)
+
+// scanAPIKey scans apiKeyCols followed by any extra columns into extra.
+func scanAPIKey(s scanner, extra ...interface{}) (*api.Key, error) {
+	k := api.Key{}
+	var scopes, origins string
+	var expiry, lastUsed *time.Time
+	dest := append([]interface{}{&k.ID, &k.UserID, &k.Prefix, &scopes, &origins,
+		&expiry, &lastUsed, &k.Created, &k.LastUpdated}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return nil, err
+	}
+	k.Scopes = splitList(scopes)
+	k.Origins = splitList(origins)
+	if expiry != nil {
+		k.Expires = *expiry
+	}
+	if lastUsed != nil {
+		k.LastUsed = *lastUsed
+	}
+	return &k, nil
+}
+
+// joinList encodes a list of values without spaces such as scopes for
+// storage in a single column, see splitList().
+func joinList(vals []string) string {
+	return strings.Join(vals, " ")
+}
+
+func splitList(list string) []string {
+	return strings.Fields(list)
+}
@@ -0,0 +1,210 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type brandKey struct {
+	normName string
+	itemID   string
+	unitID   string
+}
+
+// UpsertBrands inserts brands, their items, measuring units and barcodes in
+// a single transaction, reusing any that already exist (matched by
+// normalized name or an alias left behind by a merge). Existing barcodes
+// are re-assigned to the brand they appear with in brands. It returns
+// brands with all IDs assigned.
+func (s *SQLite) UpsertBrands(ctx context.Context, brands []shopping.Brand) ([]shopping.Brand, error) {
+	if len(brands) == 0 {
+		return nil, nil
+	}
+	out := make([]shopping.Brand, len(brands))
+	copy(out, brands)
+	err := s.executeTx(ctx, "UpsertBrands", func(tx *sql.Tx) error {
+
+		itemNames := make([]string, 0, len(out))
+		unitNames := make([]string, 0, len(out))
+		brandNames := make([]string, 0, len(out))
+		for _, b := range out {
+			itemNames = append(itemNames, b.Item.Name)
+			unitNames = append(unitNames, b.MeasuringUnit.Name)
+			brandNames = append(brandNames, b.Name)
+		}
+		itemNames, err := resolveAliases(tx, TblItems, itemNames)
+		if err != nil {
+			return errors.Newf("resolve item aliases: %v", err)
+		}
+		brandNames, err = resolveAliases(tx, TblBrands, brandNames)
+		if err != nil {
+			return errors.Newf("resolve brand aliases: %v", err)
+		}
+		for i := range out {
+			out[i].Item.Name = itemNames[i]
+			out[i].Name = brandNames[i]
+		}
+
+		at := now()
+		itemIDs, err := upsertNamed(tx, TblItems, itemNames, at)
+		if err != nil {
+			return errors.Newf("upsert items: %v", err)
+		}
+		unitIDs, err := upsertNamed(tx, TblMeasuringUnits, unitNames, at)
+		if err != nil {
+			return errors.Newf("upsert measuring units: %v", err)
+		}
+
+		keys := make([]brandKey, len(out))
+		for i := range out {
+			out[i].Item.ID = itemIDs[shopping.NormalizeName(out[i].Item.Name)]
+			out[i].MeasuringUnit.ID = unitIDs[shopping.NormalizeName(out[i].MeasuringUnit.Name)]
+			keys[i] = brandKey{
+				normName: shopping.NormalizeName(out[i].Name),
+				itemID:   out[i].Item.ID,
+				unitID:   out[i].MeasuringUnit.ID,
+			}
+		}
+		brandIDs, err := upsertBrandRows(tx, out, keys, at)
+		if err != nil {
+			return errors.Newf("upsert brands: %v", err)
+		}
+
+		barcodes := make(map[string]string)
+		for i := range out {
+			out[i].ID = brandIDs[keys[i]]
+			for _, code := range out[i].Barcodes {
+				if code = strings.TrimSpace(code); code != "" {
+					barcodes[code] = out[i].ID
+				}
+			}
+		}
+		if err := upsertBarcodes(tx, barcodes, at); err != nil {
+			return errors.Newf("upsert barcodes: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// upsertNamed inserts names into tbl (which must have the name and
+// normName columns) if not exists and returns the IDs mapped by normalized
+// name.
+func upsertNamed(tx *sql.Tx, tbl string, names []string, now time.Time) (map[string]string, error) {
+	distinct := make(map[string]string)
+	args := []interface{}{now}
+	for _, name := range names {
+		norm := shopping.NormalizeName(name)
+		if _, ok := distinct[norm]; ok || norm == "" {
+			continue
+		}
+		distinct[norm] = ""
+		args = append(args, shopping.CleanName(name), norm)
+	}
+	if len(distinct) == 0 {
+		return distinct, nil
+	}
+	insCols := ColDesc(ColName, ColNormName, ColCreateDate, ColUpdateDate)
+	q := `
+		INSERT INTO ` + tbl + ` (` + insCols + `)
+			VALUES ` + rowPlaceholders(2, len(distinct), 2, "$1", "$1") + `
+			ON CONFLICT (` + ColNormName + `)
+			DO UPDATE SET ` + ColUpdateDate + ` = $1
+			RETURNING ` + ColDesc(ColID, ColNormName)
+	rows, err := tx.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ID, norm string
+		if err := rows.Scan(&ID, &norm); err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		distinct[norm] = ID
+	}
+	return distinct, rows.Err()
+}
+
+func upsertBrandRows(tx *sql.Tx, brands []shopping.Brand, keys []brandKey, now time.Time) (map[brandKey]string, error) {
+	IDs := make(map[brandKey]string)
+	args := []interface{}{now}
+	for i, k := range keys {
+		if _, ok := IDs[k]; ok {
+			continue
+		}
+		if k.normName == "" || k.itemID == "" || k.unitID == "" {
+			return nil, errors.Newf("brand '%s' needs a name, item and measuring unit",
+				brands[i].Name)
+		}
+		IDs[k] = ""
+		args = append(args, shopping.CleanName(brands[i].Name), k.normName, k.itemID, k.unitID)
+	}
+	insCols := ColDesc(ColName, ColNormName, ColItemID, ColMeasUnitID,
+		ColCreateDate, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblBrands + ` (` + insCols + `)
+			VALUES ` + rowPlaceholders(2, len(IDs), 4, "$1", "$1") + `
+			ON CONFLICT (` + ColDesc(ColNormName, ColItemID, ColMeasUnitID) + `)
+			DO UPDATE SET ` + ColUpdateDate + ` = $1
+			RETURNING ` + ColDesc(ColID, ColNormName, ColItemID, ColMeasUnitID)
+	rows, err := tx.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ID string
+		k := brandKey{}
+		if err := rows.Scan(&ID, &k.normName, &k.itemID, &k.unitID); err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		IDs[k] = ID
+	}
+	return IDs, rows.Err()
+}
+
+func upsertBarcodes(tx *sql.Tx, brandIDByCode map[string]string, now time.Time) error {
+	if len(brandIDByCode) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, 1+2*len(brandIDByCode))
+	args = append(args, now)
+	for code, brandID := range brandIDByCode {
+		args = append(args, code, brandID)
+	}
+	insCols := ColDesc(ColCode, ColBrandID, ColCreateDate, ColUpdateDate)
+	updCols := ColDesc(ColBrandID, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblBarcodes + ` (` + insCols + `)
+			VALUES ` + rowPlaceholders(2, len(brandIDByCode), 2, "$1", "$1") + `
+			ON CONFLICT (` + ColCode + `)
+			DO UPDATE SET (` + updCols + `) = (excluded.` + ColBrandID + `, $1)`
+	_, err := tx.Exec(q, args...)
+	return err
+}
+
+// rowPlaceholders returns numRows comma separated value lists each
+// containing numCols sequential placeholders, starting from $from,
+// followed by extra e.g.
+// rowPlaceholders(2, 2, 2, "$1") yields "($2, $3, $1), ($4, $5, $1)".
+func rowPlaceholders(from, numRows, numCols int, extra ...string) string {
+	rows := make([]string, numRows)
+	for i := range rows {
+		cols := make([]string, 0, numCols+len(extra))
+		for j := 0; j < numCols; j++ {
+			cols = append(cols, "$"+strconv.Itoa(from+i*numCols+j))
+		}
+		rows[i] = "(" + ColDesc(append(cols, extra...)...) + ")"
+	}
+	return strings.Join(rows, ", ")
+}
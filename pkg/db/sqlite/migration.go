@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+)
+
+// firstVersion is the schema version SQLite DBs were introduced at. Roach
+// migrations to it (hashing API keys and adding their policy columns) are
+// already part of the SQLite tables.
+const firstVersion = 2
+
+func (s *SQLite) migrate(fromVersion, toVersion int) error {
+
+	if err := s.connect(); err != nil {
+		return fmt.Errorf("connect to db: %v", err)
+	}
+
+	// migrations[v-firstVersion] migrates from version v to v+1 and should
+	// mirror the Roach migration of the same version.
+	migrations := []func() error{s.migrate2To3}
+	if fromVersion < firstVersion || fromVersion >= toVersion ||
+		toVersion > firstVersion+len(migrations) {
+		return errors.New("not supported")
+	}
+	for v := fromVersion; v < toVersion; v++ {
+		if err := migrations[v-firstVersion](); err != nil {
+			return fmt.Errorf("to version %d: %v", v+1, err)
+		}
+	}
+	return s.setRunningVersionCurrent()
+}
+
+// migrate2To3 adds the price verdicts table, see Roach.migrate2To3.
+func (s *SQLite) migrate2To3() error {
+	if _, err := s.db.Exec(TblDescPriceVerdicts); err != nil {
+		return fmt.Errorf("create price verdicts table: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// InsertPrice inserts p and records v in one transaction and returns p
+// with its ID, creation date, brand and store branch details assigned.
+// p.Brand.ID must reference an existing brand, p.AtStoreBranch.ID must
+// reference an existing store branch or be empty.
+func (s *SQLite) InsertPrice(ctx context.Context, p shopping.Price, v shopping.Verdicts) (*shopping.Price, error) {
+	insCols := ColDesc(ColValue, ColCurrency, ColBrandID, ColStoreBrID,
+		ColUserID, ColStatus, ColOutlierSc, ColCreateDate, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblPrices + ` (` + insCols + `)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+			RETURNING ` + ColID
+	var inserted *shopping.Price
+	err := s.executeTx(ctx, "InsertPrice", func(tx *sql.Tx) error {
+		var ID string
+		err := tx.QueryRow(q, p.Value, p.Currency, p.Brand.ID,
+			nullString(p.AtStoreBranch.ID), p.SubmittedBy, p.Status,
+			p.OutlierScore, now()).Scan(&ID)
+		if err != nil {
+			return err
+		}
+		if err := recordVerdicts(tx, ID, v); err != nil {
+			return err
+		}
+		inserted, err = priceByID(tx, ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
+// PriceByID returns the price with ID.
+func (s *SQLite) PriceByID(ctx context.Context, ID string) (*shopping.Price, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "PriceByID")()
+	return priceByID(s.db, ID)
+}
+
+// RecentApprovedPrices returns the latest (up to limit) approved prices of
+// brandID in currency that were observed since, latest first. Prices
+// observed at any store branch are included if storeBranchID is empty.
+func (s *SQLite) RecentApprovedPrices(ctx context.Context, brandID, storeBranchID, currency string, since time.Time, limit int) ([]shopping.Price, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "RecentApprovedPrices")()
+	args := []interface{}{brandID, currency, shopping.PriceStatusApproved, since.UTC(), limit}
+	where := ``
+	if storeBranchID != "" {
+		where = ` AND p.` + ColStoreBrID + `=$6`
+		args = append(args, storeBranchID)
+	}
+	q := selectPricesQ + `
+		WHERE p.` + ColBrandID + `=$1 AND p.` + ColCurrency + `=$2
+			AND p.` + ColStatus + `=$3 AND p.` + ColCreateDate + `>=$4` + where + `
+		ORDER BY p.` + ColCreateDate + ` DESC, p.` + ColID + ` DESC
+		LIMIT $5`
+	return s.queryPrices(ctx, q, args...)
+}
+
+// PricesByStatus returns prices with status, oldest first.
+func (s *SQLite) PricesByStatus(ctx context.Context, status string, offset, count int64) ([]shopping.Price, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "PricesByStatus")()
+	q := selectPricesQ + `
+		WHERE p.` + ColStatus + `=$1
+		ORDER BY p.` + ColCreateDate + `, p.` + ColID + `
+		LIMIT $2 OFFSET $3`
+	return s.queryPrices(ctx, q, status, count, offset)
+}
+
+// UpdatePriceStatus sets the status of the price with priceID, reverses
+// the verdicts it previously cast and records v in one transaction and
+// returns the updated price. A price that already has status is returned
+// unchanged and v is not recorded so that concurrent updates to the same
+// status record v only once.
+func (s *SQLite) UpdatePriceStatus(ctx context.Context, priceID, status string, v shopping.Verdicts) (*shopping.Price, error) {
+	updCols := ColDesc(ColStatus, ColUpdateDate)
+	q := `
+		UPDATE ` + TblPrices + `
+			SET (` + updCols + `) = ($1, $2)
+			WHERE ` + ColID + `=$3 AND ` + ColStatus + `<>$1`
+	var updated *shopping.Price
+	err := s.executeTx(ctx, "UpdatePriceStatus", func(tx *sql.Tx) error {
+		res, err := tx.Exec(q, status, now(), priceID)
+		if err != nil {
+			return err
+		}
+		c, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if c == 1 {
+			if err := revokeVerdicts(tx, priceID); err != nil {
+				return err
+			}
+			if err := recordVerdicts(tx, priceID, v); err != nil {
+				return err
+			}
+		}
+		updated, err = priceByID(tx, priceID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// recordVerdicts increments the confirmations of prices with v.Confirmed
+// and the contradictions of prices with v.Contradicted and stores v as
+// cast by the price with priceID so that revokeVerdicts can reverse them.
+func recordVerdicts(tx *sql.Tx, priceID string, v shopping.Verdicts) error {
+	for col, IDs := range map[string][]string{
+		ColConfirms:   v.Confirmed,
+		ColContradics: v.Contradicted,
+	} {
+		if len(IDs) == 0 {
+			continue
+		}
+		args := []interface{}{now()}
+		for _, ID := range IDs {
+			args = append(args, ID)
+		}
+		q := `
+			UPDATE ` + TblPrices + `
+				SET (` + ColDesc(col, ColUpdateDate) + `) = (` + col + ` + 1, $1)
+				WHERE ` + ColID + ` IN (` + placeholders(2, len(IDs)) + `)`
+		res, err := tx.Exec(q, args...)
+		if err := checkRowsAffected(res, err, int64(len(IDs))); err != nil {
+			return errors.Newf("update %s: %v", col, err)
+		}
+	}
+	insCols := ColDesc(ColPriceID, ColJudgedID, ColConfirming, ColCreateDate)
+	for confirming, IDs := range map[bool][]string{
+		true:  v.Confirmed,
+		false: v.Contradicted,
+	} {
+		if len(IDs) == 0 {
+			continue
+		}
+		args := []interface{}{priceID, confirming, now()}
+		for _, ID := range IDs {
+			args = append(args, ID)
+		}
+		q := `
+			INSERT INTO ` + TblPriceVerdicts + ` (` + insCols + `)
+				SELECT $1, ` + ColID + `, $2, $3 FROM ` + TblPrices + `
+					WHERE ` + ColID + ` IN (` + placeholders(4, len(IDs)) + `)
+				ON CONFLICT DO NOTHING`
+		if _, err := tx.Exec(q, args...); err != nil {
+			return errors.Newf("store verdicts: %v", err)
+		}
+	}
+	return nil
+}
+
+// revokeVerdicts reverses the verdicts recorded by recordVerdicts for the
+// price with priceID.
+func revokeVerdicts(tx *sql.Tx, priceID string) error {
+	for col, confirming := range map[string]bool{
+		ColConfirms:   true,
+		ColContradics: false,
+	} {
+		q := `
+			UPDATE ` + TblPrices + `
+				SET (` + ColDesc(col, ColUpdateDate) + `) = (` + col + ` - 1, $1)
+				WHERE ` + ColID + ` IN (
+					SELECT ` + ColJudgedID + ` FROM ` + TblPriceVerdicts + `
+						WHERE ` + ColPriceID + `=$2 AND ` + ColConfirming + `=$3
+				)`
+		if _, err := tx.Exec(q, now(), priceID, confirming); err != nil {
+			return errors.Newf("revert %s: %v", col, err)
+		}
+	}
+	q := `DELETE FROM ` + TblPriceVerdicts + ` WHERE ` + ColPriceID + `=$1`
+	if _, err := tx.Exec(q, priceID); err != nil {
+		return errors.Newf("delete verdicts: %v", err)
+	}
+	return nil
+}
+
+// Contributors returns the price submission tallies of the users with
+// userIDs. Users who never submitted a price are left out.
+func (s *SQLite) Contributors(ctx context.Context, userIDs []string) ([]shopping.Contributor, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "Contributors")()
+	if len(userIDs) == 0 {
+		return nil, errors.NewNotFound("no contributors found")
+	}
+	args := make([]interface{}, len(userIDs))
+	for i, ID := range userIDs {
+		args[i] = ID
+	}
+	q := selectContributorsQ + `
+		WHERE ` + ColUserID + ` IN (` + placeholders(1, len(userIDs)) + `)
+		GROUP BY ` + ColUserID
+	return s.queryContributors(ctx, q, args...)
+}
+
+// ContributorsByTrust returns contributors ordered by shopping.TrustScore,
+// most trusted first.
+func (s *SQLite) ContributorsByTrust(ctx context.Context, offset, count int64) ([]shopping.Contributor, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "ContributorsByTrust")()
+	// Keep the ordering in sync with shopping.TrustScore().
+	q := selectContributorsQ + `
+		GROUP BY ` + ColUserID + `
+		ORDER BY CAST(SUM(` + ColConfirms + `) + 1 AS REAL) /
+				(SUM(` + ColConfirms + `) + SUM(` + ColContradics + `) + 2) DESC,
+			COUNT(*) DESC, ` + ColUserID + `
+		LIMIT $1 OFFSET $2`
+	return s.queryContributors(ctx, q, count, offset)
+}
+
+var selectContributorsQ = `
+	SELECT ` + ColUserID + `, COUNT(*), SUM(` + ColConfirms + `), SUM(` + ColContradics + `)
+		FROM ` + TblPrices
+
+func (s *SQLite) queryContributors(ctx context.Context, q string, args ...interface{}) ([]shopping.Contributor, error) {
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cs []shopping.Contributor
+	for rows.Next() {
+		c := shopping.Contributor{}
+		err := rows.Scan(&c.UserID, &c.Submissions, &c.Confirmations, &c.Contradictions)
+		if err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		c.Trust = shopping.TrustScore(c.Confirmations, c.Contradictions)
+		cs = append(cs, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Newf("iterate result set: %v", err)
+	}
+	if len(cs) == 0 {
+		return nil, errors.NewNotFound("no contributors found")
+	}
+	return cs, nil
+}
+
+func priceByID(qr queryRower, ID string) (*shopping.Price, error) {
+	p, err := scanPrice(qr.QueryRow(selectPricesQ+` WHERE p.`+ColID+`=$1`, ID))
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundf("price %s not found", ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+var selectPricesQ = `
+	SELECT p.` + ColID + `, p.` + ColValue + `, p.` + ColCurrency + `,
+			p.` + ColUserID + `, p.` + ColStatus + `, p.` + ColOutlierSc + `,
+			p.` + ColConfirms + `, p.` + ColContradics + `, p.` + ColCreateDate + `,
+			b.` + ColID + `, b.` + ColName + `,
+			i.` + ColID + `, i.` + ColName + `,
+			mu.` + ColID + `, mu.` + ColName + `,
+			sb.` + ColID + `, sb.` + ColName + `,
+			s.` + ColID + `, s.` + ColName + `
+		FROM ` + TblPrices + ` AS p
+		INNER JOIN ` + TblBrands + ` AS b ON b.` + ColID + `=p.` + ColBrandID + `
+		INNER JOIN ` + TblItems + ` AS i ON i.` + ColID + `=b.` + ColItemID + `
+		INNER JOIN ` + TblMeasuringUnits + ` AS mu ON mu.` + ColID + `=b.` + ColMeasUnitID + `
+		LEFT JOIN ` + TblStoreBranches + ` AS sb ON sb.` + ColID + `=p.` + ColStoreBrID + `
+		LEFT JOIN ` + TblStores + ` AS s ON s.` + ColID + `=sb.` + ColStoreID
+
+func (s *SQLite) queryPrices(ctx context.Context, q string, args ...interface{}) ([]shopping.Price, error) {
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ps []shopping.Price
+	for rows.Next() {
+		p, err := scanPrice(rows)
+		if err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		ps = append(ps, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Newf("iterate result set: %v", err)
+	}
+	if len(ps) == 0 {
+		return nil, errors.NewNotFound("no prices found")
+	}
+	return ps, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPrice(s scanner) (shopping.Price, error) {
+	p := shopping.Price{}
+	var sbID, sbName, storeID, storeName sql.NullString
+	err := s.Scan(&p.ID, &p.Value, &p.Currency, &p.SubmittedBy, &p.Status,
+		&p.OutlierScore, &p.Confirmations, &p.Contradictions, &p.Created,
+		&p.Brand.ID, &p.Brand.Name, &p.Brand.Item.ID, &p.Brand.Item.Name,
+		&p.Brand.MeasuringUnit.ID, &p.Brand.MeasuringUnit.Name,
+		&sbID, &sbName, &storeID, &storeName)
+	if err != nil {
+		return p, err
+	}
+	p.AtStoreBranch.ID = sbID.String
+	p.AtStoreBranch.Name = sbName.String
+	p.AtStoreBranch.Store.ID = storeID.String
+	p.AtStoreBranch.Store.Name = storeName.String
+	return p, nil
+}
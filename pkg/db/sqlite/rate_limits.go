@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+)
+
+// TakeRateLimitToken takes a token from the token bucket identified by key.
+// Buckets are shared by all processes using the DB file.
+func (s *SQLite) TakeRateLimitToken(ctx context.Context, key string, l ratelimit.Limit) (ratelimit.Result, error) {
+	var res ratelimit.Result
+	err := s.executeTx(ctx, "TakeRateLimitToken", func(tx *sql.Tx) error {
+
+		var b ratelimit.Bucket
+		q := `
+			SELECT ` + ColDesc(ColTokens, ColUpdateDate) + `
+				FROM ` + TblRateLimits + `
+				WHERE ` + ColKey + `=$1`
+		err := tx.QueryRowContext(ctx, q, key).Scan(&b.Tokens, &b.Updated)
+		if err != nil && err != sql.ErrNoRows {
+			return errors.Newf("get bucket: %v", err)
+		}
+
+		b, res = b.Take(l, now())
+
+		q = `
+			INSERT INTO ` + TblRateLimits + ` (` + ColDesc(ColKey, ColTokens, ColUpdateDate) + `)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (` + ColKey + `)
+				DO UPDATE SET (` + ColDesc(ColTokens, ColUpdateDate) + `) = ($2, $3)`
+		if _, err := tx.ExecContext(ctx, q, key, b.Tokens, b.Updated); err != nil {
+			return errors.Newf("update bucket: %v", err)
+		}
+		return nil
+	})
+	return res, err
+}
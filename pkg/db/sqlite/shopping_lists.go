@@ -0,0 +1,306 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// UpsertShoppingList inserts sl if sl.UserID has no shopping list named
+// sl.Name and returns the stored shopping list. An existing shopping list
+// is returned unchanged.
+func (s *SQLite) UpsertShoppingList(ctx context.Context, sl shopping.ShoppingList) (*shopping.ShoppingList, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "UpsertShoppingList")()
+	insCols := ColDesc(ColUserID, ColName, ColMode, ColCreateDate, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblShoppingLists + ` (` + insCols + `)
+			VALUES ($1, $2, $3, $4, $4)
+			ON CONFLICT (` + ColDesc(ColUserID, ColName) + `)
+			DO UPDATE SET ` + ColName + ` = excluded.` + ColName + `
+			RETURNING ` + shoppingListCols
+	return scanShoppingList(s.db.QueryRowContext(ctx, q, sl.UserID, sl.Name, sl.Mode, now()))
+}
+
+// UpdateShoppingList updates the name and/or mode of the shopping list
+// with ID owned by userID.
+func (s *SQLite) UpdateShoppingList(ctx context.Context, userID, ID string, name, mode crdb.StringUpdate) (*shopping.ShoppingList, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "UpdateShoppingList")()
+	var cols []string
+	var args []interface{}
+	if name.Updating {
+		cols = append(cols, ColName)
+		args = append(args, name.NewVal)
+	}
+	if mode.Updating {
+		cols = append(cols, ColMode)
+		args = append(args, mode.NewVal)
+	}
+	if len(cols) == 0 {
+		return nil, errors.NewClient("nothing to update")
+	}
+	cols = append(cols, ColUpdateDate)
+	args = append(args, now())
+	vals := placeholders(1, len(cols))
+	args = append(args, ID, userID)
+	q := `
+		UPDATE ` + TblShoppingLists + `
+			SET (` + ColDesc(cols...) + `) = (` + vals + `)
+			WHERE ` + ColID + `=$` + strconv.Itoa(len(args)-1) + `
+				AND ` + ColUserID + `=$` + strconv.Itoa(len(args)) + `
+			RETURNING ` + shoppingListCols
+	sl, err := scanShoppingList(s.db.QueryRowContext(ctx, q, args...))
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundf("shopping list %s not found", ID)
+	}
+	return sl, err
+}
+
+// ShoppingLists returns the shopping lists owned by userID, most recently
+// updated first.
+func (s *SQLite) ShoppingLists(ctx context.Context, userID string, offset, count int64) ([]shopping.ShoppingList, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "ShoppingLists")()
+	q := `
+		SELECT ` + shoppingListCols + `
+			FROM ` + TblShoppingLists + `
+			WHERE ` + ColUserID + `=$1
+			ORDER BY ` + ColUpdateDate + ` DESC, ` + ColID + `
+			LIMIT $2 OFFSET $3`
+	rows, err := s.db.QueryContext(ctx, q, userID, count, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sls []shopping.ShoppingList
+	for rows.Next() {
+		sl, err := scanShoppingList(rows)
+		if err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		sls = append(sls, *sl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Newf("iterate result set: %v", err)
+	}
+	if len(sls) == 0 {
+		return nil, errors.NewNotFound("no shopping lists found")
+	}
+	return sls, nil
+}
+
+// ShoppingList returns the shopping list with ID owned by userID.
+func (s *SQLite) ShoppingList(ctx context.Context, userID, ID string) (*shopping.ShoppingList, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "ShoppingList")()
+	q := `
+		SELECT ` + shoppingListCols + `
+			FROM ` + TblShoppingLists + `
+			WHERE ` + ColID + `=$1 AND ` + ColUserID + `=$2`
+	sl, err := scanShoppingList(s.db.QueryRowContext(ctx, q, ID, userID))
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundf("shopping list %s not found", ID)
+	}
+	return sl, err
+}
+
+// UpsertShoppingListItem inserts item into the shopping list with
+// item.ShoppingList.ID owned by userID or updates the item of the same
+// brand (item.Price.Brand.ID) if the shopping list already has one.
+// item.Price.ID may be empty in which case an existing item's price is
+// kept.
+func (s *SQLite) UpsertShoppingListItem(ctx context.Context, userID string, item shopping.ShoppingListItem) (*shopping.ShoppingListItem, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "UpsertShoppingListItem")()
+	insCols := ColDesc(ColShopListID, ColBrandID, ColPriceID, ColQuantity,
+		ColInList, ColInCart, ColCreateDate, ColUpdateDate)
+	updCols := ColDesc(ColPriceID, ColQuantity, ColInList, ColInCart, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblShopListItems + ` (` + insCols + `)
+			SELECT ` + ColID + `, $3, $4, $5, $6, $7, $8, $8
+				FROM ` + TblShoppingLists + `
+				WHERE ` + ColID + `=$1 AND ` + ColUserID + `=$2
+			ON CONFLICT (` + ColDesc(ColShopListID, ColBrandID) + `)
+			DO UPDATE SET (` + updCols + `) = (
+				COALESCE(excluded.` + ColPriceID + `, ` + TblShopListItems + `.` + ColPriceID + `),
+				excluded.` + ColQuantity + `, excluded.` + ColInList + `,
+				excluded.` + ColInCart + `, excluded.` + ColUpdateDate + `
+			)
+			RETURNING ` + ColID
+	var ID string
+	err := s.db.QueryRowContext(ctx, q, item.ShoppingList.ID, userID, item.Price.Brand.ID,
+		nullString(item.Price.ID), item.Quantity, item.InList, item.InCart, now()).
+		Scan(&ID)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundf("shopping list %s not found", item.ShoppingList.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.queryShoppingListItems(ctx, selectShoppingListItemsQ+`
+		WHERE sli.`+ColID+`=$1`, ID)
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// DeleteShoppingListItem deletes the shopping list item with ID from a
+// shopping list owned by userID.
+func (s *SQLite) DeleteShoppingListItem(ctx context.Context, userID, ID string) error {
+	if err := s.InitDBIfNot(); err != nil {
+		return err
+	}
+	defer s.observeQuery(ctx, "DeleteShoppingListItem")()
+	q := `
+		DELETE FROM ` + TblShopListItems + `
+			WHERE ` + ColID + `=$1 AND ` + ColShopListID + ` IN (
+				SELECT ` + ColID + ` FROM ` + TblShoppingLists + `
+					WHERE ` + ColUserID + `=$2
+			)`
+	res, err := s.db.ExecContext(ctx, q, ID, userID)
+	return checkRowsAffected(res, err, 1)
+}
+
+// ShoppingListItems returns the items in the shopping list with
+// shoppingListID owned by userID in the order they were added.
+func (s *SQLite) ShoppingListItems(ctx context.Context, userID, shoppingListID string, offset, count int64) ([]shopping.ShoppingListItem, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "ShoppingListItems")()
+	q := selectShoppingListItemsQ + `
+		WHERE sl.` + ColUserID + `=$1 AND sl.` + ColID + `=$2
+		ORDER BY sli.` + ColCreateDate + `, sli.` + ColID + `
+		LIMIT $3 OFFSET $4`
+	return s.queryShoppingListItems(ctx, q, userID, shoppingListID, count, offset)
+}
+
+// SearchShoppingListItems returns items in any shopping list owned by
+// userID whose item, brand and measuring unit names contain those in q,
+// ignoring case. SQLite only folds the case of ASCII letters. The most
+// recently updated items are returned first.
+func (s *SQLite) SearchShoppingListItems(ctx context.Context, userID string, q shopping.ItemSearch, offset, count int64) ([]shopping.ShoppingListItem, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(ctx, "SearchShoppingListItems")()
+	query := selectShoppingListItemsQ + `
+		WHERE sl.` + ColUserID + `=$1
+			AND i.` + ColName + ` LIKE $2 ESCAPE '\'
+			AND b.` + ColName + ` LIKE $3 ESCAPE '\'
+			AND mu.` + ColName + ` LIKE $4 ESCAPE '\'
+		ORDER BY sli.` + ColUpdateDate + ` DESC, sli.` + ColID + `
+		LIMIT $5 OFFSET $6`
+	return s.queryShoppingListItems(ctx, query, userID, containsPattern(q.ItemName),
+		containsPattern(q.BrandName), containsPattern(q.MeasuringUnit),
+		count, offset)
+}
+
+var shoppingListCols = ColDesc(ColID, ColUserID, ColName, ColMode,
+	ColCreateDate, ColUpdateDate)
+
+func scanShoppingList(s scanner) (*shopping.ShoppingList, error) {
+	sl := shopping.ShoppingList{}
+	var created, updated time.Time
+	err := s.Scan(&sl.ID, &sl.UserID, &sl.Name, &sl.Mode, &created, &updated)
+	if err != nil {
+		return nil, err
+	}
+	sl.Created = created.Format(config.TimeFormat)
+	sl.LastUpdated = updated.Format(config.TimeFormat)
+	return &sl, nil
+}
+
+var selectShoppingListItemsQ = `
+	SELECT sli.` + ColID + `, sli.` + ColQuantity + `, sli.` + ColInList + `,
+			sli.` + ColInCart + `,
+			sl.` + ColID + `, sl.` + ColUserID + `, sl.` + ColName + `,
+			sl.` + ColMode + `, sl.` + ColCreateDate + `, sl.` + ColUpdateDate + `,
+			b.` + ColID + `, b.` + ColName + `,
+			i.` + ColID + `, i.` + ColName + `,
+			mu.` + ColID + `, mu.` + ColName + `,
+			p.` + ColID + `, p.` + ColValue + `, p.` + ColCurrency + `,
+			p.` + ColStatus + `, p.` + ColCreateDate + `,
+			sb.` + ColID + `, sb.` + ColName + `,
+			s.` + ColID + `, s.` + ColName + `
+		FROM ` + TblShopListItems + ` AS sli
+		INNER JOIN ` + TblShoppingLists + ` AS sl ON sl.` + ColID + `=sli.` + ColShopListID + `
+		INNER JOIN ` + TblBrands + ` AS b ON b.` + ColID + `=sli.` + ColBrandID + `
+		INNER JOIN ` + TblItems + ` AS i ON i.` + ColID + `=b.` + ColItemID + `
+		INNER JOIN ` + TblMeasuringUnits + ` AS mu ON mu.` + ColID + `=b.` + ColMeasUnitID + `
+		LEFT JOIN ` + TblPrices + ` AS p ON p.` + ColID + `=sli.` + ColPriceID + `
+		LEFT JOIN ` + TblStoreBranches + ` AS sb ON sb.` + ColID + `=p.` + ColStoreBrID + `
+		LEFT JOIN ` + TblStores + ` AS s ON s.` + ColID + `=sb.` + ColStoreID
+
+func (s *SQLite) queryShoppingListItems(ctx context.Context, q string, args ...interface{}) ([]shopping.ShoppingListItem, error) {
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []shopping.ShoppingListItem
+	for rows.Next() {
+		i := shopping.ShoppingListItem{}
+		var slCreated, slUpdated time.Time
+		var pID, pCurrency, pStatus, sbID, sbName, storeID, storeName sql.NullString
+		var pValue sql.NullFloat64
+		var pCreated *time.Time
+		err := rows.Scan(&i.ID, &i.Quantity, &i.InList, &i.InCart,
+			&i.ShoppingList.ID, &i.ShoppingList.UserID, &i.ShoppingList.Name,
+			&i.ShoppingList.Mode, &slCreated, &slUpdated,
+			&i.Price.Brand.ID, &i.Price.Brand.Name,
+			&i.Price.Brand.Item.ID, &i.Price.Brand.Item.Name,
+			&i.Price.Brand.MeasuringUnit.ID, &i.Price.Brand.MeasuringUnit.Name,
+			&pID, &pValue, &pCurrency, &pStatus, &pCreated,
+			&sbID, &sbName, &storeID, &storeName)
+		if err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		i.ShoppingList.Created = slCreated.Format(config.TimeFormat)
+		i.ShoppingList.LastUpdated = slUpdated.Format(config.TimeFormat)
+		i.Price.ID = pID.String
+		i.Price.Value = float32(pValue.Float64)
+		i.Price.Currency = pCurrency.String
+		i.Price.Status = pStatus.String
+		if pCreated != nil {
+			i.Price.Created = *pCreated
+		}
+		i.Price.AtStoreBranch.ID = sbID.String
+		i.Price.AtStoreBranch.Name = sbName.String
+		i.Price.AtStoreBranch.Store.ID = storeID.String
+		i.Price.AtStoreBranch.Store.Name = storeName.String
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Newf("iterate result set: %v", err)
+	}
+	if len(items) == 0 {
+		return nil, errors.NewNotFound("no shopping list items found")
+	}
+	return items, nil
+}
+
+// containsPattern returns a LIKE pattern matching values containing s.
+func containsPattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
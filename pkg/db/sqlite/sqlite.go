@@ -0,0 +1,317 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/db/roach"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	// registers the "sqlite" database/sql driver.
+	_ "modernc.org/sqlite"
+)
+
+var tracer = otel.Tracer("github.com/tomogoma/shoppingms/pkg/db/sqlite")
+
+// SQLite is a store in a single SQLite database file, for single-node
+// deployments that do not warrant a CockroachDB cluster. It holds the same
+// tables and constraints as Roach.
+// Use NewSQLite() to instantiate.
+type SQLite struct {
+	errors.NotFoundErrCheck
+	path             string
+	db               *sql.DB
+	compatibilityErr error
+	observer         roach.Observer
+
+	connMutex sync.Mutex
+
+	isDBInitMutex sync.Mutex
+	isDBInit      bool
+	// noMigration is set by WithoutMigration().
+	noMigration bool
+}
+
+const (
+	keyDBVersion = "db.version"
+
+	// dsnParams enforce foreign keys, wait for locks held by other
+	// connections instead of failing, and take the write lock at the start
+	// of transactions so that concurrent transactions queue up rather than
+	// deadlock.
+	dsnParams = "_pragma=foreign_keys(1)&_pragma=busy_timeout(10000)" +
+		"&_pragma=journal_mode(WAL)&_txlock=immediate&_time_format=sqlite"
+)
+
+// NewSQLite creates an instance of *SQLite. The database file is only
+// opened (and created if missing) when InitDBIfNot() or one of the
+// Execute/Query methods is called.
+func NewSQLite(opts ...Option) *SQLite {
+	s := &SQLite{
+		path:     config.DefaultSQLitePath(),
+		observer: noopObserver{},
+	}
+	for _, f := range opts {
+		f(s)
+	}
+	return s
+}
+
+// InitDBIfNot opens and sets up the DB; creating the file and tables if
+// necessary.
+func (s *SQLite) InitDBIfNot() error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+	return s.instantiate()
+}
+
+// Close closes the DB once queries in progress finish.
+// The SQLite must not be used afterwards.
+func (s *SQLite) Close() error {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// ExecuteTx prepares a transaction for execution in fn.
+// It commits the changes if fn returns nil, otherwise changes are rolled back.
+func (s *SQLite) ExecuteTx(fn func(*sql.Tx) error) error {
+	return s.executeTx(context.Background(), "ExecuteTx", fn)
+}
+
+// executeTx is ExecuteTx() reporting the transaction to the Observer as op
+// and tracing it as a child of the span in ctx, if any. Transactions are
+// serialized by the DB so, unlike with Roach, they are never retried.
+func (s *SQLite) executeTx(ctx context.Context, op string, fn func(*sql.Tx) error) error {
+	if err := s.InitDBIfNot(); err != nil {
+		return err
+	}
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	start := time.Now()
+	err := executeTx(ctx, s.db, fn)
+	s.observer.ObserveTx(op, time.Since(start), 0)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func executeTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Newf("begin transaction: %v", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Newf("commit transaction: %v", err)
+	}
+	return nil
+}
+
+// observeQuery reports the time elapsed until the returned func is called
+// to the Observer as a query made by op, tracing it as a child of the span
+// in ctx, if any e.g.
+//     defer s.observeQuery(ctx, "ShoppingLists")()
+func (s *SQLite) observeQuery(ctx context.Context, op string) func() {
+	_, span := startSpan(ctx, op)
+	start := time.Now()
+	return func() {
+		s.observer.ObserveQuery(op, time.Since(start))
+		span.End()
+	}
+}
+
+// startSpan starts a span for op only if ctx already carries one so that
+// calls made outside a request (e.g. by importers) do not start new traces.
+func startSpan(ctx context.Context, op string) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.SpanContext().IsValid() {
+		return ctx, parent
+	}
+	return tracer.Start(ctx, "sqlite."+op, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "sqlite")))
+}
+
+// Ping checks that the DB file can be opened. Unlike InitDBIfNot() it does
+// not fail if the DB schema is incompatible, see SchemaVersion() for that.
+func (s *SQLite) Ping(ctx context.Context) error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+	if err := s.db.PingContext(ctx); err != nil {
+		return errors.Newf("ping db: %v", err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the version of the DB schema in use, which should
+// equal Version once the DB is initialized.
+func (s *SQLite) SchemaVersion(ctx context.Context) (int, error) {
+	if err := s.connect(); err != nil {
+		return -1, err
+	}
+	return s.runningVersion(ctx)
+}
+
+// connect opens the DB file, creating its directory if necessary, unless
+// already open.
+func (s *SQLite) connect() error {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+	if s.db != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return errors.Newf("create db directory: %v", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+s.path+"?"+dsnParams)
+	if err != nil {
+		return errors.Newf("open db: %v", err)
+	}
+	s.db = db
+	return nil
+}
+
+func (s *SQLite) instantiate() error {
+	s.isDBInitMutex.Lock()
+	defer s.isDBInitMutex.Unlock()
+	if s.compatibilityErr != nil {
+		return s.compatibilityErr
+	}
+	if s.isDBInit {
+		return nil
+	}
+	if s.noMigration {
+		if _, err := s.validateRunningVersion(); err != nil {
+			if s.IsNotFoundError(err) {
+				return errors.Newf("db not set up: need db version '%d'", Version)
+			}
+			return errors.Newf("check db version: %v", err)
+		}
+		s.isDBInit = true
+		return nil
+	}
+	err := executeTx(context.Background(), s.db, func(tx *sql.Tx) error {
+		for _, desc := range AllTableDescs {
+			if _, err := tx.Exec(desc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Newf("instantiating db: %v", err)
+	}
+	if runningVersion, err := s.validateRunningVersion(); err != nil {
+		if !s.IsNotFoundError(err) {
+			if err != s.compatibilityErr {
+				return errors.Newf("check db version: %v", err)
+			}
+			if err := s.migrate(runningVersion, Version); err != nil {
+				return errors.Newf("migrate from version %d to %d: %v",
+					runningVersion, Version, err)
+			}
+		}
+		if err := s.setRunningVersionCurrent(); err != nil {
+			return errors.Newf("set db version: %v", err)
+		}
+	}
+	s.isDBInit = true
+	return nil
+}
+
+func (s *SQLite) validateRunningVersion() (int, error) {
+	runningVersion, err := s.runningVersion(context.Background())
+	if err != nil {
+		return -1, err
+	}
+	if runningVersion != Version {
+		s.compatibilityErr = errors.Newf("db incompatible: need db"+
+			" version '%d', found '%d'", Version, runningVersion)
+		return runningVersion, s.compatibilityErr
+	}
+	return runningVersion, nil
+}
+
+func (s *SQLite) runningVersion(ctx context.Context) (int, error) {
+	var runningVersion int
+	q := `SELECT ` + ColValue + ` FROM ` + TblConfigurations + ` WHERE ` + ColKey + `=$1`
+	var confB []byte
+	if err := s.db.QueryRowContext(ctx, q, keyDBVersion).Scan(&confB); err != nil {
+		if err == sql.ErrNoRows {
+			return -1, errors.NewNotFoundf("config not found")
+		}
+		return -1, errors.Newf("get conf: %v", err)
+	}
+	if err := json.Unmarshal(confB, &runningVersion); err != nil {
+		return -1, errors.Newf("Unmarshalling config: %v", err)
+	}
+	return runningVersion, nil
+}
+
+func (s *SQLite) setRunningVersionCurrent() error {
+	valB, err := json.Marshal(Version)
+	if err != nil {
+		return errors.Newf("marshal conf: %v", err)
+	}
+	cols := ColDesc(ColKey, ColValue, ColCreateDate, ColUpdateDate)
+	updCols := ColDesc(ColValue, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblConfigurations + ` (` + cols + `)
+			VALUES ($1, $2, $3, $3)
+			ON CONFLICT (` + ColKey + `)
+			DO UPDATE SET (` + updCols + `) = ($2, $3)`
+	res, err := s.db.Exec(q, keyDBVersion, valB, now())
+	if err := checkRowsAffected(res, err, 1); err != nil {
+		return err
+	}
+	s.compatibilityErr = nil
+	return nil
+}
+
+// now returns the current time in UTC. Times are stored as text, which
+// only sorts chronologically if all of them are in the same time zone.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+func checkRowsAffected(r sql.Result, err error, expAffected int64) error {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NewNotFound("none found")
+		}
+		return err
+	}
+	c, err := r.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if c == 0 {
+		return errors.NewNotFound("none found for update")
+	}
+	if c != expAffected {
+		return errors.Newf("expected %d affected rows but got %d",
+			expAffected, c)
+	}
+	return nil
+}
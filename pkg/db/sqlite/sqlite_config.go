@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/db/roach"
+)
+
+// Option allows extra configuration for instantiating SQLite. Use the
+// With... functions to set options e.g.
+//     pathOpt := WithPath("/var/lib/shoppingms/shoppingms.db")
+type Option func(*SQLite)
+
+// WithPath sets the path of the database file to be used by SQLite.
+func WithPath(path string) Option {
+	return func(s *SQLite) {
+		s.path = path
+	}
+}
+
+// WithoutMigration stops SQLite from creating or migrating the DB schema
+// in InitDBIfNot() and the Execute/Query methods, which fail instead unless
+// the schema is already at Version. It is for tools that must not change
+// the schema as a side effect of reading the DB.
+func WithoutMigration() Option {
+	return func(s *SQLite) {
+		s.noMigration = true
+	}
+}
+
+// WithObserver sets the Observer to report DB queries and transactions to.
+// They are not reported by default or if o is nil.
+func WithObserver(o roach.Observer) Option {
+	return func(s *SQLite) {
+		if o != nil {
+			s.observer = o
+		}
+	}
+}
+
+type noopObserver struct{}
+
+func (noopObserver) ObserveQuery(string, time.Duration)   {}
+func (noopObserver) ObserveTx(string, time.Duration, int) {}
@@ -0,0 +1,296 @@
+package sqlite
+
+import "github.com/tomogoma/shoppingms/pkg/db/roach"
+
+// Table and column names and the schema version are shared with Roach so
+// that both hold the same schema.
+const (
+	// Database definition version
+	Version = roach.Version
+
+	// Table names
+	TblConfigurations = roach.TblConfigurations
+	TblAPIKeys        = roach.TblAPIKeys
+	TblStores         = roach.TblStores
+	TblStoreBranches  = roach.TblStoreBranches
+	TblItems          = roach.TblItems
+	TblMeasuringUnits = roach.TblMeasuringUnits
+	TblBrands         = roach.TblBrands
+	TblBarcodes       = roach.TblBarcodes
+	TblAliases        = roach.TblAliases
+	TblShoppingLists  = roach.TblShoppingLists
+	TblPrices         = roach.TblPrices
+	TblPriceVerdicts  = roach.TblPriceVerdicts
+	TblShopListItems  = roach.TblShopListItems
+	TblRateLimits     = roach.TblRateLimits
+
+	// DB Table Columns
+	ColID         = roach.ColID
+	ColCreateDate = roach.ColCreateDate
+	ColUpdateDate = roach.ColUpdateDate
+	ColUserID     = roach.ColUserID
+	ColKey        = roach.ColKey
+	ColValue      = roach.ColValue
+	ColName       = roach.ColName
+	ColStoreID    = roach.ColStoreID
+	ColLatitude   = roach.ColLatitude
+	ColLongitude  = roach.ColLongitude
+	ColOSMID      = roach.ColOSMID
+	ColNormName   = roach.ColNormName
+	ColItemID     = roach.ColItemID
+	ColMeasUnitID = roach.ColMeasUnitID
+	ColBrandID    = roach.ColBrandID
+	ColCode       = roach.ColCode
+	ColEntityType = roach.ColEntityType
+	ColCanonName  = roach.ColCanonName
+	ColMode       = roach.ColMode
+	ColCurrency   = roach.ColCurrency
+	ColStoreBrID  = roach.ColStoreBrID
+	ColShopListID = roach.ColShopListID
+	ColPriceID    = roach.ColPriceID
+	ColQuantity   = roach.ColQuantity
+	ColInList     = roach.ColInList
+	ColInCart     = roach.ColInCart
+	ColStatus     = roach.ColStatus
+	ColOutlierSc  = roach.ColOutlierSc
+	ColConfirms   = roach.ColConfirms
+	ColContradics = roach.ColContradics
+	ColTokens     = roach.ColTokens
+	ColKeyPrefix  = roach.ColKeyPrefix
+	ColKeySalt    = roach.ColKeySalt
+	ColKeyHash    = roach.ColKeyHash
+	ColScopes     = roach.ColScopes
+	ColOrigins    = roach.ColOrigins
+	ColExpiryDate = roach.ColExpiryDate
+	ColLastUsed   = roach.ColLastUsed
+	ColJudgedID   = roach.ColJudgedID
+	ColConfirming = roach.ColConfirming
+
+	// Index names. SQLite has no inline indexes, those Roach declares
+	// in CREATE TABLE are created by the IdxDesc... descriptions.
+	IdxAPIKeysPrefix         = roach.IdxAPIKeysPrefix
+	IdxStoreBranchesLocation = "storeBranchesStoreIDLocationIdx"
+	IdxBarcodesBrand         = "barcodesBrandIDIdx"
+	IdxPricesBrandStatus     = "pricesBrandIDStatusCreateDateIdx"
+	IdxPricesStatus          = "pricesStatusCreateDateIdx"
+	IdxPricesUser            = "pricesUserIDIdx"
+	IdxShopListItemsBrand    = "shoppingListItemsBrandIDIdx"
+	IdxShopListItemsPrice    = "shoppingListItemsPriceIDIdx"
+
+	// CREATE TABLE DESCRIPTIONS
+	// SQLite does not enforce column types, VARCHAR lengths and integer
+	// columns are enforced by CHECK constraints instead. Dates are always
+	// set by the queries (in UTC, see now()) rather than defaulted to
+	// CURRENT_TIMESTAMP whose format would not sort with theirs.
+	TblDescConfigurations = `
+	CREATE TABLE IF NOT EXISTS ` + TblConfigurations + ` (
+		` + ColKey + ` VARCHAR(56) PRIMARY KEY NOT NULL CHECK (LENGTH(` + ColKey + `) BETWEEN 1 AND 56),
+		` + ColValue + ` BLOB NOT NULL CHECK (` + ColValue + ` != ''),
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL
+	);
+	`
+	TblDescAPIKeys = `
+	CREATE TABLE IF NOT EXISTS ` + TblAPIKeys + ` (
+		` + ColID + ` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL CHECK (` + ColID + `>0),
+		` + ColUserID + ` INTEGER NOT NULL CHECK (TYPEOF(` + ColUserID + `) = 'integer'),
+		` + ColKeyPrefix + ` VARCHAR(16) NOT NULL CHECK (LENGTH(` + ColKeyPrefix + `) BETWEEN 1 AND 16),
+		` + ColKeySalt + ` BLOB NOT NULL,
+		` + ColKeyHash + ` BLOB NOT NULL,
+		` + ColScopes + ` TEXT NOT NULL,
+		` + ColOrigins + ` TEXT NOT NULL DEFAULT '',
+		` + ColExpiryDate + ` TIMESTAMP,
+		` + ColLastUsed + ` TIMESTAMP,
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL
+	);
+	`
+	TblDescStores = `
+	CREATE TABLE IF NOT EXISTS ` + TblStores + ` (
+		` + ColID + ` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL CHECK (` + ColID + `>0),
+		` + ColName + ` VARCHAR(256) UNIQUE NOT NULL CHECK (LENGTH(` + ColName + `) BETWEEN 1 AND 256),
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL
+	);
+	`
+	TblDescStoreBranches = `
+	CREATE TABLE IF NOT EXISTS ` + TblStoreBranches + ` (
+		` + ColID + ` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL CHECK (` + ColID + `>0),
+		` + ColStoreID + ` INTEGER NOT NULL REFERENCES ` + TblStores + ` (` + ColID + `),
+		` + ColName + ` VARCHAR(256) NOT NULL CHECK (LENGTH(` + ColName + `) BETWEEN 1 AND 256),
+		` + ColLatitude + ` REAL NOT NULL CHECK (` + ColLatitude + ` BETWEEN -90 AND 90),
+		` + ColLongitude + ` REAL NOT NULL CHECK (` + ColLongitude + ` BETWEEN -180 AND 180),
+		` + ColOSMID + ` VARCHAR(64) UNIQUE CHECK (LENGTH(` + ColOSMID + `) <= 64),
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL
+	);
+	`
+	TblDescItems = `
+	CREATE TABLE IF NOT EXISTS ` + TblItems + ` (
+		` + ColID + ` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL CHECK (` + ColID + `>0),
+		` + ColName + ` VARCHAR(256) NOT NULL CHECK (LENGTH(` + ColName + `) BETWEEN 1 AND 256),
+		` + ColNormName + ` VARCHAR(256) UNIQUE NOT NULL CHECK (LENGTH(` + ColNormName + `) BETWEEN 1 AND 256),
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL
+	);
+	`
+	TblDescMeasuringUnits = `
+	CREATE TABLE IF NOT EXISTS ` + TblMeasuringUnits + ` (
+		` + ColID + ` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL CHECK (` + ColID + `>0),
+		` + ColName + ` VARCHAR(256) NOT NULL CHECK (LENGTH(` + ColName + `) BETWEEN 1 AND 256),
+		` + ColNormName + ` VARCHAR(256) UNIQUE NOT NULL CHECK (LENGTH(` + ColNormName + `) BETWEEN 1 AND 256),
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL
+	);
+	`
+	TblDescBrands = `
+	CREATE TABLE IF NOT EXISTS ` + TblBrands + ` (
+		` + ColID + ` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL CHECK (` + ColID + `>0),
+		` + ColName + ` VARCHAR(256) NOT NULL CHECK (LENGTH(` + ColName + `) BETWEEN 1 AND 256),
+		` + ColNormName + ` VARCHAR(256) NOT NULL CHECK (LENGTH(` + ColNormName + `) BETWEEN 1 AND 256),
+		` + ColItemID + ` INTEGER NOT NULL REFERENCES ` + TblItems + ` (` + ColID + `),
+		` + ColMeasUnitID + ` INTEGER NOT NULL REFERENCES ` + TblMeasuringUnits + ` (` + ColID + `),
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL,
+		UNIQUE (` + ColNormName + `, ` + ColItemID + `, ` + ColMeasUnitID + `)
+	);
+	`
+	TblDescBarcodes = `
+	CREATE TABLE IF NOT EXISTS ` + TblBarcodes + ` (
+		` + ColCode + ` VARCHAR(64) PRIMARY KEY NOT NULL CHECK (LENGTH(` + ColCode + `) BETWEEN 1 AND 64),
+		` + ColBrandID + ` INTEGER NOT NULL REFERENCES ` + TblBrands + ` (` + ColID + `),
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL
+	);
+	`
+	TblDescAliases = `
+	CREATE TABLE IF NOT EXISTS ` + TblAliases + ` (
+		` + ColEntityType + ` VARCHAR(32) NOT NULL CHECK (LENGTH(` + ColEntityType + `) BETWEEN 1 AND 32),
+		` + ColNormName + ` VARCHAR(256) NOT NULL CHECK (LENGTH(` + ColNormName + `) BETWEEN 1 AND 256),
+		` + ColCanonName + ` VARCHAR(256) NOT NULL CHECK (LENGTH(` + ColCanonName + `) BETWEEN 1 AND 256),
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL,
+		PRIMARY KEY (` + ColEntityType + `, ` + ColNormName + `)
+	);
+	`
+	TblDescShoppingLists = `
+	CREATE TABLE IF NOT EXISTS ` + TblShoppingLists + ` (
+		` + ColID + ` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL CHECK (` + ColID + `>0),
+		` + ColUserID + ` VARCHAR(256) NOT NULL CHECK (LENGTH(` + ColUserID + `) BETWEEN 1 AND 256),
+		` + ColName + ` VARCHAR(256) NOT NULL CHECK (LENGTH(` + ColName + `) BETWEEN 1 AND 256),
+		` + ColMode + ` VARCHAR(32) NOT NULL CHECK (LENGTH(` + ColMode + `) <= 32),
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL,
+		UNIQUE (` + ColUserID + `, ` + ColName + `)
+	);
+	`
+	TblDescPrices = `
+	CREATE TABLE IF NOT EXISTS ` + TblPrices + ` (
+		` + ColID + ` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL CHECK (` + ColID + `>0),
+		` + ColValue + ` REAL NOT NULL CHECK (` + ColValue + ` >= 0),
+		` + ColCurrency + ` VARCHAR(3) NOT NULL CHECK (LENGTH(` + ColCurrency + `) = 3),
+		` + ColBrandID + ` INTEGER NOT NULL REFERENCES ` + TblBrands + ` (` + ColID + `),
+		` + ColStoreBrID + ` INTEGER REFERENCES ` + TblStoreBranches + ` (` + ColID + `),
+		` + ColUserID + ` VARCHAR(256) NOT NULL CHECK (LENGTH(` + ColUserID + `) BETWEEN 1 AND 256),
+		` + ColStatus + ` VARCHAR(16) NOT NULL CHECK (LENGTH(` + ColStatus + `) BETWEEN 1 AND 16),
+		` + ColOutlierSc + ` REAL NOT NULL DEFAULT 0,
+		` + ColConfirms + ` INTEGER NOT NULL DEFAULT 0 CHECK (` + ColConfirms + ` >= 0),
+		` + ColContradics + ` INTEGER NOT NULL DEFAULT 0 CHECK (` + ColContradics + ` >= 0),
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL
+	);
+	`
+	TblDescPriceVerdicts = `
+	CREATE TABLE IF NOT EXISTS ` + TblPriceVerdicts + ` (
+		` + ColPriceID + ` INTEGER NOT NULL REFERENCES ` + TblPrices + ` (` + ColID + `),
+		` + ColJudgedID + ` INTEGER NOT NULL REFERENCES ` + TblPrices + ` (` + ColID + `),
+		` + ColConfirming + ` BOOLEAN NOT NULL,
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		PRIMARY KEY (` + ColPriceID + `, ` + ColJudgedID + `, ` + ColConfirming + `)
+	);
+	`
+	TblDescShopListItems = `
+	CREATE TABLE IF NOT EXISTS ` + TblShopListItems + ` (
+		` + ColID + ` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL CHECK (` + ColID + `>0),
+		` + ColShopListID + ` INTEGER NOT NULL REFERENCES ` + TblShoppingLists + ` (` + ColID + `),
+		` + ColBrandID + ` INTEGER NOT NULL REFERENCES ` + TblBrands + ` (` + ColID + `),
+		` + ColPriceID + ` INTEGER REFERENCES ` + TblPrices + ` (` + ColID + `),
+		` + ColQuantity + ` INTEGER NOT NULL CHECK (` + ColQuantity + ` >= 0),
+		` + ColInList + ` BOOLEAN NOT NULL,
+		` + ColInCart + ` BOOLEAN NOT NULL,
+		` + ColCreateDate + ` TIMESTAMP NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL,
+		UNIQUE (` + ColShopListID + `, ` + ColBrandID + `)
+	);
+	`
+	TblDescRateLimits = `
+	CREATE TABLE IF NOT EXISTS ` + TblRateLimits + ` (
+		` + ColKey + ` VARCHAR(320) PRIMARY KEY NOT NULL CHECK (LENGTH(` + ColKey + `) BETWEEN 1 AND 320),
+		` + ColTokens + ` REAL NOT NULL,
+		` + ColUpdateDate + ` TIMESTAMP NOT NULL
+	);
+	`
+
+	// CREATE INDEX DESCRIPTIONS
+	IdxDescAPIKeysPrefix = `
+	CREATE INDEX IF NOT EXISTS ` + IdxAPIKeysPrefix + ` ON ` + TblAPIKeys + ` (` + ColUserID + `, ` + ColKeyPrefix + `);
+	`
+	IdxDescStoreBranchesLocation = `
+	CREATE INDEX IF NOT EXISTS ` + IdxStoreBranchesLocation + ` ON ` + TblStoreBranches + ` (` + ColStoreID + `, ` + ColLatitude + `, ` + ColLongitude + `);
+	`
+	IdxDescBarcodesBrand = `
+	CREATE INDEX IF NOT EXISTS ` + IdxBarcodesBrand + ` ON ` + TblBarcodes + ` (` + ColBrandID + `);
+	`
+	IdxDescPricesBrandStatus = `
+	CREATE INDEX IF NOT EXISTS ` + IdxPricesBrandStatus + ` ON ` + TblPrices + ` (` + ColBrandID + `, ` + ColStatus + `, ` + ColCreateDate + `);
+	`
+	IdxDescPricesStatus = `
+	CREATE INDEX IF NOT EXISTS ` + IdxPricesStatus + ` ON ` + TblPrices + ` (` + ColStatus + `, ` + ColCreateDate + `);
+	`
+	IdxDescPricesUser = `
+	CREATE INDEX IF NOT EXISTS ` + IdxPricesUser + ` ON ` + TblPrices + ` (` + ColUserID + `);
+	`
+	IdxDescShopListItemsBrand = `
+	CREATE INDEX IF NOT EXISTS ` + IdxShopListItemsBrand + ` ON ` + TblShopListItems + ` (` + ColBrandID + `);
+	`
+	IdxDescShopListItemsPrice = `
+	CREATE INDEX IF NOT EXISTS ` + IdxShopListItemsPrice + ` ON ` + TblShopListItems + ` (` + ColPriceID + `);
+	`
+)
+
+// AllTableDescs lists all CREATE TABLE DESCRIPTIONS in order of dependency
+// (tables with foreign key references listed after parent table descriptions)
+// followed by the CREATE INDEX DESCRIPTIONS.
+var AllTableDescs = []string{
+	TblDescConfigurations,
+	TblDescAPIKeys,
+	TblDescStores,
+	TblDescStoreBranches,
+	TblDescItems,
+	TblDescMeasuringUnits,
+	TblDescBrands,
+	TblDescBarcodes,
+	TblDescAliases,
+	TblDescShoppingLists,
+	TblDescPrices,
+	TblDescPriceVerdicts,
+	TblDescShopListItems,
+	TblDescRateLimits,
+	IdxDescAPIKeysPrefix,
+	IdxDescStoreBranchesLocation,
+	IdxDescBarcodesBrand,
+	IdxDescPricesBrandStatus,
+	IdxDescPricesStatus,
+	IdxDescPricesUser,
+	IdxDescShopListItemsBrand,
+	IdxDescShopListItemsPrice,
+}
+
+// AllTableNames lists all table names in order of dependency
+// (tables with foreign key references listed after parent table descriptions).
+var AllTableNames = roach.AllTableNames
+
+// ColDesc returns a string containing cols in the given order separated by ",".
+func ColDesc(cols ...string) string {
+	return roach.ColDesc(cols...)
+}
@@ -0,0 +1,202 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/db/sqlite"
+	"github.com/tomogoma/shoppingms/pkg/db/storagetest"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestSQLite_InitDBIfNot(t *testing.T) {
+
+	tt := []struct {
+		name       string
+		hasVersion bool
+		version    []byte
+		expErr     bool
+	}{
+		{
+			name:       "first use",
+			hasVersion: false,
+			expErr:     false,
+		},
+		{
+			name:       "versions equal",
+			hasVersion: true,
+			version:    []byte(strconv.Itoa(sqlite.Version)),
+			expErr:     false,
+		},
+		{
+			name:       "previous version",
+			hasVersion: true,
+			version:    []byte(strconv.Itoa(sqlite.Version - 1)),
+			expErr:     false,
+		},
+		{
+			name:       "db version smaller than migratable",
+			hasVersion: true,
+			version:    []byte(strconv.Itoa(-1)),
+			expErr:     true,
+		},
+		{
+			name:       "db version bigger",
+			hasVersion: true,
+			version:    []byte(strconv.Itoa(sqlite.Version + 1)),
+			expErr:     true,
+		},
+	}
+
+	cols := sqlite.ColDesc(sqlite.ColKey, sqlite.ColValue, sqlite.ColCreateDate,
+		sqlite.ColUpdateDate)
+	updCols := sqlite.ColDesc(sqlite.ColValue, sqlite.ColUpdateDate)
+	upsertQ := `
+		INSERT INTO ` + sqlite.TblConfigurations + ` (` + cols + `)
+			VALUES ('db.version', $1, $2, $2)
+			ON CONFLICT (` + sqlite.ColKey + `)
+			DO UPDATE SET (` + updCols + `) = ($1, $2)`
+	delQ := `
+		DELETE FROM ` + sqlite.TblConfigurations + `
+			WHERE ` + sqlite.ColKey + `='db.version'`
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+			s := sqlite.NewSQLite(sqlite.WithPath(path))
+			defer s.Close()
+			if err := s.InitDBIfNot(); err != nil {
+				t.Fatalf("Error setting up: initial init: %v", err)
+			}
+			s.Close()
+			db := getDB(t, path)
+			defer db.Close()
+			if _, err := db.Exec(delQ); err != nil {
+				t.Fatalf("Error setting up: clear previous config: %v", err)
+			}
+			if tc.hasVersion {
+				if _, err := db.Exec(upsertQ, tc.version, time.Now().UTC()); err != nil {
+					t.Fatalf("Error setting up: insert test config: %v", err)
+				}
+			}
+
+			s = sqlite.NewSQLite(sqlite.WithPath(path))
+			defer s.Close()
+			err := s.InitDBIfNot()
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				// set db to have correct version (init error should be cached not queried)
+				if _, err := db.Exec(upsertQ, []byte(strconv.Itoa(sqlite.Version)), time.Now().UTC()); err != nil {
+					t.Fatalf("Error setting up: insert test config: %v", err)
+				}
+				if err := s.InitDBIfNot(); err == nil {
+					t.Fatalf("Subsequent init db not returning error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got an error: %v", err)
+			}
+			// set db to have incorrect version (isInit flag should be cached, not queried)
+			if _, err := db.Exec(upsertQ, []byte(strconv.Itoa(sqlite.Version+10)), time.Now().UTC()); err != nil {
+				t.Fatalf("Error setting up: insert test config: %v", err)
+			}
+			if err = s.InitDBIfNot(); err != nil {
+				t.Fatalf("Subsequent init not working")
+			}
+		})
+	}
+}
+
+func TestSQLite_SchemaVersion(t *testing.T) {
+	s := newSQLite(t)
+	defer s.Close()
+	if err := s.InitDBIfNot(); err != nil {
+		t.Fatalf("Error setting up: init db: %v", err)
+	}
+
+	if err := s.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: got error: %v", err)
+	}
+	v, err := s.SchemaVersion(context.Background())
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if v != sqlite.Version {
+		t.Errorf("Expected schema version %d, got %d", sqlite.Version, v)
+	}
+}
+
+func TestSQLite_WithoutMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	s := sqlite.NewSQLite(sqlite.WithPath(path), sqlite.WithoutMigration())
+	defer s.Close()
+	if _, err := s.UpsertStore("Corner Shop"); err == nil {
+		t.Fatalf("Expected an error before the DB is set up, got nil")
+	}
+	if v, err := s.SchemaVersion(context.Background()); err == nil {
+		t.Fatalf("Expected the DB to be left unset up, got version %d", v)
+	}
+
+	migrator := sqlite.NewSQLite(sqlite.WithPath(path))
+	defer migrator.Close()
+	if err := migrator.InitDBIfNot(); err != nil {
+		t.Fatalf("Error setting up: init db: %v", err)
+	}
+
+	s = sqlite.NewSQLite(sqlite.WithPath(path), sqlite.WithoutMigration())
+	defer s.Close()
+	if _, err := s.UpsertStore("Corner Shop"); err != nil {
+		t.Errorf("Expected to use the set up DB, got %v", err)
+	}
+}
+
+func TestSQLite_reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "test.db")
+	s := sqlite.NewSQLite(sqlite.WithPath(path))
+	st, err := s.UpsertStore("Corner Shop")
+	if err != nil {
+		t.Fatalf("Error setting up: upsert store: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	s = sqlite.NewSQLite(sqlite.WithPath(path))
+	defer s.Close()
+	got, err := s.UpsertStore("Corner Shop")
+	if err != nil {
+		t.Fatalf("UpsertStore() after reopening: %v", err)
+	}
+	if got.ID != st.ID {
+		t.Errorf("Expected store %+v to persist, got %+v", st, got)
+	}
+}
+
+func TestSQLite_storage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) (shopping.Storage, func()) {
+		return newSQLite(t), func() {}
+	})
+}
+
+func newSQLite(t *testing.T) *sqlite.SQLite {
+	s := sqlite.NewSQLite(sqlite.WithPath(filepath.Join(t.TempDir(), "test.db")))
+	if s == nil {
+		t.Fatalf("Got nil SQLite")
+	}
+	return s
+}
+
+func getDB(t *testing.T, path string) *sql.DB {
+	DB, err := sql.Open("sqlite", "file:"+path+"?_time_format=sqlite")
+	if err != nil {
+		t.Fatalf("new db instance: %s", err)
+	}
+	return DB
+}
@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// UpsertStore inserts a store with name if one does not already exist and
+// returns the stored value.
+func (s *SQLite) UpsertStore(name string) (*shopping.Store, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(context.Background(), "UpsertStore")()
+	insCols := ColDesc(ColName, ColCreateDate, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblStores + ` (` + insCols + `)
+			VALUES ($1, $2, $2)
+			ON CONFLICT (` + ColName + `)
+			DO UPDATE SET ` + ColUpdateDate + ` = $2
+			RETURNING ` + ColDesc(ColID, ColName)
+	st := shopping.Store{}
+	if err := s.db.QueryRow(q, name, now()).Scan(&st.ID, &st.Name); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// InsertStoreBranch inserts sb and returns it with its ID assigned.
+// sb.Store.ID must reference an existing store.
+func (s *SQLite) InsertStoreBranch(sb shopping.StoreBranch) (*shopping.StoreBranch, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(context.Background(), "InsertStoreBranch")()
+	insCols := ColDesc(ColStoreID, ColName, ColLatitude, ColLongitude,
+		ColOSMID, ColCreateDate, ColUpdateDate)
+	q := `
+		INSERT INTO ` + TblStoreBranches + ` (` + insCols + `)
+			VALUES ($1, $2, $3, $4, $5, $6, $6)
+			RETURNING ` + ColID
+	err := s.db.QueryRow(q, sb.Store.ID, sb.Name, sb.Location.Latitude,
+		sb.Location.Longitude, nullString(sb.OSMID), now()).Scan(&sb.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &sb, nil
+}
+
+// UpdateStoreBranch overwrites the name, location and OSMID of the store
+// branch with sb.ID.
+func (s *SQLite) UpdateStoreBranch(sb shopping.StoreBranch) error {
+	if err := s.InitDBIfNot(); err != nil {
+		return err
+	}
+	defer s.observeQuery(context.Background(), "UpdateStoreBranch")()
+	updCols := ColDesc(ColName, ColLatitude, ColLongitude, ColOSMID, ColUpdateDate)
+	q := `
+		UPDATE ` + TblStoreBranches + `
+			SET (` + updCols + `) = ($1, $2, $3, $4, $5)
+			WHERE ` + ColID + `=$6`
+	res, err := s.db.Exec(q, sb.Name, sb.Location.Latitude,
+		sb.Location.Longitude, nullString(sb.OSMID), now(), sb.ID)
+	return checkRowsAffected(res, err, 1)
+}
+
+// StoreBranchByOSMID returns the store branch that was imported from the
+// OpenStreetMap element osmID.
+func (s *SQLite) StoreBranchByOSMID(osmID string) (*shopping.StoreBranch, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(context.Background(), "StoreBranchByOSMID")()
+	q := selectStoreBranchesQ + ` WHERE sb.` + ColOSMID + `=$1`
+	sbs, err := s.queryStoreBranches(q, osmID)
+	if err != nil {
+		return nil, err
+	}
+	return &sbs[0], nil
+}
+
+// StoreBranchesWithin returns all branches of the store with storeID that
+// lie inside the box bounded by the south-west (sw) and north-east (ne)
+// corners.
+func (s *SQLite) StoreBranchesWithin(storeID string, sw, ne shopping.Location) ([]shopping.StoreBranch, error) {
+	if err := s.InitDBIfNot(); err != nil {
+		return nil, err
+	}
+	defer s.observeQuery(context.Background(), "StoreBranchesWithin")()
+	q := selectStoreBranchesQ + `
+		WHERE sb.` + ColStoreID + `=$1
+			AND sb.` + ColLatitude + ` BETWEEN $2 AND $3
+			AND sb.` + ColLongitude + ` BETWEEN $4 AND $5`
+	return s.queryStoreBranches(q, storeID, sw.Latitude, ne.Latitude,
+		sw.Longitude, ne.Longitude)
+}
+
+var selectStoreBranchesQ = `
+	SELECT sb.` + ColID + `, sb.` + ColName + `, sb.` + ColLatitude + `,
+			sb.` + ColLongitude + `, sb.` + ColOSMID + `,
+			s.` + ColID + `, s.` + ColName + `
+		FROM ` + TblStoreBranches + ` AS sb
+		INNER JOIN ` + TblStores + ` AS s ON s.` + ColID + `=sb.` + ColStoreID
+
+func (s *SQLite) queryStoreBranches(q string, args ...interface{}) ([]shopping.StoreBranch, error) {
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sbs []shopping.StoreBranch
+	for rows.Next() {
+		sb := shopping.StoreBranch{}
+		var osmID sql.NullString
+		err := rows.Scan(&sb.ID, &sb.Name, &sb.Location.Latitude,
+			&sb.Location.Longitude, &osmID, &sb.Store.ID, &sb.Store.Name)
+		if err != nil {
+			return nil, errors.Newf("scan result set row: %v", err)
+		}
+		sb.OSMID = osmID.String
+		sbs = append(sbs, sb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Newf("iterate result set: %v", err)
+	}
+	if len(sbs) == 0 {
+		return nil, errors.NewNotFound("no store branches found")
+	}
+	return sbs, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,1086 @@
+// Package storagetest tests implementations of shopping.Storage against the
+// behaviour expected of every storage backend.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/crdb"
+	apiG "github.com/tomogoma/go-api-guard"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// NewStorageFunc returns an empty Storage for a test together with a func
+// that releases it once the test is done.
+type NewStorageFunc func(t *testing.T) (s shopping.Storage, tearDown func())
+
+// Run runs the storage tests against the Storages returned by newStorage,
+// a new one per test.
+func Run(t *testing.T, newStorage NewStorageFunc) {
+	tt := []struct {
+		name string
+		test func(t *testing.T, s shopping.Storage)
+	}{
+		{name: "shopping lists", test: testShoppingLists},
+		{name: "shopping list items", test: testShoppingListItems},
+		{name: "upsert brands", test: testUpsertBrands},
+		{name: "merge items", test: testMergeItems},
+		{name: "merge brands", test: testMergeBrands},
+		{name: "prices", test: testPrices},
+		{name: "contributors", test: testContributors},
+		{name: "rejected verdicts", test: testRejectedVerdicts},
+		{name: "stores", test: testStores},
+		{name: "store branches within", test: testStoreBranchesWithin},
+		{name: "API keys", test: testAPIKeys},
+		{name: "API key policy", test: testAPIKeyPolicy},
+		{name: "API key with policy", test: testAPIKeyWithPolicy},
+		{name: "touch API key", test: testTouchAPIKey},
+		{name: "rate limits", test: testRateLimits},
+		{name: "transactions", test: testTransactions},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s, tearDown := newStorage(t)
+			defer tearDown()
+			defer s.Close()
+			if err := s.Ping(context.Background()); err != nil {
+				t.Fatalf("Ping(): %v", err)
+			}
+			tc.test(t, s)
+		})
+	}
+}
+
+func testShoppingLists(t *testing.T, s shopping.Storage) {
+	sl, err := s.UpsertShoppingList(context.Background(), shopping.ShoppingList{
+		UserID: "usr1",
+		Name:   "Groceries",
+		Mode:   shopping.ModePreparation,
+	})
+	if err != nil {
+		t.Fatalf("UpsertShoppingList(): %v", err)
+	}
+	if sl.ID == "" || sl.Created == "" {
+		t.Errorf("Expected ID and created date, got %+v", sl)
+	}
+	dup, err := s.UpsertShoppingList(context.Background(), shopping.ShoppingList{
+		UserID: "usr1",
+		Name:   "Groceries",
+		Mode:   shopping.ModeShopping,
+	})
+	if err != nil {
+		t.Fatalf("UpsertShoppingList() duplicate: %v", err)
+	}
+	if dup.ID != sl.ID || dup.Mode != shopping.ModePreparation {
+		t.Errorf("Expected existing list %+v, got %+v", sl, dup)
+	}
+	if _, err := s.UpsertShoppingList(context.Background(), shopping.ShoppingList{UserID: "usr1"}); err == nil {
+		t.Errorf("Expected an error for a list without a name, got nil")
+	}
+
+	_, err = s.UpdateShoppingList(context.Background(), "usr1", sl.ID, crdb.StringUpdate{}, crdb.StringUpdate{})
+	if err == nil {
+		t.Errorf("Expected an error with nothing to update, got nil")
+	}
+	upd, err := s.UpdateShoppingList(context.Background(), "usr1", sl.ID, crdb.StringUpdate{},
+		crdb.StringUpdate{Updating: true, NewVal: shopping.ModeShopping})
+	if err != nil {
+		t.Fatalf("UpdateShoppingList(): %v", err)
+	}
+	if upd.Name != sl.Name || upd.Mode != shopping.ModeShopping {
+		t.Errorf("Expected only mode updated, got %+v", upd)
+	}
+	_, err = s.UpdateShoppingList(context.Background(), "usr2", sl.ID, crdb.StringUpdate{},
+		crdb.StringUpdate{Updating: true, NewVal: shopping.ModeShopping})
+	if !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for another user's list, got %v", err)
+	}
+
+	other, err := s.UpsertShoppingList(context.Background(), shopping.ShoppingList{
+		UserID: "usr1",
+		Name:   "Hardware",
+		Mode:   shopping.ModePreparation,
+	})
+	if err != nil {
+		t.Fatalf("UpsertShoppingList() other: %v", err)
+	}
+	_, err = s.UpdateShoppingList(context.Background(), "usr1", other.ID,
+		crdb.StringUpdate{Updating: true, NewVal: sl.Name}, crdb.StringUpdate{})
+	if err == nil {
+		t.Errorf("Expected an error renaming a list to an existing name, got nil")
+	}
+
+	sls, err := s.ShoppingLists(context.Background(), "usr1", 0, 10)
+	if err != nil {
+		t.Fatalf("ShoppingLists(): %v", err)
+	}
+	if len(sls) != 2 || sls[0].ID != other.ID || sls[1].ID != sl.ID {
+		t.Errorf("Expected lists %s then %s, got %+v", other.ID, sl.ID, sls)
+	}
+	sls, err = s.ShoppingLists(context.Background(), "usr1", 1, 10)
+	if err != nil {
+		t.Fatalf("ShoppingLists() offset: %v", err)
+	}
+	if len(sls) != 1 || sls[0].ID != sl.ID {
+		t.Errorf("Expected only list %s, got %+v", sl.ID, sls)
+	}
+	_, err = s.ShoppingLists(context.Background(), "usr2", 0, 10)
+	if !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for user without lists, got %v", err)
+	}
+
+	got, err := s.ShoppingList(context.Background(), "usr1", sl.ID)
+	if err != nil {
+		t.Fatalf("ShoppingList(): %v", err)
+	}
+	if got.ID != sl.ID || got.Name != sl.Name || got.Mode != shopping.ModeShopping {
+		t.Errorf("Expected list %+v, got %+v", upd, got)
+	}
+	_, err = s.ShoppingList(context.Background(), "usr2", sl.ID)
+	if !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for another user's list, got %v", err)
+	}
+}
+
+func testShoppingListItems(t *testing.T, s shopping.Storage) {
+	brand := insertBrand(t, s)
+	price := insertPrice(t, s, brand, "", 200, shopping.PriceStatusApproved)
+	sl, err := s.UpsertShoppingList(context.Background(), shopping.ShoppingList{
+		UserID: "usr1",
+		Name:   "Groceries",
+		Mode:   shopping.ModePreparation,
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: upsert shopping list: %v", err)
+	}
+
+	item, err := s.UpsertShoppingListItem(context.Background(), "usr1", shopping.ShoppingListItem{
+		Quantity:     2,
+		InList:       true,
+		ShoppingList: *sl,
+		Price:        *price,
+	})
+	if err != nil {
+		t.Fatalf("UpsertShoppingListItem(): %v", err)
+	}
+	if item.Price.ID != price.ID || item.Price.Brand.Item.Name != brand.Item.Name {
+		t.Errorf("Expected price %s of %+v, got %+v", price.ID, brand, item.Price)
+	}
+
+	// Upserting the same brand without a price keeps the item's price.
+	upd, err := s.UpsertShoppingListItem(context.Background(), "usr1", shopping.ShoppingListItem{
+		Quantity:     3,
+		InList:       true,
+		InCart:       true,
+		ShoppingList: *sl,
+		Price:        shopping.Price{Brand: brand},
+	})
+	if err != nil {
+		t.Fatalf("UpsertShoppingListItem() update: %v", err)
+	}
+	if upd.ID != item.ID || upd.Quantity != 3 || !upd.InCart || upd.Price.ID != price.ID {
+		t.Errorf("Expected item %s updated with price kept, got %+v", item.ID, upd)
+	}
+
+	_, err = s.UpsertShoppingListItem(context.Background(), "usr2", shopping.ShoppingListItem{
+		Quantity:     1,
+		ShoppingList: *sl,
+		Price:        shopping.Price{Brand: brand},
+	})
+	if !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for another user's list, got %v", err)
+	}
+	_, err = s.UpsertShoppingListItem(context.Background(), "usr1", shopping.ShoppingListItem{
+		Quantity:     -1,
+		ShoppingList: *sl,
+		Price:        shopping.Price{Brand: brand},
+	})
+	if err == nil {
+		t.Errorf("Expected an error for a negative quantity, got nil")
+	}
+
+	items, err := s.ShoppingListItems(context.Background(), "usr1", sl.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("ShoppingListItems(): %v", err)
+	}
+	if len(items) != 1 || items[0].ID != item.ID || items[0].Quantity != 3 {
+		t.Errorf("Expected only item %s, got %+v", item.ID, items)
+	}
+	_, err = s.ShoppingListItems(context.Background(), "usr2", sl.ID, 0, 10)
+	if !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for another user's list, got %v", err)
+	}
+
+	found, err := s.SearchShoppingListItems(context.Background(), "usr1",
+		shopping.ItemSearch{ItemName: "TOOTH"}, 0, 10)
+	if err != nil {
+		t.Fatalf("SearchShoppingListItems(): %v", err)
+	}
+	if len(found) != 1 || found[0].ID != item.ID {
+		t.Errorf("Expected only item %s found, got %+v", item.ID, found)
+	}
+	_, err = s.SearchShoppingListItems(context.Background(), "usr1",
+		shopping.ItemSearch{ItemName: "%"}, 0, 10)
+	if !s.IsNotFoundError(err) {
+		t.Errorf("Expected wildcards to be matched literally, got %v", err)
+	}
+
+	if err := s.DeleteShoppingListItem(context.Background(), "usr2", item.ID); !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error deleting another user's item, got %v", err)
+	}
+	if err := s.DeleteShoppingListItem(context.Background(), "usr1", item.ID); err != nil {
+		t.Fatalf("DeleteShoppingListItem(): %v", err)
+	}
+	_, err = s.ShoppingListItems(context.Background(), "usr1", sl.ID, 0, 10)
+	if !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error on empty list, got %v", err)
+	}
+}
+
+func testUpsertBrands(t *testing.T, s shopping.Storage) {
+	brands := []shopping.Brand{
+		{
+			Name:          "Colgate",
+			Item:          shopping.Item{Name: "Toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+			Barcodes:      []string{"8718951065545"},
+		},
+		{
+			Name:          " colgate ",
+			Item:          shopping.Item{Name: "toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ML"},
+			Barcodes:      []string{"8718951065546"},
+		},
+	}
+	first, err := s.UpsertBrands(context.Background(), brands)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(first) != len(brands) {
+		t.Fatalf("Expected %d brands, got %d", len(brands), len(first))
+	}
+	if first[0].ID == "" || first[0].Item.ID == "" || first[0].MeasuringUnit.ID == "" {
+		t.Fatalf("IDs were not assigned: %+v", first[0])
+	}
+	if first[1].ID != first[0].ID {
+		t.Errorf("Expected names differing in case and space to share a brand ID")
+	}
+	second, err := s.UpsertBrands(context.Background(), brands[:1])
+	if err != nil {
+		t.Fatalf("Got error on repeat upsert: %v", err)
+	}
+	if second[0].ID != first[0].ID {
+		t.Errorf("Expected repeat upsert to yield brand ID %s, got %s",
+			first[0].ID, second[0].ID)
+	}
+	_, err = s.UpsertBrands(context.Background(), []shopping.Brand{{Name: "No item"}})
+	if err == nil {
+		t.Errorf("Expected an error for a brand without item, got nil")
+	}
+
+	items, err := s.Items(context.Background())
+	if err != nil {
+		t.Fatalf("Items(): %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "Toothpaste" {
+		t.Errorf("Expected only item Toothpaste, got %+v", items)
+	}
+	all, err := s.Brands(context.Background())
+	if err != nil {
+		t.Fatalf("Brands(): %v", err)
+	}
+	if len(all) != 1 || all[0].ID != first[0].ID || all[0].MeasuringUnit.Name != "100 ml" {
+		t.Errorf("Expected only brand %+v, got %+v", first[0], all)
+	}
+}
+
+func testMergeItems(t *testing.T, s shopping.Storage) {
+	brands, err := s.UpsertBrands(context.Background(), []shopping.Brand{
+		{
+			Name:          "Colgate",
+			Item:          shopping.Item{Name: "Toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+		},
+		{
+			Name:          "Colgate",
+			Item:          shopping.Item{Name: "Tooth paste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+		},
+		{
+			Name:          "Sensodyne",
+			Item:          shopping.Item{Name: "Tooth paste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: upsert brands: %v", err)
+	}
+	survivor, dupBrand, movedBrand := brands[0], brands[1], brands[2]
+	price := insertPrice(t, s, dupBrand, "", 200, shopping.PriceStatusApproved)
+
+	if _, err := s.MergeItems(context.Background(), survivor.Item.ID, []string{"999999"}); !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for a missing duplicate, got %v", err)
+	}
+
+	merged, err := s.MergeItems(context.Background(), survivor.Item.ID, []string{dupBrand.Item.ID})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if merged.ID != survivor.Item.ID || merged.Name != survivor.Item.Name {
+		t.Errorf("Expected survivor %+v, got %+v", survivor.Item, merged)
+	}
+	items, err := s.Items(context.Background())
+	if err != nil {
+		t.Fatalf("Items(): %v", err)
+	}
+	if len(items) != 1 || items[0].ID != survivor.Item.ID {
+		t.Errorf("Expected only item %s left, got %+v", survivor.Item.ID, items)
+	}
+	all, err := s.Brands(context.Background())
+	if err != nil {
+		t.Fatalf("Brands(): %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected the duplicate brand merged, got %+v", all)
+	}
+	for _, b := range all {
+		if b.Item.ID != survivor.Item.ID {
+			t.Errorf("Expected brand %s moved to item %s, got %+v", movedBrand.ID,
+				survivor.Item.ID, b)
+		}
+	}
+	got, err := s.PriceByID(context.Background(), price.ID)
+	if err != nil {
+		t.Fatalf("PriceByID(): %v", err)
+	}
+	if got.Brand.ID != survivor.ID {
+		t.Errorf("Expected price moved to brand %s, got %+v", survivor.ID, got.Brand)
+	}
+
+	// The merged name should now resolve to the survivor.
+	again, err := s.UpsertBrands(context.Background(), []shopping.Brand{movedBrand})
+	if err != nil {
+		t.Fatalf("Got error re-inserting brand of merged item: %v", err)
+	}
+	if again[0].ID != movedBrand.ID || again[0].Item.ID != survivor.Item.ID {
+		t.Errorf("Expected alias to resolve to item %s, got %+v", survivor.Item.ID, again[0])
+	}
+}
+
+func testMergeBrands(t *testing.T, s shopping.Storage) {
+	brands, err := s.UpsertBrands(context.Background(), []shopping.Brand{
+		{
+			Name:          "Colgate",
+			Item:          shopping.Item{Name: "Toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+		},
+		{
+			Name:          "Colgate Ltd",
+			Item:          shopping.Item{Name: "Toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+			Barcodes:      []string{"8718951065545"},
+		},
+		{
+			Name:          "Colgate Ltd",
+			Item:          shopping.Item{Name: "Toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "50 ml"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: upsert brands: %v", err)
+	}
+	survivor, dup, otherUnit := brands[0], brands[1], brands[2]
+
+	sl, err := s.UpsertShoppingList(context.Background(), shopping.ShoppingList{
+		UserID: "usr1",
+		Name:   "Groceries",
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: upsert shopping list: %v", err)
+	}
+	for _, b := range []shopping.Brand{survivor, dup} {
+		_, err := s.UpsertShoppingListItem(context.Background(), "usr1", shopping.ShoppingListItem{
+			Quantity:     1,
+			ShoppingList: *sl,
+			Price:        shopping.Price{Brand: b},
+		})
+		if err != nil {
+			t.Fatalf("Error setting up: upsert shopping list item: %v", err)
+		}
+	}
+
+	if _, err := s.MergeBrands(context.Background(), survivor.ID, []string{otherUnit.ID}); err == nil {
+		t.Fatalf("Expected an error merging brands of different units, got nil")
+	}
+
+	merged, err := s.MergeBrands(context.Background(), survivor.ID, []string{dup.ID})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if merged.ID != survivor.ID {
+		t.Errorf("Expected survivor ID %s, got %s", survivor.ID, merged.ID)
+	}
+	items, err := s.ShoppingListItems(context.Background(), "usr1", sl.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("ShoppingListItems(): %v", err)
+	}
+	if len(items) != 1 || items[0].Price.Brand.ID != survivor.ID {
+		t.Errorf("Expected the duplicate's list item dropped, got %+v", items)
+	}
+
+	// The merged name should now resolve to the survivor.
+	again, err := s.UpsertBrands(context.Background(), []shopping.Brand{dup})
+	if err != nil {
+		t.Fatalf("Got error re-inserting merged brand: %v", err)
+	}
+	if again[0].ID != survivor.ID {
+		t.Errorf("Expected alias to resolve to brand %s, got %s", survivor.ID, again[0].ID)
+	}
+}
+
+func testPrices(t *testing.T, s shopping.Storage) {
+	brand := insertBrand(t, s)
+	sb := insertStoreBranch(t, s, "node/1", shopping.Location{Latitude: -1.28, Longitude: 36.82})
+
+	approved := insertPrice(t, s, brand, sb.ID, 200, shopping.PriceStatusApproved)
+	insertPrice(t, s, brand, "", 210, shopping.PriceStatusApproved)
+	pending := insertPrice(t, s, brand, sb.ID, 20000, shopping.PriceStatusPending)
+
+	if approved.Brand.Item.Name != brand.Item.Name || approved.AtStoreBranch.Store.Name == "" {
+		t.Errorf("Expected brand and store branch details, got %+v", approved)
+	}
+	for _, p := range []shopping.Price{
+		{Value: -1, Currency: "KES", Brand: brand, SubmittedBy: "usr1", Status: shopping.PriceStatusApproved},
+		{Value: 1, Currency: "KSH.", Brand: brand, SubmittedBy: "usr1", Status: shopping.PriceStatusApproved},
+		{Value: 1, Currency: "KES", Brand: shopping.Brand{ID: "999999"}, SubmittedBy: "usr1", Status: shopping.PriceStatusApproved},
+	} {
+		if _, err := s.InsertPrice(context.Background(), p, shopping.Verdicts{}); err == nil {
+			t.Errorf("Expected an error inserting %+v, got nil", p)
+		}
+	}
+
+	since := time.Now().Add(-time.Hour)
+	recent, err := s.RecentApprovedPrices(context.Background(), brand.ID, sb.ID, "KES", since, 10)
+	if err != nil {
+		t.Fatalf("RecentApprovedPrices() at branch: %v", err)
+	}
+	if len(recent) != 1 || recent[0].ID != approved.ID {
+		t.Errorf("Expected only approved price %s at branch, got %+v", approved.ID, recent)
+	}
+	recent, err = s.RecentApprovedPrices(context.Background(), brand.ID, "", "KES", since, 10)
+	if err != nil {
+		t.Fatalf("RecentApprovedPrices() at any branch: %v", err)
+	}
+	if len(recent) != 2 {
+		t.Errorf("Expected 2 approved prices at any branch, got %+v", recent)
+	}
+
+	queue, err := s.PricesByStatus(context.Background(), shopping.PriceStatusPending, 0, 10)
+	if err != nil {
+		t.Fatalf("PricesByStatus(): %v", err)
+	}
+	if len(queue) != 1 || queue[0].ID != pending.ID {
+		t.Errorf("Expected only price %s pending, got %+v", pending.ID, queue)
+	}
+
+	rejected, err := s.UpdatePriceStatus(context.Background(), pending.ID,
+		shopping.PriceStatusRejected, shopping.Verdicts{})
+	if err != nil {
+		t.Fatalf("UpdatePriceStatus(): %v", err)
+	}
+	if rejected.Status != shopping.PriceStatusRejected {
+		t.Errorf("Expected status %s, got %s", shopping.PriceStatusRejected, rejected.Status)
+	}
+	_, err = s.PricesByStatus(context.Background(), shopping.PriceStatusPending, 0, 10)
+	if !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error on empty queue, got %v", err)
+	}
+	_, err = s.UpdatePriceStatus(context.Background(), "999999",
+		shopping.PriceStatusApproved, shopping.Verdicts{})
+	if !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for missing price, got %v", err)
+	}
+}
+
+func testContributors(t *testing.T, s shopping.Storage) {
+	brand := insertBrand(t, s)
+	p1 := insertPrice(t, s, brand, "", 200, shopping.PriceStatusApproved)
+	p2 := insertPrice(t, s, brand, "", 300, shopping.PriceStatusApproved)
+	p3 := insertPrice(t, s, brand, "", 250, shopping.PriceStatusPending)
+
+	_, err := s.UpdatePriceStatus(context.Background(), p3.ID, shopping.PriceStatusApproved,
+		shopping.Verdicts{Confirmed: []string{p1.ID, p2.ID}, Contradicted: []string{p1.ID}})
+	if err != nil {
+		t.Fatalf("UpdatePriceStatus() with verdicts: %v", err)
+	}
+	got, err := s.PriceByID(context.Background(), p1.ID)
+	if err != nil {
+		t.Fatalf("PriceByID(): %v", err)
+	}
+	if got.Confirmations != 1 || got.Contradictions != 1 {
+		t.Errorf("Expected 1 confirmation and 1 contradiction, got %+v", got)
+	}
+
+	// A second approval e.g. by a concurrent moderator records nothing.
+	again, err := s.UpdatePriceStatus(context.Background(), p3.ID, shopping.PriceStatusApproved,
+		shopping.Verdicts{Confirmed: []string{p1.ID, p2.ID}, Contradicted: []string{p1.ID}})
+	if err != nil {
+		t.Fatalf("UpdatePriceStatus() approving again: %v", err)
+	}
+	if again.ID != p3.ID || again.Status != shopping.PriceStatusApproved {
+		t.Errorf("Expected approved price %s, got %+v", p3.ID, again)
+	}
+	if got, err = s.PriceByID(context.Background(), p1.ID); err != nil {
+		t.Fatalf("PriceByID() after approving again: %v", err)
+	}
+	if got.Confirmations != 1 || got.Contradictions != 1 {
+		t.Errorf("Expected verdicts recorded once, got %+v", got)
+	}
+
+	cs, err := s.Contributors(context.Background(), []string{"usr1", "none"})
+	if err != nil {
+		t.Fatalf("Contributors(): %v", err)
+	}
+	exp := shopping.Contributor{
+		UserID:         "usr1",
+		Submissions:    3,
+		Confirmations:  2,
+		Contradictions: 1,
+		Trust:          shopping.TrustScore(2, 1),
+	}
+	if len(cs) != 1 || cs[0] != exp {
+		t.Errorf("Expected %+v, got %+v", exp, cs)
+	}
+	cs, err = s.ContributorsByTrust(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatalf("ContributorsByTrust(): %v", err)
+	}
+	if len(cs) != 1 || cs[0] != exp {
+		t.Errorf("Expected %+v, got %+v", exp, cs)
+	}
+	_, err = s.Contributors(context.Background(), []string{"none"})
+	if !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for users without prices, got %v", err)
+	}
+}
+
+func testRejectedVerdicts(t *testing.T, s shopping.Storage) {
+	brand := insertBrand(t, s)
+	judged := insertPrice(t, s, brand, "", 200, shopping.PriceStatusApproved)
+	confirming, err := s.InsertPrice(context.Background(), shopping.Price{
+		Value:       200,
+		Currency:    "KES",
+		Brand:       brand,
+		SubmittedBy: "usr2",
+		Status:      shopping.PriceStatusApproved,
+	}, shopping.Verdicts{Confirmed: []string{judged.ID}})
+	if err != nil {
+		t.Fatalf("Error setting up: InsertPrice() with verdicts: %v", err)
+	}
+	contradicting := insertPrice(t, s, brand, "", 400, shopping.PriceStatusPending)
+	_, err = s.UpdatePriceStatus(context.Background(), contradicting.ID,
+		shopping.PriceStatusApproved, shopping.Verdicts{Contradicted: []string{judged.ID}})
+	if err != nil {
+		t.Fatalf("Error setting up: UpdatePriceStatus() with verdicts: %v", err)
+	}
+
+	expVerdicts := func(step string, expConfirms, expContradicts int64) {
+		t.Helper()
+		got, err := s.PriceByID(context.Background(), judged.ID)
+		if err != nil {
+			t.Fatalf("PriceByID() %s: %v", step, err)
+		}
+		if got.Confirmations != expConfirms || got.Contradictions != expContradicts {
+			t.Errorf("Expected %d confirmations and %d contradictions %s, got %+v",
+				expConfirms, expContradicts, step, got)
+		}
+	}
+	expVerdicts("after approval", 1, 1)
+
+	for _, ID := range []string{contradicting.ID, confirming.ID} {
+		_, err := s.UpdatePriceStatus(context.Background(), ID,
+			shopping.PriceStatusRejected, shopping.Verdicts{})
+		if err != nil {
+			t.Fatalf("UpdatePriceStatus() rejecting %s: %v", ID, err)
+		}
+	}
+	expVerdicts("after rejection", 0, 0)
+
+	// Rejecting again must not reverse the verdicts twice.
+	_, err = s.UpdatePriceStatus(context.Background(), confirming.ID,
+		shopping.PriceStatusRejected, shopping.Verdicts{})
+	if err != nil {
+		t.Fatalf("UpdatePriceStatus() rejecting again: %v", err)
+	}
+	expVerdicts("after rejecting again", 0, 0)
+
+	_, err = s.UpdatePriceStatus(context.Background(), confirming.ID,
+		shopping.PriceStatusApproved, shopping.Verdicts{Confirmed: []string{judged.ID}})
+	if err != nil {
+		t.Fatalf("UpdatePriceStatus() re-approving: %v", err)
+	}
+	expVerdicts("after re-approval", 1, 0)
+}
+
+func testStores(t *testing.T, s shopping.Storage) {
+	first, err := s.UpsertStore("Naivas")
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if first.ID == "" {
+		t.Fatalf("ID was not assigned")
+	}
+	second, err := s.UpsertStore("Naivas")
+	if err != nil {
+		t.Fatalf("Got error on repeat upsert: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("Expected repeat upsert to yield ID %s, got %s", first.ID, second.ID)
+	}
+	if _, err := s.UpsertStore(""); err == nil {
+		t.Errorf("Expected an error for empty name, got nil")
+	}
+
+	sb := insertStoreBranch(t, s, "node/1", shopping.Location{Latitude: -1.28, Longitude: 36.82})
+	_, err = s.InsertStoreBranch(shopping.StoreBranch{
+		Name:     "Naivas copy",
+		Store:    *first,
+		Location: sb.Location,
+		OSMID:    sb.OSMID,
+	})
+	if err == nil {
+		t.Errorf("Expected an error for a duplicate OSM ID, got nil")
+	}
+	_, err = s.InsertStoreBranch(shopping.StoreBranch{
+		Name:     "Naivas north pole",
+		Store:    *first,
+		Location: shopping.Location{Latitude: 91},
+	})
+	if err == nil {
+		t.Errorf("Expected an error for a latitude out of range, got nil")
+	}
+
+	sb.Name = "Naivas Westlands"
+	sb.OSMID = "way/2"
+	if err := s.UpdateStoreBranch(*sb); err != nil {
+		t.Fatalf("UpdateStoreBranch(): %v", err)
+	}
+	got, err := s.StoreBranchByOSMID("way/2")
+	if err != nil {
+		t.Fatalf("StoreBranchByOSMID(): %v", err)
+	}
+	if got.ID != sb.ID || got.Name != sb.Name || got.Store.Name != first.Name {
+		t.Errorf("Expected %+v, got %+v", sb, got)
+	}
+	if _, err := s.StoreBranchByOSMID("node/1"); !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error for the previous OSM ID, got %v", err)
+	}
+	sb.ID = "999999"
+	if err := s.UpdateStoreBranch(*sb); !s.IsNotFoundError(err) {
+		t.Errorf("Expected a not found error updating a missing branch, got %v", err)
+	}
+}
+
+func testStoreBranchesWithin(t *testing.T, s shopping.Storage) {
+	near := insertStoreBranch(t, s, "node/1", shopping.Location{Latitude: -1.2636, Longitude: 36.8035})
+	insertStoreBranch(t, s, "node/2", shopping.Location{Latitude: -1.3000, Longitude: 36.8500})
+	sw, ne := shopping.BoundingBox(near.Location, 200)
+	tt := []struct {
+		name        string
+		storeID     string
+		expNotFound bool
+	}{
+		{name: "found", storeID: near.Store.ID, expNotFound: false},
+		{name: "other store", storeID: "9999", expNotFound: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sbs, err := s.StoreBranchesWithin(tc.storeID, sw, ne)
+			if tc.expNotFound {
+				if !s.IsNotFoundError(err) {
+					t.Fatalf("Expected not found error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if len(sbs) != 1 || sbs[0].ID != near.ID {
+				t.Errorf("Expected only branch %s, got %+v", near.ID, sbs)
+			}
+		})
+	}
+}
+
+func testAPIKeys(t *testing.T, s shopping.Storage) {
+	setupTime := time.Now()
+	validKey := []byte(strings.Repeat("axui", 14))
+	for _, tc := range []struct {
+		name  string
+		key   []byte
+		usrID string
+	}{
+		{name: "bad user ID", key: validKey, usrID: "bad id"},
+		{name: "short key", key: validKey[:10], usrID: "123"},
+	} {
+		if _, err := s.InsertAPIKey(tc.usrID, tc.key); err == nil {
+			t.Errorf("%s: expected an error, got nil", tc.name)
+		}
+	}
+
+	kI, err := s.InsertAPIKey("123", validKey)
+	if err != nil {
+		t.Fatalf("InsertAPIKey(): %v", err)
+	}
+	k, ok := kI.(api.Key)
+	if !ok {
+		t.Fatalf("Expected API key of type %T, got %T", api.Key{}, kI)
+	}
+	if k.ID == "" || k.Created.Before(setupTime) || k.LastUpdated.Before(setupTime) {
+		t.Errorf("Expected ID and dates assigned, got %+v", k)
+	}
+	if k.UserID != "123" || !bytes.Equal(k.Val, validKey) || k.Prefix != apikeys.Prefix(validKey) {
+		t.Errorf("Expected key %s of user 123, got %+v", validKey, k)
+	}
+	if !reflect.DeepEqual(k.Scopes, apikeys.DefaultScopes) {
+		t.Errorf("Expected new key to have the default scopes, got %v", k.Scopes)
+	}
+	k2 := insertAPIKey(t, s, "123")
+	k3 := insertAPIKey(t, s, "456")
+
+	for _, tc := range []struct {
+		name   string
+		userID string
+		key    []byte
+	}{
+		{name: "empty key", userID: "123"},
+		{name: "same prefix", userID: "123", key: append([]byte(apikeys.Prefix(validKey)), "other"...)},
+		{name: "other user", userID: "456", key: validKey},
+	} {
+		if _, err := s.APIKeyByUserIDVal(tc.userID, tc.key); !s.IsNotFoundError(err) {
+			t.Errorf("%s: expected not found error, got %v", tc.name, err)
+		}
+	}
+	found, err := s.APIKeyByUserIDVal("123", validKey)
+	if err != nil {
+		t.Fatalf("APIKeyByUserIDVal(): %v", err)
+	}
+	if !reflect.DeepEqual(kI, found) {
+		t.Errorf("API Key mismatch:\nExpect:\t%+v\nGot:\t%+v", kI, found)
+	}
+
+	tt := []struct {
+		name        string
+		userID      string
+		offset      int64
+		expKeys     []apiG.Key
+		expNotFound bool
+	}{
+		{name: "all users", expKeys: []apiG.Key{k, k2, k3}},
+		{name: "one user", userID: "123", expKeys: []apiG.Key{k, k2}},
+		{name: "offset", userID: "123", offset: 1, expKeys: []apiG.Key{k2}},
+		{name: "none", userID: "789", expNotFound: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, err := s.APIKeys(context.Background(), tc.userID, tc.offset, 10)
+			if tc.expNotFound {
+				if !s.IsNotFoundError(err) {
+					t.Fatalf("Expected not found error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if len(ks) != len(tc.expKeys) {
+				t.Fatalf("Expected %d keys, got %d", len(tc.expKeys), len(ks))
+			}
+			for i := range ks {
+				if len(ks[i].Val) > 0 {
+					t.Errorf("Expected key values not to be returned, got %s", ks[i].Val)
+				}
+				ks[i].Val = tc.expKeys[i].Value()
+				if !reflect.DeepEqual(tc.expKeys[i], ks[i]) {
+					t.Errorf("API Key mismatch:\nExpect:\t%+v\nGot:\t%+v",
+						tc.expKeys[i], ks[i])
+				}
+			}
+		})
+	}
+
+	got, err := s.APIKeyByID(context.Background(), k.ID)
+	if err != nil {
+		t.Fatalf("APIKeyByID(): %v", err)
+	}
+	got.Val = k.Val
+	if !reflect.DeepEqual(k, *got) {
+		t.Errorf("API Key mismatch:\nExpect:\t%+v\nGot:\t%+v", k, *got)
+	}
+	if err := s.DeleteAPIKey(context.Background(), k.ID); err != nil {
+		t.Fatalf("DeleteAPIKey(): %v", err)
+	}
+	if _, err := s.APIKeyByUserIDVal(k.UserID, k.Val); !s.IsNotFoundError(err) {
+		t.Errorf("Expected deleted key not to be found by value, got %v", err)
+	}
+	if _, err := s.APIKeyByID(context.Background(), k.ID); !s.IsNotFoundError(err) {
+		t.Errorf("Expected deleted key not to be found by ID, got %v", err)
+	}
+	if err := s.DeleteAPIKey(context.Background(), k.ID); !s.IsNotFoundError(err) {
+		t.Errorf("Expected not found error deleting again, got %v", err)
+	}
+}
+
+func testAPIKeyPolicy(t *testing.T, s shopping.Storage) {
+	k := insertAPIKey(t, s, "123").(api.Key)
+	scopes := []string{apikeys.ScopeListsRead, apikeys.ScopeAdmin}
+	origins := []string{"https://example.com"}
+	expires := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	upd, err := s.UpdateAPIKeyPolicy(context.Background(), k.ID, scopes, origins, expires)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if !reflect.DeepEqual(upd.Scopes, scopes) || !reflect.DeepEqual(upd.Origins, origins) ||
+		!upd.Expires.Equal(expires) {
+		t.Errorf("Expected policy %v %v %v, got %+v", scopes, origins, expires, upd)
+	}
+	if _, err := s.APIKeyByUserIDVal(k.UserID, k.Val); err != nil {
+		t.Errorf("Expected key not yet expired to be found, got %v", err)
+	}
+
+	expired := time.Now().Add(-time.Hour)
+	if _, err := s.UpdateAPIKeyPolicy(context.Background(), k.ID, scopes, nil, expired); err != nil {
+		t.Fatalf("Expire: got error: %v", err)
+	}
+	if _, err := s.APIKeyByUserIDVal(k.UserID, k.Val); !s.IsNotFoundError(err) {
+		t.Errorf("Expected expired key not to be found, got %v", err)
+	}
+
+	if _, err := s.UpdateAPIKeyPolicy(context.Background(), "999", scopes, nil, time.Time{}); !s.IsNotFoundError(err) {
+		t.Errorf("Expected not found error for unknown key, got %v", err)
+	}
+}
+
+func testAPIKeyWithPolicy(t *testing.T, s shopping.Storage) {
+	key := bytes.Repeat([]byte("p"), 56)
+	scopes := []string{apikeys.ScopeListsRead}
+	origins := []string{"https://example.com"}
+	expires := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	k, err := s.InsertAPIKeyWithPolicy(context.Background(), "123", key, scopes, origins, expires)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if k.ID == "" || string(k.Val) != string(key) {
+		t.Errorf("Expected an ID and the key's value, got %+v", k)
+	}
+	if !reflect.DeepEqual(k.Scopes, scopes) || !reflect.DeepEqual(k.Origins, origins) ||
+		!k.Expires.Equal(expires) {
+		t.Errorf("Expected policy %v %v %v, got %+v", scopes, origins, expires, k)
+	}
+	found, err := s.APIKeyByID(context.Background(), k.ID)
+	if err != nil {
+		t.Fatalf("APIKeyByID(): %v", err)
+	}
+	if !reflect.DeepEqual(found.Scopes, scopes) || !reflect.DeepEqual(found.Origins, origins) ||
+		!found.Expires.Equal(expires) {
+		t.Errorf("Expected stored policy %v %v %v, got %+v", scopes, origins, expires, found)
+	}
+
+	expiredKey := bytes.Repeat([]byte("e"), 56)
+	_, err = s.InsertAPIKeyWithPolicy(context.Background(), "123", expiredKey, scopes,
+		nil, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Insert expired: got error: %v", err)
+	}
+	if _, err := s.APIKeyByUserIDVal("123", expiredKey); !s.IsNotFoundError(err) {
+		t.Errorf("Expected expired key not to be found, got %v", err)
+	}
+
+	if _, err := s.InsertAPIKeyWithPolicy(context.Background(), "123", []byte("short"),
+		scopes, nil, time.Time{}); err == nil {
+		t.Errorf("Expected an error for a short key, got nil")
+	}
+}
+
+func testTouchAPIKey(t *testing.T, s shopping.Storage) {
+	k := insertAPIKey(t, s, "123").(api.Key)
+	if !k.LastUsed.IsZero() {
+		t.Errorf("Expected new key not to have been used, got %v", k.LastUsed)
+	}
+	if err := s.TouchAPIKey(context.Background(), k.ID); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	touched, err := s.APIKeyByID(context.Background(), k.ID)
+	if err != nil {
+		t.Fatalf("Get by ID: got error: %v", err)
+	}
+	if touched.LastUsed.IsZero() {
+		t.Fatalf("Expected last use recorded")
+	}
+	if err := s.TouchAPIKey(context.Background(), k.ID); err != nil {
+		t.Fatalf("Touch again: got error: %v", err)
+	}
+	again, err := s.APIKeyByID(context.Background(), k.ID)
+	if err != nil {
+		t.Fatalf("Get by ID: got error: %v", err)
+	}
+	if !again.LastUsed.Equal(touched.LastUsed) {
+		t.Errorf("Expected last use within a minute not to be rerecorded, got %v then %v",
+			touched.LastUsed, again.LastUsed)
+	}
+}
+
+func testRateLimits(t *testing.T, s shopping.Storage) {
+	l := ratelimit.Limit{Rate: 0.001, Burst: 2}
+	for i, expAllowed := range []bool{true, true, false} {
+		res, err := s.TakeRateLimitToken(context.Background(), "apiKey:app1", l)
+		if err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+		if res.Allowed != expAllowed {
+			t.Errorf("Request %d: expected allowed %t, got %+v", i, expAllowed, res)
+		}
+	}
+	res, err := s.TakeRateLimitToken(context.Background(), "apiKey:app2", l)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if !res.Allowed || res.Remaining != 1 {
+		t.Errorf("Expected a separate bucket per key, got %+v", res)
+	}
+}
+
+// testTransactions checks that calls which fail part way leave no changes
+// behind.
+func testTransactions(t *testing.T, s shopping.Storage) {
+	_, err := s.UpsertBrands(context.Background(), []shopping.Brand{
+		{
+			Name:          "Colgate",
+			Item:          shopping.Item{Name: "Toothpaste"},
+			MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+			Barcodes:      []string{"8718951065545"},
+		},
+		{Name: "No item"},
+	})
+	if err == nil {
+		t.Fatalf("Expected an error for a brand without item, got nil")
+	}
+	if _, err := s.Items(context.Background()); !s.IsNotFoundError(err) {
+		t.Errorf("Expected no items after a failed upsert, got %v", err)
+	}
+
+	brand := insertBrand(t, s)
+	p := insertPrice(t, s, brand, "", 200, shopping.PriceStatusApproved)
+	pending := insertPrice(t, s, brand, "", 20000, shopping.PriceStatusPending)
+	badVerdicts := shopping.Verdicts{Confirmed: []string{p.ID}, Contradicted: []string{"999999"}}
+	_, err = s.InsertPrice(context.Background(), shopping.Price{
+		Value:       210,
+		Currency:    "KES",
+		Brand:       brand,
+		SubmittedBy: "usr2",
+		Status:      shopping.PriceStatusApproved,
+	}, badVerdicts)
+	if err == nil {
+		t.Fatalf("Expected an error inserting a price judging a missing price, got nil")
+	}
+	_, err = s.UpdatePriceStatus(context.Background(), pending.ID,
+		shopping.PriceStatusApproved, badVerdicts)
+	if err == nil {
+		t.Fatalf("Expected an error approving a price judging a missing price, got nil")
+	}
+	got, err := s.PriceByID(context.Background(), p.ID)
+	if err != nil {
+		t.Fatalf("PriceByID(): %v", err)
+	}
+	if got.Confirmations != 0 {
+		t.Errorf("Expected confirmations rolled back, got %d", got.Confirmations)
+	}
+	recent, err := s.RecentApprovedPrices(context.Background(), brand.ID, "", "KES",
+		time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("RecentApprovedPrices(): %v", err)
+	}
+	if len(recent) != 1 || recent[0].ID != p.ID {
+		t.Errorf("Expected the price insert and approval rolled back, got %+v", recent)
+	}
+
+	dups, err := s.UpsertBrands(context.Background(), []shopping.Brand{{
+		Name:          "Colgate Ltd",
+		Item:          brand.Item,
+		MeasuringUnit: brand.MeasuringUnit,
+	}})
+	if err != nil {
+		t.Fatalf("Error setting up: upsert duplicate brand: %v", err)
+	}
+	_, err = s.MergeBrands(context.Background(), brand.ID, []string{dups[0].ID, "999999"})
+	if !s.IsNotFoundError(err) {
+		t.Fatalf("Expected a not found error for a missing duplicate, got %v", err)
+	}
+	brands, err := s.Brands(context.Background())
+	if err != nil {
+		t.Fatalf("Brands(): %v", err)
+	}
+	if len(brands) != 2 {
+		t.Errorf("Expected both brands kept after a failed merge, got %+v", brands)
+	}
+}
+
+func insertBrand(t *testing.T, s shopping.Storage) shopping.Brand {
+	brands, err := s.UpsertBrands(context.Background(), []shopping.Brand{{
+		Name:          "Colgate",
+		Item:          shopping.Item{Name: "Toothpaste"},
+		MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+	}})
+	if err != nil {
+		t.Fatalf("Error setting up: upsert brand: %v", err)
+	}
+	return brands[0]
+}
+
+func insertPrice(t *testing.T, s shopping.Storage, b shopping.Brand, storeBranchID string, value float32, status string) *shopping.Price {
+	p, err := s.InsertPrice(context.Background(), shopping.Price{
+		Value:         value,
+		Currency:      "KES",
+		Brand:         b,
+		AtStoreBranch: shopping.StoreBranch{ID: storeBranchID},
+		SubmittedBy:   "usr1",
+		Status:        status,
+	}, shopping.Verdicts{})
+	if err != nil {
+		t.Fatalf("Error setting up: insert price: %v", err)
+	}
+	return p
+}
+
+func insertStoreBranch(t *testing.T, s shopping.Storage, osmID string, loc shopping.Location) *shopping.StoreBranch {
+	store, err := s.UpsertStore("Naivas")
+	if err != nil {
+		t.Fatalf("Error setting up: upsert store: %v", err)
+	}
+	sb, err := s.InsertStoreBranch(shopping.StoreBranch{
+		Name:     "Naivas " + osmID,
+		Store:    *store,
+		Location: loc,
+		OSMID:    osmID,
+	})
+	if err != nil {
+		t.Fatalf("Error setting up: insert store branch: %v", err)
+	}
+	return sb
+}
+
+func insertAPIKey(t *testing.T, s shopping.Storage, usrID string) apiG.Key {
+	k, err := s.InsertAPIKey(usrID, bytes.Repeat([]byte("x"), 56))
+	if err != nil {
+		t.Fatalf("Error setting up: insert API key: %v", err)
+	}
+	return k
+}
@@ -0,0 +1,87 @@
+package dev
+
+import (
+	"crypto/rand"
+	"time"
+
+	jwtLib "github.com/dgrijalva/jwt-go"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/jwt"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+const (
+	// APIKey is the master API key in developer mode. It is fixed so that
+	// clients under development can hard code it.
+	APIKey = "shoppingms-dev-key"
+
+	// Address is where the standalone server listens in developer mode.
+	// It is only reachable from the local machine.
+	Address = "127.0.0.1:8080"
+
+	// TokenTTL is how long JWTs issued by Tokens are valid for.
+	TokenTTL = 24 * time.Hour
+
+	jwtKeyLen = 32
+)
+
+// Config returns the config used in developer mode: in-memory storage,
+// APIKey as the master API key, any origin allowed and no rate limits.
+func Config() config.General {
+	return config.General{
+		Service: config.Service{
+			MasterAPIKey:   APIKey,
+			AllowedOrigins: []string{"*"},
+			LogLevel:       config.LogLevelDebug,
+		},
+		Storage:    config.Storage{Backend: config.StorageMemory},
+		Standalone: config.Standalone{Address: Address},
+	}
+}
+
+// NewJWTHandler returns a *jwt.Handler signing and validating JWTs with a
+// random key that only lives as long as the process, in place of the key
+// shared with the authentication micro-service.
+func NewJWTHandler() (*jwt.Handler, error) {
+	key := make([]byte, jwtKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Newf("generate JWT key: %v", err)
+	}
+	return jwt.NewHandler(key)
+}
+
+// JWTGenerator signs JWTs.
+type JWTGenerator interface {
+	Generate(claims jwtLib.Claims) (string, error)
+}
+
+// Tokens issues JWTs for any user, standing in for the authentication
+// micro-service in developer mode.
+// Use NewTokens() to instantiate.
+type Tokens struct {
+	errors.ErrToHTTP
+	jwter JWTGenerator
+}
+
+func NewTokens(jwter JWTGenerator) (*Tokens, error) {
+	if jwter == nil {
+		return nil, errors.New("JWTGenerator was nil")
+	}
+	return &Tokens{jwter: jwter}, nil
+}
+
+// Issue returns a JWT identifying userID, valid for TokenTTL.
+func (t *Tokens) Issue(userID string) (string, error) {
+	if userID == "" {
+		return "", errors.NewClient("user ID was empty")
+	}
+	clm := shopping.Claim{UsrID: userID}
+	clm.IssuedAt = time.Now().Unix()
+	clm.ExpiresAt = time.Now().Add(TokenTTL).Unix()
+	JWT, err := t.jwter.Generate(clm)
+	if err != nil {
+		return "", errors.Newf("generate JWT: %v", err)
+	}
+	return JWT, nil
+}
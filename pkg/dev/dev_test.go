@@ -0,0 +1,107 @@
+package dev_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/db/memory"
+	"github.com/tomogoma/shoppingms/pkg/dev"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestTokens_Issue(t *testing.T) {
+	jwter, err := dev.NewJWTHandler()
+	if err != nil {
+		t.Fatalf("Error setting up: new JWT handler: %v", err)
+	}
+	tkns, err := dev.NewTokens(jwter)
+	if err != nil {
+		t.Fatalf("Error setting up: new tokens: %v", err)
+	}
+
+	tt := []struct {
+		name     string
+		userID   string
+		expClErr bool
+	}{
+		{name: "valid", userID: "123"},
+		{name: "empty user ID", userID: "", expClErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			JWT, err := tkns.Issue(tc.userID)
+			if tc.expClErr {
+				if !(errors.ClErrCheck{}).IsClientError(err) {
+					t.Fatalf("Expected a client error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			clm := new(shopping.Claim)
+			if _, err := jwter.Validate(JWT, clm); err != nil {
+				t.Fatalf("Validate issued JWT: %v", err)
+			}
+			if clm.UsrID != tc.userID {
+				t.Errorf("Expected JWT for user %s, got %s", tc.userID, clm.UsrID)
+			}
+			expExpiry := time.Now().Add(dev.TokenTTL)
+			if exp := time.Unix(clm.ExpiresAt, 0); exp.Before(expExpiry.Add(-time.Minute)) ||
+				exp.After(expExpiry) {
+				t.Errorf("Expected JWT to expire around %s, got %s", expExpiry, exp)
+			}
+		})
+	}
+}
+
+func TestNewTokens_nilJWTGenerator(t *testing.T) {
+	if _, err := dev.NewTokens(nil); err == nil {
+		t.Errorf("Expected an error, got nil")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	conf := dev.Config()
+	if conf.Service.MasterAPIKey != dev.APIKey {
+		t.Errorf("Expected master API key %s, got %s", dev.APIKey, conf.Service.MasterAPIKey)
+	}
+	// the dev JWT key is generated rather than read from a file.
+	conf.Service.AuthTokenKeyFile = "dev.go"
+	if err := conf.Validate(); err != nil {
+		t.Errorf("Invalid config: %v", err)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewMemory()
+	r, err := dev.Seed(ctx, db)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if r.Brands == 0 || r.StoreBranches == 0 || r.Prices != r.Brands*r.StoreBranches {
+		t.Fatalf("Expected a price for every brand at every store branch, got %+v", r)
+	}
+
+	brands, err := db.Brands(ctx)
+	if err != nil {
+		t.Fatalf("Get seeded brands: %v", err)
+	}
+	if len(brands) != r.Brands {
+		t.Fatalf("Expected %d seeded brands, got %d", r.Brands, len(brands))
+	}
+	for _, b := range brands {
+		ps, err := db.RecentApprovedPrices(ctx, b.ID, "", shopping.DefaultCurrency,
+			time.Now().Add(-time.Hour), 100)
+		if err != nil {
+			t.Fatalf("Get seeded prices: %v", err)
+		}
+		if len(ps) != r.StoreBranches {
+			t.Errorf("Expected %d seeded prices of %s, got %d",
+				r.StoreBranches, b.Name, len(ps))
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package dev
+
+import (
+	"context"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// SeedUserID is the user demo prices are submitted by.
+const SeedUserID = "dev-seed"
+
+// SeedDB persists the demo data.
+type SeedDB interface {
+	UpsertBrands(ctx context.Context, brands []shopping.Brand) ([]shopping.Brand, error)
+	UpsertStore(name string) (*shopping.Store, error)
+	InsertStoreBranch(sb shopping.StoreBranch) (*shopping.StoreBranch, error)
+	InsertPrice(ctx context.Context, p shopping.Price, v shopping.Verdicts) (*shopping.Price, error)
+}
+
+// SeedReport counts the demo data inserted by Seed().
+type SeedReport struct {
+	Brands        int
+	StoreBranches int
+	Prices        int
+}
+
+type demoBrand struct {
+	name, item, unit string
+	barcode          string
+	// price is the price at the first demo store branch, the other
+	// branches charge a little more.
+	price float32
+}
+
+var demoBrands = []demoBrand{
+	{name: "Brookside", item: "Milk", unit: "500ml", barcode: "6161100410014", price: 60},
+	{name: "Tuzo", item: "Milk", unit: "500ml", barcode: "6161100420013", price: 58},
+	{name: "Jogoo", item: "Maize Flour", unit: "2kg", barcode: "6161101560015", price: 170},
+	{name: "Pembe", item: "Maize Flour", unit: "2kg", barcode: "6161101570014", price: 165},
+	{name: "Mumias", item: "Sugar", unit: "1kg", barcode: "6161100650013", price: 180},
+	{name: "Kabras", item: "Sugar", unit: "1kg", barcode: "6161100660012", price: 175},
+	{name: "Ketepa", item: "Tea Leaves", unit: "250g", barcode: "6161100110013", price: 150},
+	{name: "Elianto", item: "Cooking Oil", unit: "1l", barcode: "6161100810011", price: 390},
+	{name: "Festive", item: "Bread", unit: "400g", barcode: "6161100910018", price: 65},
+	{name: "Kenchic", item: "Eggs", unit: "tray", barcode: "6161101010014", price: 450},
+}
+
+type demoStoreBranch struct {
+	store, name string
+	location    shopping.Location
+	// markup is added to the demoBrand price at this branch.
+	markup float32
+}
+
+var demoStoreBranches = []demoStoreBranch{
+	{store: "Naivas", name: "Naivas Westlands",
+		location: shopping.Location{Latitude: -1.2649, Longitude: 36.8027}, markup: 0},
+	{store: "Naivas", name: "Naivas Kilimani",
+		location: shopping.Location{Latitude: -1.2897, Longitude: 36.7838}, markup: 2},
+	{store: "Carrefour", name: "Carrefour Junction",
+		location: shopping.Location{Latitude: -1.2986, Longitude: 36.7622}, markup: 5},
+	{store: "Quickmart", name: "Quickmart Lavington",
+		location: shopping.Location{Latitude: -1.2771, Longitude: 36.7702}, markup: 3},
+}
+
+// Seed inserts demo brands, stores and their branches in Nairobi, and an
+// approved price of every brand at every branch submitted by SeedUserID.
+// It is meant for an empty db, seeding twice inserts the store branches
+// and prices twice.
+func Seed(ctx context.Context, db SeedDB) (SeedReport, error) {
+	r := SeedReport{}
+
+	brands := make([]shopping.Brand, len(demoBrands))
+	for i, b := range demoBrands {
+		brands[i] = shopping.Brand{
+			Name:          b.name,
+			Item:          shopping.Item{Name: b.item},
+			MeasuringUnit: shopping.MeasuringUnit{Name: b.unit},
+			Barcodes:      []string{b.barcode},
+		}
+	}
+	brands, err := db.UpsertBrands(ctx, brands)
+	if err != nil {
+		return r, errors.Newf("upsert brands: %v", err)
+	}
+	r.Brands = len(brands)
+
+	for _, dsb := range demoStoreBranches {
+		st, err := db.UpsertStore(dsb.store)
+		if err != nil {
+			return r, errors.Newf("upsert store %s: %v", dsb.store, err)
+		}
+		sb, err := db.InsertStoreBranch(shopping.StoreBranch{
+			Name:     dsb.name,
+			Store:    *st,
+			Location: dsb.location,
+		})
+		if err != nil {
+			return r, errors.Newf("insert store branch %s: %v", dsb.name, err)
+		}
+		r.StoreBranches++
+
+		for i, b := range brands {
+			_, err := db.InsertPrice(ctx, shopping.Price{
+				Value:         demoBrands[i].price + dsb.markup,
+				Currency:      shopping.DefaultCurrency,
+				Brand:         b,
+				AtStoreBranch: *sb,
+				SubmittedBy:   SeedUserID,
+				Status:        shopping.PriceStatusApproved,
+			}, shopping.Verdicts{})
+			if err != nil {
+				return r, errors.Newf("insert price of %s at %s: %v",
+					b.Name, sb.Name, err)
+			}
+			r.Prices++
+		}
+	}
+	return r, nil
+}
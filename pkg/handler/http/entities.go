@@ -2,27 +2,12 @@ package http
 
 import (
 	"github.com/tomogoma/shoppingms/pkg/shopping"
-	"github.com/tomogoma/crdb"
-	"encoding/json"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/health"
+	"time"
 )
 
-type JSONStringUpdate struct {
-	crdb.StringUpdate
-}
-
-func (jsu *JSONStringUpdate) UnmarshalJSON(data []byte) error {
-	// If this method was called, the value was set in the JSON string.
-	if string(data) == "null" { // Ignore the null literal value.
-		return nil
-	}
-
-	jsu.Updating = true
-	if err := json.Unmarshal(data, &jsu.NewVal); err != nil {
-		return err
-	}
-	return nil
-}
-
 /**
  * @apiDefine ShoppingLists200
  * @apiSuccess (200 JSON Response Body) {Object[]} shoppingLists
@@ -100,11 +85,39 @@ type Item struct {
 	Name string `json:"name,omitempty"`
 }
 
+func NewMeasuringUnit(mu *shopping.MeasuringUnit) *MeasuringUnit {
+	if mu == nil {
+		return nil
+	}
+	return &MeasuringUnit{ID: mu.ID, Name: mu.Name}
+}
+
+func NewItem(item *shopping.Item) *Item {
+	if item == nil {
+		return nil
+	}
+	return &Item{ID: item.ID, Name: item.Name}
+}
+
 type Brand struct {
 	ID            string         `json:"ID,omitempty"`
 	Name          string         `json:"name,omitempty"`
 	MeasuringUnit *MeasuringUnit `json:"measuringUnit,omitempty"`
 	Item          *Item          `json:"item,omitempty"`
+	Barcodes      []string       `json:"barcodes,omitempty"`
+}
+
+func NewBrand(b *shopping.Brand) *Brand {
+	if b == nil {
+		return nil
+	}
+	return &Brand{
+		ID:            b.ID,
+		Name:          b.Name,
+		MeasuringUnit: NewMeasuringUnit(&b.MeasuringUnit),
+		Item:          NewItem(&b.Item),
+		Barcodes:      b.Barcodes,
+	}
 }
 
 type Store struct {
@@ -118,8 +131,44 @@ type StoreBranch struct {
 	Store *Store `json:"store,omitempty"`
 }
 
+func NewStoreBranch(sb *shopping.StoreBranch) *StoreBranch {
+	if sb == nil || sb.ID == "" {
+		return nil
+	}
+	return &StoreBranch{
+		ID:    sb.ID,
+		Name:  sb.Name,
+		Store: &Store{ID: sb.Store.ID, Name: sb.Store.Name},
+	}
+}
+
 /**
- * @apiDefine PriceObject200
+ * @apiDefine Price200
+ * @apiSuccess (200 JSON Response Body) {String} ID
+ *		Unique ID of the Price.
+ * @apiSuccess (200 JSON Response Body) {Float} value
+ *		The price point of the brand e.g. 200.
+ * @apiSuccess (200 JSON Response Body) {String} currency
+ * 		Active ISO 4217 code denoting currency of value field e.g. KES.
+ * @apiSuccess (200 JSON Response Body) {Object} brand
+ *		The brand for which the price point applies, see "200 JSON Response
+ *		Body" of <a href="#api-Admin-MergeBrands">Merge Brands</a> for details.
+ * @apiSuccess (200 JSON Response Body) {Object} [atStoreBranch]
+ *		The store branch at which the price was observed if known.
+ * @apiSuccess (200 JSON Response Body) {String} atStoreBranch.ID
+ *		Unique ID of the Store Branch.
+ * @apiSuccess (200 JSON Response Body) {String} atStoreBranch.name
+ *		Name of the Store Branch.
+ * @apiSuccess (200 JSON Response Body) {Object} atStoreBranch.store
+ *		The store to which the store branch belongs.
+ * @apiSuccess (200 JSON Response Body) {String="APPROVED","PENDING","REJECTED"} status
+ *		Moderation status of the price. Only approved prices are used in
+ *		price calculations.
+ * @apiSuccess (200 JSON Response Body) {Float} outlierScore
+ *		How many (robust) standard deviations the price was from recent
+ *		history when submitted, 0 if there was too little history.
+ * @apiSuccess (200 JSON Response Body) {String} created
+ *		ISO8601 date the price was submitted.
  */
 type Price struct {
 	ID            string       `json:"ID,omitempty"`
@@ -127,6 +176,103 @@ type Price struct {
 	Currency      string       `json:"currency,omitempty"`
 	Brand         *Brand       `json:"brand,omitempty"`
 	AtStoreBranch *StoreBranch `json:"atStoreBranch,omitempty"`
+	Status        string       `json:"status,omitempty"`
+	OutlierScore  float64      `json:"outlierScore"`
+	Created       string       `json:"created,omitempty"`
+
+	// Only included for admins so that contributors remain anonymous to
+	// other users.
+	SubmittedBy    string `json:"submittedBy,omitempty"`
+	Confirmations  *int64 `json:"confirmations,omitempty"`
+	Contradictions *int64 `json:"contradictions,omitempty"`
+}
+
+func NewPrice(p *shopping.Price) *Price {
+	if p == nil {
+		return nil
+	}
+	return &Price{
+		ID:            p.ID,
+		Value:         p.Value,
+		Currency:      p.Currency,
+		Brand:         NewBrand(&p.Brand),
+		AtStoreBranch: NewStoreBranch(&p.AtStoreBranch),
+		Status:        p.Status,
+		OutlierScore:  p.OutlierScore,
+		Created:       p.Created.Format(config.TimeFormat),
+	}
+}
+
+/**
+ * @apiDefine ModeratedPrice200
+ * @apiSuccess (200 JSON Response Body) {String} submittedBy
+ *		ID of the user who observed the price.
+ * @apiSuccess (200 JSON Response Body) {Long} confirmations
+ *		Number of later observations by other users that agreed with the price.
+ * @apiSuccess (200 JSON Response Body) {Long} contradictions
+ *		Number of later observations by other users that disagreed with the
+ *		price.
+ */
+
+// NewModeratedPrice is like NewPrice but includes the submitter's identity
+// and how other users' observations judged the price. It is only for admin
+// responses.
+func NewModeratedPrice(p *shopping.Price) *Price {
+	res := NewPrice(p)
+	if res == nil {
+		return nil
+	}
+	res.SubmittedBy = p.SubmittedBy
+	res.Confirmations = &p.Confirmations
+	res.Contradictions = &p.Contradictions
+	return res
+}
+
+func NewModeratedPrices(ps []shopping.Price) []Price {
+	ress := make([]Price, 0, len(ps))
+	for i := range ps {
+		ress = append(ress, *NewModeratedPrice(&ps[i]))
+	}
+	return ress
+}
+
+/**
+ * @apiDefine Contributors200
+ * @apiSuccess (200 JSON Response Body) {Object[]} contributors
+ * 		Contributors ordered by trust, most trusted first.
+ * @apiSuccess (200 JSON Response Body) {String} contributors.userID
+ * 		ID of the user.
+ * @apiSuccess (200 JSON Response Body) {Long} contributors.submissions
+ * 		Number of prices the user submitted.
+ * @apiSuccess (200 JSON Response Body) {Long} contributors.confirmations
+ * 		Number of times the user's prices were confirmed by later
+ * 		observations of other users.
+ * @apiSuccess (200 JSON Response Body) {Long} contributors.contradictions
+ * 		Number of times the user's prices were contradicted by later
+ * 		observations of other users.
+ * @apiSuccess (200 JSON Response Body) {Float} contributors.trust
+ * 		Trust score (0 to 1) of the user, 0.5 for users without history.
+ */
+type Contributor struct {
+	UserID         string  `json:"userID"`
+	Submissions    int64   `json:"submissions"`
+	Confirmations  int64   `json:"confirmations"`
+	Contradictions int64   `json:"contradictions"`
+	Trust          float64 `json:"trust"`
+}
+
+func NewContributors(cs []shopping.Contributor) []Contributor {
+	ress := make([]Contributor, 0, len(cs))
+	for _, c := range cs {
+		ress = append(ress, Contributor{
+			UserID:         c.UserID,
+			Submissions:    c.Submissions,
+			Confirmations:  c.Confirmations,
+			Contradictions: c.Contradictions,
+			Trust:          c.Trust,
+		})
+	}
+	return ress
 }
 
 /**
@@ -192,3 +338,254 @@ type ShoppingListItem struct {
 	ShoppingList *ShoppingList `json:"shoppingList,omitempty"`
 	Price        *Price        `json:"price,omitempty"`
 }
+
+func NewShoppingListItem(item *shopping.ShoppingListItem) *ShoppingListItem {
+	if item == nil {
+		return nil
+	}
+	price := NewPrice(&item.Price)
+	if item.Price.ID == "" {
+		// Only the brand is known until a price is observed.
+		price = &Price{Brand: price.Brand}
+	}
+	return &ShoppingListItem{
+		ID:           item.ID,
+		Quantity:     item.Quantity,
+		InList:       item.InList,
+		InCart:       item.InCart,
+		ShoppingList: NewShoppingList(&item.ShoppingList),
+		Price:        price,
+	}
+}
+
+func NewShoppingListItems(items []shopping.ShoppingListItem) []ShoppingListItem {
+	ress := make([]ShoppingListItem, 0, len(items))
+	for i := range items {
+		ress = append(ress, *NewShoppingListItem(&items[i]))
+	}
+	return ress
+}
+
+type CatalogEntry struct {
+	ID   string `json:"ID"`
+	Name string `json:"name"`
+}
+
+/**
+ * @apiDefine DuplicateGroups200
+ * @apiSuccess (200 JSON Response Body) {Object[]} duplicateGroups
+ * 		Groups of probable duplicates, empty if none were found.
+ * @apiSuccess (200 JSON Response Body) {Object[]} duplicateGroups.entries
+ * 		The catalog entries in the group ordered by ID.
+ * @apiSuccess (200 JSON Response Body) {String} duplicateGroups.entries.ID
+ * 		Unique ID of the catalog entry.
+ * @apiSuccess (200 JSON Response Body) {String} duplicateGroups.entries.name
+ * 		Name of the catalog entry.
+ * @apiSuccess (200 JSON Response Body) {Float} duplicateGroups.score
+ * 		Highest name similarity (0 to 1) between entries of the group.
+ */
+type DuplicateGroup struct {
+	Entries []CatalogEntry `json:"entries"`
+	Score   float64        `json:"score"`
+}
+
+func NewDuplicateGroups(dgs []shopping.DuplicateGroup) []DuplicateGroup {
+	ress := make([]DuplicateGroup, 0, len(dgs))
+	for _, dg := range dgs {
+		res := DuplicateGroup{Score: dg.Score}
+		for _, e := range dg.Entries {
+			res.Entries = append(res.Entries, CatalogEntry{ID: e.ID, Name: e.Name})
+		}
+		ress = append(ress, res)
+	}
+	return ress
+}
+
+/**
+ * @apiDefine Health
+ * @apiSuccess (JSON Response Body) {Boolean} live
+ *		false if the instance should be restarted.
+ * @apiSuccess (JSON Response Body) {Boolean} ready
+ *		false if the instance should not be sent traffic.
+ * @apiSuccess (JSON Response Body) {Object[]} dependencies
+ *		Health of each dependency checked to determine readiness.
+ * @apiSuccess (JSON Response Body) {String} dependencies.name
+ *		Name of the dependency e.g. database.
+ * @apiSuccess (JSON Response Body) {Boolean} dependencies.healthy
+ *		Whether the dependency is healthy.
+ * @apiSuccess (JSON Response Body) {Number} dependencies.latencyMillis
+ *		How long checking the dependency took in milliseconds.
+ * @apiSuccess (JSON Response Body) {String} [dependencies.error]
+ *		Why the dependency is unhealthy, only included in /status.
+ */
+type DependencyHealth struct {
+	Name          string `json:"name"`
+	Healthy       bool   `json:"healthy"`
+	LatencyMillis int64  `json:"latencyMillis"`
+	Error         string `json:"error,omitempty"`
+}
+
+// NewDependencyHealths leaves out errors unless withErrs is true since
+// they may describe the service's internals.
+func NewDependencyHealths(rs []health.Result, withErrs bool) []DependencyHealth {
+	dhs := make([]DependencyHealth, 0, len(rs))
+	for _, r := range rs {
+		dh := DependencyHealth{
+			Name:          r.Name,
+			Healthy:       r.Healthy,
+			LatencyMillis: int64(r.Latency / time.Millisecond),
+		}
+		if withErrs && r.Err != nil {
+			dh.Error = r.Err.Error()
+		}
+		dhs = append(dhs, dh)
+	}
+	return dhs
+}
+
+/**
+ * @apiDefine ConfigStatus
+ * @apiSuccess (JSON Response Body) {Object} config
+ *		Where config values were taken from.
+ * @apiSuccess (JSON Response Body) {String} config.precedence
+ *		Which source wins when a value is set in more than one.
+ * @apiSuccess (JSON Response Body) {Object[]} config.overrides
+ *		Values set from environment variables.
+ * @apiSuccess (JSON Response Body) {String} config.overrides.field
+ *		YAML path of the value e.g. database.password.
+ * @apiSuccess (JSON Response Body) {String} config.overrides.env
+ *		The environment variable the value was read from.
+ * @apiSuccess (JSON Response Body) {String} config.overrides.value
+ *		The effective value, [redacted] for secrets.
+ */
+type ConfigStatus struct {
+	Precedence string           `json:"precedence"`
+	Overrides  []ConfigOverride `json:"overrides"`
+}
+
+type ConfigOverride struct {
+	Field string `json:"field"`
+	Env   string `json:"env"`
+	Value string `json:"value"`
+}
+
+func NewConfigStatus(ovs []config.Override) ConfigStatus {
+	cs := ConfigStatus{
+		Precedence: config.Precedence,
+		Overrides:  make([]ConfigOverride, 0, len(ovs)),
+	}
+	for _, o := range ovs {
+		cs.Overrides = append(cs.Overrides,
+			ConfigOverride{Field: o.Field, Env: o.Env, Value: o.Value})
+	}
+	return cs
+}
+
+/**
+ * @apiDefine APIKey200
+ * @apiSuccess (200 JSON Response Body) {String} ID
+ *		Unique ID of the API key.
+ * @apiSuccess (200 JSON Response Body) {String} userID
+ *		ID of the client app user the API key was issued to.
+ * @apiSuccess (200 JSON Response Body) {String} prefix
+ *		The first characters of the API key, to tell keys apart.
+ * @apiSuccess (200 JSON Response Body) {String} key
+ *		The API key to send in the x-api-key header. It is only included
+ *		when the key is created or rotated and cannot be retrieved later.
+ * @apiSuccess (200 JSON Response Body) {String[]} scopes
+ *		The scopes the API key grants.
+ * @apiSuccess (200 JSON Response Body) {String[]} [origins]
+ *		The origins the API key may be used from, any if omitted.
+ * @apiSuccess (200 JSON Response Body) {String} [expires]
+ *		ISO8601 date the API key expires, never if omitted.
+ * @apiSuccess (200 JSON Response Body) {String} [lastUsed]
+ *		ISO8601 date the API key was last used, to the minute. Omitted if
+ *		never used.
+ * @apiSuccess (200 JSON Response Body) {String} created
+ *		ISO8601 date the API key was created.
+ * @apiSuccess (200 JSON Response Body) {String} lastUpdated
+ *		ISO8601 date the API key was last updated.
+ */
+/**
+ * @apiDefine APIKeys200
+ * @apiSuccess (200 JSON Response Body) {Object[]} apiKeys
+ *		API keys, oldest first, without their key values.
+ * @apiSuccess (200 JSON Response Body) {String} apiKeys.ID
+ *		Unique ID of the API key.
+ * @apiSuccess (200 JSON Response Body) {String} apiKeys.userID
+ *		ID of the client app user the API key was issued to.
+ * @apiSuccess (200 JSON Response Body) {String} apiKeys.prefix
+ *		The first characters of the API key, to tell keys apart.
+ * @apiSuccess (200 JSON Response Body) {String[]} apiKeys.scopes
+ *		The scopes the API key grants.
+ * @apiSuccess (200 JSON Response Body) {String[]} [apiKeys.origins]
+ *		The origins the API key may be used from, any if omitted.
+ * @apiSuccess (200 JSON Response Body) {String} [apiKeys.expires]
+ *		ISO8601 date the API key expires, never if omitted.
+ * @apiSuccess (200 JSON Response Body) {String} [apiKeys.lastUsed]
+ *		ISO8601 date the API key was last used, to the minute. Omitted if
+ *		never used.
+ * @apiSuccess (200 JSON Response Body) {String} apiKeys.created
+ *		ISO8601 date the API key was created.
+ * @apiSuccess (200 JSON Response Body) {String} apiKeys.lastUpdated
+ *		ISO8601 date the API key was last updated.
+ */
+type APIKey struct {
+	ID          string   `json:"ID"`
+	UserID      string   `json:"userID"`
+	Prefix      string   `json:"prefix"`
+	Key         string   `json:"key,omitempty"`
+	Scopes      []string `json:"scopes"`
+	Origins     []string `json:"origins,omitempty"`
+	Expires     string   `json:"expires,omitempty"`
+	LastUsed    string   `json:"lastUsed,omitempty"`
+	Created     string   `json:"created"`
+	LastUpdated string   `json:"lastUpdated"`
+}
+
+func NewAPIKey(k *api.Key) *APIKey {
+	if k == nil {
+		return nil
+	}
+	return &APIKey{
+		ID:          k.ID,
+		UserID:      k.UserID,
+		Prefix:      k.Prefix,
+		Key:         string(k.Val),
+		Scopes:      k.Scopes,
+		Origins:     k.Origins,
+		Expires:     formatTimeIfSet(k.Expires),
+		LastUsed:    formatTimeIfSet(k.LastUsed),
+		Created:     k.Created.Format(config.TimeFormat),
+		LastUpdated: k.LastUpdated.Format(config.TimeFormat),
+	}
+}
+
+// formatTimeIfSet formats t with config.TimeFormat or returns an empty
+// string if t is zero.
+func formatTimeIfSet(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(config.TimeFormat)
+}
+
+func NewAPIKeys(ks []api.Key) []APIKey {
+	ress := make([]APIKey, 0, len(ks))
+	for i := range ks {
+		ress = append(ress, *NewAPIKey(&ks[i]))
+	}
+	return ress
+}
+
+/**
+ * @apiDefine DevToken200
+ * @apiSuccess (200 JSON Response Body) {String} userID
+ *		ID of the user the JWT was issued to.
+ * @apiSuccess (200 JSON Response Body) {String} token
+ *		The JWT to send in the Authorization header as a Bearer token.
+ */
+type DevToken struct {
+	UserID string `json:"userID"`
+	Token  string `json:"token"`
+}
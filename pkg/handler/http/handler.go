@@ -3,21 +3,41 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"net/http"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
-	"github.com/pborman/uuid"
 	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
 	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/health"
+	"github.com/tomogoma/shoppingms/pkg/handler/request"
 	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/metrics"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
 	"strings"
 	"io/ioutil"
 	"github.com/tomogoma/shoppingms/pkg/shopping"
 	"github.com/tomogoma/crdb"
 	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/tracing"
+	"github.com/tomogoma/shoppingms/pkg/validation"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer starts a server span per request as a child of the trace in the
+// request's traceparent header, if any.
+var tracer = otel.Tracer("github.com/tomogoma/shoppingms/pkg/handler/http")
+
 type contextKey string
 
 type Guard interface {
@@ -26,18 +46,89 @@ type Guard interface {
 
 type ShoppingManager interface {
 	errors.ToHTTPResponser
-	InsertShoppingList(JWT, name, mode string) (*shopping.ShoppingList, error)
-	UpdateShoppingList(JWT, shoppingListID string, name, mode crdb.StringUpdate) (*shopping.ShoppingList, error)
-	ShoppingLists(JWT string, offset, count int64) ([]shopping.ShoppingList, error)
-	ShoppingListItems(JWT, shoppingListID string, offset, count int64) ([]shopping.ShoppingList, error)
+	InsertShoppingList(ctx context.Context, JWT, name, mode string) (*shopping.ShoppingList, error)
+	UpdateShoppingList(ctx context.Context, JWT, shoppingListID string, name, mode crdb.StringUpdate) (*shopping.ShoppingList, error)
+	ShoppingLists(ctx context.Context, JWT string, offset, count int64) ([]shopping.ShoppingList, error)
+	UpsertShoppingListItem(ctx context.Context, JWT string, item shopping.ShoppingListItemUpsert) (*shopping.ShoppingListItem, error)
+	DeleteShoppingListItem(ctx context.Context, JWT, ID string) error
+	ShoppingListItems(ctx context.Context, JWT, shoppingListID string, offset, count int64) ([]shopping.ShoppingListItem, error)
+	SearchShoppingItems(ctx context.Context, JWT string, q shopping.ItemSearch, offset, count int64) ([]shopping.ShoppingListItem, error)
+	UserID(JWT string) (string, error)
+}
+
+// CatalogManager detects and merges duplicates in the shared catalog.
+type CatalogManager interface {
+	errors.ToHTTPResponser
+	DuplicateItems(ctx context.Context, threshold float64) ([]shopping.DuplicateGroup, error)
+	DuplicateBrands(ctx context.Context, threshold float64) ([]shopping.DuplicateGroup, error)
+	MergeItems(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Item, error)
+	MergeBrands(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Brand, error)
+}
+
+// PriceManager accepts shared price observations and moderates suspicious
+// ones.
+type PriceManager interface {
+	errors.ToHTTPResponser
+	Submit(ctx context.Context, JWT string, price shopping.Price) (*shopping.Price, error)
+	CurrentPrice(ctx context.Context, brandID, storeBranchID, currency string) (*shopping.Price, error)
+	ModerationQueue(ctx context.Context, offset, count int64) ([]shopping.Price, error)
+	Approve(ctx context.Context, priceID string) (*shopping.Price, error)
+	Reject(ctx context.Context, priceID string) (*shopping.Price, error)
+	Contributors(ctx context.Context, offset, count int64) ([]shopping.Contributor, error)
+}
+
+// APIKeyManager creates, lists, rotates and revokes the API keys of client
+// apps and authorizes requests bearing them.
+type APIKeyManager interface {
+	errors.ToHTTPResponser
+	Create(ctx context.Context, userID string, p apikeys.Policy) (*api.Key, error)
+	Keys(ctx context.Context, userID string, offset, count int64) ([]api.Key, error)
+	Rotate(ctx context.Context, ID string) (*api.Key, error)
+	Revoke(ctx context.Context, ID string) error
+	Authorize(ctx context.Context, userID string, key []byte, scope, origin string) error
+}
+
+// HealthChecker reports on the health of the service and its dependencies.
+type HealthChecker interface {
+	Live() health.Report
+	Ready(ctx context.Context) health.Report
+}
+
+// RateLimiter limits requests per client app user and per authenticated
+// user.
+type RateLimiter interface {
+	AllowAPIKey(ctx context.Context, clUsrID string) (ratelimit.Result, error)
+	AllowUser(ctx context.Context, usrID string) (ratelimit.Result, error)
+}
+
+// Metrics records HTTP request metrics and serves all metrics collected.
+type Metrics interface {
+	ObserveHTTP(route, method string, code int, d time.Duration)
+	APIKeyInvalid(transport string)
+	Handler() http.Handler
+}
+
+// DevTokenIssuer issues JWTs for any user in developer mode.
+type DevTokenIssuer interface {
+	errors.ToHTTPResponser
+	Issue(userID string) (string, error)
 }
 
 type handler struct {
 	errors.ErrToHTTP
 
-	guard   Guard
-	logger  logging.Logger
-	manager ShoppingManager
+	guard        Guard
+	logger       logging.Logger
+	manager      ShoppingManager
+	catalog      CatalogManager
+	prices       PriceManager
+	apiKeys      APIKeyManager
+	health       HealthChecker
+	metrics      Metrics
+	limiter      RateLimiter
+	masterAPIKey *apikeys.MasterKey
+	configStatus ConfigStatus
+	devTokens    DevTokenIssuer
 }
 
 type Config struct {
@@ -46,15 +137,45 @@ type Config struct {
 	BaseURL        string
 	AllowedOrigins []string
 	Manager        ShoppingManager
+	Catalog        CatalogManager
+	Prices         PriceManager
+	APIKeys        APIKeyManager
+	Health         HealthChecker
+	Metrics        Metrics
+	RateLimiter    RateLimiter
+	// MasterAPIKey grants access to admin endpoints, they are
+	// inaccessible if it is nil or empty.
+	MasterAPIKey *apikeys.MasterKey
+	// ConfigOverrides are reported by /status, see config.ApplyEnv().
+	ConfigOverrides []config.Override
+	// DevTokens issues JWTs at /dev/tokens, which is only served if it is
+	// not nil. It must only be set in developer mode.
+	DevTokens DevTokenIssuer
 }
 
 const (
 	keyAPIKey = "x-api-key"
 
-	ctxKeyLog = contextKey("log")
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+
+	ctxKeyLog      = contextKey("log")
+	ctxKeyClUsrID  = contextKey("clUsrID")
+	ctxKeyIsMaster = contextKey("isMaster")
+	ctxKeyTransID  = contextKey("transID")
 )
 
-func NewHandler(conf Config) (http.Handler, error) {
+// Handler serves the HTTP API. Use NewHandler() to instantiate.
+type Handler struct {
+	router http.Handler
+	// cors is the http.Handler wrapping router with CORS headers for the
+	// origins currently allowed.
+	cors atomic.Value
+}
+
+func NewHandler(conf Config) (*Handler, error) {
 	if conf.Guard == nil {
 		return nil, errors.New("Guard was nil")
 	}
@@ -64,23 +185,73 @@ func NewHandler(conf Config) (http.Handler, error) {
 	if conf.Manager == nil {
 		return nil, errors.New("ShoppingManager was nil")
 	}
+	if conf.Catalog == nil {
+		return nil, errors.New("CatalogManager was nil")
+	}
+	if conf.Prices == nil {
+		return nil, errors.New("PriceManager was nil")
+	}
+	if conf.APIKeys == nil {
+		return nil, errors.New("APIKeyManager was nil")
+	}
+	if conf.Health == nil {
+		return nil, errors.New("HealthChecker was nil")
+	}
+	if conf.Metrics == nil {
+		return nil, errors.New("Metrics was nil")
+	}
+	if conf.RateLimiter == nil {
+		return nil, errors.New("RateLimiter was nil")
+	}
 
 	r := mux.NewRouter().PathPrefix(conf.BaseURL).Subrouter()
-	handler{guard: conf.Guard, logger: conf.Logger, manager: conf.Manager}.handleRoute(r)
+	handler{
+		guard:        conf.Guard,
+		logger:       conf.Logger,
+		manager:      conf.Manager,
+		catalog:      conf.Catalog,
+		prices:       conf.Prices,
+		apiKeys:      conf.APIKeys,
+		health:       conf.Health,
+		metrics:      conf.Metrics,
+		limiter:      conf.RateLimiter,
+		masterAPIKey: conf.MasterAPIKey,
+		configStatus: NewConfigStatus(conf.ConfigOverrides),
+		devTokens:    conf.DevTokens,
+	}.handleRoute(r)
+
+	h := &Handler{router: r}
+	h.SetAllowedOrigins(conf.AllowedOrigins)
+	return h, nil
+}
 
+// SetAllowedOrigins replaces the origins allowed by CORS e.g. when config is
+// reloaded. Requests already being served are unaffected.
+func (h *Handler) SetAllowedOrigins(origins []string) {
 	corsOpts := []handlers.CORSOption{
 		handlers.AllowedHeaders([]string{
 			"X-Requested-With", "Accept", "Content-Type", "Content-Length",
 			"Accept-Encoding", "X-CSRF-Token", "Authorization", "X-api-key",
 		}),
-		handlers.AllowedOrigins(conf.AllowedOrigins),
-		handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "OPTIONS"}),
+		handlers.ExposedHeaders([]string{
+			headerRateLimitLimit, headerRateLimitRemaining, headerRateLimitReset,
+			headerRetryAfter,
+		}),
+		handlers.AllowedOrigins(origins),
+		handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"}),
 	}
-	return handlers.CORS(corsOpts...)(r), nil
+	h.cors.Store(handlers.CORS(corsOpts...)(h.router))
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.cors.Load().(http.Handler).ServeHTTP(w, r)
 }
 
 func (s handler) handleRoute(r *mux.Router) {
 	s.handleStatus(r)
+	s.handleLiveness(r)
+	s.handleReadiness(r)
+	s.handleMetrics(r)
 	s.handleDocs(r)
 
 	s.handleNewShoppingList(r)
@@ -92,6 +263,27 @@ func (s handler) handleRoute(r *mux.Router) {
 	s.handleGetShoppingListItems(r)
 	s.handleSearchShoppingItems(r)
 
+	s.handleGetDuplicateItems(r)
+	s.handleGetDuplicateBrands(r)
+	s.handleMergeItems(r)
+	s.handleMergeBrands(r)
+
+	s.handleSubmitPrice(r)
+	s.handleGetCurrentPrice(r)
+	s.handleGetPriceModerationQueue(r)
+	s.handleApprovePrice(r)
+	s.handleRejectPrice(r)
+	s.handleGetContributors(r)
+
+	s.handleNewAPIKey(r)
+	s.handleGetAPIKeys(r)
+	s.handleRotateAPIKey(r)
+	s.handleRevokeAPIKey(r)
+
+	if s.devTokens != nil {
+		s.handleNewDevToken(r)
+	}
+
 	s.handleNotFound(r)
 }
 
@@ -107,28 +299,115 @@ func (s handler) handleRoute(r *mux.Router) {
  * @apiSuccess (200)  {String} version http://semver.org version.
  * @apiSuccess (200)  {String} description Short description of the micro-service.
  * @apiSuccess (200)  {String} canonicalName Canonical name of the micro-service.
+ * @apiUse Health
+ * @apiUse ConfigStatus
  *
  */
 func (s *handler) handleStatus(r *mux.Router) {
 	r.Methods(http.MethodGet).
-		PathPrefix("/status").
+		Path("/status").
 		HandlerFunc(
-		s.apiGuardChain(func(w http.ResponseWriter, r *http.Request) {
+		s.apiGuardChain(apikeys.ScopeAny, func(w http.ResponseWriter, r *http.Request) {
+			ready := s.health.Ready(r.Context())
 			s.respondJsonOn(w, r, nil, struct {
-				Name          string `json:"name"`
-				Version       string `json:"version"`
-				Description   string `json:"description"`
-				CanonicalName string `json:"canonicalName"`
+				Name          string             `json:"name"`
+				Version       string             `json:"version"`
+				Description   string             `json:"description"`
+				CanonicalName string             `json:"canonicalName"`
+				Live          bool               `json:"live"`
+				Ready         bool               `json:"ready"`
+				Dependencies  []DependencyHealth `json:"dependencies"`
+				Config        ConfigStatus       `json:"config"`
 			}{
 				Name:          config.Name,
 				Version:       config.VersionFull,
 				Description:   config.Description,
 				CanonicalName: config.CanonicalWebName(),
+				Live:          s.health.Live().Healthy,
+				Ready:         ready.Healthy,
+				Dependencies:  NewDependencyHealths(ready.Checks, true),
+				Config:        s.configStatus,
 			}, http.StatusOK, nil, s)
 		}),
 	)
 }
 
+/**
+ * @api {get} /status/live Liveness
+ * @apiName Liveness
+ * @apiVersion 0.1.0
+ * @apiGroup Service
+ * @apiDescription Reports whether the instance should be restarted. Meant
+ *		for load balancers and orchestrators so no API key is required.
+ *
+ * @apiSuccess (200) {Boolean} live true.
+ * @apiError (503) {Boolean} live false.
+ *
+ */
+func (s *handler) handleLiveness(r *mux.Router) {
+	r.Methods(http.MethodGet).
+		Path("/status/live").
+		HandlerFunc(
+		s.prepLogger(func(w http.ResponseWriter, r *http.Request) {
+			live := s.health.Live()
+			s.respondJsonOn(w, r, nil, struct {
+				Live bool `json:"live"`
+			}{Live: live.Healthy}, healthCode(live), nil, s)
+		}),
+	)
+}
+
+/**
+ * @api {get} /status/ready Readiness
+ * @apiName Readiness
+ * @apiVersion 0.1.0
+ * @apiGroup Service
+ * @apiDescription Reports whether the instance's dependencies are healthy
+ *		enough for it to be sent traffic. Meant for load balancers and
+ *		orchestrators so no API key is required. See /status for why a
+ *		dependency is unhealthy.
+ *
+ * @apiSuccess (200) {Boolean} ready true.
+ * @apiSuccess (200) {Object[]} dependencies see /status.
+ * @apiError (503) {Boolean} ready false.
+ * @apiError (503) {Object[]} dependencies see /status.
+ *
+ */
+func (s *handler) handleReadiness(r *mux.Router) {
+	r.Methods(http.MethodGet).
+		Path("/status/ready").
+		HandlerFunc(
+		s.prepLogger(func(w http.ResponseWriter, r *http.Request) {
+			ready := s.health.Ready(r.Context())
+			s.respondJsonOn(w, r, nil, struct {
+				Ready        bool               `json:"ready"`
+				Dependencies []DependencyHealth `json:"dependencies"`
+			}{
+				Ready:        ready.Healthy,
+				Dependencies: NewDependencyHealths(ready.Checks, false),
+			}, healthCode(ready), nil, s)
+		}),
+	)
+}
+
+/**
+ * @api {get} /metrics Metrics
+ * @apiName Metrics
+ * @apiVersion 0.1.0
+ * @apiGroup Service
+ * @apiDescription Request, DB and API key validation metrics in the
+ *		prometheus exposition format. Meant to be scraped by prometheus so
+ *		no API key is required.
+ *
+ * @apiSuccess (200) {text} metrics https://prometheus.io/docs/instrumenting/exposition_formats/
+ *
+ */
+func (s *handler) handleMetrics(r *mux.Router) {
+	r.Methods(http.MethodGet).
+		Path("/metrics").
+		Handler(s.metrics.Handler())
+}
+
 /**
  * @api {get} /docs Docs
  * @apiName Docs
@@ -148,13 +427,14 @@ func (s *handler) handleDocs(r *mux.Router) {
  * @apiName InsertShoppingList
  * @apiVersion 0.1.0
  * @apiGroup Service
+ * @apiPermission lists:write
  * @apiDescription insert a shopping list by name if not exists.
  *
  * @apiHeader x-api-key the api key
  * @apiHeader Authorization Bearer token received from authentication
  * 		micro-service in the form "Bearer {token-value}".
  *
- * @apiParam (JSON Request Body) {String} name The name of the new shopping list.
+ * @apiParam (JSON Request Body) {String{..256}} name The name of the new shopping list.
  * @apiParam (JSON Request Body) {String="PREPARATION","SHOPPING"} [mode="PREPARATION"]
  * 		The current mode of the shopping list on the client apps.
  *
@@ -163,14 +443,13 @@ func (s *handler) handleDocs(r *mux.Router) {
  */
 func (s *handler) handleNewShoppingList(r *mux.Router) {
 	r.Methods(http.MethodPut).
-		PathPrefix("/shoppinglists").
+		Path("/shoppinglists").
 		HandlerFunc(
-		s.apiGuardChain(func(w http.ResponseWriter, r *http.Request) {
+		s.apiGuardChain(apikeys.ScopeListsWrite, func(w http.ResponseWriter, r *http.Request) {
 
 			req := struct {
-				JWT  string
-				Name string
-				Mode string
+				JWT string
+				request.ShoppingList
 			}{}
 
 			if err := readJSONBody(r, &req); err != nil {
@@ -184,7 +463,12 @@ func (s *handler) handleNewShoppingList(r *mux.Router) {
 				return
 			}
 
-			sl, err := s.manager.InsertShoppingList(req.JWT, req.Name, req.Mode)
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			sl, err := s.manager.InsertShoppingList(r.Context(), req.JWT, req.Name, req.Mode)
 			s.respondJsonOn(w, r, req, NewShoppingList(sl), http.StatusOK, err, s.manager)
 		}),
 	)
@@ -195,6 +479,7 @@ func (s *handler) handleNewShoppingList(r *mux.Router) {
  * @apiName UpdateShoppingList
  * @apiVersion 0.1.0
  * @apiGroup Service
+ * @apiPermission lists:write
  * @apiDescription update a shopping list with {ID}.
  *
  * @apiHeader x-api-key the api key
@@ -203,9 +488,9 @@ func (s *handler) handleNewShoppingList(r *mux.Router) {
  *
  * @apiParam (URL Path Params) {String} id The ID of the shopping list.
  *
- * @apiParam (JSON Request Body) {String} name
+ * @apiParam (JSON Request Body) {String{..256}} [name]
  * 		Unique name of the shopping list.
- * @apiParam (JSON Request Body) {String="PREPARATION","SHOPPING"} mode
+ * @apiParam (JSON Request Body) {String="PREPARATION","SHOPPING"} [mode]
  * 		The current mode of the shopping list on the client apps.
  *
  * @apiUse ShoppingList200
@@ -213,15 +498,13 @@ func (s *handler) handleNewShoppingList(r *mux.Router) {
  */
 func (s *handler) handleUpdateShoppingList(r *mux.Router) {
 	r.Methods(http.MethodPut).
-		PathPrefix("/shoppinglists/{ID}").
+		Path("/shoppinglists/{ID}").
 		HandlerFunc(
-		s.apiGuardChain(func(w http.ResponseWriter, r *http.Request) {
+		s.apiGuardChain(apikeys.ScopeListsWrite, func(w http.ResponseWriter, r *http.Request) {
 
 			req := struct {
-				JWT            string
-				ShoppingListID string
-				Name           JSONStringUpdate
-				Mode           JSONStringUpdate
+				JWT string
+				request.ShoppingListUpdate
 			}{}
 
 			if err := readJSONBody(r, &req); err != nil {
@@ -237,8 +520,13 @@ func (s *handler) handleUpdateShoppingList(r *mux.Router) {
 				return
 			}
 
-			sl, err := s.manager.UpdateShoppingList(req.JWT, req.ShoppingListID,
-				req.Name.StringUpdate, req.Mode.StringUpdate)
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			sl, err := s.manager.UpdateShoppingList(r.Context(), req.JWT, req.ShoppingListID,
+				request.StringUpdate(req.Name), request.StringUpdate(req.Mode))
 
 			s.respondJsonOn(w, r, req, NewShoppingList(sl), http.StatusOK, err, s.manager)
 		}),
@@ -250,6 +538,7 @@ func (s *handler) handleUpdateShoppingList(r *mux.Router) {
  * @apiName GetShoppingLists
  * @apiVersion 0.1.0
  * @apiGroup Service
+ * @apiPermission lists:read
  * @apiDescription Get shopping lists for a user.
  *
  * @apiHeader x-api-key the api key
@@ -258,7 +547,7 @@ func (s *handler) handleUpdateShoppingList(r *mux.Router) {
  *
  * @apiParam (URL Query Params) {Long} [offset=0]
  * 		Offset index to fetch from.
- * @apiParam (URL Query Params) {Long} [count=10]
+ * @apiParam (URL Query Params) {Long{1-100}} [count=10]
  * 		Number of shopping lists to fetch.
  *
  * @apiUse ShoppingLists200
@@ -266,14 +555,13 @@ func (s *handler) handleUpdateShoppingList(r *mux.Router) {
  */
 func (s *handler) handleGetShoppingLists(r *mux.Router) {
 	r.Methods(http.MethodGet).
-		PathPrefix("/shoppinglists").
+		Path("/shoppinglists").
 		HandlerFunc(
-		s.apiGuardChain(func(w http.ResponseWriter, r *http.Request) {
+		s.apiGuardChain(apikeys.ScopeListsRead, func(w http.ResponseWriter, r *http.Request) {
 
 			req := struct {
-				JWT    string
-				Offset int64
-				Count  int64
+				JWT string
+				request.Page
 			}{}
 
 			var err error
@@ -292,7 +580,12 @@ func (s *handler) handleGetShoppingLists(r *mux.Router) {
 				return
 			}
 
-			sls, err := s.manager.ShoppingLists(req.JWT, req.Offset, req.Count)
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			sls, err := s.manager.ShoppingLists(r.Context(), req.JWT, req.Offset, req.Count)
 			s.respondJsonOn(w, r, req, NewShoppingLists(sls), http.StatusOK, err, s.manager)
 		}),
 	)
@@ -303,6 +596,7 @@ func (s *handler) handleGetShoppingLists(r *mux.Router) {
  * @apiName UpsertShoppingListItem
  * @apiVersion 0.1.0
  * @apiGroup Service
+ * @apiPermission lists:write
  * @apiDescription Update/Insert a list item's values for a shopping list.
  *		Note that all details under the Price object are shared with
  * 		other users and will not be deleted during item deletion.
@@ -313,7 +607,7 @@ func (s *handler) handleGetShoppingLists(r *mux.Router) {
  *
  * @apiParam (URL Path Params) {String} id The ID of the shopping list.
  *
- * @apiParam (JSON Request Body) {String} itemName
+ * @apiParam (JSON Request Body) {String{..256}} itemName
  * 		Name of the ShoppingItem e.g. Toothpaste.
  * @apiParam (JSON Request Body) {Boolean} [inList]
  * 		True if item is in the shopping list, false otherwise.
@@ -322,25 +616,51 @@ func (s *handler) handleGetShoppingLists(r *mux.Router) {
  * 		true automatically sets inList to true.
  * @apiParam (JSON Request Body) {String} [brandName]
  * 		Name of the Brand of the itemName e.g. Colgate.
- * @apiParam (JSON Request Body) {Int} [quantity]
+ * @apiParam (JSON Request Body) {Int{0-}} [quantity]
  * 		Number of items in the shopping List.
  * @apiParam (JSON Request Body) {String} [measurementUnit]
  * 		The measurement Unit to use e.g. 250ml Tub, KG, 5Kg bag, etc.
- * @apiParam (JSON Request Body) {Float} [unitPrice]
+ * @apiParam (JSON Request Body) {Float{0-}} [unitPrice]
  * 		Price of one unit of measurement e.g. 200 if a 250ml Tub costs that.
  * @apiParam (JSON Request Body) {String} [currency=KES]
  *		Active ISO 4217 code denoting currency of the unitPrice.
+ * @apiParam (JSON Request Body) {String} [storeBranchID]
+ *		ID of the store branch where unitPrice was observed.
  *
  * @apiUse ShoppingListItem200
  *
  */
 func (s *handler) handleUpsertShoppingListItem(r *mux.Router) {
 	r.Methods(http.MethodPut).
-		PathPrefix("/shoppinglists/{ID}/items").
+		Path("/shoppinglists/{ID}/items").
 		HandlerFunc(
-		s.apiGuardChain(func(w http.ResponseWriter, r *http.Request) {
-			// TODO()
-			handleError(w, r, r, errors.NewNotImplemented(), s)
+		s.apiGuardChain(apikeys.ScopeListsWrite, func(w http.ResponseWriter, r *http.Request) {
+
+			req := struct {
+				JWT string
+				request.ShoppingListItem
+			}{}
+
+			if err := readJSONBody(r, &req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			req.ShoppingListID = mux.Vars(r)["ID"]
+
+			var err error
+			if req.JWT, err = readJWT(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			item, err := s.manager.UpsertShoppingListItem(r.Context(), req.JWT, req.Upsert())
+			s.respondJsonOn(w, r, req, NewShoppingListItem(item), http.StatusOK, err, s.manager)
 		}),
 	)
 }
@@ -350,6 +670,7 @@ func (s *handler) handleUpsertShoppingListItem(r *mux.Router) {
  * @apiName DeleteShoppingListItem
  * @apiVersion 0.1.0
  * @apiGroup Service
+ * @apiPermission lists:write
  * @apiDescription Delete a shopping list Item. Note that this only deletes
  * 		the top level shopping list, the price details remain intact.
  *
@@ -365,11 +686,28 @@ func (s *handler) handleUpsertShoppingListItem(r *mux.Router) {
  */
 func (s *handler) handleDeleteShoppingListItem(r *mux.Router) {
 	r.Methods(http.MethodDelete).
-		PathPrefix("/items/{ID}").
+		Path("/items/{ID}").
 		HandlerFunc(
-		s.apiGuardChain(func(w http.ResponseWriter, r *http.Request) {
-			// TODO()
-			handleError(w, r, r, errors.NewNotImplemented(), s)
+		s.apiGuardChain(apikeys.ScopeListsWrite, func(w http.ResponseWriter, r *http.Request) {
+
+			req := struct {
+				JWT string
+				ID  string
+			}{}
+
+			req.ID = mux.Vars(r)["ID"]
+
+			var err error
+			if req.JWT, err = readJWT(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if err := s.manager.DeleteShoppingListItem(r.Context(), req.JWT, req.ID); err != nil {
+				handleError(w, r, req, err, s.manager)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
 		}),
 	)
 }
@@ -379,6 +717,7 @@ func (s *handler) handleDeleteShoppingListItem(r *mux.Router) {
  * @apiName GetShoppingListItems
  * @apiVersion 0.1.0
  * @apiGroup Service
+ * @apiPermission lists:read
  * @apiDescription Get shopping items for a shopping list.
  *
  * @apiHeader x-api-key the api key
@@ -387,7 +726,7 @@ func (s *handler) handleDeleteShoppingListItem(r *mux.Router) {
  *
  * @apiParam (URL Query Params) {Long} [offset=0]
  * 		Offset index to fetch from.
- * @apiParam (URL Query Params) {Long} [count=10]
+ * @apiParam (URL Query Params) {Long{1-100}} [count=10]
  * 		Number of shopping lists to fetch.
  *
  * @apiSuccess (200 JSON Response Body) {Object[]} items
@@ -398,15 +737,14 @@ func (s *handler) handleDeleteShoppingListItem(r *mux.Router) {
  */
 func (s *handler) handleGetShoppingListItems(r *mux.Router) {
 	r.Methods(http.MethodGet).
-		PathPrefix("/shoppinglists/{ID}/items").
+		Path("/shoppinglists/{ID}/items").
 		HandlerFunc(
-		s.apiGuardChain(func(w http.ResponseWriter, r *http.Request) {
+		s.apiGuardChain(apikeys.ScopeListsRead, func(w http.ResponseWriter, r *http.Request) {
 
 			req := struct {
 				JWT            string
 				ShoppingListID string
-				Offset         int64
-				Count          int64
+				request.Page
 			}{}
 
 			req.ShoppingListID = mux.Vars(r)["ID"]
@@ -427,8 +765,13 @@ func (s *handler) handleGetShoppingListItems(r *mux.Router) {
 				return
 			}
 
-			sls, err := s.manager.ShoppingListItems(req.JWT, req.ShoppingListID, req.Offset, req.Count)
-			s.respondJsonOn(w, r, req, NewShoppingLists(sls), http.StatusOK, err, s.manager)
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			items, err := s.manager.ShoppingListItems(r.Context(), req.JWT, req.ShoppingListID, req.Offset, req.Count)
+			s.respondJsonOn(w, r, req, NewShoppingListItems(items), http.StatusOK, err, s.manager)
 		}),
 	)
 }
@@ -438,6 +781,7 @@ func (s *handler) handleGetShoppingListItems(r *mux.Router) {
  * @apiName SearchShoppingItems
  * @apiVersion 0.1.0
  * @apiGroup Service
+ * @apiPermission lists:read
  * @apiDescription Search Shopping Items not necessarily belonging to a
  *		specific ShoppingList.
  *
@@ -447,14 +791,12 @@ func (s *handler) handleGetShoppingListItems(r *mux.Router) {
  *
  * @apiParam (URL Query Params) {Long} [offset=0]
  * 		Offset index to fetch from.
- * @apiParam (URL Query Params) {Long} [count=10]
+ * @apiParam (URL Query Params) {Long{1-100}} [count=10]
  * 		Number of shopping lists to fetch.
  * @apiParam (URL Query Params) {String} [brandName]
  * 		If provided, filter items where brandName contains provided text.
  * @apiParam (URL Query Params) {String} [itemName]
  * 		If provided, filter items where itemName contains provided text.
- * @apiParam (URL Query Params) {String} [brandPrice]
- * 		If provided, filter items where brandPrice contains provided text.
  * @apiParam (URL Query Params) {String} [measuringUnit]
  * 		If provided, filter items where measuringUnit contains provided text.
  *
@@ -466,117 +808,1023 @@ func (s *handler) handleGetShoppingListItems(r *mux.Router) {
  */
 func (s *handler) handleSearchShoppingItems(r *mux.Router) {
 	r.Methods(http.MethodGet).
-		PathPrefix("/items/search").
+		Path("/items/search").
 		HandlerFunc(
-		s.apiGuardChain(func(w http.ResponseWriter, r *http.Request) {
-			// TODO()
-			handleError(w, r, r, errors.NewNotImplemented(), s)
-		}),
-	)
-}
+		s.apiGuardChain(apikeys.ScopeListsRead, func(w http.ResponseWriter, r *http.Request) {
 
-func (s handler) handleNotFound(r *mux.Router) {
-	r.NotFoundHandler = http.HandlerFunc(
-		s.prepLogger(func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, "Nothing to see here", http.StatusNotFound)
-		}),
-	)
-}
+			req := struct {
+				JWT string
+				request.ItemSearch
+				request.Page
+			}{}
 
-func (s *handler) apiGuardChain(next http.HandlerFunc) http.HandlerFunc {
-	return s.prepLogger(s.guardRoute(next))
-}
+			var err error
+			if req.JWT, err = readJWT(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
 
-func (s handler) prepLogger(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+			if req.Offset, err = readOffset(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
 
-		log := s.logger.WithHTTPRequest(r).
-			WithField(logging.FieldTransID, uuid.New())
+			if req.Count, err = readCount(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
 
-		log.WithFields(map[string]interface{}{
-			logging.FieldURLPath:    r.URL.Path,
-			logging.FieldHTTPMethod: r.Method,
-		}).Info("new request")
+			q := r.URL.Query()
+			req.ItemSearch = request.ItemSearch{
+				ItemName:      q.Get("itemName"),
+				BrandName:     q.Get("brandName"),
+				MeasuringUnit: q.Get("measuringUnit"),
+			}
 
-		ctx := context.WithValue(r.Context(), ctxKeyLog, log)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	}
-}
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
 
-func (s *handler) guardRoute(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		APIKey := r.Header.Get(keyAPIKey)
-		clUsrID, err := s.guard.APIKeyValid([]byte(APIKey))
-		log := r.Context().Value(ctxKeyLog).(logging.Logger).
-			WithField(logging.FieldClientAppUserID, clUsrID)
-		ctx := context.WithValue(r.Context(), ctxKeyLog, log)
-		if err != nil {
-			handleError(w, r.WithContext(ctx), nil, err, s)
-			return
-		}
-		next.ServeHTTP(w, r.WithContext(ctx))
-	}
+			items, err := s.manager.SearchShoppingItems(r.Context(), req.JWT, req.Query(), req.Offset, req.Count)
+			s.respondJsonOn(w, r, req, NewShoppingListItems(items), http.StatusOK, err, s.manager)
+		}),
+	)
 }
 
-// respondJsonOn marshals respData to json and writes it and the code as the
-// http header to w. If err is not nil, handleError is called instead of the
-// documented write to w.
-func (s *handler) respondJsonOn(w http.ResponseWriter, r *http.Request, reqData interface{},
-	respData interface{}, code int, err error, errSrc errors.ToHTTPResponser) int {
-
-	if err != nil {
-		handleError(w, r, reqData, err, errSrc)
-		return 0
-	}
+/**
+ * @api {get} /catalog/items/duplicates Get Duplicate Items
+ * @apiName GetDuplicateItems
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission catalog:write
+ * @apiDescription Get groups of catalog items whose names are similar
+ *		enough to probably be duplicates e.g. "Tooth paste" and "Toothpaste".
+ *
+ * @apiHeader x-api-key the api key
+ *
+ * @apiParam (URL Query Params) {Float{0-1}} [threshold=0.85]
+ * 		Minimum name similarity (0 to 1) for items to be grouped.
+ *
+ * @apiUse DuplicateGroups200
+ *
+ */
+func (s *handler) handleGetDuplicateItems(r *mux.Router) {
+	r.Methods(http.MethodGet).
+		Path("/catalog/items/duplicates").
+		HandlerFunc(
+		s.apiGuardChain(apikeys.ScopeCatalogWrite, func(w http.ResponseWriter, r *http.Request) {
 
-	respBytes, err := json.Marshal(respData)
-	if err != nil {
-		handleError(w, r, reqData, err, errSrc)
-		return 0
-	}
+			req := request.Duplicates{}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
+			var err error
+			if req.Threshold, err = readThreshold(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
 
-	i, err := w.Write(respBytes)
-	if err != nil {
-		log := r.Context().Value(ctxKeyLog).(logging.Logger)
-		log.Errorf("unable write data to response stream: %v", err)
-		return i
-	}
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
 
-	return i
+			dgs, err := s.catalog.DuplicateItems(r.Context(), req.Threshold)
+			s.respondJsonOn(w, r, req, NewDuplicateGroups(dgs), http.StatusOK, err, s.catalog)
+		}),
+	)
 }
 
-// handleError writes an error to w using errSrc's logic and logs the error
-// using the logger acquired by the prepLogger middleware on r. reqData is
-// included in the log data.
-func handleError(w http.ResponseWriter, r *http.Request, reqData interface{}, err error, errSrc errors.ToHTTPResponser) {
-	reqDataB, _ := json.Marshal(reqData)
-	log := r.Context().Value(ctxKeyLog).(logging.Logger).
-		WithField(logging.FieldRequest, string(reqDataB))
+/**
+ * @api {get} /catalog/brands/duplicates Get Duplicate Brands
+ * @apiName GetDuplicateBrands
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission catalog:write
+ * @apiDescription Get groups of catalog brands of the same item and
+ *		measuring unit whose names are similar enough to probably be
+ *		duplicates e.g. "Colgate" and "Colgate Ltd".
+ *
+ * @apiHeader x-api-key the api key
+ *
+ * @apiParam (URL Query Params) {Float{0-1}} [threshold=0.85]
+ * 		Minimum name similarity (0 to 1) for brands to be grouped.
+ *
+ * @apiUse DuplicateGroups200
+ *
+ */
+func (s *handler) handleGetDuplicateBrands(r *mux.Router) {
+	r.Methods(http.MethodGet).
+		Path("/catalog/brands/duplicates").
+		HandlerFunc(
+		s.apiGuardChain(apikeys.ScopeCatalogWrite, func(w http.ResponseWriter, r *http.Request) {
 
-	if code, ok := errSrc.ToHTTPResponse(err, w); ok {
-		log.WithField(logging.FieldResponseCode, code).Warn(err)
-		return
-	}
+			req := request.Duplicates{}
 
-	log.WithField(logging.FieldResponseCode, http.StatusInternalServerError).
-		Error(err)
-	http.Error(w, "Something wicked happened, please try again later",
-		http.StatusInternalServerError)
-}
+			var err error
+			if req.Threshold, err = readThreshold(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
 
-func readJSONBody(r *http.Request, into interface{}) error {
-	bodyB, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		return errors.NewClientf("read request body: %v", err)
-	}
-	defer r.Body.Close()
-	if err = json.Unmarshal(bodyB, into); err != nil {
-		return errors.NewClientf("invalid json body: %v", err)
-	}
-	return nil
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			dgs, err := s.catalog.DuplicateBrands(r.Context(), req.Threshold)
+			s.respondJsonOn(w, r, req, NewDuplicateGroups(dgs), http.StatusOK, err, s.catalog)
+		}),
+	)
+}
+
+/**
+ * @api {post} /catalog/items/{ID}/merge Merge Items
+ * @apiName MergeItems
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission catalog:write
+ * @apiDescription Merge duplicate items into the item with {ID} in one
+ *		transaction. Brands of the duplicates are moved to the surviving item
+ *		and the duplicates' names are kept as aliases so that future entries
+ *		with those names resolve to the surviving item.
+ *
+ * @apiHeader x-api-key the api key
+ *
+ * @apiParam (URL Path Params) {String} id The ID of the surviving item.
+ *
+ * @apiParam (JSON Request Body) {String[]} duplicateIDs
+ * 		IDs of the items to merge into the surviving item.
+ *
+ * @apiSuccess (200 JSON Response Body) {String} ID Unique ID of the surviving item.
+ * @apiSuccess (200 JSON Response Body) {String} name Name of the surviving item.
+ *
+ */
+func (s *handler) handleMergeItems(r *mux.Router) {
+	r.Methods(http.MethodPost).
+		Path("/catalog/items/{ID}/merge").
+		HandlerFunc(
+		s.apiGuardChain(apikeys.ScopeCatalogWrite, func(w http.ResponseWriter, r *http.Request) {
+
+			req := request.Merge{}
+
+			if err := readJSONBody(r, &req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			req.SurvivorID = mux.Vars(r)["ID"]
+
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			item, err := s.catalog.MergeItems(r.Context(), req.SurvivorID, req.DuplicateIDs)
+			s.respondJsonOn(w, r, req, NewItem(item), http.StatusOK, err, s.catalog)
+		}),
+	)
+}
+
+/**
+ * @api {post} /catalog/brands/{ID}/merge Merge Brands
+ * @apiName MergeBrands
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission catalog:write
+ * @apiDescription Merge duplicate brands into the brand with {ID} in one
+ *		transaction. Prices, shopping list items and barcodes of the
+ *		duplicates are re-pointed to the surviving brand and the duplicates'
+ *		names are kept as aliases so that future entries with those names
+ *		resolve to the surviving brand. All duplicates must be of the same
+ *		item and measuring unit as the surviving brand.
+ *
+ * @apiHeader x-api-key the api key
+ *
+ * @apiParam (URL Path Params) {String} id The ID of the surviving brand.
+ *
+ * @apiParam (JSON Request Body) {String[]} duplicateIDs
+ * 		IDs of the brands to merge into the surviving brand.
+ *
+ * @apiSuccess (200 JSON Response Body) {String} ID Unique ID of the surviving brand.
+ * @apiSuccess (200 JSON Response Body) {String} name Name of the surviving brand.
+ * @apiSuccess (200 JSON Response Body) {Object} measuringUnit
+ *		The unit measurement to which the brand can be priced.
+ * @apiSuccess (200 JSON Response Body) {Object} item
+ *		The item to which the brand is derived.
+ *
+ */
+func (s *handler) handleMergeBrands(r *mux.Router) {
+	r.Methods(http.MethodPost).
+		Path("/catalog/brands/{ID}/merge").
+		HandlerFunc(
+		s.apiGuardChain(apikeys.ScopeCatalogWrite, func(w http.ResponseWriter, r *http.Request) {
+
+			req := request.Merge{}
+
+			if err := readJSONBody(r, &req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			req.SurvivorID = mux.Vars(r)["ID"]
+
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			brand, err := s.catalog.MergeBrands(r.Context(), req.SurvivorID, req.DuplicateIDs)
+			s.respondJsonOn(w, r, req, NewBrand(brand), http.StatusOK, err, s.catalog)
+		}),
+	)
+}
+
+/**
+ * @api {put} /prices Submit Price
+ * @apiName SubmitPrice
+ * @apiVersion 0.1.0
+ * @apiGroup Service
+ * @apiPermission prices:write
+ * @apiDescription Submit an observed price of a brand. Prices are shared
+ *		with other users so the price is scored against recent prices of the
+ *		same brand at the same store branch (or at any store branch if the
+ *		store branch has too little history). Suspicious prices are held
+ *		for moderation with status PENDING and left out of price
+ *		calculations until approved.
+ *
+ * @apiHeader x-api-key the api key
+ * @apiHeader Authorization Bearer token received from authentication
+ * 		micro-service in the form "Bearer {token-value}".
+ *
+ * @apiParam (JSON Request Body) {String} brandID
+ * 		ID of the brand whose price was observed.
+ * @apiParam (JSON Request Body) {String} [storeBranchID]
+ * 		ID of the store branch at which the price was observed.
+ * @apiParam (JSON Request Body) {Float} value
+ * 		The observed price e.g. 200.
+ * @apiParam (JSON Request Body) {String} [currency=KES]
+ *		Active ISO 4217 code denoting currency of value.
+ *
+ * @apiUse Price200
+ *
+ */
+func (s *handler) handleSubmitPrice(r *mux.Router) {
+	r.Methods(http.MethodPut).
+		Path("/prices").
+		HandlerFunc(
+		s.apiGuardChain(apikeys.ScopePricesWrite, func(w http.ResponseWriter, r *http.Request) {
+
+			req := struct {
+				JWT string
+				request.Price
+			}{}
+
+			if err := readJSONBody(r, &req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			var err error
+			if req.JWT, err = readJWT(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			p, err := s.prices.Submit(r.Context(), req.JWT, req.Price.Price())
+			s.respondJsonOn(w, r, req, NewPrice(p), http.StatusOK, err, s.prices)
+		}),
+	)
+}
+
+/**
+ * @api {get} /brands/{ID}/price Get Current Price
+ * @apiName GetCurrentPrice
+ * @apiVersion 0.1.0
+ * @apiGroup Service
+ * @apiPermission prices:read
+ * @apiDescription Get the current price of the brand with {ID}. This is
+ *		the median of recent approved prices weighted by the trust of the
+ *		users who submitted them. Prices pending moderation or rejected are
+ *		never considered.
+ *
+ * @apiHeader x-api-key the api key
+ *
+ * @apiParam (URL Path Params) {String} id The ID of the brand.
+ *
+ * @apiParam (URL Query Params) {String} [storeBranchID]
+ * 		Get the price at this store branch, any store branch if not provided.
+ * @apiParam (URL Query Params) {String} [currency=KES]
+ *		Active ISO 4217 code of the currency to get the price in.
+ *
+ * @apiUse Price200
+ *
+ */
+func (s *handler) handleGetCurrentPrice(r *mux.Router) {
+	r.Methods(http.MethodGet).
+		Path("/brands/{ID}/price").
+		HandlerFunc(
+		s.apiGuardChain(apikeys.ScopePricesRead, func(w http.ResponseWriter, r *http.Request) {
+
+			req := struct {
+				BrandID       string
+				StoreBranchID string
+				Currency      string
+			}{
+				BrandID:       mux.Vars(r)["ID"],
+				StoreBranchID: r.URL.Query().Get("storeBranchID"),
+				Currency:      r.URL.Query().Get("currency"),
+			}
+
+			p, err := s.prices.CurrentPrice(r.Context(), req.BrandID, req.StoreBranchID, req.Currency)
+			s.respondJsonOn(w, r, req, NewPrice(p), http.StatusOK, err, s.prices)
+		}),
+	)
+}
+
+/**
+ * @api {get} /prices/moderation Get Price Moderation Queue
+ * @apiName GetPriceModerationQueue
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission admin
+ * @apiDescription Get submitted prices held for moderation, oldest first.
+ *
+ * @apiHeader x-api-key the master api key or an api key with the admin scope
+ *
+ * @apiParam (URL Query Params) {Long} [offset=0]
+ * 		Offset index to fetch from.
+ * @apiParam (URL Query Params) {Long{1-100}} [count=10]
+ * 		Number of prices to fetch.
+ *
+ * @apiSuccess (200 JSON Response Body) {Object[]} prices
+ *		List of prices. See "200 JSON Response Body" of
+ *		<a href="#api-Admin-ApprovePrice">Approve Price</a>
+ *		for details on what each price looks like.
+ *
+ */
+func (s *handler) handleGetPriceModerationQueue(r *mux.Router) {
+	r.Methods(http.MethodGet).
+		Path("/prices/moderation").
+		HandlerFunc(
+		s.adminGuardChain(func(w http.ResponseWriter, r *http.Request) {
+
+			req := request.Page{}
+
+			var err error
+			if req.Offset, err = readOffset(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if req.Count, err = readCount(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			ps, err := s.prices.ModerationQueue(r.Context(), req.Offset, req.Count)
+			s.respondJsonOn(w, r, req, NewModeratedPrices(ps), http.StatusOK, err, s.prices)
+		}),
+	)
+}
+
+/**
+ * @api {post} /prices/{ID}/approve Approve Price
+ * @apiName ApprovePrice
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission admin
+ * @apiDescription Approve the price with {ID} so that it is used in price
+ *		calculations.
+ *
+ * @apiHeader x-api-key the master api key or an api key with the admin scope
+ *
+ * @apiParam (URL Path Params) {String} id The ID of the price.
+ *
+ * @apiUse Price200
+ * @apiUse ModeratedPrice200
+ *
+ */
+func (s *handler) handleApprovePrice(r *mux.Router) {
+	r.Methods(http.MethodPost).
+		Path("/prices/{ID}/approve").
+		HandlerFunc(
+		s.adminGuardChain(func(w http.ResponseWriter, r *http.Request) {
+			req := struct {
+				PriceID string
+			}{PriceID: mux.Vars(r)["ID"]}
+			p, err := s.prices.Approve(r.Context(), req.PriceID)
+			s.respondJsonOn(w, r, req, NewModeratedPrice(p), http.StatusOK, err, s.prices)
+		}),
+	)
+}
+
+/**
+ * @api {post} /prices/{ID}/reject Reject Price
+ * @apiName RejectPrice
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission admin
+ * @apiDescription Reject the price with {ID} so that it is never used in
+ *		price calculations.
+ *
+ * @apiHeader x-api-key the master api key or an api key with the admin scope
+ *
+ * @apiParam (URL Path Params) {String} id The ID of the price.
+ *
+ * @apiUse Price200
+ * @apiUse ModeratedPrice200
+ *
+ */
+func (s *handler) handleRejectPrice(r *mux.Router) {
+	r.Methods(http.MethodPost).
+		Path("/prices/{ID}/reject").
+		HandlerFunc(
+		s.adminGuardChain(func(w http.ResponseWriter, r *http.Request) {
+			req := struct {
+				PriceID string
+			}{PriceID: mux.Vars(r)["ID"]}
+			p, err := s.prices.Reject(r.Context(), req.PriceID)
+			s.respondJsonOn(w, r, req, NewModeratedPrice(p), http.StatusOK, err, s.prices)
+		}),
+	)
+}
+
+/**
+ * @api {get} /contributors Get Contributors
+ * @apiName GetContributors
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission admin
+ * @apiDescription Get users who submitted prices together with their trust
+ *		scores. A user's trust grows as other users' observations confirm
+ *		their prices and drops as they are contradicted. Prices from trusted
+ *		users count more when calculating current prices.
+ *
+ * @apiHeader x-api-key the master api key or an api key with the admin scope
+ *
+ * @apiParam (URL Query Params) {Long} [offset=0]
+ * 		Offset index to fetch from.
+ * @apiParam (URL Query Params) {Long{1-100}} [count=10]
+ * 		Number of contributors to fetch.
+ *
+ * @apiUse Contributors200
+ *
+ */
+func (s *handler) handleGetContributors(r *mux.Router) {
+	r.Methods(http.MethodGet).
+		Path("/contributors").
+		HandlerFunc(
+		s.adminGuardChain(func(w http.ResponseWriter, r *http.Request) {
+
+			req := request.Page{}
+
+			var err error
+			if req.Offset, err = readOffset(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if req.Count, err = readCount(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			cs, err := s.prices.Contributors(r.Context(), req.Offset, req.Count)
+			s.respondJsonOn(w, r, req, NewContributors(cs), http.StatusOK, err, s.prices)
+		}),
+	)
+}
+
+/**
+ * @api {post} /apikeys Create API Key
+ * @apiName CreateAPIKey
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission admin
+ * @apiDescription Create an API key for a client app. The key value is
+ *		only returned now, store it safely.
+ *
+ * @apiHeader x-api-key the master api key or an api key with the admin scope
+ *
+ * @apiParam (JSON Request Body) {String} userID
+ *		The (numeric) ID of the client app user to issue the key to.
+ * @apiParam (JSON Request Body) {String[]} [scopes]
+ *		The scopes the key grants, any of lists:read, lists:write,
+ *		prices:read, prices:write, catalog:write and admin. Defaults to
+ *		lists:read, lists:write, prices:read and prices:write. At most 20.
+ * @apiParam (JSON Request Body) {String[]} [origins]
+ *		The origins (scheme://host[:port]) the key may be used from. Requests
+ *		bearing the key must then carry one of them in their Origin header.
+ *		The key may be used from anywhere if omitted. At most 20.
+ * @apiParam (JSON Request Body) {String} [expires]
+ *		ISO8601 date from which the key is no longer valid. The key does
+ *		not expire if omitted.
+ *
+ * @apiUse APIKey200
+ *
+ */
+func (s *handler) handleNewAPIKey(r *mux.Router) {
+	r.Methods(http.MethodPost).
+		Path("/apikeys").
+		HandlerFunc(
+		s.adminGuardChain(func(w http.ResponseWriter, r *http.Request) {
+
+			req := request.APIKey{}
+
+			if err := readJSONBody(r, &req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			p := req.Policy()
+			if req.Expires != "" {
+				var err error
+				if p.Expires, err = time.Parse(config.TimeFormat, req.Expires); err != nil {
+					handleError(w, r, req, validation.NewErrorf("expires", "%v", err), s)
+					return
+				}
+			}
+
+			k, err := s.apiKeys.Create(r.Context(), req.UserID, p)
+			s.respondJsonOn(w, r, req, NewAPIKey(k), http.StatusOK, err, s.apiKeys)
+		}),
+	)
+}
+
+/**
+ * @api {get} /apikeys Get API Keys
+ * @apiName GetAPIKeys
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission admin
+ * @apiDescription Get API keys of all client apps or of one client app.
+ *		Key values are not included.
+ *
+ * @apiHeader x-api-key the master api key or an api key with the admin scope
+ *
+ * @apiParam (URL Query Params) {String} [userID]
+ * 		Only fetch API keys issued to this client app user.
+ * @apiParam (URL Query Params) {Long} [offset=0]
+ * 		Offset index to fetch from.
+ * @apiParam (URL Query Params) {Long{1-100}} [count=10]
+ * 		Number of API keys to fetch.
+ *
+ * @apiUse APIKeys200
+ *
+ */
+func (s *handler) handleGetAPIKeys(r *mux.Router) {
+	r.Methods(http.MethodGet).
+		Path("/apikeys").
+		HandlerFunc(
+		s.adminGuardChain(func(w http.ResponseWriter, r *http.Request) {
+
+			req := struct {
+				UserID string
+				request.Page
+			}{UserID: r.URL.Query().Get("userID")}
+
+			var err error
+			if req.Offset, err = readOffset(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if req.Count, err = readCount(r); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			ks, err := s.apiKeys.Keys(r.Context(), req.UserID, req.Offset, req.Count)
+			s.respondJsonOn(w, r, req, NewAPIKeys(ks), http.StatusOK, err, s.apiKeys)
+		}),
+	)
+}
+
+/**
+ * @api {post} /apikeys/{ID}/rotate Rotate API Key
+ * @apiName RotateAPIKey
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission admin
+ * @apiDescription Replace the API key with {ID} by a new key for the same
+ *		client app. The replaced key is rejected from then on. The new key
+ *		value is only returned now, store it safely.
+ *
+ * @apiHeader x-api-key the master api key or an api key with the admin scope
+ *
+ * @apiParam (URL Path Params) {String} id The ID of the API key.
+ *
+ * @apiUse APIKey200
+ *
+ */
+func (s *handler) handleRotateAPIKey(r *mux.Router) {
+	r.Methods(http.MethodPost).
+		Path("/apikeys/{ID}/rotate").
+		HandlerFunc(
+		s.adminGuardChain(func(w http.ResponseWriter, r *http.Request) {
+			req := struct {
+				ID string
+			}{ID: mux.Vars(r)["ID"]}
+			k, err := s.apiKeys.Rotate(r.Context(), req.ID)
+			s.respondJsonOn(w, r, req, NewAPIKey(k), http.StatusOK, err, s.apiKeys)
+		}),
+	)
+}
+
+/**
+ * @api {delete} /apikeys/{ID} Revoke API Key
+ * @apiName RevokeAPIKey
+ * @apiVersion 0.1.0
+ * @apiGroup Admin
+ * @apiPermission admin
+ * @apiDescription Revoke the API key with {ID}. Requests bearing it are
+ *		rejected from then on.
+ *
+ * @apiHeader x-api-key the master api key or an api key with the admin scope
+ *
+ * @apiParam (URL Path Params) {String} id The ID of the API key.
+ *
+ */
+func (s *handler) handleRevokeAPIKey(r *mux.Router) {
+	r.Methods(http.MethodDelete).
+		Path("/apikeys/{ID}").
+		HandlerFunc(
+		s.adminGuardChain(func(w http.ResponseWriter, r *http.Request) {
+			req := struct {
+				ID string
+			}{ID: mux.Vars(r)["ID"]}
+			if err := s.apiKeys.Revoke(r.Context(), req.ID); err != nil {
+				handleError(w, r, req, err, s.apiKeys)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+}
+
+/**
+ * @api {post} /dev/tokens New Dev Token
+ * @apiName NewDevToken
+ * @apiVersion 0.1.0
+ * @apiGroup Dev
+ * @apiPermission admin
+ * @apiDescription Issue a JWT for any user, for use in the Authorization
+ *		header of other endpoints. Only available in developer mode, where
+ *		JWTs are signed with a key generated on start up in place of the
+ *		authentication micro-service's key.
+ *
+ * @apiHeader x-api-key the master api key (the dev api key in developer mode)
+ *
+ * @apiParam (JSON Request Body) {String} userID The ID of the user the JWT
+ *		is issued to.
+ *
+ * @apiUse DevToken200
+ *
+ */
+func (s *handler) handleNewDevToken(r *mux.Router) {
+	r.Methods(http.MethodPost).
+		Path("/dev/tokens").
+		HandlerFunc(
+		s.adminGuardChain(func(w http.ResponseWriter, r *http.Request) {
+
+			req := request.DevToken{}
+
+			if err := readJSONBody(r, &req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			if err := validation.Struct(req); err != nil {
+				handleError(w, r, req, err, s)
+				return
+			}
+
+			JWT, err := s.devTokens.Issue(req.UserID)
+			s.respondJsonOn(w, r, req, DevToken{UserID: req.UserID, Token: JWT},
+				http.StatusOK, err, s.devTokens)
+		}),
+	)
+}
+
+// handleNotFound responds to requests that match no route, with 405 Method
+// Not Allowed if their path matches a route with other methods. mux's
+// MethodNotAllowedHandler is not used as it is not reliably called for
+// subrouters. Both are traced and measured as metrics.RouteUnmatched.
+func (s handler) handleNotFound(router *mux.Router) {
+	unmatched := func(*http.Request) string { return metrics.RouteUnmatched }
+	router.NotFoundHandler = http.HandlerFunc(
+		s.prepRouteLogger(unmatched, func(w http.ResponseWriter, r *http.Request) {
+			if allowed := allowedMethods(router, r); len(allowed) > 0 {
+				w.Header().Set("Allow", strings.Join(allowed, ", "))
+				writeProblem(w, r, Problem{Status: http.StatusMethodNotAllowed,
+					Detail: r.Method + " is not supported on this path"})
+				return
+			}
+			writeProblem(w, r, Problem{Status: http.StatusNotFound,
+				Detail: "Nothing to see here"})
+		}),
+	)
+}
+
+// allowedMethods returns the methods of the routes in router that match
+// the path of r.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var allowed []string
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut,
+		http.MethodDelete} {
+		if method == r.Method {
+			continue
+		}
+		req := r.Clone(context.Background())
+		req.Method = method
+		m := mux.RouteMatch{}
+		if router.Match(req, &m) && m.MatchErr == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// apiGuardChain only lets requests bearing an API key that grants scope
+// through to next, see guardRoute().
+func (s *handler) apiGuardChain(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return s.prepLogger(s.guardRoute(scope, s.rateLimit(next)))
+}
+
+func (s *handler) adminGuardChain(next http.HandlerFunc) http.HandlerFunc {
+	return s.apiGuardChain(apikeys.ScopeAdmin, next)
+}
+
+func (s handler) prepLogger(next http.HandlerFunc) http.HandlerFunc {
+	return s.prepRouteLogger(routeTemplate, next)
+}
+
+// prepRouteLogger is prepLogger with the route that requests are traced
+// and measured as returned by routeOf.
+func (s handler) prepRouteLogger(routeOf func(*http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		route := routeOf(r)
+		prop := otel.GetTextMapPropagator()
+		ctx := prop.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPRouteKey.String(route),
+			),
+		)
+		defer span.End()
+
+		transID := tracing.TransID(ctx)
+		log := s.logger.WithHTTPRequest(r).
+			WithField(logging.FieldTransID, transID)
+
+		log.WithFields(map[string]interface{}{
+			logging.FieldURLPath:    r.URL.Path,
+			logging.FieldHTTPMethod: r.Method,
+		}).Info("new request")
+
+		ctx = context.WithValue(ctx, ctxKeyLog, log)
+		ctx = context.WithValue(ctx, ctxKeyTransID, transID)
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(sw, r.WithContext(ctx))
+		s.metrics.ObserveHTTP(route, r.Method, sw.code, time.Since(start))
+
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(sw.code))
+		if sw.code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.code))
+		}
+	}
+}
+
+// statusWriter remembers the status code written to the ResponseWriter.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// routeTemplate returns the template of the route r matched so that
+// metrics are not labeled with raw paths.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return metrics.RouteUnmatched
+	}
+	tmpl, err := route.GetPathTemplate()
+	if err != nil {
+		return metrics.RouteUnmatched
+	}
+	return tmpl
+}
+
+// guardRoute only lets requests through to next if they bear the master
+// API key or a valid API key that grants scope and may be used from the
+// request's Origin. The master key is checked before the guard so that a
+// master key replaced on config reload takes effect straight away.
+func (s *handler) guardRoute(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey := []byte(r.Header.Get(keyAPIKey))
+		if s.masterAPIKey.Matches(APIKey) {
+			ctx := context.WithValue(r.Context(), ctxKeyIsMaster, true)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+		clUsrID, err := s.guard.APIKeyValid(APIKey)
+		log := r.Context().Value(ctxKeyLog).(logging.Logger).
+			WithField(logging.FieldClientAppUserID, clUsrID)
+		ctx := context.WithValue(r.Context(), ctxKeyLog, log)
+		if err == nil {
+			err = s.apiKeys.Authorize(ctx, clUsrID, APIKey, scope, r.Header.Get("Origin"))
+		}
+		if err != nil {
+			s.metrics.APIKeyInvalid(metrics.TransportHTTP)
+			handleError(w, r.WithContext(ctx), nil, err, s)
+			return
+		}
+		ctx = context.WithValue(ctx, ctxKeyClUsrID, clUsrID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// rateLimit only lets requests through while neither the client app user
+// validated by guardRoute() nor the user owning the request's JWT, if any,
+// is over their rate limit. Requests are let through if a limit cannot be
+// checked. Requests with invalid JWTs are only limited per client app user
+// and are rejected later on. Requests bearing the master API key are not
+// limited.
+func (s *handler) rateLimit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if isMaster, _ := r.Context().Value(ctxKeyIsMaster).(bool); isMaster {
+			next.ServeHTTP(w, r)
+			return
+		}
+		log := r.Context().Value(ctxKeyLog).(logging.Logger)
+		clUsrID, _ := r.Context().Value(ctxKeyClUsrID).(string)
+
+		res, err := s.limiter.AllowAPIKey(r.Context(), clUsrID)
+		if err != nil {
+			log.Warnf("Check client app rate limit: %v", err)
+			res = ratelimit.Result{Allowed: true}
+		}
+		if JWT, err := readJWT(r); err == nil && res.Allowed {
+			if usrID, err := s.manager.UserID(JWT); err == nil {
+				usrRes, err := s.limiter.AllowUser(r.Context(), usrID)
+				if err != nil {
+					log.Warnf("Check user rate limit: %v", err)
+				} else {
+					res = ratelimit.Stricter(res, usrRes)
+				}
+			}
+		}
+
+		writeRateLimit(w, res)
+		if !res.Allowed {
+			log.WithField(logging.FieldResponseCode, http.StatusTooManyRequests).
+				Warn("rate limit exceeded")
+			writeProblem(w, r, Problem{Status: http.StatusTooManyRequests,
+				Detail: "Rate limit exceeded, please try again later"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// writeRateLimit sets the RateLimit-* headers describing res, and the
+// Retry-After header if the request was not allowed.
+func writeRateLimit(w http.ResponseWriter, res ratelimit.Result) {
+	if res.Limit == 0 {
+		return
+	}
+	h := w.Header()
+	h.Set(headerRateLimitLimit, strconv.Itoa(res.Limit))
+	h.Set(headerRateLimitRemaining, strconv.Itoa(res.Remaining))
+	h.Set(headerRateLimitReset, ceilSeconds(res.Reset))
+	if !res.Allowed {
+		h.Set(headerRetryAfter, ceilSeconds(res.RetryAfter))
+	}
+}
+
+// ceilSeconds formats d as a whole number of seconds, rounded up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// respondJsonOn marshals respData to json and writes it and the code as the
+// http header to w. If err is not nil, handleError is called instead of the
+// documented write to w.
+func (s *handler) respondJsonOn(w http.ResponseWriter, r *http.Request, reqData interface{},
+	respData interface{}, code int, err error, errSrc errors.ToHTTPResponser) int {
+
+	if err != nil {
+		handleError(w, r, reqData, err, errSrc)
+		return 0
+	}
+
+	respBytes, err := json.Marshal(respData)
+	if err != nil {
+		handleError(w, r, reqData, err, errSrc)
+		return 0
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	i, err := w.Write(respBytes)
+	if err != nil {
+		log := r.Context().Value(ctxKeyLog).(logging.Logger)
+		log.Errorf("unable write data to response stream: %v", err)
+		return i
+	}
+
+	return i
+}
+
+// handleError writes err to w as a Problem and logs it using the logger
+// acquired by the prepLogger middleware on r. reqData is included in the
+// log data without its credentials. Validation errors list the invalid fields, other errors get the
+// status code and detail written by errSrc or, if errSrc does not
+// recognise err, an internal error with the detail withheld.
+func handleError(w http.ResponseWriter, r *http.Request, reqData interface{}, err error, errSrc errors.ToHTTPResponser) {
+	log := r.Context().Value(ctxKeyLog).(logging.Logger).
+		WithField(logging.FieldRequest, logging.RequestData(reqData))
+
+	if vs, ok := validation.Violations(err); ok {
+		log.WithField(logging.FieldResponseCode, http.StatusBadRequest).Warn(err)
+		writeProblem(w, r, Problem{
+			Type:          ProblemTypeInvalidParams,
+			Title:         "Invalid request parameters",
+			Status:        http.StatusBadRequest,
+			Detail:        err.Error(),
+			InvalidParams: NewInvalidParams(vs),
+		})
+		return
+	}
+
+	rec := newProblemRecorder()
+	if code, ok := errSrc.ToHTTPResponse(err, rec); ok {
+		log.WithField(logging.FieldResponseCode, code).Warn(err)
+		writeProblem(w, r, Problem{Status: code, Detail: rec.detail()})
+		return
+	}
+
+	log.WithField(logging.FieldResponseCode, http.StatusInternalServerError).
+		Error(err)
+	writeProblem(w, r, Problem{Status: http.StatusInternalServerError,
+		Detail: msgInternalError})
+}
+
+func healthCode(rprt health.Report) int {
+	if !rprt.Healthy {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func readJSONBody(r *http.Request, into interface{}) error {
+	bodyB, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return errors.NewClientf("read request body: %v", err)
+	}
+	defer r.Body.Close()
+	if err = json.Unmarshal(bodyB, into); err != nil {
+		if tErr, ok := err.(*json.UnmarshalTypeError); ok && tErr.Field != "" {
+			return validation.NewErrorf(tErr.Field, "wrong type, got a JSON %s", tErr.Value)
+		}
+		return errors.NewClientf("invalid json body: %v", err)
+	}
+	return nil
 }
 
 func readJWT(r *http.Request) (string, error) {
@@ -598,7 +1846,7 @@ func readOffset(r *http.Request) (int64, error) {
 	}
 	offset, err := strconv.ParseInt(offsetStr, 10, 64)
 	if err != nil {
-		return -1, errors.NewClientf("invalid offset: %v", err)
+		return -1, validation.NewErrorf("offset", "%v", err)
 	}
 	return offset, nil
 }
@@ -606,11 +1854,23 @@ func readOffset(r *http.Request) (int64, error) {
 func readCount(r *http.Request) (int64, error) {
 	countStr := r.URL.Query().Get("count")
 	if countStr == "" {
-		return 10, nil
+		return request.DefaultCount, nil
 	}
 	count, err := strconv.ParseInt(countStr, 10, 64)
 	if err != nil {
-		return -1, errors.NewClientf("invalid count: %v", err)
+		return -1, validation.NewErrorf("count", "%v", err)
 	}
 	return count, nil
 }
+
+func readThreshold(r *http.Request) (float64, error) {
+	thresholdStr := r.URL.Query().Get("threshold")
+	if thresholdStr == "" {
+		return shopping.DefaultSimilarityThreshold, nil
+	}
+	threshold, err := strconv.ParseFloat(thresholdStr, 64)
+	if err != nil {
+		return -1, validation.NewErrorf("threshold", "%v", err)
+	}
+	return threshold, nil
+}
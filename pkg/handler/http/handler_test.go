@@ -2,13 +2,28 @@ package http
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/handler/request"
+	"github.com/tomogoma/shoppingms/pkg/health"
 	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/metrics"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
 	testingH "github.com/tomogoma/shoppingms/pkg/mocks"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 func TestNewHandler(t *testing.T) {
@@ -47,7 +62,18 @@ func TestNewHandler(t *testing.T) {
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			h, err := NewHandler(tc.guard, tc.logger, "", tc.allowedOrigins)
+			h, err := NewHandler(Config{
+				Guard:          tc.guard,
+				Logger:         tc.logger,
+				AllowedOrigins: tc.allowedOrigins,
+				Manager:        &shopping.Manager{},
+				Catalog:        &shopping.Catalog{},
+				Prices:         &shopping.Prices{},
+				APIKeys:        &apiKeyManager{},
+				Health:         &health.Health{},
+				Metrics:        newMetrics(t),
+				RateLimiter:    newLimiter(t),
+			})
 			if tc.expErr {
 				if err == nil {
 					t.Fatal("Expected an error but got nil")
@@ -74,6 +100,7 @@ func TestHandler_handleRoute(t *testing.T) {
 		reqWBasicAuth bool
 		expStatusCode int
 		guard         Guard
+		health        HealthChecker
 	}{
 		{
 			name:          "status",
@@ -89,6 +116,59 @@ func TestHandler_handleRoute(t *testing.T) {
 			reqMethod:     http.MethodGet,
 			expStatusCode: http.StatusInternalServerError,
 		},
+		{
+			name:          "status unhealthy",
+			guard:         &testingH.Guard{},
+			health:        newHealth(t, errors.New("db down")),
+			reqURLSuffix:  "/status",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusOK,
+		},
+		{
+			name:          "live",
+			guard:         &testingH.Guard{ExpAPIKValidErr: errors.Newf("guard error")},
+			health:        newHealth(t, errors.New("db down")),
+			reqURLSuffix:  "/status/live",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusOK,
+		},
+		{
+			name:          "ready",
+			guard:         &testingH.Guard{ExpAPIKValidErr: errors.Newf("guard error")},
+			health:        newHealth(t, nil),
+			reqURLSuffix:  "/status/ready",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusOK,
+		},
+		{
+			name:          "not ready",
+			guard:         &testingH.Guard{},
+			health:        newHealth(t, errors.New("db down")),
+			reqURLSuffix:  "/status/ready",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:          "metrics",
+			guard:         &testingH.Guard{ExpAPIKValidErr: errors.Newf("guard error")},
+			reqURLSuffix:  "/metrics",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusOK,
+		},
+		{
+			name:          "catalog without scope",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/catalog/items/duplicates",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusForbidden,
+		},
+		{
+			name:          "API keys without master key",
+			guard:         &testingH.Guard{},
+			reqURLSuffix:  "/apikeys",
+			reqMethod:     http.MethodGet,
+			expStatusCode: http.StatusForbidden,
+		},
 		{
 			name:          "not found",
 			guard:         &testingH.Guard{},
@@ -101,7 +181,8 @@ func TestHandler_handleRoute(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 
 			lg := &testingH.Logger{}
-			h := newHandler(t, tc.guard, lg, tc.baseURL, nil)
+			h := newHandler(t, Config{Guard: tc.guard, Logger: lg, Health: tc.health,
+				BaseURL: tc.baseURL})
 			srvr := httptest.NewServer(h)
 			defer srvr.Close()
 
@@ -133,10 +214,925 @@ func TestHandler_handleRoute(t *testing.T) {
 	}
 }
 
-func newHandler(t *testing.T, g Guard, lg logging.Logger, baseURL string, allowedOrigins []string) http.Handler {
-	h, err := NewHandler(g, lg, baseURL, allowedOrigins)
+func TestHandler_metrics(t *testing.T) {
+	lg := &testingH.Logger{}
+	h := newHandler(t, Config{
+		Guard:   &testingH.Guard{ExpAPIKValidErr: errors.NewForbidden("guard")},
+		Logger:  lg,
+		BaseURL: "/base",
+	})
+	srvr := httptest.NewServer(h)
+	defer srvr.Close()
+
+	for _, req := range []struct{ method, path string }{
+		{method: http.MethodGet, path: "/base/status"},
+		{method: http.MethodGet, path: "/base/status"},
+		{method: http.MethodGet, path: "/base/none_existent/123"},
+		{method: http.MethodGet, path: "/outside_base"},
+		{method: http.MethodPut, path: "/base/status"},
+	} {
+		httpReq, err := http.NewRequest(req.method, srvr.URL+req.path, nil)
+		if err != nil {
+			t.Fatalf("Error setting up: new request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(httpReq)
+		if err != nil {
+			t.Fatalf("Do request error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(srvr.URL + "/base/metrics")
+	if err != nil {
+		t.Fatalf("Do request error: %v", err)
+	}
+	defer resp.Body.Close()
+	bodyB, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		t.Fatalf("http.NewHandler(): %v", err)
+		t.Fatalf("Read response body: %v", err)
+	}
+	body := string(bodyB)
+	expLines := []string{
+		`shoppingms_http_requests_total{code="403",method="GET",route="/base/status"} 2`,
+		`shoppingms_http_requests_total{code="404",method="GET",route="unmatched"} 2`,
+		`shoppingms_http_requests_total{code="405",method="PUT",route="unmatched"} 1`,
+		`shoppingms_api_key_validation_failures_total{transport="http"} 2`,
+	}
+	for _, l := range expLines {
+		if !strings.Contains(body, l) {
+			lg.PrintLogs(t)
+			t.Errorf("Expected metrics to contain\n%s\ngot\n%s", l, body)
+		}
+	}
+}
+
+func TestHandler_traceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	lg := &testingH.Logger{}
+	h := newHandler(t, Config{Logger: lg})
+	srvr := httptest.NewServer(h)
+	defer srvr.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srvr.URL+"/status", nil)
+	if err != nil {
+		t.Fatalf("Error setting up: new request: %v", err)
+	}
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do request error: %v", err)
+	}
+	resp.Body.Close()
+
+	if tp := resp.Header.Get("traceparent"); tp != "" {
+		t.Errorf("Expected no traceparent in the response, got %q", tp)
+	}
+	if transID := lg.Fields[logging.FieldTransID]; transID != traceID {
+		lg.PrintLogs(t)
+		t.Errorf("Expected log transaction ID %s, got %v", traceID, transID)
+	}
+}
+
+func TestHandler_statusConfig(t *testing.T) {
+	ovs := []config.Override{
+		{Field: "database.password", Env: "SHOPPINGMS_DATABASE_PASSWORD_FILE", Value: config.RedactedValue},
+		{Field: "database.port", Env: "SHOPPINGMS_DATABASE_PORT", Value: "26258"},
+	}
+	h := newHandler(t, Config{
+		ConfigOverrides: ovs,
+	})
+	srvr := httptest.NewServer(h)
+	defer srvr.Close()
+
+	resp, err := http.Get(srvr.URL + "/status")
+	if err != nil {
+		t.Fatalf("Do request error: %v", err)
+	}
+	defer resp.Body.Close()
+	var status struct {
+		Config ConfigStatus `json:"config"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("Decode response: %v", err)
+	}
+	expect := NewConfigStatus(ovs)
+	if !reflect.DeepEqual(status.Config, expect) {
+		t.Errorf("Config status mismatch:\nexpect:\t%+v\ngot:\t%+v", expect, status.Config)
+	}
+}
+
+func TestHandler_reload(t *testing.T) {
+	master := apikeys.NewMasterKey("master")
+	h := newHandler(t, Config{
+		AllowedOrigins: []string{"https://a.example"},
+		MasterAPIKey:   master,
+	})
+	srvr := httptest.NewServer(h)
+	defer srvr.Close()
+
+	do := func(apiKey, origin string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, srvr.URL+"/apikeys", nil)
+		if err != nil {
+			t.Fatalf("Error setting up: new request: %v", err)
+		}
+		req.Header.Set("x-api-key", apiKey)
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Do request error: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := do("master", "https://a.example")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected master key accepted, got %s", resp.Status)
+	}
+	if o := resp.Header.Get("Access-Control-Allow-Origin"); o != "https://a.example" {
+		t.Errorf("Expected origin https://a.example allowed, got %q", o)
+	}
+
+	master.Set("rotated")
+	h.SetAllowedOrigins([]string{"https://b.example"})
+
+	if resp := do("master", "https://b.example"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected replaced master key rejected, got %s", resp.Status)
+	}
+	resp = do("rotated", "https://b.example")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected new master key accepted, got %s", resp.Status)
+	}
+	if o := resp.Header.Get("Access-Control-Allow-Origin"); o != "https://b.example" {
+		t.Errorf("Expected origin https://b.example allowed, got %q", o)
+	}
+	if resp := do("rotated", "https://a.example"); resp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected origin https://a.example no longer allowed")
+	}
+}
+
+func TestHandler_rateLimit(t *testing.T) {
+	lm, err := ratelimit.NewLimiter(ratelimit.NewMemory(),
+		ratelimit.WithAPIKeyLimit(ratelimit.Limit{Rate: 0.001, Burst: 3}),
+		ratelimit.WithUserLimit(ratelimit.Limit{Rate: 0.001, Burst: 1}),
+	)
+	if err != nil {
+		t.Fatalf("Error setting up: new limiter: %v", err)
+	}
+	lg := &testingH.Logger{}
+	h := newHandler(t, Config{
+		Logger:      lg,
+		Manager:     &fakeUserManager{ShoppingManager: &shopping.Manager{}, usrID: "usr1"},
+		RateLimiter: lm,
+	})
+	srvr := httptest.NewServer(h)
+	defer srvr.Close()
+
+	tt := []struct {
+		name          string
+		withJWT       bool
+		expStatusCode int
+		expHeaders    map[string]string
+	}{
+		{
+			name:          "within limits",
+			withJWT:       true,
+			expStatusCode: http.StatusOK,
+			expHeaders:    map[string]string{"RateLimit-Limit": "1", "RateLimit-Remaining": "0"},
+		},
+		{
+			name:          "user over limit",
+			withJWT:       true,
+			expStatusCode: http.StatusTooManyRequests,
+			expHeaders:    map[string]string{"RateLimit-Limit": "1", "Retry-After": "1000"},
+		},
+		{
+			name:          "API key within limit",
+			expStatusCode: http.StatusOK,
+			expHeaders:    map[string]string{"RateLimit-Limit": "3", "RateLimit-Remaining": "0"},
+		},
+		{
+			name:          "API key over limit",
+			expStatusCode: http.StatusTooManyRequests,
+			expHeaders:    map[string]string{"RateLimit-Limit": "3", "RateLimit-Remaining": "0"},
+		},
+	}
+	for _, tc := range tt {
+		req, err := http.NewRequest(http.MethodGet, srvr.URL+"/status", nil)
+		if err != nil {
+			t.Fatalf("Error setting up: new request: %v", err)
+		}
+		if tc.withJWT {
+			req.Header.Set("Authorization", "Bearer jwt")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: do request error: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.expStatusCode {
+			lg.PrintLogs(t)
+			t.Errorf("%s: expected status code %d, got %s",
+				tc.name, tc.expStatusCode, resp.Status)
+		}
+		for k, v := range tc.expHeaders {
+			if got := resp.Header.Get(k); got != v {
+				t.Errorf("%s: expected header %s: %s, got %q", tc.name, k, v, got)
+			}
+		}
+	}
+}
+
+// apiKeyManager is an APIKeyManager that knows only the API key with ID "1".
+// Every API key is authorized for scopes, keyed by the API key, or
+// apikeys.DefaultScopes if not listed.
+type apiKeyManager struct {
+	errors.ErrToHTTP
+	scopes map[string][]string
+}
+
+func (m *apiKeyManager) Authorize(ctx context.Context, userID string, key []byte, scope, origin string) error {
+	scopes, ok := m.scopes[string(key)]
+	if !ok {
+		scopes = apikeys.DefaultScopes
+	}
+	if !apikeys.HasScope(scopes, scope) {
+		return errors.NewForbiddenf("API key lacks the %s scope", scope)
+	}
+	return nil
+}
+
+func (m *apiKeyManager) Create(ctx context.Context, userID string, p apikeys.Policy) (*api.Key, error) {
+	return &api.Key{ID: "2", UserID: userID, Val: []byte("new key")}, nil
+}
+
+func (m *apiKeyManager) Keys(ctx context.Context, userID string, offset, count int64) ([]api.Key, error) {
+	return []api.Key{{ID: "1", UserID: "123"}}, nil
+}
+
+func (m *apiKeyManager) Rotate(ctx context.Context, ID string) (*api.Key, error) {
+	if ID == "broken" {
+		return nil, errors.New("connection refused")
+	}
+	if ID != "1" {
+		return nil, errors.NewNotFound("API key not found")
+	}
+	return &api.Key{ID: "2", UserID: "123", Val: []byte("new key")}, nil
+}
+
+func (m *apiKeyManager) Revoke(ctx context.Context, ID string) error {
+	if ID != "1" {
+		return errors.NewNotFound("API key not found")
+	}
+	return nil
+}
+
+func TestHandler_apiKeys(t *testing.T) {
+	lg := &testingH.Logger{}
+	keys := &apiKeyManager{scopes: map[string][]string{
+		"admin key": {apikeys.ScopeAdmin},
+	}}
+	h := newHandler(t, Config{
+		Logger:       lg,
+		APIKeys:      keys,
+		MasterAPIKey: apikeys.NewMasterKey("master"),
+	})
+	srvr := httptest.NewServer(h)
+	defer srvr.Close()
+
+	tt := []struct {
+		name          string
+		apiKey        string
+		method        string
+		path          string
+		body          string
+		expStatusCode int
+		expBody       string
+	}{
+		{name: "create", method: http.MethodPost, path: "/apikeys", body: `{"userID":"123"}`,
+			expStatusCode: http.StatusOK, expBody: `"key":"new key"`},
+		{name: "create with policy", method: http.MethodPost, path: "/apikeys",
+			body: `{"userID":"123","scopes":["lists:read"],"expires":"2030-01-02T15:04:05Z"}`,
+			expStatusCode: http.StatusOK, expBody: `"key":"new key"`},
+		{name: "create bad expiry", method: http.MethodPost, path: "/apikeys",
+			body: `{"userID":"123","expires":"tomorrow"}`, expStatusCode: http.StatusBadRequest},
+		{name: "create without user ID", method: http.MethodPost, path: "/apikeys",
+			body: `{"scopes":["lists:read"]}`, expStatusCode: http.StatusBadRequest,
+			expBody: `"name":"userID"`},
+		{name: "create with too many origins", method: http.MethodPost, path: "/apikeys",
+			body: `{"userID":"123","origins":[` + strings.Repeat(`"https://example.com",`,
+				request.MaxAPIKeyOrigins) + `"https://example.com"]}`,
+			expStatusCode: http.StatusBadRequest, expBody: `"name":"origins"`},
+		{name: "list with admin scope", apiKey: "admin key", method: http.MethodGet,
+			path: "/apikeys", expStatusCode: http.StatusOK, expBody: `"userID":"123"`},
+		{name: "list without admin scope", apiKey: "client key", method: http.MethodGet,
+			path: "/apikeys", expStatusCode: http.StatusForbidden},
+		{name: "list", method: http.MethodGet, path: "/apikeys?userID=123",
+			expStatusCode: http.StatusOK, expBody: `"userID":"123"`},
+		{name: "rotate", method: http.MethodPost, path: "/apikeys/1/rotate",
+			expStatusCode: http.StatusOK, expBody: `"key":"new key"`},
+		{name: "rotate not found", method: http.MethodPost, path: "/apikeys/9/rotate",
+			expStatusCode: http.StatusNotFound},
+		{name: "revoke", method: http.MethodDelete, path: "/apikeys/1",
+			expStatusCode: http.StatusOK},
+		{name: "revoke not found", method: http.MethodDelete, path: "/apikeys/9",
+			expStatusCode: http.StatusNotFound},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, srvr.URL+tc.path, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("Error setting up: new request: %v", err)
+			}
+			apiKey := tc.apiKey
+			if apiKey == "" {
+				apiKey = "master"
+			}
+			req.Header.Set("x-api-key", apiKey)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do request error: %v", err)
+			}
+			defer resp.Body.Close()
+			bodyB, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Read response body: %v", err)
+			}
+			if resp.StatusCode != tc.expStatusCode {
+				lg.PrintLogs(t)
+				t.Fatalf("Expected status code %d, got %s: %s",
+					tc.expStatusCode, resp.Status, bodyB)
+			}
+			if !strings.Contains(string(bodyB), tc.expBody) {
+				t.Errorf("Expected body containing %s, got %s", tc.expBody, bodyB)
+			}
+		})
+	}
+}
+
+// catalogManager finds one group of duplicates and merges into item or
+// brand "1" only.
+type catalogManager struct {
+	errors.ErrToHTTP
+	threshold float64
+}
+
+func (m *catalogManager) DuplicateItems(ctx context.Context, threshold float64) ([]shopping.DuplicateGroup, error) {
+	m.threshold = threshold
+	return []shopping.DuplicateGroup{{Score: 1, Entries: []shopping.CatalogEntry{
+		{ID: "1", Name: "Toothpaste"}, {ID: "2", Name: "Tooth paste"},
+	}}}, nil
+}
+
+func (m *catalogManager) DuplicateBrands(ctx context.Context, threshold float64) ([]shopping.DuplicateGroup, error) {
+	m.threshold = threshold
+	return nil, errors.NewNotFound("no duplicate brands")
+}
+
+func (m *catalogManager) MergeItems(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Item, error) {
+	if err := m.merge(survivorID, duplicateIDs); err != nil {
+		return nil, err
+	}
+	return &shopping.Item{ID: survivorID, Name: "Toothpaste"}, nil
+}
+
+func (m *catalogManager) MergeBrands(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Brand, error) {
+	if err := m.merge(survivorID, duplicateIDs); err != nil {
+		return nil, err
+	}
+	return &shopping.Brand{ID: survivorID, Name: "Colgate"}, nil
+}
+
+func (m *catalogManager) merge(survivorID string, duplicateIDs []string) error {
+	for _, ID := range duplicateIDs {
+		if ID == survivorID {
+			return errors.NewClient("cannot merge an entry into itself")
+		}
+	}
+	if survivorID != "1" {
+		return errors.NewNotFound("survivor not found")
+	}
+	return nil
+}
+
+func TestHandler_catalog(t *testing.T) {
+	tt := []struct {
+		name          string
+		apiKey        string
+		method        string
+		path          string
+		body          string
+		expStatusCode int
+		expBody       string
+		expThreshold  float64
+	}{
+		{name: "duplicate items without scope", apiKey: "client key", method: http.MethodGet,
+			path: "/catalog/items/duplicates", expStatusCode: http.StatusForbidden},
+		{name: "duplicate items with catalog scope", apiKey: "catalog key", method: http.MethodGet,
+			path: "/catalog/items/duplicates", expStatusCode: http.StatusOK,
+			expBody: `"name":"Tooth paste"`, expThreshold: shopping.DefaultSimilarityThreshold},
+		{name: "duplicate items with threshold", method: http.MethodGet,
+			path: "/catalog/items/duplicates?threshold=0.5", expStatusCode: http.StatusOK,
+			expThreshold: 0.5},
+		{name: "duplicate items bad threshold", method: http.MethodGet,
+			path: "/catalog/items/duplicates?threshold=high", expStatusCode: http.StatusBadRequest,
+			expBody: `"name":"threshold"`},
+		{name: "duplicate items zero threshold", method: http.MethodGet,
+			path: "/catalog/items/duplicates?threshold=0", expStatusCode: http.StatusBadRequest,
+			expBody: `"name":"threshold"`},
+		{name: "duplicate items threshold above 1", method: http.MethodGet,
+			path: "/catalog/items/duplicates?threshold=1.5", expStatusCode: http.StatusBadRequest,
+			expBody: `"name":"threshold"`},
+		{name: "duplicate brands none", method: http.MethodGet,
+			path: "/catalog/brands/duplicates?threshold=1", expStatusCode: http.StatusNotFound,
+			expThreshold: 1},
+		{name: "duplicate brands without scope", apiKey: "client key", method: http.MethodGet,
+			path: "/catalog/brands/duplicates", expStatusCode: http.StatusForbidden},
+		{name: "merge items", method: http.MethodPost, path: "/catalog/items/1/merge",
+			body: `{"duplicateIDs":["2"]}`, expStatusCode: http.StatusOK, expBody: `"ID":"1"`},
+		{name: "merge items without scope", apiKey: "client key", method: http.MethodPost,
+			path: "/catalog/items/1/merge", body: `{"duplicateIDs":["2"]}`,
+			expStatusCode: http.StatusForbidden},
+		{name: "merge items no duplicates", method: http.MethodPost, path: "/catalog/items/1/merge",
+			body: `{}`, expStatusCode: http.StatusBadRequest, expBody: `"name":"duplicateIDs"`},
+		{name: "merge items bad JSON", method: http.MethodPost, path: "/catalog/items/1/merge",
+			body: `{"duplicateIDs":"2"}`, expStatusCode: http.StatusBadRequest},
+		{name: "merge items into itself", method: http.MethodPost, path: "/catalog/items/1/merge",
+			body: `{"duplicateIDs":["1"]}`, expStatusCode: http.StatusBadRequest},
+		{name: "merge items survivor not found", method: http.MethodPost,
+			path: "/catalog/items/9/merge", body: `{"duplicateIDs":["2"]}`,
+			expStatusCode: http.StatusNotFound},
+		{name: "merge brands", method: http.MethodPost, path: "/catalog/brands/1/merge",
+			body: `{"duplicateIDs":["2","3"]}`, expStatusCode: http.StatusOK,
+			expBody: `"name":"Colgate"`},
+		{name: "merge brands too many duplicates", method: http.MethodPost,
+			path: "/catalog/brands/1/merge",
+			body: `{"duplicateIDs":["2"` + strings.Repeat(`,"2"`, 100) + `]}`,
+			expStatusCode: http.StatusBadRequest, expBody: `"name":"duplicateIDs"`},
+		{name: "merge brands survivor not found", method: http.MethodPost,
+			path: "/catalog/brands/9/merge", body: `{"duplicateIDs":["2"]}`,
+			expStatusCode: http.StatusNotFound},
+		{name: "duplicate items below duplicates", method: http.MethodGet,
+			path: "/catalog/items/duplicates/1", expStatusCode: http.StatusNotFound},
+		{name: "duplicate brands below duplicates", method: http.MethodGet,
+			path: "/catalog/brands/duplicates/1", expStatusCode: http.StatusNotFound},
+		{name: "merge items below merge", method: http.MethodPost,
+			path: "/catalog/items/1/merge/now", body: `{"duplicateIDs":["2"]}`,
+			expStatusCode: http.StatusNotFound},
+		{name: "merge brands below merge", method: http.MethodPost,
+			path: "/catalog/brands/1/merge/now", body: `{"duplicateIDs":["2"]}`,
+			expStatusCode: http.StatusNotFound},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			cat := &catalogManager{}
+			h := newHandler(t, Config{
+				Logger:  lg,
+				Catalog: cat,
+				APIKeys: &apiKeyManager{scopes: map[string][]string{
+					"catalog key": {apikeys.ScopeCatalogWrite},
+				}},
+				MasterAPIKey: apikeys.NewMasterKey("master"),
+			})
+			apiKey := tc.apiKey
+			if apiKey == "" {
+				apiKey = "master"
+			}
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("x-api-key", apiKey)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tc.expStatusCode {
+				lg.PrintLogs(t)
+				t.Fatalf("Expected status code %d, got %d: %s",
+					tc.expStatusCode, w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tc.expBody) {
+				t.Errorf("Expected body containing %s, got %s", tc.expBody, w.Body)
+			}
+			if cat.threshold != tc.expThreshold {
+				t.Errorf("Expected threshold %f, got %f", tc.expThreshold, cat.threshold)
+			}
+		})
+	}
+}
+
+// priceManager is a PriceManager that knows only the brand and the price
+// with ID "1".
+type priceManager struct {
+	errors.ErrToHTTP
+	JWT string
+}
+
+func (m *priceManager) Submit(ctx context.Context, JWT string, price shopping.Price) (*shopping.Price, error) {
+	m.JWT = JWT
+	price.ID = "2"
+	price.Status = shopping.PriceStatusApproved
+	return &price, nil
+}
+
+func (m *priceManager) CurrentPrice(ctx context.Context, brandID, storeBranchID, currency string) (*shopping.Price, error) {
+	if brandID != "1" {
+		return nil, errors.NewNotFound("no recent prices")
+	}
+	return &shopping.Price{ID: "1", Value: 200, Currency: currency}, nil
+}
+
+func (m *priceManager) ModerationQueue(ctx context.Context, offset, count int64) ([]shopping.Price, error) {
+	return []shopping.Price{{ID: "1", Value: 20000, Status: shopping.PriceStatusPending}}, nil
+}
+
+func (m *priceManager) Approve(ctx context.Context, priceID string) (*shopping.Price, error) {
+	return m.moderate(priceID, shopping.PriceStatusApproved)
+}
+
+func (m *priceManager) Reject(ctx context.Context, priceID string) (*shopping.Price, error) {
+	return m.moderate(priceID, shopping.PriceStatusRejected)
+}
+
+func (m *priceManager) moderate(priceID, status string) (*shopping.Price, error) {
+	if priceID != "1" {
+		return nil, errors.NewNotFound("price not found")
+	}
+	return &shopping.Price{ID: priceID, Value: 20000, Status: status}, nil
+}
+
+func (m *priceManager) Contributors(ctx context.Context, offset, count int64) ([]shopping.Contributor, error) {
+	return []shopping.Contributor{{UserID: "usr1", Submissions: 3, Trust: 0.5}}, nil
+}
+
+func TestHandler_prices(t *testing.T) {
+	validPrice := `{"brandID":"1","value":200,"currency":"KES"}`
+	tt := []struct {
+		name          string
+		apiKey        string
+		JWT           string
+		method        string
+		path          string
+		body          string
+		expStatusCode int
+		expBody       string
+		expJWT        string
+	}{
+		{name: "submit price", apiKey: "client key", JWT: "a.jwt", method: http.MethodPut,
+			path: "/prices", body: validPrice, expStatusCode: http.StatusOK,
+			expBody: `"status":"APPROVED"`, expJWT: "a.jwt"},
+		{name: "submit price without scope", apiKey: "lists key", JWT: "a.jwt",
+			method: http.MethodPut, path: "/prices", body: validPrice,
+			expStatusCode: http.StatusForbidden},
+		{name: "submit price without JWT", apiKey: "client key", method: http.MethodPut,
+			path: "/prices", body: validPrice, expStatusCode: http.StatusUnauthorized},
+		{name: "submit price without brand", apiKey: "client key", JWT: "a.jwt",
+			method: http.MethodPut, path: "/prices", body: `{"value":200,"currency":"KES"}`,
+			expStatusCode: http.StatusBadRequest, expBody: `"name":"brandID"`},
+		{name: "submit price below prices", apiKey: "client key", JWT: "a.jwt",
+			method: http.MethodPut, path: "/prices/1", body: validPrice,
+			expStatusCode: http.StatusNotFound},
+		{name: "current price", apiKey: "client key", method: http.MethodGet,
+			path: "/brands/1/price?currency=USD", expStatusCode: http.StatusOK,
+			expBody: `"currency":"USD"`},
+		{name: "current price without scope", apiKey: "lists key", method: http.MethodGet,
+			path: "/brands/1/price", expStatusCode: http.StatusForbidden},
+		{name: "current price unknown brand", apiKey: "client key", method: http.MethodGet,
+			path: "/brands/9/price", expStatusCode: http.StatusNotFound},
+		{name: "current price below price", apiKey: "client key", method: http.MethodGet,
+			path: "/brands/1/price/history", expStatusCode: http.StatusNotFound},
+		{name: "moderation queue", method: http.MethodGet, path: "/prices/moderation",
+			expStatusCode: http.StatusOK, expBody: `"status":"PENDING"`},
+		{name: "moderation queue without admin scope", apiKey: "client key",
+			method: http.MethodGet, path: "/prices/moderation",
+			expStatusCode: http.StatusForbidden},
+		{name: "moderation queue bad count", method: http.MethodGet,
+			path: "/prices/moderation?count=0", expStatusCode: http.StatusBadRequest,
+			expBody: `"name":"count"`},
+		{name: "moderation queue below moderation", method: http.MethodGet,
+			path: "/prices/moderation/1", expStatusCode: http.StatusNotFound},
+		{name: "approve price", method: http.MethodPost, path: "/prices/1/approve",
+			expStatusCode: http.StatusOK, expBody: `"status":"APPROVED"`},
+		{name: "approve price without admin scope", apiKey: "client key",
+			method: http.MethodPost, path: "/prices/1/approve",
+			expStatusCode: http.StatusForbidden},
+		{name: "approve unknown price", method: http.MethodPost, path: "/prices/9/approve",
+			expStatusCode: http.StatusNotFound},
+		{name: "approve below approve", method: http.MethodPost,
+			path: "/prices/1/approve/now", expStatusCode: http.StatusNotFound},
+		{name: "reject price", method: http.MethodPost, path: "/prices/1/reject",
+			expStatusCode: http.StatusOK, expBody: `"status":"REJECTED"`},
+		{name: "reject price without admin scope", apiKey: "client key",
+			method: http.MethodPost, path: "/prices/1/reject",
+			expStatusCode: http.StatusForbidden},
+		{name: "reject unknown price", method: http.MethodPost, path: "/prices/9/reject",
+			expStatusCode: http.StatusNotFound},
+		{name: "reject below reject", method: http.MethodPost,
+			path: "/prices/1/reject/now", expStatusCode: http.StatusNotFound},
+		{name: "contributors", method: http.MethodGet, path: "/contributors",
+			expStatusCode: http.StatusOK, expBody: `"userID":"usr1"`},
+		{name: "contributors without admin scope", apiKey: "client key",
+			method: http.MethodGet, path: "/contributors", expStatusCode: http.StatusForbidden},
+		{name: "contributors bad offset", method: http.MethodGet,
+			path: "/contributors?offset=-1", expStatusCode: http.StatusBadRequest,
+			expBody: `"name":"offset"`},
+		{name: "contributors below contributors", method: http.MethodGet,
+			path: "/contributors/usr1", expStatusCode: http.StatusNotFound},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			prices := &priceManager{}
+			h := newHandler(t, Config{
+				Logger:  lg,
+				Manager: &fakeUserManager{usrID: "usr1"},
+				Prices:  prices,
+				APIKeys: &apiKeyManager{scopes: map[string][]string{
+					"lists key": {apikeys.ScopeListsRead, apikeys.ScopeListsWrite},
+				}},
+				MasterAPIKey: apikeys.NewMasterKey("master"),
+			})
+			apiKey := tc.apiKey
+			if apiKey == "" {
+				apiKey = "master"
+			}
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("x-api-key", apiKey)
+			if tc.JWT != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.JWT)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tc.expStatusCode {
+				lg.PrintLogs(t)
+				t.Fatalf("Expected status code %d, got %d: %s",
+					tc.expStatusCode, w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tc.expBody) {
+				t.Errorf("Expected body containing %s, got %s", tc.expBody, w.Body)
+			}
+			if prices.JWT != tc.expJWT {
+				t.Errorf("Expected JWT %q submitted, got %q", tc.expJWT, prices.JWT)
+			}
+		})
+	}
+}
+
+func TestHandler_problems(t *testing.T) {
+	lg := &testingH.Logger{}
+	h := newHandler(t, Config{
+		Logger:       lg,
+		MasterAPIKey: apikeys.NewMasterKey("master"),
+	})
+
+	tt := []struct {
+		name             string
+		method           string
+		path             string
+		body             string
+		expProblem       Problem
+		expAllow         string
+		expDetailNotHave string
+	}{
+		{
+			name: "not found route", method: http.MethodGet, path: "/none",
+			expProblem: Problem{Type: ProblemTypeBlank, Title: "Not Found",
+				Status: http.StatusNotFound, Detail: "Nothing to see here", Instance: "/none"},
+		},
+		{
+			name: "method not allowed", method: http.MethodPatch, path: "/apikeys",
+			expProblem: Problem{Type: ProblemTypeBlank, Title: "Method Not Allowed",
+				Status: http.StatusMethodNotAllowed,
+				Detail: "PATCH is not supported on this path", Instance: "/apikeys"},
+			expAllow: "GET, POST",
+		},
+		{
+			name: "typed error", method: http.MethodPost, path: "/apikeys/9/rotate",
+			expProblem: Problem{Type: ProblemTypeBlank, Title: "Not Found",
+				Status: http.StatusNotFound, Detail: "API key not found",
+				Instance: "/apikeys/9/rotate"},
+		},
+		{
+			name: "internal error", method: http.MethodPost, path: "/apikeys/broken/rotate",
+			expProblem: Problem{Type: ProblemTypeBlank, Title: "Internal Server Error",
+				Status: http.StatusInternalServerError, Detail: msgInternalError,
+				Instance: "/apikeys/broken/rotate"},
+			expDetailNotHave: "connection refused",
+		},
+		{
+			name: "invalid query param", method: http.MethodGet, path: "/apikeys?count=many",
+			expProblem: Problem{Type: ProblemTypeInvalidParams,
+				Title: "Invalid request parameters", Status: http.StatusBadRequest,
+				Instance: "/apikeys", InvalidParams: []InvalidParam{{Name: "count"}}},
+		},
+		{
+			name: "invalid JSON field type", method: http.MethodPost, path: "/apikeys",
+			body: `{"userID":123}`,
+			expProblem: Problem{Type: ProblemTypeInvalidParams,
+				Title: "Invalid request parameters", Status: http.StatusBadRequest,
+				Instance: "/apikeys", InvalidParams: []InvalidParam{
+					{Name: "userID", Reason: "wrong type, got a JSON number"},
+				}},
+		},
+		{
+			name: "count too large", method: http.MethodGet, path: "/apikeys?count=10000000",
+			expProblem: Problem{Type: ProblemTypeInvalidParams,
+				Title: "Invalid request parameters", Status: http.StatusBadRequest,
+				Instance: "/apikeys", InvalidParams: []InvalidParam{
+					{Name: "count", Reason: "must not be greater than 100"},
+				}},
+		},
+		{
+			name: "all invalid fields", method: http.MethodPut, path: "/shoppinglists/1/items",
+			body: `{"itemName":" ","quantity":-1,"unitPrice":-20,"currency":"KSHS"}`,
+			expProblem: Problem{Type: ProblemTypeInvalidParams,
+				Title: "Invalid request parameters", Status: http.StatusBadRequest,
+				Instance: "/shoppinglists/1/items", InvalidParams: []InvalidParam{
+					{Name: "itemName", Reason: "is required"},
+					{Name: "quantity", Reason: "must not be less than 0"},
+					{Name: "unitPrice", Reason: "must not be less than 0"},
+					{Name: "currency", Reason: "must be 3 characters long"},
+				}},
+		},
+		{
+			name: "invalid shopping list", method: http.MethodPut, path: "/shoppinglists",
+			body: `{"name":"` + strings.Repeat("a", 257) + `","mode":"cooking"}`,
+			expProblem: Problem{Type: ProblemTypeInvalidParams,
+				Title: "Invalid request parameters", Status: http.StatusBadRequest,
+				Instance: "/shoppinglists", InvalidParams: []InvalidParam{
+					{Name: "name", Reason: "must not be longer than 256 characters"},
+					{Name: "mode", Reason: "must be one of PREPARATION or SHOPPING"},
+				}},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("x-api-key", "master")
+			req.Header.Set("Authorization", "Bearer jwt")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if ct := w.Header().Get("Content-Type"); ct != ContentTypeProblem {
+				t.Errorf("Expected Content-Type %s, got %s", ContentTypeProblem, ct)
+			}
+			var p Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("Unmarshal problem %s: %v", w.Body, err)
+			}
+			if w.Code != tc.expProblem.Status {
+				t.Errorf("Expected status code %d, got %d", tc.expProblem.Status, w.Code)
+			}
+			if p.TransactionID == "" {
+				t.Errorf("Expected a transaction ID, got none")
+			}
+			if tc.expProblem.Detail == "" {
+				tc.expProblem.Detail = p.Detail
+			}
+			for i := range p.InvalidParams {
+				if i < len(tc.expProblem.InvalidParams) && tc.expProblem.InvalidParams[i].Reason == "" {
+					tc.expProblem.InvalidParams[i].Reason = p.InvalidParams[i].Reason
+				}
+			}
+			tc.expProblem.TransactionID = p.TransactionID
+			if !reflect.DeepEqual(p, tc.expProblem) {
+				lg.PrintLogs(t)
+				t.Errorf("Problem mismatch:\nExpect:\t%+v\nGot:\t%+v", tc.expProblem, p)
+			}
+			if allow := w.Header().Get("Allow"); tc.expAllow != allow {
+				t.Errorf("Expected Allow header %q, got %q", tc.expAllow, allow)
+			}
+			if tc.expDetailNotHave != "" && strings.Contains(w.Body.String(), tc.expDetailNotHave) {
+				t.Errorf("Expected %q to be withheld, got %s", tc.expDetailNotHave, w.Body)
+			}
+		})
+	}
+}
+
+// devTokens issues "JWT-<userID>" for any user.
+type devTokens struct {
+	errors.ErrToHTTP
+}
+
+func (devTokens) Issue(userID string) (string, error) {
+	if userID == "" {
+		return "", errors.NewClient("user ID was empty")
+	}
+	return "JWT-" + userID, nil
+}
+
+func TestHandler_devTokens(t *testing.T) {
+	tt := []struct {
+		name          string
+		devTokens     DevTokenIssuer
+		apiKey        string
+		body          string
+		expStatusCode int
+		expBody       string
+	}{
+		{name: "issue", devTokens: devTokens{}, apiKey: "master", body: `{"userID":"123"}`,
+			expStatusCode: http.StatusOK, expBody: `"token":"JWT-123"`},
+		{name: "empty user ID", devTokens: devTokens{}, apiKey: "master", body: `{}`,
+			expStatusCode: http.StatusBadRequest, expBody: `"name":"userID"`},
+		{name: "not master API key", devTokens: devTokens{}, apiKey: "client key",
+			body: `{"userID":"123"}`, expStatusCode: http.StatusForbidden},
+		{name: "not in developer mode", apiKey: "master", body: `{"userID":"123"}`,
+			expStatusCode: http.StatusNotFound},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lg := &testingH.Logger{}
+			h := newHandler(t, Config{
+				Logger:       lg,
+				MasterAPIKey: apikeys.NewMasterKey("master"),
+				DevTokens:    tc.devTokens,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/dev/tokens", strings.NewReader(tc.body))
+			req.Header.Set("x-api-key", tc.apiKey)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tc.expStatusCode {
+				lg.PrintLogs(t)
+				t.Fatalf("Expected status code %d, got %d: %s",
+					tc.expStatusCode, w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tc.expBody) {
+				t.Errorf("Expected body containing %s, got %s", tc.expBody, w.Body)
+			}
+		})
+	}
+}
+
+// fakeUserManager is a ShoppingManager whose JWTs are all issued to usrID.
+type fakeUserManager struct {
+	ShoppingManager
+	usrID string
+}
+
+func (m *fakeUserManager) UserID(JWT string) (string, error) {
+	return m.usrID, nil
+}
+
+// newHandler returns a *Handler for conf, with fields that are nil
+// replaced by fakes that do nothing. MasterAPIKey is left nil.
+func newHandler(t *testing.T, conf Config) *Handler {
+	if conf.Guard == nil {
+		conf.Guard = &testingH.Guard{}
+	}
+	if conf.Logger == nil {
+		conf.Logger = &testingH.Logger{}
+	}
+	if conf.Manager == nil {
+		conf.Manager = &shopping.Manager{}
+	}
+	if conf.Catalog == nil {
+		conf.Catalog = &shopping.Catalog{}
+	}
+	if conf.Prices == nil {
+		conf.Prices = &shopping.Prices{}
+	}
+	if conf.APIKeys == nil {
+		conf.APIKeys = &apiKeyManager{}
+	}
+	if conf.Health == nil {
+		conf.Health = &health.Health{}
+	}
+	if conf.Metrics == nil {
+		conf.Metrics = newMetrics(t)
+	}
+	if conf.RateLimiter == nil {
+		conf.RateLimiter = newLimiter(t)
+	}
+	h, err := NewHandler(conf)
+	if err != nil {
+		t.Fatalf("Error setting up: new handler: %v", err)
+	}
+	return h
+}
+
+// newHealth returns a *health.Health whose only check fails with checkErr
+// if checkErr is not nil.
+func newHealth(t *testing.T, checkErr error) *health.Health {
+	h, err := health.NewHealth(health.WithCheck("database", func(context.Context) error {
+		return checkErr
+	}))
+	if err != nil {
+		t.Fatalf("Error setting up: new health: %v", err)
 	}
 	return h
 }
+
+func newLimiter(t *testing.T) *ratelimit.Limiter {
+	lm, err := ratelimit.NewLimiter(ratelimit.NewMemory())
+	if err != nil {
+		t.Fatalf("Error setting up: new limiter: %v", err)
+	}
+	return lm
+}
+
+func newMetrics(t *testing.T) *metrics.Metrics {
+	m, err := metrics.NewMetrics()
+	if err != nil {
+		t.Fatalf("Error setting up: new metrics: %v", err)
+	}
+	return m
+}
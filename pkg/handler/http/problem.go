@@ -0,0 +1,111 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/validation"
+)
+
+const (
+	// ContentTypeProblem is the content type of error responses.
+	ContentTypeProblem = "application/problem+json"
+
+	// ProblemTypeBlank is the type of problems that are fully described by
+	// their status code.
+	ProblemTypeBlank = "about:blank"
+	// ProblemTypeInvalidParams is the type of problems caused by invalid
+	// request fields, listed in Problem.InvalidParams.
+	ProblemTypeInvalidParams = "urn:" + config.Name + ":problem:invalid-params"
+
+	msgInternalError = "Something wicked happened, please try again later"
+)
+
+// Problem is an RFC 7807 problem details object describing an error, see
+// apidoc_header.md.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	TransactionID string         `json:"transactionID,omitempty"`
+	InvalidParams []InvalidParam `json:"invalidParams,omitempty"`
+}
+
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func NewInvalidParams(vs []validation.Violation) []InvalidParam {
+	ips := make([]InvalidParam, 0, len(vs))
+	for _, v := range vs {
+		ips = append(ips, InvalidParam{Name: v.Field, Reason: v.Reason})
+	}
+	return ips
+}
+
+// writeProblem writes p to w as application/problem+json, defaulting its
+// type to ProblemTypeBlank, its title to the text of its status code and
+// its instance and transaction ID to those of r.
+func writeProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Type == "" {
+		p.Type = ProblemTypeBlank
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.TransactionID == "" {
+		p.TransactionID, _ = r.Context().Value(ctxKeyTransID).(string)
+	}
+	pB, err := json.Marshal(p)
+	if err != nil {
+		// unreachable, Problem has no fields that fail to marshal.
+		http.Error(w, msgInternalError, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	if _, err := w.Write(pB); err != nil {
+		if log, ok := r.Context().Value(ctxKeyLog).(logging.Logger); ok {
+			log.Errorf("unable write problem to response stream: %v", err)
+		}
+	}
+}
+
+// problemRecorder is an http.ResponseWriter that keeps the body written by
+// errors.ToHTTPResponser so that it can be rewritten as a Problem.
+type problemRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func newProblemRecorder() *problemRecorder {
+	return &problemRecorder{header: make(http.Header)}
+}
+
+func (rec *problemRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *problemRecorder) Write(b []byte) (int, error) {
+	return rec.body.Write(b)
+}
+
+// WriteHeader ignores code, which is also returned by
+// errors.ToHTTPResponser.
+func (rec *problemRecorder) WriteHeader(code int) {}
+
+// detail returns the body written, which describes the error.
+func (rec *problemRecorder) detail() string {
+	return strings.TrimSpace(rec.body.String())
+}
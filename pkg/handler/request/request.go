@@ -0,0 +1,143 @@
+// Package request declares the rules that the inputs of the HTTP and RPC
+// handlers must satisfy. Handlers fill in a request struct and pass it to
+// validation.Struct() before calling the managers so that clients get every
+// violation at once. The managers still validate their inputs.
+package request
+
+import (
+	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// Limits enforced by the validate tags below, see validation.Struct().
+// Tags cannot refer to constants so these must be kept in sync with them.
+const (
+	// DefaultCount is the page of results returned if none is requested.
+	DefaultCount = 10
+	// MaxCount is the largest page of results that may be requested.
+	MaxCount = 100
+	// MaxNameLen is the longest (in characters) a name may be, as limited
+	// by the DB.
+	MaxNameLen = 256
+	// MaxMergeDuplicates is the most duplicates that may be merged at once.
+	MaxMergeDuplicates = 100
+	// MaxAPIKeyScopes is the most scopes that may be granted to an API key.
+	MaxAPIKeyScopes = 20
+	// MaxAPIKeyOrigins is the most origins an API key may be limited to.
+	MaxAPIKeyOrigins = 20
+)
+
+// Page selects a page of results.
+type Page struct {
+	Offset int64 `json:"offset" validate:"min=0"`
+	Count  int64 `json:"count" validate:"min=1,max=100"`
+}
+
+type ShoppingList struct {
+	Name string `json:"name" validate:"required,maxlen=256"`
+	Mode string `json:"mode" validate:"oneof=PREPARATION SHOPPING"`
+}
+
+// ShoppingListUpdate leaves fields that are nil unchanged.
+type ShoppingListUpdate struct {
+	ShoppingListID string  `validate:"required"`
+	Name           *string `json:"name" validate:"required,maxlen=256"`
+	Mode           *string `json:"mode" validate:"oneof=PREPARATION SHOPPING"`
+}
+
+type ShoppingListItem struct {
+	ShoppingListID string  `validate:"required"`
+	ItemName       string  `json:"itemName" validate:"required,maxlen=256"`
+	InList         bool    `json:"inList"`
+	InCart         bool    `json:"inCart"`
+	BrandName      string  `json:"brandName" validate:"maxlen=256"`
+	Quantity       int     `json:"quantity" validate:"min=0"`
+	MeasuringUnit  string  `json:"measurementUnit" validate:"maxlen=256"`
+	UnitPrice      float32 `json:"unitPrice" validate:"min=0"`
+	Currency       string  `json:"currency" validate:"len=3"`
+	StoreBranchID  string  `json:"storeBranchID"`
+}
+
+type ItemSearch struct {
+	ItemName      string `json:"itemName" validate:"maxlen=256"`
+	BrandName     string `json:"brandName" validate:"maxlen=256"`
+	MeasuringUnit string `json:"measuringUnit" validate:"maxlen=256"`
+}
+
+// Duplicates selects catalog entries whose names are at least Threshold
+// similar.
+type Duplicates struct {
+	Threshold float64 `json:"threshold" validate:"gt=0,max=1"`
+}
+
+type Merge struct {
+	SurvivorID   string   `validate:"required"`
+	DuplicateIDs []string `json:"duplicateIDs" validate:"required,maxlen=100"`
+}
+
+type Price struct {
+	BrandID       string  `json:"brandID" validate:"required"`
+	StoreBranchID string  `json:"storeBranchID"`
+	Value         float32 `json:"value" validate:"min=0"`
+	Currency      string  `json:"currency" validate:"len=3"`
+}
+
+// APIKey issues an API key to a client app user. The values of Scopes and
+// Origins are checked by apikeys.Manager. Expires is an ISO8601 date, the
+// key does not expire if it is empty.
+type APIKey struct {
+	UserID  string   `json:"userID" validate:"required"`
+	Scopes  []string `json:"scopes" validate:"maxlen=20"`
+	Origins []string `json:"origins" validate:"maxlen=20"`
+	Expires string   `json:"expires"`
+}
+
+func (k APIKey) Policy() apikeys.Policy {
+	return apikeys.Policy{Scopes: k.Scopes, Origins: k.Origins}
+}
+
+type DevToken struct {
+	UserID string `json:"userID" validate:"required"`
+}
+
+// StringUpdate converts an optional value to a crdb.StringUpdate that only
+// updates if val is not nil.
+func StringUpdate(val *string) crdb.StringUpdate {
+	if val == nil {
+		return crdb.StringUpdate{}
+	}
+	return crdb.StringUpdate{Updating: true, NewVal: *val}
+}
+
+func (i ShoppingListItem) Upsert() shopping.ShoppingListItemUpsert {
+	return shopping.ShoppingListItemUpsert{
+		ShoppingListID: i.ShoppingListID,
+		ItemName:       i.ItemName,
+		BrandName:      i.BrandName,
+		MeasuringUnit:  i.MeasuringUnit,
+		Quantity:       i.Quantity,
+		InList:         i.InList,
+		InCart:         i.InCart,
+		UnitPrice:      i.UnitPrice,
+		Currency:       i.Currency,
+		StoreBranchID:  i.StoreBranchID,
+	}
+}
+
+func (s ItemSearch) Query() shopping.ItemSearch {
+	return shopping.ItemSearch{
+		ItemName:      s.ItemName,
+		BrandName:     s.BrandName,
+		MeasuringUnit: s.MeasuringUnit,
+	}
+}
+
+func (p Price) Price() shopping.Price {
+	return shopping.Price{
+		Value:         p.Value,
+		Currency:      p.Currency,
+		Brand:         shopping.Brand{ID: p.BrandID},
+		AtStoreBranch: shopping.StoreBranch{ID: p.StoreBranchID},
+	}
+}
@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func newShoppingList(sl *shopping.ShoppingList) *api.ShoppingList {
+	if sl == nil {
+		return nil
+	}
+	return &api.ShoppingList{
+		ID:          sl.ID,
+		UserID:      sl.UserID,
+		Name:        sl.Name,
+		Mode:        sl.Mode,
+		Created:     sl.Created,
+		LastUpdated: sl.LastUpdated,
+	}
+}
+
+func newItem(i *shopping.Item) *api.Item {
+	if i == nil {
+		return nil
+	}
+	return &api.Item{ID: i.ID, Name: i.Name}
+}
+
+func newBrand(b *shopping.Brand) *api.Brand {
+	if b == nil {
+		return nil
+	}
+	return &api.Brand{
+		ID:   b.ID,
+		Name: b.Name,
+		MeasuringUnit: &api.MeasuringUnit{
+			ID:   b.MeasuringUnit.ID,
+			Name: b.MeasuringUnit.Name,
+		},
+		Item:     newItem(&b.Item),
+		Barcodes: b.Barcodes,
+	}
+}
+
+func newStoreBranch(sb *shopping.StoreBranch) *api.StoreBranch {
+	if sb == nil || sb.ID == "" {
+		return nil
+	}
+	return &api.StoreBranch{
+		ID:    sb.ID,
+		Name:  sb.Name,
+		Store: &api.Store{ID: sb.Store.ID, Name: sb.Store.Name},
+		Location: &api.Location{
+			Latitude:  sb.Location.Latitude,
+			Longitude: sb.Location.Longitude,
+		},
+		OSMID: sb.OSMID,
+	}
+}
+
+// newPrice leaves out the submitter so that contributors remain anonymous.
+func newPrice(p *shopping.Price) *api.Price {
+	if p == nil {
+		return nil
+	}
+	res := &api.Price{
+		ID:            p.ID,
+		Value:         p.Value,
+		Currency:      p.Currency,
+		Brand:         newBrand(&p.Brand),
+		AtStoreBranch: newStoreBranch(&p.AtStoreBranch),
+		Status:        p.Status,
+		OutlierScore:  p.OutlierScore,
+	}
+	if !p.Created.IsZero() {
+		res.Created = p.Created.Format(config.TimeFormat)
+	}
+	return res
+}
+
+func newShoppingListItem(i *shopping.ShoppingListItem) *api.ShoppingListItem {
+	if i == nil {
+		return nil
+	}
+	return &api.ShoppingListItem{
+		ID:           i.ID,
+		Quantity:     int64(i.Quantity),
+		InList:       i.InList,
+		InCart:       i.InCart,
+		ShoppingList: newShoppingList(&i.ShoppingList),
+		Price:        newPrice(&i.Price),
+	}
+}
+
+func newShoppingListItems(items []shopping.ShoppingListItem) []*api.ShoppingListItem {
+	ress := make([]*api.ShoppingListItem, 0, len(items))
+	for i := range items {
+		ress = append(ress, newShoppingListItem(&items[i]))
+	}
+	return ress
+}
+
+func newDuplicateGroups(dgs []shopping.DuplicateGroup) []*api.DuplicateGroup {
+	ress := make([]*api.DuplicateGroup, 0, len(dgs))
+	for _, dg := range dgs {
+		res := &api.DuplicateGroup{Score: dg.Score}
+		for _, e := range dg.Entries {
+			res.Entries = append(res.Entries, &api.CatalogEntry{ID: e.ID, Name: e.Name})
+		}
+		ress = append(ress, res)
+	}
+	return ress
+}
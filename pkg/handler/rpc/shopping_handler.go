@@ -0,0 +1,242 @@
+package rpc
+
+import (
+	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/handler/request"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+	"github.com/tomogoma/shoppingms/pkg/validation"
+	"golang.org/x/net/context"
+)
+
+type ShoppingManager interface {
+	InsertShoppingList(ctx context.Context, JWT, name, mode string) (*shopping.ShoppingList, error)
+	UpdateShoppingList(ctx context.Context, JWT, shoppingListID string, name, mode crdb.StringUpdate) (*shopping.ShoppingList, error)
+	ShoppingLists(ctx context.Context, JWT string, offset, count int64) ([]shopping.ShoppingList, error)
+	UpsertShoppingListItem(ctx context.Context, JWT string, item shopping.ShoppingListItemUpsert) (*shopping.ShoppingListItem, error)
+	DeleteShoppingListItem(ctx context.Context, JWT, ID string) error
+	ShoppingListItems(ctx context.Context, JWT, shoppingListID string, offset, count int64) ([]shopping.ShoppingListItem, error)
+	SearchShoppingItems(ctx context.Context, JWT string, q shopping.ItemSearch, offset, count int64) ([]shopping.ShoppingListItem, error)
+}
+
+// CatalogManager detects and merges duplicates in the shared catalog.
+type CatalogManager interface {
+	DuplicateItems(ctx context.Context, threshold float64) ([]shopping.DuplicateGroup, error)
+	DuplicateBrands(ctx context.Context, threshold float64) ([]shopping.DuplicateGroup, error)
+	MergeItems(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Item, error)
+	MergeBrands(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Brand, error)
+}
+
+// ShoppingListsHandler serves the ShoppingLists RPC service. Serve it with
+// Wrappers() to have API keys validated and authorized, and errors mapped;
+// catalog methods require the catalog:write scope. Requests are validated
+// against the same rules as their HTTP equivalents, see package request.
+// Use NewShoppingListsHandler() to instantiate.
+type ShoppingListsHandler struct {
+	manager ShoppingManager
+	catalog CatalogManager
+}
+
+func NewShoppingListsHandler(m ShoppingManager, c CatalogManager) (*ShoppingListsHandler, error) {
+	if m == nil {
+		return nil, errors.New("ShoppingManager was nil")
+	}
+	if c == nil {
+		return nil, errors.New("CatalogManager was nil")
+	}
+	return &ShoppingListsHandler{manager: m, catalog: c}, nil
+}
+
+func (h *ShoppingListsHandler) InsertShoppingList(c context.Context, req *api.InsertShoppingListRequest, resp *api.ShoppingList) error {
+	sl := request.ShoppingList{Name: req.Name, Mode: req.Mode}
+	if err := validation.Struct(sl); err != nil {
+		return err
+	}
+	inserted, err := h.manager.InsertShoppingList(c, req.JWT, sl.Name, sl.Mode)
+	if err != nil {
+		return err
+	}
+	*resp = *newShoppingList(inserted)
+	return nil
+}
+
+func (h *ShoppingListsHandler) UpdateShoppingList(c context.Context, req *api.UpdateShoppingListRequest, resp *api.ShoppingList) error {
+	u := request.ShoppingListUpdate{
+		ShoppingListID: req.ShoppingListID,
+		Name:           optionalString(req.Name),
+		Mode:           optionalString(req.Mode),
+	}
+	if err := validation.Struct(u); err != nil {
+		return err
+	}
+	sl, err := h.manager.UpdateShoppingList(c, req.JWT, u.ShoppingListID,
+		request.StringUpdate(u.Name), request.StringUpdate(u.Mode))
+	if err != nil {
+		return err
+	}
+	*resp = *newShoppingList(sl)
+	return nil
+}
+
+func (h *ShoppingListsHandler) GetShoppingLists(c context.Context, req *api.GetShoppingListsRequest, resp *api.ShoppingListsResponse) error {
+	pg := page(req.Offset, req.Count)
+	if err := validation.Struct(pg); err != nil {
+		return err
+	}
+	sls, err := h.manager.ShoppingLists(c, req.JWT, pg.Offset, pg.Count)
+	if err != nil {
+		return err
+	}
+	for i := range sls {
+		resp.ShoppingLists = append(resp.ShoppingLists, newShoppingList(&sls[i]))
+	}
+	return nil
+}
+
+func (h *ShoppingListsHandler) UpsertShoppingListItem(c context.Context, req *api.UpsertShoppingListItemRequest, resp *api.ShoppingListItem) error {
+	upsert := request.ShoppingListItem{
+		ShoppingListID: req.ShoppingListID,
+		ItemName:       req.ItemName,
+		BrandName:      req.BrandName,
+		MeasuringUnit:  req.MeasuringUnit,
+		Quantity:       int(req.Quantity),
+		InList:         req.InList,
+		InCart:         req.InCart,
+		UnitPrice:      req.UnitPrice,
+		Currency:       req.Currency,
+		StoreBranchID:  req.StoreBranchID,
+	}
+	if err := validation.Struct(upsert); err != nil {
+		return err
+	}
+	item, err := h.manager.UpsertShoppingListItem(c, req.JWT, upsert.Upsert())
+	if err != nil {
+		return err
+	}
+	*resp = *newShoppingListItem(item)
+	return nil
+}
+
+func (h *ShoppingListsHandler) DeleteShoppingListItem(c context.Context, req *api.DeleteShoppingListItemRequest, resp *api.Empty) error {
+	return h.manager.DeleteShoppingListItem(c, req.JWT, req.ID)
+}
+
+func (h *ShoppingListsHandler) GetShoppingListItems(c context.Context, req *api.GetShoppingListItemsRequest, resp *api.ShoppingListItemsResponse) error {
+	pg := page(req.Offset, req.Count)
+	if err := validation.Struct(pg); err != nil {
+		return err
+	}
+	items, err := h.manager.ShoppingListItems(c, req.JWT, req.ShoppingListID,
+		pg.Offset, pg.Count)
+	if err != nil {
+		return err
+	}
+	resp.Items = newShoppingListItems(items)
+	return nil
+}
+
+func (h *ShoppingListsHandler) SearchShoppingItems(c context.Context, req *api.SearchShoppingItemsRequest, resp *api.ShoppingListItemsResponse) error {
+	search := struct {
+		request.ItemSearch
+		request.Page
+	}{
+		ItemSearch: request.ItemSearch{
+			ItemName:      req.ItemName,
+			BrandName:     req.BrandName,
+			MeasuringUnit: req.MeasuringUnit,
+		},
+		Page: page(req.Offset, req.Count),
+	}
+	if err := validation.Struct(search); err != nil {
+		return err
+	}
+	items, err := h.manager.SearchShoppingItems(c, req.JWT, search.Query(),
+		search.Offset, search.Count)
+	if err != nil {
+		return err
+	}
+	resp.Items = newShoppingListItems(items)
+	return nil
+}
+
+// DuplicateItems uses shopping.DefaultSimilarityThreshold if
+// req.Threshold is 0.
+func (h *ShoppingListsHandler) DuplicateItems(c context.Context, req *api.DuplicatesRequest, resp *api.DuplicateGroupsResponse) error {
+	d := request.Duplicates{Threshold: threshold(req.Threshold)}
+	if err := validation.Struct(d); err != nil {
+		return err
+	}
+	dgs, err := h.catalog.DuplicateItems(c, d.Threshold)
+	if err != nil {
+		return err
+	}
+	resp.DuplicateGroups = newDuplicateGroups(dgs)
+	return nil
+}
+
+// DuplicateBrands uses shopping.DefaultSimilarityThreshold if
+// req.Threshold is 0.
+func (h *ShoppingListsHandler) DuplicateBrands(c context.Context, req *api.DuplicatesRequest, resp *api.DuplicateGroupsResponse) error {
+	d := request.Duplicates{Threshold: threshold(req.Threshold)}
+	if err := validation.Struct(d); err != nil {
+		return err
+	}
+	dgs, err := h.catalog.DuplicateBrands(c, d.Threshold)
+	if err != nil {
+		return err
+	}
+	resp.DuplicateGroups = newDuplicateGroups(dgs)
+	return nil
+}
+
+func (h *ShoppingListsHandler) MergeItems(c context.Context, req *api.MergeRequest, resp *api.Item) error {
+	m := request.Merge{SurvivorID: req.SurvivorID, DuplicateIDs: req.DuplicateIDs}
+	if err := validation.Struct(m); err != nil {
+		return err
+	}
+	item, err := h.catalog.MergeItems(c, m.SurvivorID, m.DuplicateIDs)
+	if err != nil {
+		return err
+	}
+	*resp = *newItem(item)
+	return nil
+}
+
+func (h *ShoppingListsHandler) MergeBrands(c context.Context, req *api.MergeRequest, resp *api.Brand) error {
+	m := request.Merge{SurvivorID: req.SurvivorID, DuplicateIDs: req.DuplicateIDs}
+	if err := validation.Struct(m); err != nil {
+		return err
+	}
+	b, err := h.catalog.MergeBrands(c, m.SurvivorID, m.DuplicateIDs)
+	if err != nil {
+		return err
+	}
+	*resp = *newBrand(b)
+	return nil
+}
+
+// page uses request.DefaultCount if count is 0, as the HTTP handler does
+// when count is omitted.
+func page(offset, count int64) request.Page {
+	if count == 0 {
+		count = request.DefaultCount
+	}
+	return request.Page{Offset: offset, Count: count}
+}
+
+func threshold(t float64) float64 {
+	if t == 0 {
+		return shopping.DefaultSimilarityThreshold
+	}
+	return t
+}
+
+// optionalString returns the new value of su, or nil if su does not
+// update.
+func optionalString(su *api.StringUpdate) *string {
+	if su == nil || !su.Updating {
+		return nil
+	}
+	return &su.NewVal
+}
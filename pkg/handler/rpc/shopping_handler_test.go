@@ -0,0 +1,271 @@
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/errors"
+	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/handler/rpc"
+	"github.com/tomogoma/shoppingms/pkg/mocks"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+const masterKey = "master-key"
+
+type manager struct {
+	expErr error
+	// count is the page size last requested.
+	count int64
+}
+
+func (m *manager) InsertShoppingList(_ context.Context, JWT, name, mode string) (*shopping.ShoppingList, error) {
+	if m.expErr != nil {
+		return nil, m.expErr
+	}
+	return &shopping.ShoppingList{ID: "list", Name: name, Mode: mode}, nil
+}
+
+func (m *manager) UpdateShoppingList(_ context.Context, JWT, shoppingListID string, name, mode crdb.StringUpdate) (*shopping.ShoppingList, error) {
+	return &shopping.ShoppingList{ID: shoppingListID, Name: name.NewVal, Mode: mode.NewVal}, m.expErr
+}
+
+func (m *manager) ShoppingLists(_ context.Context, JWT string, offset, count int64) ([]shopping.ShoppingList, error) {
+	m.count = count
+	return []shopping.ShoppingList{{ID: "list"}}, m.expErr
+}
+
+func (m *manager) UpsertShoppingListItem(_ context.Context, JWT string, item shopping.ShoppingListItemUpsert) (*shopping.ShoppingListItem, error) {
+	return &shopping.ShoppingListItem{ID: "item", Quantity: item.Quantity}, m.expErr
+}
+
+func (m *manager) DeleteShoppingListItem(_ context.Context, JWT, ID string) error {
+	return m.expErr
+}
+
+func (m *manager) ShoppingListItems(_ context.Context, JWT, shoppingListID string, offset, count int64) ([]shopping.ShoppingListItem, error) {
+	m.count = count
+	return []shopping.ShoppingListItem{{ID: "item"}}, m.expErr
+}
+
+func (m *manager) SearchShoppingItems(_ context.Context, JWT string, q shopping.ItemSearch, offset, count int64) ([]shopping.ShoppingListItem, error) {
+	m.count = count
+	return []shopping.ShoppingListItem{{ID: "item"}}, m.expErr
+}
+
+type catalog struct{}
+
+func (c *catalog) DuplicateItems(_ context.Context, threshold float64) ([]shopping.DuplicateGroup, error) {
+	return []shopping.DuplicateGroup{{Score: threshold}}, nil
+}
+
+func (c *catalog) DuplicateBrands(_ context.Context, threshold float64) ([]shopping.DuplicateGroup, error) {
+	return []shopping.DuplicateGroup{{Score: threshold}}, nil
+}
+
+func (c *catalog) MergeItems(_ context.Context, survivorID string, duplicateIDs []string) (*shopping.Item, error) {
+	return &shopping.Item{ID: survivorID}, nil
+}
+
+func (c *catalog) MergeBrands(_ context.Context, survivorID string, duplicateIDs []string) (*shopping.Brand, error) {
+	return &shopping.Brand{ID: survivorID}, nil
+}
+
+func TestNewShoppingListsHandler(t *testing.T) {
+	tt := []struct {
+		name    string
+		manager rpc.ShoppingManager
+		catalog rpc.CatalogManager
+		expErr  bool
+	}{
+		{name: "valid deps", manager: &manager{}, catalog: &catalog{}},
+		{name: "nil manager", catalog: &catalog{}, expErr: true},
+		{name: "nil catalog", manager: &manager{}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := rpc.NewShoppingListsHandler(tc.manager, tc.catalog)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if h == nil {
+				t.Fatalf("Got nil *rpc.ShoppingListsHandler")
+			}
+		})
+	}
+}
+
+func TestShoppingListsHandler_InsertShoppingList(t *testing.T) {
+	tt := []struct {
+		name       string
+		guard      *mocks.Guard
+		listName   string
+		managerErr error
+		expCode    int32
+	}{
+		{name: "valid", guard: &mocks.Guard{}, listName: "Groceries"},
+		{
+			name:    "invalid request",
+			guard:   &mocks.Guard{},
+			expCode: http.StatusBadRequest,
+		},
+		{
+			name:     "guard forbidden",
+			guard:    &mocks.Guard{ExpAPIKValidErr: errors.NewForbidden("guard")},
+			listName: "Groceries",
+			expCode:  http.StatusForbidden,
+		},
+		{
+			name:       "client error",
+			guard:      &mocks.Guard{},
+			listName:   "Groceries",
+			managerErr: errors.NewClient("name was empty"),
+			expCode:    http.StatusBadRequest,
+		},
+		{
+			name:       "internal error",
+			guard:      &mocks.Guard{},
+			listName:   "Groceries",
+			managerErr: errors.New("db down"),
+			expCode:    http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newShoppingListsHandler(t, &manager{expErr: tc.managerErr})
+			req := &api.InsertShoppingListRequest{Name: tc.listName}
+			resp := new(api.ShoppingList)
+			err := call(t, "ShoppingLists.InsertShoppingList",
+				callOpts{guard: tc.guard, req: req, resp: resp},
+				func(ctx context.Context) error {
+					return h.InsertShoppingList(ctx, req, resp)
+				})
+			if tc.expCode != 0 {
+				if mErr, ok := err.(*microErrors.Error); !ok || mErr.Code != tc.expCode {
+					t.Fatalf("Expected error code %d, got %v", tc.expCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if resp.ID != "list" || resp.Name != "Groceries" {
+				t.Errorf("Expected list Groceries, got %+v", resp)
+			}
+		})
+	}
+}
+
+func TestShoppingListsHandler_paging(t *testing.T) {
+	tt := []struct {
+		name     string
+		count    int64
+		expCount int64
+		expCode  int32
+	}{
+		{name: "count omitted", expCount: 10},
+		{name: "count set", count: 100, expCount: 100},
+		{name: "count too large", count: 101, expCode: http.StatusBadRequest},
+		{name: "count negative", count: -1, expCode: http.StatusBadRequest},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &manager{}
+			h := newShoppingListsHandler(t, m)
+			listsReq := &api.GetShoppingListsRequest{Count: tc.count}
+			itemsReq := &api.GetShoppingListItemsRequest{ShoppingListID: "list", Count: tc.count}
+			searchReq := &api.SearchShoppingItemsRequest{ItemName: "milk", Count: tc.count}
+			calls := []struct {
+				method string
+				req    interface{}
+				fn     func(ctx context.Context) error
+			}{
+				{method: "ShoppingLists.GetShoppingLists", req: listsReq,
+					fn: func(ctx context.Context) error {
+						return h.GetShoppingLists(ctx, listsReq, new(api.ShoppingListsResponse))
+					}},
+				{method: "ShoppingLists.GetShoppingListItems", req: itemsReq,
+					fn: func(ctx context.Context) error {
+						return h.GetShoppingListItems(ctx, itemsReq, new(api.ShoppingListItemsResponse))
+					}},
+				{method: "ShoppingLists.SearchShoppingItems", req: searchReq,
+					fn: func(ctx context.Context) error {
+						return h.SearchShoppingItems(ctx, searchReq, new(api.ShoppingListItemsResponse))
+					}},
+			}
+			for _, c := range calls {
+				m.count = 0
+				err := call(t, c.method, callOpts{req: c.req}, c.fn)
+				if tc.expCode != 0 {
+					if mErr, ok := err.(*microErrors.Error); !ok || mErr.Code != tc.expCode {
+						t.Errorf("%s: expected error code %d, got %v", c.method, tc.expCode, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("%s: got error: %v", c.method, err)
+					continue
+				}
+				if m.count != tc.expCount {
+					t.Errorf("%s: expected count %d, got %d", c.method, tc.expCount, m.count)
+				}
+			}
+		})
+	}
+}
+
+func TestShoppingListsHandler_MergeItems(t *testing.T) {
+	tt := []struct {
+		name   string
+		APIKey string
+		expErr bool
+	}{
+		{name: "master key", APIKey: masterKey},
+		{name: "other key", APIKey: "other-key", expErr: true},
+		{name: "no key", expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newShoppingListsHandler(t, &manager{})
+			req := &api.MergeRequest{
+				APIKey:       tc.APIKey,
+				SurvivorID:   "1",
+				DuplicateIDs: []string{"2"},
+			}
+			resp := new(api.Item)
+			err := call(t, "ShoppingLists.MergeItems", callOpts{req: req, resp: resp},
+				func(ctx context.Context) error {
+					return h.MergeItems(ctx, req, resp)
+				})
+			if tc.expErr {
+				if mErr, ok := err.(*microErrors.Error); !ok || mErr.Code != http.StatusForbidden {
+					t.Fatalf("Expected a forbidden error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if resp.ID != "1" {
+				t.Errorf("Expected survivor 1, got %+v", resp)
+			}
+		})
+	}
+}
+
+func newShoppingListsHandler(t *testing.T, m rpc.ShoppingManager) *rpc.ShoppingListsHandler {
+	h, err := rpc.NewShoppingListsHandler(m, &catalog{})
+	if err != nil {
+		t.Fatalf("Error setting up: new shopping lists handler: %v", err)
+	}
+	return h
+}
@@ -1,13 +1,12 @@
 package rpc
 
 import (
-	"encoding/json"
+	"time"
 
-	"github.com/pborman/uuid"
 	"github.com/tomogoma/go-typed-errors"
 	"github.com/tomogoma/shoppingms/pkg/api"
 	"github.com/tomogoma/shoppingms/pkg/config"
-	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/health"
 	"golang.org/x/net/context"
 )
 
@@ -17,55 +16,71 @@ type Guard interface {
 	APIKeyValid(key []byte) (string, error)
 }
 
-type StatusHandler struct {
-	errors.NotImplErrCheck
-	errors.AuthErrCheck
-	errors.ClErrCheck
-
-	guard  Guard
-	logger logging.Logger
+// HealthChecker reports on the health of the service and its dependencies.
+type HealthChecker interface {
+	Live() health.Report
+	Ready(ctx context.Context) health.Report
 }
 
-func NewStatusHandler(g Guard, l logging.Logger) (*StatusHandler, error) {
-	if g == nil {
-		return nil, errors.New("Guard was nil")
-	}
-	if l == nil {
-		return nil, errors.New("Logger was nil")
-	}
-
-	return &StatusHandler{guard: g, logger: l}, nil
+// StatusHandler serves the Status RPC service. Serve it with Wrappers()
+// to have API keys validated.
+// Use NewStatusHandler() to instantiate.
+type StatusHandler struct {
+	health    HealthChecker
+	overrides []config.Override
 }
 
-func (sh StatusHandler) prepLogger(method string) logging.Logger {
-	log := sh.logger.WithField(logging.FieldTransID, uuid.New())
-	log.WithFields(map[string]interface{}{
-		logging.FieldRPCMethod:      method,
-		logging.FieldRequestHandler: "RPC",
-	}).Info("new request")
-	return log
+// NewStatusHandler reports config overrides (see config.ApplyEnv()) in
+// every response so that operators can tell where config values came from.
+func NewStatusHandler(hc HealthChecker, overrides []config.Override) (*StatusHandler, error) {
+	if hc == nil {
+		return nil, errors.New("HealthChecker was nil")
+	}
+	return &StatusHandler{health: hc, overrides: overrides}, nil
 }
 
+// Check reports an unhealthy service in resp rather than as an error so
+// that callers can tell which dependency is unhealthy.
 func (sh *StatusHandler) Check(c context.Context, req *api.Request, resp *api.Response) error {
-	log := sh.prepLogger("check")
-	_, err := sh.guard.APIKeyValid([]byte(req.APIKey))
-	if err != nil {
-		reqDataB, _ := json.Marshal(req)
-		log = log.WithField(logging.FieldRequest, reqDataB)
-		if sh.guard.IsUnauthorizedError(err) {
-			log.Warnf("Unauthorized: %v", err)
-			return err
-		}
-		if sh.guard.IsForbiddenError(err) {
-			log.Warnf("Forbidden: %v", err)
-			return err
-		}
-		log.Errorf("Error checking API Key Valid (guard): %v", err)
-		return errors.Newf("Something wicked happened")
-	}
 	resp.Name = config.Name
 	resp.Version = config.VersionFull
 	resp.Description = config.Description
 	resp.CanonicalName = config.CanonicalRPCName()
+	resp.Live = sh.health.Live().Healthy
+	ready := sh.health.Ready(c)
+	resp.Ready = ready.Healthy
+	resp.Dependencies = newDependencyHealths(ready.Checks)
+	resp.Config = newConfigStatus(sh.overrides)
 	return nil
 }
+
+func newConfigStatus(ovs []config.Override) *api.ConfigStatus {
+	cs := &api.ConfigStatus{
+		Precedence: config.Precedence,
+		Overrides:  make([]*api.ConfigOverride, 0, len(ovs)),
+	}
+	for _, o := range ovs {
+		cs.Overrides = append(cs.Overrides, &api.ConfigOverride{
+			Field: o.Field,
+			Env:   o.Env,
+			Value: o.Value,
+		})
+	}
+	return cs
+}
+
+func newDependencyHealths(rs []health.Result) []*api.DependencyHealth {
+	dhs := make([]*api.DependencyHealth, 0, len(rs))
+	for _, r := range rs {
+		dh := &api.DependencyHealth{
+			Name:          r.Name,
+			Healthy:       r.Healthy,
+			LatencyMillis: int64(r.Latency / time.Millisecond),
+		}
+		if r.Err != nil {
+			dh.Error = r.Err.Error()
+		}
+		dhs = append(dhs, dh)
+	}
+	return dhs
+}
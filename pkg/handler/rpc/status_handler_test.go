@@ -1,114 +1,75 @@
 package rpc_test
 
 import (
+	"context"
 	"testing"
 
-	"github.com/tomogoma/shoppingms/pkg/handler/rpc"
-	"github.com/tomogoma/shoppingms/pkg/logging"
-	"github.com/tomogoma/shoppingms/pkg/mocks"
-	"context"
-	"github.com/tomogoma/shoppingms/pkg/api"
 	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/handler/rpc"
+	"github.com/tomogoma/shoppingms/pkg/health"
 )
 
-func TestNewHandler(t *testing.T) {
+func TestNewStatusHandler(t *testing.T) {
+	if _, err := rpc.NewStatusHandler(nil, nil); err == nil {
+		t.Errorf("Expected an error for nil HealthChecker, got nil")
+	}
+	sh, err := rpc.NewStatusHandler(&health.Health{}, nil)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if sh == nil {
+		t.Fatalf("Got nil *rpc.StatusHandler")
+	}
+}
+
+func TestStatusHandler_Check(t *testing.T) {
 	tt := []struct {
-		name   string
-		guard  rpc.Guard
-		logger logging.Logger
-		expErr bool
+		name     string
+		checkErr error
+		expReady bool
 	}{
-		{
-			name:   "valid deps",
-			guard:  &mocks.Guard{},
-			logger: &mocks.Logger{},
-			expErr: false,
-		},
-		{
-			name:   "nil guard",
-			guard:  nil,
-			logger: &mocks.Logger{},
-			expErr: true,
-		},
-		{
-			name:   "nil logger",
-			guard:  &mocks.Guard{},
-			logger: nil,
-			expErr: true,
-		},
+		{name: "ready", expReady: true},
+		{name: "not ready", checkErr: errors.New("db down")},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			sh, err := rpc.NewStatusHandler(tc.guard, tc.logger)
-			if tc.expErr {
-				if err == nil {
-					t.Fatalf("Expected an error, got nil")
-				}
-				return
+			hc, err := health.NewHealth(health.WithCheck("database", func(context.Context) error {
+				return tc.checkErr
+			}))
+			if err != nil {
+				t.Fatalf("Error setting up: new health: %v", err)
 			}
+			ovs := []config.Override{{Field: "database.password",
+				Env: "SHOPPINGMS_DATABASE_PASSWORD", Value: config.RedactedValue}}
+			sh, err := rpc.NewStatusHandler(hc, ovs)
 			if err != nil {
+				t.Fatalf("Error setting up: new status handler: %v", err)
+			}
+			resp := new(api.Response)
+			if err := sh.Check(context.TODO(), &api.Request{}, resp); err != nil {
 				t.Fatalf("Got error: %v", err)
 			}
-			if sh == nil {
-				t.Fatalf("Got nil *rpc.StatusHandler")
+			if resp.Name != config.Name || resp.CanonicalName != config.CanonicalRPCName() {
+				t.Errorf("Expected service %s (%s), got %+v",
+					config.Name, config.CanonicalRPCName(), resp)
 			}
-		})
-	}
-}
-
-
-func TestStatusHandler_Check(t *testing.T) {
-	tt := []struct {
-		name string
-		guard *mocks.Guard
-		req *api.Request
-		expErr bool
-	}{
-		{
-			name: "valid",
-			guard: &mocks.Guard{},
-			req: &api.Request{},
-			expErr: false,
-		},
-		{
-			name: "forbidden",
-			guard: &mocks.Guard{ExpAPIKValidErr: errors.NewForbidden("guard")},
-			req: &api.Request{},
-			expErr: true,
-		},
-		{
-			name: "unauthorized",
-			guard: &mocks.Guard{ExpAPIKValidErr: errors.NewUnauthorized("guard")},
-			req: &api.Request{},
-			expErr: true,
-		},
-		{
-			name: "internal error",
-			guard: &mocks.Guard{ExpAPIKValidErr: errors.Newf("guard")},
-			req: &api.Request{},
-			expErr: true,
-		},
-	}
-	for _, tc := range tt {
-		sh := newStatusHandler(t, tc.guard, &mocks.Logger{})
-		resp := new(api.Response)
-		err := sh.Check(context.TODO(), tc.req, resp)
-		if tc.expErr {
-			if err ==nil {
-				t.Fatalf("Expected an error, got nil")
+			if !resp.Live || resp.Ready != tc.expReady {
+				t.Errorf("Expected live and ready %t, got %+v", tc.expReady, resp)
+			}
+			if len(resp.Dependencies) != 1 || resp.Dependencies[0].Name != "database" ||
+				resp.Dependencies[0].Healthy != tc.expReady ||
+				(resp.Dependencies[0].Error != "") == tc.expReady {
+				t.Errorf("Expected database healthy %t, got %+v",
+					tc.expReady, resp.Dependencies)
 			}
-			return
-		}
-		if err != nil {
-			t.Fatalf("Got error: %v", err)
-		}
+			if resp.Config == nil || resp.Config.Precedence != config.Precedence ||
+				len(resp.Config.Overrides) != 1 ||
+				*resp.Config.Overrides[0] != (api.ConfigOverride{Field: ovs[0].Field,
+					Env: ovs[0].Env, Value: ovs[0].Value}) {
+				t.Errorf("Expected config overrides %+v, got %+v", ovs, resp.Config)
+			}
+		})
 	}
 }
-
-func newStatusHandler(t *testing.T, g rpc.Guard, lg logging.Logger) *rpc.StatusHandler {
-	sh, err := rpc.NewStatusHandler(g, lg)
-	if err != nil {
-		t.Fatalf("Error setting up: new status handler: %v", err)
-	}
-	return sh
-}
\ No newline at end of file
@@ -0,0 +1,405 @@
+package rpc
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	microErrors "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/logging"
+	"github.com/tomogoma/shoppingms/pkg/metrics"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+	"github.com/tomogoma/shoppingms/pkg/tracing"
+	"github.com/tomogoma/shoppingms/pkg/validation"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+// tracer starts a server span per call as a child of the trace in the
+// call's traceparent metadata, if any.
+var tracer = otel.Tracer("github.com/tomogoma/shoppingms/pkg/handler/rpc")
+
+type contextKey string
+
+const (
+	// KeyAPIKey is the metadata key carrying the API key of RPC calls.
+	KeyAPIKey = "x-api-key"
+
+	ctxKeyLog     = contextKey("log")
+	ctxKeyIsAdmin = contextKey("isAdmin")
+	ctxKeyClUsrID = contextKey("clUsrID")
+)
+
+type errCheck struct {
+	errors.AuthErrCheck
+	errors.ClErrCheck
+	errors.NotFoundErrCheck
+	errors.NotImplErrCheck
+}
+
+// apiKeyer is implemented by request messages that carry an API key in
+// their body.
+type apiKeyer interface {
+	GetAPIKey() string
+}
+
+// jwtBearer is implemented by request messages that carry a JWT.
+type jwtBearer interface {
+	GetJWT() string
+}
+
+// RateLimiter limits calls per client app user and per authenticated user.
+type RateLimiter interface {
+	AllowAPIKey(ctx context.Context, clUsrID string) (ratelimit.Result, error)
+	AllowUser(ctx context.Context, usrID string) (ratelimit.Result, error)
+}
+
+// Authorizer authorizes calls bearing API keys validated by the Guard for
+// the scope the method requires.
+type Authorizer interface {
+	Authorize(ctx context.Context, userID string, key []byte, scope, origin string) error
+}
+
+// methodScopes are the API key scopes methods require. Methods not listed
+// require apikeys.ScopeAdmin.
+var methodScopes = map[string]string{
+	"Status.Check":                         apikeys.ScopeAny,
+	"ShoppingLists.InsertShoppingList":     apikeys.ScopeListsWrite,
+	"ShoppingLists.UpdateShoppingList":     apikeys.ScopeListsWrite,
+	"ShoppingLists.GetShoppingLists":       apikeys.ScopeListsRead,
+	"ShoppingLists.UpsertShoppingListItem": apikeys.ScopeListsWrite,
+	"ShoppingLists.DeleteShoppingListItem": apikeys.ScopeListsWrite,
+	"ShoppingLists.GetShoppingListItems":   apikeys.ScopeListsRead,
+	"ShoppingLists.SearchShoppingItems":    apikeys.ScopeListsRead,
+	"ShoppingLists.DuplicateItems":         apikeys.ScopeCatalogWrite,
+	"ShoppingLists.DuplicateBrands":        apikeys.ScopeCatalogWrite,
+	"ShoppingLists.MergeItems":             apikeys.ScopeCatalogWrite,
+	"ShoppingLists.MergeBrands":            apikeys.ScopeCatalogWrite,
+}
+
+// UserIDer identifies the user a JWT was issued to.
+type UserIDer interface {
+	UserID(JWT string) (string, error)
+}
+
+// Metrics records RPC call metrics.
+type Metrics interface {
+	ObserveRPC(method string, code int32, d time.Duration)
+	APIKeyInvalid(transport string)
+}
+
+// Wrappers returns the handler wrappers every RPC handler should be served
+// with (see micro.WrapHandler()). Outermost first, they continue the
+// caller's trace, add a logger identifying the trace to the context, record
+// metrics, map errors to go-micro errors, recover panics, validate and
+// authorize the API key (see methodScopes) and enforce rate limits. Calls
+// bearing the master key skip the guard and rate limits and are marked as
+// admin calls, master is ignored if nil or empty. master may be Set() at
+// any time e.g. on config reload.
+func Wrappers(g Guard, lg logging.Logger, m Metrics, lm RateLimiter, u UserIDer, a Authorizer, master *apikeys.MasterKey) ([]server.HandlerWrapper, error) {
+	if g == nil {
+		return nil, errors.New("Guard was nil")
+	}
+	if lg == nil {
+		return nil, errors.New("Logger was nil")
+	}
+	if m == nil {
+		return nil, errors.New("Metrics was nil")
+	}
+	if lm == nil {
+		return nil, errors.New("RateLimiter was nil")
+	}
+	if u == nil {
+		return nil, errors.New("UserIDer was nil")
+	}
+	if a == nil {
+		return nil, errors.New("Authorizer was nil")
+	}
+	return []server.HandlerWrapper{
+		traceWrapper(),
+		logWrapper(lg),
+		metricsWrapper(m),
+		errorWrapper(g),
+		recoverWrapper(),
+		authWrapper(g, a, m, master),
+		rateLimitWrapper(lm, u),
+	}, nil
+}
+
+// traceWrapper starts a span for the call as a child of the trace
+// propagated in the call's W3C traceparent metadata and propagates the span
+// in the context's metadata for calls made by the handler. It must wrap
+// errorWrapper() for the status code to be known.
+func traceWrapper() server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			prop := otel.GetTextMapPropagator()
+			md, _ := metadata.FromContext(ctx)
+			ctx = prop.Extract(ctx, metadataCarrier(md))
+			ctx, span := tracer.Start(ctx, req.Method(),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("rpc.system", "go-micro"),
+					attribute.String("rpc.service", req.Service()),
+					attribute.String("rpc.method", req.Method()),
+				),
+			)
+			defer span.End()
+
+			outMD := metadataCarrier{}
+			for k, v := range md {
+				outMD[k] = v
+			}
+			prop.Inject(ctx, outMD)
+			ctx = metadata.NewContext(ctx, metadata.Metadata(outMD))
+
+			err := next(ctx, req, rsp)
+			code := errCode(err)
+			span.SetAttributes(attribute.Int("rpc.code", int(code)))
+			if code >= http.StatusInternalServerError {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// logWrapper adds a logger identifying the call's trace to the context
+// and logs the call.
+func logWrapper(lg logging.Logger) server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			log := lg.WithField(logging.FieldTransID, tracing.TransID(ctx))
+			log.WithFields(map[string]interface{}{
+				logging.FieldRPCMethod:      req.Method(),
+				logging.FieldRequestHandler: "RPC",
+			}).Info("new request")
+			return next(context.WithValue(ctx, ctxKeyLog, log), req, rsp)
+		}
+	}
+}
+
+// metricsWrapper records the duration and status code of the call. It
+// must wrap errorWrapper() for the status code to be known.
+func metricsWrapper(m Metrics) server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			start := time.Now()
+			err := next(ctx, req, rsp)
+			m.ObserveRPC(req.Method(), errCode(err), time.Since(start))
+			return err
+		}
+	}
+}
+
+// errCode returns the status code of a call that returned err.
+func errCode(err error) int32 {
+	if err == nil {
+		return http.StatusOK
+	}
+	if mErr, ok := err.(*microErrors.Error); ok {
+		return mErr.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// errorWrapper maps go-typed-errors and validation errors returned by the
+// handler to go-micro errors with equivalent codes. Errors not caused by the caller are logged
+// and replaced with a generic internal error. The request is logged
+// without its credentials.
+func errorWrapper(g Guard) server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			err := next(ctx, req, rsp)
+			if err == nil {
+				return nil
+			}
+			if _, ok := err.(*microErrors.Error); ok {
+				return err
+			}
+
+			log := loggerFrom(ctx).WithField(logging.FieldRequest,
+				logging.RequestData(req.Request()))
+
+			id := config.CanonicalRPCName()
+			var code int32
+			chk := errCheck{}
+			_, isInvalid := validation.Violations(err)
+			switch {
+			case isInvalid:
+				code = http.StatusBadRequest
+			case g.IsUnauthorizedError(err) || chk.IsUnauthorizedError(err):
+				code = http.StatusUnauthorized
+			case g.IsForbiddenError(err) || chk.IsForbiddenError(err):
+				code = http.StatusForbidden
+			case chk.IsAuthError(err):
+				code = http.StatusUnauthorized
+			case chk.IsClientError(err):
+				code = http.StatusBadRequest
+			case chk.IsNotFoundError(err):
+				code = http.StatusNotFound
+			case chk.IsNotImplementedError(err):
+				code = http.StatusNotImplemented
+			default:
+				log.Errorf("Internal error: %v", err)
+				return microErrors.InternalServerError(id, "Something wicked happened")
+			}
+			log.Warn(err)
+			return microErrors.New(id, err.Error(), code)
+		}
+	}
+}
+
+// recoverWrapper converts panics in the handler into errors.
+func recoverWrapper() server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					loggerFrom(ctx).Errorf("Recovered from panic: %v\n%s", r, debug.Stack())
+					err = errors.Newf("panic: %v", r)
+				}
+			}()
+			return next(ctx, req, rsp)
+		}
+	}
+}
+
+// authWrapper only lets calls with a valid API key that grants the scope
+// of the method and may be used from the call's Origin metadata through to
+// the handler. The API key is read from the KeyAPIKey metadata, falling
+// back to the request message's APIKey field. See Wrappers() for
+// master.
+func authWrapper(g Guard, a Authorizer, m Metrics, master *apikeys.MasterKey) server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			APIKey := []byte(apiKey(ctx, req))
+			if master.Matches(APIKey) {
+				return next(context.WithValue(ctx, ctxKeyIsAdmin, true), req, rsp)
+			}
+			clUsrID, err := g.APIKeyValid(APIKey)
+			if err == nil {
+				err = a.Authorize(ctx, clUsrID, APIKey, methodScope(req.Method()),
+					metadataValue(ctx, "Origin"))
+			}
+			if err != nil {
+				m.APIKeyInvalid(metrics.TransportRPC)
+				return err
+			}
+			log := loggerFrom(ctx).WithField(logging.FieldClientAppUserID, clUsrID)
+			ctx = context.WithValue(ctx, ctxKeyLog, log)
+			return next(context.WithValue(ctx, ctxKeyClUsrID, clUsrID), req, rsp)
+		}
+	}
+}
+
+// rateLimitWrapper only lets calls through while neither the client app
+// user validated by authWrapper() nor the user owning the request
+// message's JWT, if any, is over their rate limit. Calls are let through if
+// a limit cannot be checked. Admin calls are not limited.
+func rateLimitWrapper(lm RateLimiter, u UserIDer) server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			if isAdmin, _ := ctx.Value(ctxKeyIsAdmin).(bool); isAdmin {
+				return next(ctx, req, rsp)
+			}
+			log := loggerFrom(ctx)
+			clUsrID, _ := ctx.Value(ctxKeyClUsrID).(string)
+
+			res, err := lm.AllowAPIKey(ctx, clUsrID)
+			if err != nil {
+				log.Warnf("Check client app rate limit: %v", err)
+				res = ratelimit.Result{Allowed: true}
+			}
+			r, ok := req.Request().(jwtBearer)
+			if ok && r.GetJWT() != "" && res.Allowed {
+				if usrID, err := u.UserID(r.GetJWT()); err == nil {
+					usrRes, err := lm.AllowUser(ctx, usrID)
+					if err != nil {
+						log.Warnf("Check user rate limit: %v", err)
+					} else {
+						res = ratelimit.Stricter(res, usrRes)
+					}
+				}
+			}
+
+			if !res.Allowed {
+				retryAfter := int64(math.Ceil(res.RetryAfter.Seconds()))
+				return microErrors.New(config.CanonicalRPCName(), fmt.Sprintf(
+					"rate limit exceeded, retry after %d seconds", retryAfter),
+					http.StatusTooManyRequests)
+			}
+			return next(ctx, req, rsp)
+		}
+	}
+}
+
+func apiKey(ctx context.Context, req server.Request) string {
+	if key := metadataValue(ctx, KeyAPIKey); key != "" {
+		return key
+	}
+	if r, ok := req.Request().(apiKeyer); ok {
+		return r.GetAPIKey()
+	}
+	return ""
+}
+
+// metadataValue returns the value of the call metadata with key, matched
+// case-insensitively.
+func metadataValue(ctx context.Context, key string) string {
+	md, _ := metadata.FromContext(ctx)
+	return metadataCarrier(md).Get(key)
+}
+
+func methodScope(method string) string {
+	if scope, ok := methodScopes[method]; ok {
+		return scope
+	}
+	return apikeys.ScopeAdmin
+}
+
+// metadataCarrier adapts go-micro metadata for trace context propagation.
+// Keys are matched case-insensitively as transports may canonicalize them.
+type metadataCarrier metadata.Metadata
+
+func (c metadataCarrier) Get(key string) string {
+	for k, v := range c {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	for k := range c {
+		if strings.EqualFold(k, key) {
+			delete(c, k)
+		}
+	}
+	c[key] = value
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func loggerFrom(ctx context.Context) logging.Logger {
+	return ctx.Value(ctxKeyLog).(logging.Logger)
+}
@@ -0,0 +1,343 @@
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	microErrors "github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+	"github.com/tomogoma/shoppingms/pkg/handler/rpc"
+	"github.com/tomogoma/shoppingms/pkg/mocks"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type request struct {
+	method string
+	body   interface{}
+}
+
+func (r request) Service() string      { return "test" }
+func (r request) Method() string       { return r.method }
+func (r request) ContentType() string  { return "application/protobuf" }
+func (r request) Request() interface{} { return r.body }
+func (r request) Stream() bool         { return false }
+
+type metricsRecorder struct {
+	methods     []string
+	codes       []int32
+	keyFailures int
+}
+
+func (m *metricsRecorder) ObserveRPC(method string, code int32, d time.Duration) {
+	m.methods = append(m.methods, method)
+	m.codes = append(m.codes, code)
+}
+
+func (m *metricsRecorder) APIKeyInvalid(transport string) {
+	m.keyFailures++
+}
+
+// userIDer treats JWTs as the IDs of the users they were issued to.
+type userIDer struct{}
+
+func (userIDer) UserID(JWT string) (string, error) {
+	return JWT, nil
+}
+
+// authorizer grants API keys the scopes keyed by the key, or
+// apikeys.DefaultScopes if not listed.
+type authorizer map[string][]string
+
+func (a authorizer) Authorize(ctx context.Context, userID string, key []byte, scope, origin string) error {
+	scopes, ok := a[string(key)]
+	if !ok {
+		scopes = apikeys.DefaultScopes
+	}
+	if !apikeys.HasScope(scopes, scope) {
+		return errors.NewForbiddenf("API key lacks the %s scope", scope)
+	}
+	return nil
+}
+
+func TestWrappers(t *testing.T) {
+	tt := []struct {
+		name    string
+		guard   rpc.Guard
+		ctx     context.Context
+		handler        func(context.Context) error
+		expCode        int32
+		expKeyFailures int
+	}{
+		{
+			name:    "valid",
+			guard:   &mocks.Guard{},
+			handler: func(context.Context) error { return nil },
+		},
+		{
+			name:    "unauthorized",
+			guard:   &mocks.Guard{ExpAPIKValidErr: errors.NewUnauthorized("guard")},
+			handler:        func(context.Context) error { return nil },
+			expCode:        http.StatusUnauthorized,
+			expKeyFailures: 1,
+		},
+		{
+			name:           "guard internal error",
+			guard:          &mocks.Guard{ExpAPIKValidErr: errors.New("guard")},
+			handler:        func(context.Context) error { return nil },
+			expCode:        http.StatusInternalServerError,
+			expKeyFailures: 1,
+		},
+		{
+			name:    "not found",
+			guard:   &mocks.Guard{},
+			handler: func(context.Context) error { return errors.NewNotFound("none") },
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "panic",
+			guard:   &mocks.Guard{},
+			handler: func(context.Context) error { panic("oops") },
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "master key from metadata",
+			guard:   &mocks.Guard{ExpAPIKValidErr: errors.NewUnauthorized("guard")},
+			ctx:     metadata.NewContext(context.TODO(), metadata.Metadata{"X-Api-Key": masterKey}),
+			handler: func(context.Context) error { return nil },
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &metricsRecorder{}
+			err := call(t, "Status.Check", callOpts{ctx: tc.ctx, guard: tc.guard, metrics: m},
+				tc.handler)
+			expCode := tc.expCode
+			if expCode == 0 {
+				expCode = http.StatusOK
+			}
+			if len(m.codes) != 1 || m.codes[0] != expCode || m.methods[0] != "Status.Check" {
+				t.Errorf("Expected one Status.Check call with code %d recorded, got %+v",
+					expCode, m)
+			}
+			if m.keyFailures != tc.expKeyFailures {
+				t.Errorf("Expected %d API key failures recorded, got %d",
+					tc.expKeyFailures, m.keyFailures)
+			}
+			if tc.expCode != 0 {
+				if mErr, ok := err.(*microErrors.Error); !ok || mErr.Code != tc.expCode {
+					t.Fatalf("Expected error code %d, got %v", tc.expCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWrappers_traceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.NewContext(context.TODO(), metadata.Metadata{
+		"Traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+	})
+
+	var handlerCtx context.Context
+	err := call(t, "Status.Check", callOpts{ctx: ctx}, func(ctx context.Context) error {
+		handlerCtx = ctx
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	if got := trace.SpanContextFromContext(handlerCtx).TraceID().String(); got != traceID {
+		t.Errorf("Expected handler context in trace %s, got %s", traceID, got)
+	}
+	md, _ := metadata.FromContext(handlerCtx)
+	var tp string
+	for k, v := range md {
+		if strings.EqualFold(k, "traceparent") {
+			tp = v
+		}
+	}
+	if !strings.Contains(tp, traceID) {
+		t.Errorf("Expected handler metadata to propagate trace %s, got %+v", traceID, md)
+	}
+}
+
+func TestWrappers_rateLimit(t *testing.T) {
+	lm, err := ratelimit.NewLimiter(ratelimit.NewMemory(),
+		ratelimit.WithAPIKeyLimit(ratelimit.Limit{Rate: 0.001, Burst: 3}),
+		ratelimit.WithUserLimit(ratelimit.Limit{Rate: 0.001, Burst: 1}),
+	)
+	if err != nil {
+		t.Fatalf("Error setting up: new limiter: %v", err)
+	}
+	tt := []struct {
+		name    string
+		ctx     context.Context
+		req     interface{}
+		expCode int32
+	}{
+		{name: "within limits", req: &api.GetShoppingListsRequest{JWT: "usr1"}},
+		{name: "user over limit", req: &api.GetShoppingListsRequest{JWT: "usr1"},
+			expCode: http.StatusTooManyRequests},
+		{name: "other user", req: &api.GetShoppingListsRequest{JWT: "usr2"}},
+		{name: "API key over limit", req: &api.Request{},
+			expCode: http.StatusTooManyRequests},
+		{
+			name: "admin not limited",
+			ctx:  metadata.NewContext(context.TODO(), metadata.Metadata{"X-Api-Key": masterKey}),
+			req:  &api.Request{},
+		},
+	}
+	for _, tc := range tt {
+		m := &metricsRecorder{}
+		err := call(t, "ShoppingLists.GetShoppingLists", callOpts{ctx: tc.ctx, metrics: m,
+			limiter: lm, req: tc.req, resp: new(api.ShoppingLists)},
+			func(context.Context) error { return nil })
+		if tc.expCode == 0 {
+			if err != nil {
+				t.Errorf("%s: got error: %v", tc.name, err)
+			}
+			continue
+		}
+		if mErr, ok := err.(*microErrors.Error); !ok || mErr.Code != tc.expCode {
+			t.Errorf("%s: expected error code %d, got %v", tc.name, tc.expCode, err)
+		}
+		if len(m.codes) != 1 || m.codes[0] != tc.expCode {
+			t.Errorf("%s: expected code %d recorded, got %+v", tc.name, tc.expCode, m)
+		}
+	}
+}
+
+func TestWrappers_scopes(t *testing.T) {
+	tt := []struct {
+		name    string
+		APIKey  string
+		method  string
+		expCode int32
+	}{
+		{name: "default scopes", method: "ShoppingLists.GetShoppingLists"},
+		{name: "any scope", APIKey: "catalog", method: "Status.Check"},
+		{name: "out of scope", APIKey: "catalog", method: "ShoppingLists.GetShoppingLists",
+			expCode: http.StatusForbidden},
+		{name: "catalog scope", APIKey: "catalog", method: "ShoppingLists.MergeItems"},
+		{name: "catalog without scope", method: "ShoppingLists.MergeItems",
+			expCode: http.StatusForbidden},
+		{name: "admin scope", APIKey: "admin", method: "ShoppingLists.MergeItems"},
+		{name: "unlisted method requires admin", method: "ShoppingLists.Unknown",
+			expCode: http.StatusForbidden},
+		{name: "unlisted method with admin scope", APIKey: "admin", method: "ShoppingLists.Unknown"},
+	}
+	a := authorizer{
+		"catalog": {apikeys.ScopeCatalogWrite},
+		"admin":   {apikeys.ScopeAdmin},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewContext(context.TODO(), metadata.Metadata{"X-Api-Key": tc.APIKey})
+			err := call(t, tc.method, callOpts{ctx: ctx, auth: a},
+				func(context.Context) error { return nil })
+			if tc.expCode == 0 {
+				if err != nil {
+					t.Fatalf("Got error: %v", err)
+				}
+				return
+			}
+			if mErr, ok := err.(*microErrors.Error); !ok || mErr.Code != tc.expCode {
+				t.Fatalf("Expected error code %d, got %v", tc.expCode, err)
+			}
+		})
+	}
+}
+
+func TestWrappers_nilDeps(t *testing.T) {
+	lm := newLimiter(t)
+	if _, err := rpc.Wrappers(nil, &mocks.Logger{}, &metricsRecorder{}, lm, userIDer{}, authorizer{}, nil); err == nil {
+		t.Errorf("Expected an error for nil guard, got nil")
+	}
+	if _, err := rpc.Wrappers(&mocks.Guard{}, nil, &metricsRecorder{}, lm, userIDer{}, authorizer{}, nil); err == nil {
+		t.Errorf("Expected an error for nil logger, got nil")
+	}
+	if _, err := rpc.Wrappers(&mocks.Guard{}, &mocks.Logger{}, nil, lm, userIDer{}, authorizer{}, nil); err == nil {
+		t.Errorf("Expected an error for nil metrics, got nil")
+	}
+	if _, err := rpc.Wrappers(&mocks.Guard{}, &mocks.Logger{}, &metricsRecorder{}, nil, userIDer{}, authorizer{}, nil); err == nil {
+		t.Errorf("Expected an error for nil rate limiter, got nil")
+	}
+	if _, err := rpc.Wrappers(&mocks.Guard{}, &mocks.Logger{}, &metricsRecorder{}, lm, nil, authorizer{}, nil); err == nil {
+		t.Errorf("Expected an error for nil user IDer, got nil")
+	}
+	if _, err := rpc.Wrappers(&mocks.Guard{}, &mocks.Logger{}, &metricsRecorder{}, lm, userIDer{}, nil, nil); err == nil {
+		t.Errorf("Expected an error for nil authorizer, got nil")
+	}
+}
+
+func newLimiter(t *testing.T) *ratelimit.Limiter {
+	lm, err := ratelimit.NewLimiter(ratelimit.NewMemory())
+	if err != nil {
+		t.Fatalf("Error setting up: new limiter: %v", err)
+	}
+	return lm
+}
+
+// callOpts configures call(). Fields left empty take the defaults noted.
+type callOpts struct {
+	ctx     context.Context // context.TODO()
+	guard   rpc.Guard       // &mocks.Guard{}
+	metrics rpc.Metrics     // &metricsRecorder{}
+	limiter rpc.RateLimiter // newLimiter(t)
+	auth    rpc.Authorizer  // authorizer{}
+	req     interface{}     // &api.Request{}
+	resp    interface{}     // new(api.Response)
+}
+
+// call calls handler through Wrappers() as the go-micro server would.
+func call(t *testing.T, method string, o callOpts, handler func(context.Context) error) error {
+	if o.ctx == nil {
+		o.ctx = context.TODO()
+	}
+	if o.guard == nil {
+		o.guard = &mocks.Guard{}
+	}
+	if o.metrics == nil {
+		o.metrics = &metricsRecorder{}
+	}
+	if o.limiter == nil {
+		o.limiter = newLimiter(t)
+	}
+	if o.auth == nil {
+		o.auth = authorizer{}
+	}
+	if o.req == nil {
+		o.req = &api.Request{}
+	}
+	if o.resp == nil {
+		o.resp = new(api.Response)
+	}
+	ws, err := rpc.Wrappers(o.guard, &mocks.Logger{}, o.metrics, o.limiter, userIDer{},
+		o.auth, apikeys.NewMasterKey(masterKey))
+	if err != nil {
+		t.Fatalf("Error setting up: wrappers: %v", err)
+	}
+	fn := server.HandlerFunc(func(ctx context.Context, req server.Request, resp interface{}) error {
+		return handler(ctx)
+	})
+	for i := len(ws); i > 0; i-- {
+		fn = ws[i-1](fn)
+	}
+	return fn(o.ctx, request{method: method, body: o.req}, o.resp)
+}
@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+const (
+	// DefaultTimeout is how long a check may take before its dependency
+	// is reported unhealthy.
+	DefaultTimeout = 5 * time.Second
+)
+
+// Check reports the health of a dependency, returning an error if the
+// dependency is unhealthy. Checks should give up once ctx is done.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single Check.
+type Result struct {
+	Name    string
+	Healthy bool
+	Latency time.Duration
+	Err     error
+}
+
+// Report is the outcome of a round of checks. Healthy is only true if all
+// checks were healthy.
+type Report struct {
+	Healthy bool
+	Checks  []Result
+}
+
+// Health runs named checks against the service's dependencies.
+// Use NewHealth() to instantiate. The zero value has no checks and is
+// always healthy.
+type Health struct {
+	names   []string
+	checks  []Check
+	timeout time.Duration
+}
+
+// Option allows extra configuration for instantiating Health. Use the
+// With... functions to set options e.g.
+//     dbOpt := WithCheck("database", db.Ping)
+type Option func(*Health)
+
+// WithCheck adds a check reported under name.
+func WithCheck(name string, c Check) Option {
+	return func(h *Health) {
+		h.names = append(h.names, name)
+		h.checks = append(h.checks, c)
+	}
+}
+
+// WithTimeout overrides DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Health) {
+		h.timeout = d
+	}
+}
+
+func NewHealth(opts ...Option) (*Health, error) {
+	h := &Health{timeout: DefaultTimeout}
+	for _, f := range opts {
+		f(h)
+	}
+	if h.timeout <= 0 {
+		return nil, errors.Newf("timeout must be positive, got %s", h.timeout)
+	}
+	seen := make(map[string]bool)
+	for i, name := range h.names {
+		if name == "" {
+			return nil, errors.New("check name was empty")
+		}
+		if seen[name] {
+			return nil, errors.Newf("check %s added more than once", name)
+		}
+		seen[name] = true
+		if h.checks[i] == nil {
+			return nil, errors.Newf("check %s was nil", name)
+		}
+	}
+	return h, nil
+}
+
+// Live reports whether the process is able to serve requests at all.
+// It does not check dependencies so that an outage of a dependency does not
+// get healthy instances restarted.
+func (h *Health) Live() Report {
+	return Report{Healthy: true}
+}
+
+// Ready runs all checks concurrently and reports whether the service's
+// dependencies are healthy enough for it to receive traffic.
+func (h *Health) Ready(ctx context.Context) Report {
+	timeout := h.timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rprt := Report{Healthy: true, Checks: make([]Result, len(h.checks))}
+	wg := sync.WaitGroup{}
+	for i := range h.checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rprt.Checks[i] = run(ctx, h.names[i], h.checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for _, r := range rprt.Checks {
+		if !r.Healthy {
+			rprt.Healthy = false
+		}
+	}
+	return rprt
+}
+
+// run runs c, giving up on it once ctx is done.
+func run(ctx context.Context, name string, c Check) Result {
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() { errCh <- c(ctx) }()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = errors.Newf("timed out: %v", ctx.Err())
+	}
+	return Result{
+		Name:    name,
+		Healthy: err == nil,
+		Latency: time.Since(start),
+		Err:     err,
+	}
+}
@@ -0,0 +1,117 @@
+package health_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/health"
+)
+
+func TestNewHealth(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	tt := []struct {
+		name   string
+		opts   []health.Option
+		expErr bool
+	}{
+		{name: "no checks"},
+		{name: "with checks", opts: []health.Option{
+			health.WithCheck("db", ok), health.WithCheck("jwt", ok),
+		}},
+		{name: "empty name", opts: []health.Option{health.WithCheck("", ok)}, expErr: true},
+		{name: "nil check", opts: []health.Option{health.WithCheck("db", nil)}, expErr: true},
+		{name: "duplicate name", opts: []health.Option{
+			health.WithCheck("db", ok), health.WithCheck("db", ok),
+		}, expErr: true},
+		{name: "bad timeout", opts: []health.Option{health.WithTimeout(0)}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := health.NewHealth(tc.opts...)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if h == nil {
+				t.Fatalf("Got nil *health.Health")
+			}
+		})
+	}
+}
+
+func TestHealth_Ready(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("db down") }
+	hang := func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }
+	tt := []struct {
+		name       string
+		opts       []health.Option
+		expHealthy bool
+		expChecks  map[string]bool
+	}{
+		{name: "no checks", expHealthy: true, expChecks: map[string]bool{}},
+		{
+			name:       "all healthy",
+			opts:       []health.Option{health.WithCheck("db", ok), health.WithCheck("jwt", ok)},
+			expHealthy: true,
+			expChecks:  map[string]bool{"db": true, "jwt": true},
+		},
+		{
+			name:      "one failing",
+			opts:      []health.Option{health.WithCheck("db", fail), health.WithCheck("jwt", ok)},
+			expChecks: map[string]bool{"db": false, "jwt": true},
+		},
+		{
+			name: "timed out",
+			opts: []health.Option{
+				health.WithCheck("db", hang),
+				health.WithTimeout(10 * time.Millisecond),
+			},
+			expChecks: map[string]bool{"db": false},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := health.NewHealth(tc.opts...)
+			if err != nil {
+				t.Fatalf("Error setting up: new health: %v", err)
+			}
+			rprt := h.Ready(context.Background())
+			if rprt.Healthy != tc.expHealthy {
+				t.Errorf("Expected healthy %t, got %t", tc.expHealthy, rprt.Healthy)
+			}
+			if len(rprt.Checks) != len(tc.expChecks) {
+				t.Fatalf("Expected %d checks, got %d", len(tc.expChecks), len(rprt.Checks))
+			}
+			for _, r := range rprt.Checks {
+				expHealthy, ok := tc.expChecks[r.Name]
+				if !ok {
+					t.Errorf("Unexpected check %s", r.Name)
+					continue
+				}
+				if r.Healthy != expHealthy || (r.Err == nil) != expHealthy {
+					t.Errorf("Expected %s healthy %t, got %+v", r.Name, expHealthy, r)
+				}
+			}
+		})
+	}
+}
+
+func TestHealth_Live(t *testing.T) {
+	h, err := health.NewHealth(health.WithCheck("db", func(context.Context) error {
+		return errors.New("db down")
+	}))
+	if err != nil {
+		t.Fatalf("Error setting up: new health: %v", err)
+	}
+	if rprt := h.Live(); !rprt.Healthy {
+		t.Errorf("Expected live despite unhealthy dependencies, got %+v", rprt)
+	}
+}
@@ -1,5 +1,16 @@
 package logging
 
+import (
+	"encoding/json"
+	"strings"
+)
+
+// RedactedValue replaces the credentials in RequestData.
+const RedactedValue = "[redacted]"
+
+// credentialFields are the request fields RequestData never reveals.
+var credentialFields = []string{"APIKey", "JWT"}
+
 func LogWarnOnError(lg Logger, err error, action string) {
 	if err != nil {
 		lg.WithField(FieldAction, action).Warn(err)
@@ -11,3 +22,29 @@ func LogFatalOnError(lg Logger, err error, action string) {
 		lg.WithField(FieldAction, action).Fatal(err)
 	}
 }
+
+// RequestData returns the JSON encoding of req for logging as FieldRequest
+// with the values of its APIKey and JWT fields (matched case insensitively)
+// replaced by RedactedValue.
+func RequestData(req interface{}) string {
+	reqB, _ := json.Marshal(req)
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(reqB, &fields); err != nil {
+		return string(reqB)
+	}
+	redacted, _ := json.Marshal(RedactedValue)
+	found := false
+	for name := range fields {
+		for _, cred := range credentialFields {
+			if strings.EqualFold(name, cred) {
+				fields[name] = redacted
+				found = true
+			}
+		}
+	}
+	if !found {
+		return string(reqB)
+	}
+	reqB, _ = json.Marshal(fields)
+	return string(reqB)
+}
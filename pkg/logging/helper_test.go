@@ -0,0 +1,47 @@
+package logging_test
+
+import (
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/logging"
+)
+
+func TestRequestData(t *testing.T) {
+	tt := []struct {
+		name string
+		req  interface{}
+		exp  string
+	}{
+		{
+			name: "credentials",
+			req: struct {
+				APIKey string
+				JWT    string
+				Name   string
+			}{APIKey: "secret-key", JWT: "secret.jwt", Name: "milk"},
+			exp: `{"APIKey":"[redacted]","JWT":"[redacted]","Name":"milk"}`,
+		},
+		{
+			name: "renamed credentials",
+			req: struct {
+				Key string `json:"apiKey"`
+				Tkn string `json:"jwt,omitempty"`
+			}{Key: "secret-key", Tkn: "secret.jwt"},
+			exp: `{"apiKey":"[redacted]","jwt":"[redacted]"}`,
+		},
+		{
+			name: "no credentials",
+			req:  struct{ Name string }{Name: "milk"},
+			exp:  `{"Name":"milk"}`,
+		},
+		{name: "not an object", req: []string{"milk"}, exp: `["milk"]`},
+		{name: "nil", req: nil, exp: `null`},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := logging.RequestData(tc.req); got != tc.exp {
+				t.Errorf("Expected %s, got %s", tc.exp, got)
+			}
+		})
+	}
+}
@@ -83,3 +83,16 @@ func (lg *Wrapper) prepare() {
 		lg.Entry = logrus.NewEntry(logrus.New())
 	}
 }
+
+// SetLevel sets the level of the underlying logger, and therefore of every
+// logger derived from lg, to one of logrus' level names e.g. "debug" or
+// "info".
+func (lg *Wrapper) SetLevel(level string) error {
+	lg.prepare()
+	l, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	lg.Entry.Logger.SetLevel(l)
+	return nil
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/config"
+)
+
+// Transports reported as the transport label of API key validation failures.
+const (
+	TransportHTTP = "http"
+	TransportRPC  = "rpc"
+)
+
+// RouteUnmatched is reported as the route of HTTP requests that matched
+// no route, so that arbitrary paths do not inflate the number of series.
+const RouteUnmatched = "unmatched"
+
+// Metrics collects the service's prometheus metrics into its own registry.
+// Labels are kept bounded: HTTP requests are labeled with route templates
+// rather than raw paths, RPC calls with their method and DB operations
+// with the storage method that ran them.
+// Use NewMetrics() to instantiate.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	rpcRequests     *prometheus.CounterVec
+	rpcDuration     *prometheus.HistogramVec
+	dbQueryDuration *prometheus.HistogramVec
+	dbTxDuration    *prometheus.HistogramVec
+	dbTxRetries     *prometheus.CounterVec
+	apiKeyFailures  *prometheus.CounterVec
+}
+
+func NewMetrics() (*Metrics, error) {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Name,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route template, method and status code.",
+		}, []string{"route", "method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Name,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latencies by route template and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		rpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Name,
+			Name:      "rpc_requests_total",
+			Help:      "RPC calls by method and status code.",
+		}, []string{"method", "code"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Name,
+			Name:      "rpc_request_duration_seconds",
+			Help:      "RPC call latencies by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Name,
+			Name:      "db_query_duration_seconds",
+			Help:      "DB query latencies by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"op"}),
+		dbTxDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Name,
+			Name:      "db_transaction_duration_seconds",
+			Help:      "DB transaction latencies, retries included, by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"op"}),
+		dbTxRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Name,
+			Name:      "db_transaction_retries_total",
+			Help:      "DB transaction retries by operation.",
+		}, []string{"op"}),
+		apiKeyFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Name,
+			Name:      "api_key_validation_failures_total",
+			Help:      "Requests rejected for bearing an invalid API key by transport.",
+		}, []string{"transport"}),
+	}
+	cs := []prometheus.Collector{
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration,
+		m.rpcRequests, m.rpcDuration,
+		m.dbQueryDuration, m.dbTxDuration, m.dbTxRetries,
+		m.apiKeyFailures,
+	}
+	for _, c := range cs {
+		if err := m.registry.Register(c); err != nil {
+			return nil, errors.Newf("register collector: %v", err)
+		}
+	}
+	return m, nil
+}
+
+// Handler serves the collected metrics in the prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTP records an HTTP request. route should be the template of the
+// route matched e.g. /shoppinglists/{shoppingListID} or RouteUnmatched.
+func (m *Metrics) ObserveHTTP(route, method string, code int, d time.Duration) {
+	m.httpRequests.WithLabelValues(route, method, strconv.Itoa(code)).Inc()
+	m.httpDuration.WithLabelValues(route, method).Observe(d.Seconds())
+}
+
+// ObserveRPC records an RPC call e.g. for method ShoppingLists.MergeItems.
+func (m *Metrics) ObserveRPC(method string, code int32, d time.Duration) {
+	m.rpcRequests.WithLabelValues(method, strconv.Itoa(int(code))).Inc()
+	m.rpcDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+// ObserveQuery records a DB query made outside a transaction.
+func (m *Metrics) ObserveQuery(op string, d time.Duration) {
+	m.dbQueryDuration.WithLabelValues(op).Observe(d.Seconds())
+}
+
+// ObserveTx records a DB transaction that was retried retries times.
+func (m *Metrics) ObserveTx(op string, d time.Duration, retries int) {
+	m.dbTxDuration.WithLabelValues(op).Observe(d.Seconds())
+	m.dbTxRetries.WithLabelValues(op).Add(float64(retries))
+}
+
+// APIKeyInvalid records a request rejected for its API key. transport is
+// one of the Transport... values.
+func (m *Metrics) APIKeyInvalid(transport string) {
+	m.apiKeyFailures.WithLabelValues(transport).Inc()
+}
@@ -0,0 +1,51 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/shoppingms/pkg/metrics"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m, err := metrics.NewMetrics()
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	m.ObserveHTTP("/v0/shoppingms/shoppinglists/{shoppingListID}", http.MethodPut, http.StatusOK, time.Millisecond)
+	m.ObserveHTTP(metrics.RouteUnmatched, http.MethodGet, http.StatusNotFound, time.Millisecond)
+	m.ObserveRPC("ShoppingLists.MergeItems", http.StatusForbidden, time.Millisecond)
+	m.ObserveQuery("ShoppingLists", time.Millisecond)
+	m.ObserveTx("MergeItems", time.Millisecond, 2)
+	m.APIKeyInvalid(metrics.TransportRPC)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	bodyB, err := ioutil.ReadAll(w.Body)
+	if err != nil {
+		t.Fatalf("Error reading body: %v", err)
+	}
+	body := string(bodyB)
+	expLines := []string{
+		`shoppingms_http_requests_total{code="200",method="PUT",route="/v0/shoppingms/shoppinglists/{shoppingListID}"} 1`,
+		`shoppingms_http_requests_total{code="404",method="GET",route="unmatched"} 1`,
+		`shoppingms_http_request_duration_seconds_count{method="PUT",route="/v0/shoppingms/shoppinglists/{shoppingListID}"} 1`,
+		`shoppingms_rpc_requests_total{code="403",method="ShoppingLists.MergeItems"} 1`,
+		`shoppingms_db_query_duration_seconds_count{op="ShoppingLists"} 1`,
+		`shoppingms_db_transaction_duration_seconds_count{op="MergeItems"} 1`,
+		`shoppingms_db_transaction_retries_total{op="MergeItems"} 2`,
+		`shoppingms_api_key_validation_failures_total{transport="rpc"} 1`,
+	}
+	for _, l := range expLines {
+		if !strings.Contains(body, l) {
+			t.Errorf("Expected metrics to contain\n%s\ngot\n%s", l, body)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type JWTEr struct {
+	ExpValidateUsrID string
+	ExpValidateErr   error
+}
+
+func (j *JWTEr) Validate(JWT string, claims jwt.Claims) (*jwt.Token, error) {
+	if j.ExpValidateErr != nil {
+		return nil, j.ExpValidateErr
+	}
+	if clm, ok := claims.(*shopping.Claim); ok {
+		clm.UsrID = j.ExpValidateUsrID
+	}
+	return &jwt.Token{Claims: claims, Valid: true}, nil
+}
@@ -0,0 +1,118 @@
+package off
+
+import (
+	"context"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// CatalogDB persists catalog entries in batches.
+type CatalogDB interface {
+	UpsertBrands(ctx context.Context, brands []shopping.Brand) ([]shopping.Brand, error)
+}
+
+const DefaultBatchSize = 500
+
+// MaxBatchSize is the largest batch size that keeps the brand upsert within
+// the storage backends' placeholder limits. Each brand takes 4 placeholders
+// of the 65535 allowed by CockroachDB and, with one shared placeholder, of
+// the 32766 allowed by SQLite.
+const MaxBatchSize = (32766 - 1) / 4
+
+// Report summarises the progress of a Loader.
+type Report struct {
+	// Read is the number of products passed to Add.
+	Read int
+	// Loaded is the number of products persisted.
+	Loaded int
+	// Skipped is the number of products lacking a brand or name, including
+	// those whose names have no letters or digits to store.
+	Skipped int
+	// Batches is the number of batches persisted.
+	Batches int
+}
+
+// Loader converts Products into catalog entries and persists them in
+// batches.
+// Use NewLoader() to instantiate.
+type Loader struct {
+	db         CatalogDB
+	batchSize  int
+	onProgress func(Report)
+
+	pending []shopping.Brand
+	report  Report
+}
+
+// LoaderOption allows extra configuration for instantiating a Loader.
+type LoaderOption func(*Loader)
+
+// WithBatchSize sets the number of products persisted per transaction.
+func WithBatchSize(n int) LoaderOption {
+	return func(l *Loader) {
+		l.batchSize = n
+	}
+}
+
+// WithProgress sets a function to be called after every persisted batch.
+func WithProgress(fn func(Report)) LoaderOption {
+	return func(l *Loader) {
+		l.onProgress = fn
+	}
+}
+
+func NewLoader(db CatalogDB, opts ...LoaderOption) (*Loader, error) {
+	if db == nil {
+		return nil, errors.New("CatalogDB was nil")
+	}
+	l := &Loader{db: db, batchSize: DefaultBatchSize}
+	for _, f := range opts {
+		f(l)
+	}
+	if l.batchSize < 1 || l.batchSize > MaxBatchSize {
+		return nil, errors.Newf("batch size must be within 1-%d, got %d",
+			MaxBatchSize, l.batchSize)
+	}
+	l.pending = make([]shopping.Brand, 0, l.batchSize)
+	return l, nil
+}
+
+// Add queues p for loading, persisting the queue if it has reached the
+// batch size. Products that cannot be converted to catalog entries are
+// counted as skipped.
+func (l *Loader) Add(p Product) error {
+	l.report.Read++
+	b, err := p.ToBrand()
+	if err != nil {
+		l.report.Skipped++
+		return nil
+	}
+	l.pending = append(l.pending, b)
+	if len(l.pending) < l.batchSize {
+		return nil
+	}
+	return l.Flush()
+}
+
+// Flush persists all queued products.
+func (l *Loader) Flush() error {
+	if len(l.pending) == 0 {
+		return nil
+	}
+	if _, err := l.db.UpsertBrands(context.Background(), l.pending); err != nil {
+		return errors.Newf("load batch %d: %v", l.report.Batches+1, err)
+	}
+	l.report.Loaded += len(l.pending)
+	l.report.Batches++
+	l.pending = l.pending[:0]
+	if l.onProgress != nil {
+		l.onProgress(l.report)
+	}
+	return nil
+}
+
+// Report returns the progress so far.
+func (l *Loader) Report() Report {
+	return l.report
+}
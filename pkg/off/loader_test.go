@@ -0,0 +1,184 @@
+package off_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/off"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type catalogDB struct {
+	batches [][]shopping.Brand
+	err     error
+}
+
+func (db *catalogDB) UpsertBrands(_ context.Context, brands []shopping.Brand) ([]shopping.Brand, error) {
+	batch := make([]shopping.Brand, len(brands))
+	copy(batch, brands)
+	db.batches = append(db.batches, batch)
+	return batch, db.err
+}
+
+func TestProduct_ToBrand(t *testing.T) {
+	tt := []struct {
+		name     string
+		product  off.Product
+		expBrand shopping.Brand
+		expErr   bool
+	}{
+		{
+			name: "generic name",
+			product: off.Product{Code: "8718951065545", Brands: "Colgate, Colgate-Palmolive",
+				GenericName: "Toothpaste", Quantity: "100ML"},
+			expBrand: shopping.Brand{
+				Name:          "Colgate",
+				Item:          shopping.Item{Name: "Toothpaste"},
+				MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+				Barcodes:      []string{"8718951065545"},
+			},
+		},
+		{
+			name: "category fallback",
+			product: off.Product{Code: "5000112548167", Brands: "Coca-Cola",
+				Categories: []string{"en:beverages", "en:carbonated-drinks"}, Quantity: "1,5 L"},
+			expBrand: shopping.Brand{
+				Name:          "Coca-Cola",
+				Item:          shopping.Item{Name: "Carbonated drinks"},
+				MeasuringUnit: shopping.MeasuringUnit{Name: "1.5 l"},
+				Barcodes:      []string{"5000112548167"},
+			},
+		},
+		{
+			name:    "product name fallback and default unit",
+			product: off.Product{Code: "not-a-barcode", Brands: "Kabras", Name: " Kabras  Sugar "},
+			expBrand: shopping.Brand{
+				Name:          "Kabras",
+				Item:          shopping.Item{Name: "Kabras Sugar"},
+				MeasuringUnit: shopping.MeasuringUnit{Name: off.DefaultMeasuringUnit},
+			},
+		},
+		{
+			name: "unstorable names fall back",
+			product: off.Product{Code: "not-a-barcode", Brands: "Kabras", GenericName: "__",
+				Categories: []string{"en:sugars", "en:_"}, Quantity: "_"},
+			expBrand: shopping.Brand{
+				Name:          "Kabras",
+				Item:          shopping.Item{Name: "Sugars"},
+				MeasuringUnit: shopping.MeasuringUnit{Name: off.DefaultMeasuringUnit},
+			},
+		},
+		{
+			name:    "no brand",
+			product: off.Product{Code: "123456", Name: "Sugar"},
+			expErr:  true,
+		},
+		{
+			name:    "brand normalizes to nothing",
+			product: off.Product{Code: "123456", Brands: " _ , Kabras", Name: "Sugar"},
+			expErr:  true,
+		},
+		{
+			name:    "name normalizes to nothing",
+			product: off.Product{Code: "123456", Brands: "Kabras", Name: "___"},
+			expErr:  true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := tc.product.ToBrand()
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if !reflect.DeepEqual(b, tc.expBrand) {
+				t.Errorf("Brand mismatch:\nExpect:\t%+v\nGot:\t%+v", tc.expBrand, b)
+			}
+		})
+	}
+}
+
+func TestLoader(t *testing.T) {
+	csv := "code\tproduct_name\tbrands\tquantity\tcategories_tags\n" +
+		"1111111\tMilk\tBrookside\t500 ml\ten:dairies,en:milks\n" +
+		"2222222\tSugar\t\t1 kg\ten:sugars\n" +
+		"3333333\tBread\tSuperloaf\t400g\ten:breads\n" +
+		"5555555\t__\tKCC\t500ml\t\n" +
+		"4444444\tFresh Milk\tKCC\t500ml\ten:dairies,en:milks\n"
+	db := &catalogDB{}
+	var progress []off.Report
+	l, err := off.NewLoader(db, off.WithBatchSize(2), off.WithProgress(func(r off.Report) {
+		progress = append(progress, r)
+	}))
+	if err != nil {
+		t.Fatalf("Error setting up: new loader: %v", err)
+	}
+	if err := off.ReadCSV(strings.NewReader(csv), l.Add); err != nil {
+		t.Fatalf("Got error reading: %v", err)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Got error flushing: %v", err)
+	}
+	expReport := off.Report{Read: 5, Loaded: 3, Skipped: 2, Batches: 2}
+	if l.Report() != expReport {
+		t.Errorf("Report mismatch:\nExpect:\t%+v\nGot:\t%+v", expReport, l.Report())
+	}
+	if len(db.batches) != 2 || len(db.batches[0]) != 2 || len(db.batches[1]) != 1 {
+		t.Errorf("Expected batches of 2 and 1, got %+v", db.batches)
+	}
+	if len(progress) != 2 {
+		t.Errorf("Expected 2 progress reports, got %d", len(progress))
+	}
+}
+
+func TestNewLoader_batchSize(t *testing.T) {
+	for _, n := range []int{0, off.MaxBatchSize + 1} {
+		if _, err := off.NewLoader(&catalogDB{}, off.WithBatchSize(n)); err == nil {
+			t.Errorf("Expected an error for batch size %d, got nil", n)
+		}
+	}
+	if _, err := off.NewLoader(&catalogDB{}, off.WithBatchSize(off.MaxBatchSize)); err != nil {
+		t.Errorf("Got error for batch size %d: %v", off.MaxBatchSize, err)
+	}
+}
+
+func TestLoader_dbError(t *testing.T) {
+	db := &catalogDB{err: errors.New("db down")}
+	l, err := off.NewLoader(db, off.WithBatchSize(1))
+	if err != nil {
+		t.Fatalf("Error setting up: new loader: %v", err)
+	}
+	err = l.Add(off.Product{Code: "1111111", Brands: "Brookside", Name: "Milk"})
+	if err == nil {
+		t.Fatalf("Expected an error, got nil")
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	jsonl := `{"code": "1111111", "product_name": "Milk", "brands": "Brookside", "categories_tags": ["en:milks"]}
+
+{"code": "2222222", "product_name": "Bread", "brands": "Superloaf"}
+`
+	var products []off.Product
+	err := off.ReadJSONL(strings.NewReader(jsonl), func(p off.Product) error {
+		products = append(products, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(products) != 2 {
+		t.Fatalf("Expected 2 products, got %d", len(products))
+	}
+	if products[0].Categories[0] != "en:milks" || products[1].Brands != "Superloaf" {
+		t.Errorf("Unexpected products: %+v", products)
+	}
+}
@@ -0,0 +1,114 @@
+// Package off loads the shared product catalog from Open Food Facts
+// (https://world.openfoodfacts.org/data) CSV or JSONL dumps.
+package off
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// DefaultMeasuringUnit is used for products whose dump entry does not
+// specify a quantity.
+const DefaultMeasuringUnit = "unit"
+
+var (
+	quantityRgx = regexp.MustCompile(`^([0-9]+(?:[.,][0-9]+)?)\s*([[:alpha:]]+)$`)
+	barcodeRgx  = regexp.MustCompile(`^[0-9]{6,14}$`)
+)
+
+// Product holds the fields of an Open Food Facts product that are relevant
+// to the catalog.
+type Product struct {
+	Code        string
+	Name        string
+	GenericName string
+	Brands      string
+	Quantity    string
+	// Categories are ordered from least to most specific, optionally with
+	// a language prefix e.g. "en:toothpastes".
+	Categories []string
+}
+
+// ToBrand converts p into a catalog Brand with its Item, MeasuringUnit
+// and barcode. It returns a client error if p lacks the data needed to
+// do so.
+func (p Product) ToBrand() (shopping.Brand, error) {
+	brandName := firstListed(p.Brands)
+	if brandName == "" {
+		return shopping.Brand{}, errors.NewClientf("product %s has no brand", p.Code)
+	}
+	itemName := p.itemName()
+	if itemName == "" {
+		return shopping.Brand{}, errors.NewClientf("product %s has no name or category", p.Code)
+	}
+	b := shopping.Brand{
+		Name:          brandName,
+		Item:          shopping.Item{Name: itemName},
+		MeasuringUnit: shopping.MeasuringUnit{Name: normalizeQuantity(p.Quantity)},
+	}
+	if code := strings.TrimSpace(p.Code); barcodeRgx.MatchString(code) {
+		b.Barcodes = []string{code}
+	}
+	return b, nil
+}
+
+// itemName returns the most generic description of p available, falling
+// back from the generic name, to the most specific category, to the
+// product name.
+func (p Product) itemName() string {
+	if name := storableName(p.GenericName); name != "" {
+		return name
+	}
+	for i := len(p.Categories) - 1; i >= 0; i-- {
+		if name := categoryName(p.Categories[i]); name != "" {
+			return name
+		}
+	}
+	return storableName(p.Name)
+}
+
+// categoryName converts a category tag e.g. "en:plant-based-foods" to a
+// display name e.g. "Plant based foods".
+func categoryName(cat string) string {
+	if i := strings.Index(cat, ":"); i >= 0 {
+		cat = cat[i+1:]
+	}
+	cat = storableName(strings.Replace(cat, "-", " ", -1))
+	if cat == "" {
+		return ""
+	}
+	runes := []rune(cat)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// normalizeQuantity converts free text quantities e.g. "250ML" or
+// " 1,5  kg" into a consistent measuring unit name e.g. "250 ml", "1.5 kg".
+func normalizeQuantity(q string) string {
+	q = storableName(q)
+	if q == "" {
+		return DefaultMeasuringUnit
+	}
+	m := quantityRgx.FindStringSubmatch(q)
+	if m == nil {
+		return q
+	}
+	return strings.Replace(m[1], ",", ".", 1) + " " + strings.ToLower(m[2])
+}
+
+func firstListed(list string) string {
+	return storableName(strings.SplitN(list, ",", 2)[0])
+}
+
+// storableName returns the cleaned up name, or "" if it normalizes to ""
+// e.g. "__" since storage rejects such names and with them the whole batch.
+func storableName(name string) string {
+	if shopping.NormalizeName(name) == "" {
+		return ""
+	}
+	return shopping.CleanName(name)
+}
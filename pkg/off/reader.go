@@ -0,0 +1,114 @@
+package off
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// maxJSONLineSize is the longest JSONL product entry ReadJSONL accepts.
+// Open Food Facts entries with many images and ingredients run into
+// hundreds of kilobytes.
+const maxJSONLineSize = 16 * 1024 * 1024
+
+// ReadCSV streams products from the tab separated Open Food Facts CSV
+// export in r, calling fn with each. Reading stops at the first error
+// returned by fn.
+func ReadCSV(r io.Reader, fn func(Product) error) error {
+	cr := csv.NewReader(r)
+	cr.Comma = '\t'
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return errors.Newf("read header: %v", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[name] = i
+	}
+	if _, ok := cols["code"]; !ok {
+		return errors.New("header has no \"code\" column, is this a" +
+			" tab separated Open Food Facts export?")
+	}
+
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Newf("read line %d: %v", line, err)
+		}
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(rec) {
+				return rec[i]
+			}
+			return ""
+		}
+		cats := field("categories_tags")
+		if cats == "" {
+			cats = field("categories_en")
+		}
+		p := Product{
+			Code:        field("code"),
+			Name:        field("product_name"),
+			GenericName: field("generic_name"),
+			Brands:      field("brands"),
+			Quantity:    field("quantity"),
+		}
+		if cats != "" {
+			p.Categories = strings.Split(cats, ",")
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+}
+
+type jsonProduct struct {
+	Code           string   `json:"code"`
+	ProductName    string   `json:"product_name"`
+	GenericName    string   `json:"generic_name"`
+	Brands         string   `json:"brands"`
+	Quantity       string   `json:"quantity"`
+	CategoriesTags []string `json:"categories_tags"`
+}
+
+// ReadJSONL streams products from the Open Food Facts JSONL export in r
+// (one product JSON object per line), calling fn with each. Reading stops
+// at the first error returned by fn.
+func ReadJSONL(r io.Reader, fn func(Product) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxJSONLineSize)
+	for line := 1; sc.Scan(); line++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var jp jsonProduct
+		if err := json.Unmarshal(sc.Bytes(), &jp); err != nil {
+			return errors.Newf("decode line %d: %v", line, err)
+		}
+		err := fn(Product{
+			Code:        jp.Code,
+			Name:        jp.ProductName,
+			GenericName: jp.GenericName,
+			Brands:      jp.Brands,
+			Quantity:    jp.Quantity,
+			Categories:  jp.CategoriesTags,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return errors.Newf("read JSONL: %v", err)
+	}
+	return nil
+}
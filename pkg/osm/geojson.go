@@ -0,0 +1,233 @@
+package osm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type feature struct {
+	ID         interface{}            `json:"id"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   *struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+// ReadGeoJSON streams the features of a GeoJSON FeatureCollection in r
+// (e.g. as produced by osmium export or osmtogeojson) and calls fn with
+// every feature that keep accepts. Polygons are reduced to the centroid
+// of their outer ring. Features with a missing, empty or unsupported
+// geometry are passed to skip. Place IDs have the same form as those read
+// by ReadPBFFile e.g. "node/123". Reading stops at the first error returned
+// by fn.
+func ReadGeoJSON(r io.Reader, keep Filter, fn func(Place) error, skip SkipFunc) error {
+	dec := json.NewDecoder(r)
+	if err := seekFeatures(dec); err != nil {
+		return err
+	}
+	for dec.More() {
+		var f feature
+		if err := dec.Decode(&f); err != nil {
+			return errors.Newf("decode feature: %v", err)
+		}
+		tags := f.tags()
+		if !keep(tags) {
+			continue
+		}
+		loc, err := f.centroid()
+		if err != nil {
+			skip(f.osmID(), err)
+			continue
+		}
+		if err := fn(Place{ID: f.osmID(), Tags: tags, Location: loc}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seekFeatures advances dec to the first element of the "features" array.
+func seekFeatures(dec *json.Decoder) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tkn, err := dec.Token()
+		if err != nil {
+			return errors.Newf("read key: %v", err)
+		}
+		if key, _ := tkn.(string); key == "features" {
+			return expectDelim(dec, '[')
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return errors.Newf("skip value of %v: %v", tkn, err)
+		}
+	}
+	return errors.New("\"features\" not found, expected a FeatureCollection")
+}
+
+func expectDelim(dec *json.Decoder, d json.Delim) error {
+	tkn, err := dec.Token()
+	if err != nil {
+		return errors.Newf("read token: %v", err)
+	}
+	if tkn != d {
+		return errors.Newf("expected '%s' but found '%v'", d, tkn)
+	}
+	return nil
+}
+
+func (f feature) tags() map[string]string {
+	tags := make(map[string]string)
+	for k, v := range f.Properties {
+		switch val := v.(type) {
+		case string:
+			tags[k] = val
+		case map[string]interface{}:
+			// osmtogeojson nests OSM tags under "tags".
+			if k != "tags" {
+				continue
+			}
+			for tk, tv := range val {
+				if s, ok := tv.(string); ok {
+					tags[tk] = s
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// osmID returns the ID of the OSM element f was exported from, either
+// from the "@type" and "@id" properties (osmtogeojson) or from the feature
+// ID e.g. "node/123" (osmtogeojson) or "n123" (osmium export). It is empty
+// if the element type is not known.
+func (f feature) osmID() string {
+	if id, ok := f.Properties["@id"]; ok {
+		typ, _ := f.Properties["@type"].(string)
+		return elementID(typ, id)
+	}
+	return elementID("", f.ID)
+}
+
+// elementID formats the OSM element with typ and ID as "type/ID". typ is
+// read from ID if it is empty.
+func elementID(typ string, ID interface{}) string {
+	var ref string
+	switch v := ID.(type) {
+	case float64:
+		ref = fmt.Sprintf("%.0f", v)
+	case string:
+		ref = v
+	default:
+		return ""
+	}
+	if i := strings.IndexByte(ref, '/'); i >= 0 {
+		typ, ref = ref[:i], ref[i+1:]
+	} else if typ == "" && ref != "" {
+		typ, ref = osmiumElement(ref[:1], ref[1:])
+	}
+	switch typ {
+	case "node", "way", "relation":
+	default:
+		return ""
+	}
+	if _, err := strconv.ParseUint(ref, 10, 64); err != nil {
+		return ""
+	}
+	return typ + "/" + ref
+}
+
+// osmiumElement returns the type and ID of the OSM element that the
+// osmium export feature with prefix and ID was built from. Areas are built
+// from ways, with even area IDs (2*ID), or relations, with odd ones
+// (2*ID+1).
+func osmiumElement(prefix, ID string) (string, string) {
+	switch prefix {
+	case "n":
+		return "node", ID
+	case "w":
+		return "way", ID
+	case "r":
+		return "relation", ID
+	case "a":
+		n, err := strconv.ParseUint(ID, 10, 64)
+		if err != nil {
+			return "", ""
+		}
+		if n%2 == 0 {
+			return "way", strconv.FormatUint(n/2, 10)
+		}
+		return "relation", strconv.FormatUint(n/2, 10)
+	}
+	return "", ""
+}
+
+func (f feature) centroid() (shopping.Location, error) {
+	if f.Geometry == nil {
+		return shopping.Location{}, errors.New("missing geometry")
+	}
+	var ring [][]float64
+	var err error
+	switch f.Geometry.Type {
+	case "Point":
+		var pt []float64
+		err = json.Unmarshal(f.Geometry.Coordinates, &pt)
+		ring = [][]float64{pt}
+	case "LineString", "MultiPoint":
+		err = json.Unmarshal(f.Geometry.Coordinates, &ring)
+	case "Polygon":
+		var poly [][][]float64
+		if err = json.Unmarshal(f.Geometry.Coordinates, &poly); err == nil && len(poly) > 0 {
+			ring = openRing(poly[0])
+		}
+	case "MultiPolygon":
+		var mPoly [][][][]float64
+		if err = json.Unmarshal(f.Geometry.Coordinates, &mPoly); err == nil &&
+			len(mPoly) > 0 && len(mPoly[0]) > 0 {
+			ring = openRing(mPoly[0][0])
+		}
+	default:
+		return shopping.Location{}, errors.Newf("unsupported geometry type '%s'",
+			f.Geometry.Type)
+	}
+	if err != nil {
+		return shopping.Location{}, errors.Newf("decode %s coordinates: %v",
+			f.Geometry.Type, err)
+	}
+	return meanLocation(ring)
+}
+
+// openRing drops the closing position of a linear ring so that the first
+// position is not weighted twice.
+func openRing(ring [][]float64) [][]float64 {
+	if len(ring) > 1 {
+		return ring[:len(ring)-1]
+	}
+	return ring
+}
+
+func meanLocation(positions [][]float64) (shopping.Location, error) {
+	if len(positions) == 0 {
+		return shopping.Location{}, errors.New("empty coordinates")
+	}
+	var loc shopping.Location
+	for _, pos := range positions {
+		if len(pos) < 2 {
+			return shopping.Location{}, errors.Newf("invalid position %v", pos)
+		}
+		loc.Longitude += pos[0]
+		loc.Latitude += pos[1]
+	}
+	loc.Longitude /= float64(len(positions))
+	loc.Latitude /= float64(len(positions))
+	return loc, nil
+}
@@ -0,0 +1,179 @@
+package osm
+
+import (
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// ImportDB persists the stores and store branches read by an Importer.
+type ImportDB interface {
+	IsNotFoundError(error) bool
+	UpsertStore(name string) (*shopping.Store, error)
+	InsertStoreBranch(sb shopping.StoreBranch) (*shopping.StoreBranch, error)
+	UpdateStoreBranch(sb shopping.StoreBranch) error
+	StoreBranchByOSMID(osmID string) (*shopping.StoreBranch, error)
+	StoreBranchesWithin(storeID string, sw, ne shopping.Location) ([]shopping.StoreBranch, error)
+}
+
+// Action describes what an Importer did with a Place.
+type Action string
+
+const (
+	ActionCreated   = Action("created")
+	ActionUpdated   = Action("updated")
+	ActionUnchanged = Action("unchanged")
+
+	// DefaultMatchRadius is the distance in meters within which an existing
+	// store branch with the same name is considered to be the same branch.
+	DefaultMatchRadius = 150
+
+	// locationTolerance is the distance in meters below which a location
+	// change is ignored as noise.
+	locationTolerance = 1
+)
+
+// Report summarises the outcome of an import.
+type Report struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	// Skipped counts the places that could not be read or imported.
+	Skipped int
+}
+
+// Add records the outcome of importing a single Place.
+func (r *Report) Add(a Action) {
+	switch a {
+	case ActionCreated:
+		r.Created++
+	case ActionUpdated:
+		r.Updated++
+	case ActionUnchanged:
+		r.Unchanged++
+	}
+}
+
+// Total returns the number of places imported, Skipped is not included.
+func (r Report) Total() int {
+	return r.Created + r.Updated + r.Unchanged
+}
+
+// Importer creates or updates stores and store branches from Places.
+// Importing the same extract more than once is safe: branches are matched
+// first by their OSM ID, then by name and distance, before a new branch is
+// created.
+// Use NewImporter() to instantiate.
+type Importer struct {
+	db          ImportDB
+	matchRadius float64
+}
+
+// ImporterOption allows extra configuration for instantiating an Importer.
+type ImporterOption func(*Importer)
+
+// WithMatchRadius sets the distance in meters within which an existing
+// branch with the same name is treated as a duplicate.
+func WithMatchRadius(meters float64) ImporterOption {
+	return func(im *Importer) {
+		im.matchRadius = meters
+	}
+}
+
+func NewImporter(db ImportDB, opts ...ImporterOption) (*Importer, error) {
+	if db == nil {
+		return nil, errors.New("ImportDB was nil")
+	}
+	im := &Importer{db: db, matchRadius: DefaultMatchRadius}
+	for _, f := range opts {
+		f(im)
+	}
+	if im.matchRadius < 0 {
+		return nil, errors.Newf("match radius must be >= 0, got %f", im.matchRadius)
+	}
+	return im, nil
+}
+
+// Import creates or updates the store and store branch represented by p.
+func (im *Importer) Import(p Place) (Action, error) {
+
+	storeName := p.StoreName()
+	if storeName == "" {
+		return "", errors.Newf("%s has no name or brand", p.ID)
+	}
+
+	store, err := im.db.UpsertStore(storeName)
+	if err != nil {
+		return "", errors.Newf("upsert store: %v", err)
+	}
+
+	sb := shopping.StoreBranch{
+		Name:     p.BranchName(),
+		Store:    *store,
+		Location: p.Location,
+		OSMID:    p.ID,
+	}
+
+	existing, err := im.findExisting(sb)
+	if err != nil {
+		return "", err
+	}
+
+	if existing == nil {
+		if _, err := im.db.InsertStoreBranch(sb); err != nil {
+			return "", errors.Newf("insert store branch: %v", err)
+		}
+		return ActionCreated, nil
+	}
+
+	if existing.Name == sb.Name && existing.OSMID == sb.OSMID &&
+		shopping.Distance(existing.Location, sb.Location) < locationTolerance {
+		return ActionUnchanged, nil
+	}
+
+	sb.ID = existing.ID
+	if err := im.db.UpdateStoreBranch(sb); err != nil {
+		return "", errors.Newf("update store branch: %v", err)
+	}
+	return ActionUpdated, nil
+}
+
+// findExisting returns the stored branch that sb duplicates or nil if none.
+func (im *Importer) findExisting(sb shopping.StoreBranch) (*shopping.StoreBranch, error) {
+
+	if sb.OSMID != "" {
+		existing, err := im.db.StoreBranchByOSMID(sb.OSMID)
+		if err == nil {
+			return existing, nil
+		}
+		if !im.db.IsNotFoundError(err) {
+			return nil, errors.Newf("store branch by OSM ID: %v", err)
+		}
+	}
+
+	sw, ne := shopping.BoundingBox(sb.Location, im.matchRadius)
+	candidates, err := im.db.StoreBranchesWithin(sb.Store.ID, sw, ne)
+	if err != nil {
+		if im.db.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Newf("store branches within radius: %v", err)
+	}
+
+	name := shopping.NormalizeName(sb.Name)
+	var nearest *shopping.StoreBranch
+	nearestDist := im.matchRadius
+	for i, c := range candidates {
+		// A branch imported from a different OSM element is a distinct branch.
+		if c.OSMID != "" && c.OSMID != sb.OSMID {
+			continue
+		}
+		if shopping.NormalizeName(c.Name) != name {
+			continue
+		}
+		if d := shopping.Distance(c.Location, sb.Location); d <= nearestDist {
+			nearest = &candidates[i]
+			nearestDist = d
+		}
+	}
+	return nearest, nil
+}
@@ -0,0 +1,264 @@
+package osm_test
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/osm"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type storeDB struct {
+	errors.NotFoundErrCheck
+	stores   map[string]*shopping.Store
+	branches []shopping.StoreBranch
+}
+
+func newStoreDB() *storeDB {
+	return &storeDB{stores: make(map[string]*shopping.Store)}
+}
+
+func (db *storeDB) UpsertStore(name string) (*shopping.Store, error) {
+	if s, ok := db.stores[name]; ok {
+		return s, nil
+	}
+	s := &shopping.Store{ID: strconv.Itoa(len(db.stores) + 1), Name: name}
+	db.stores[name] = s
+	return s, nil
+}
+
+func (db *storeDB) InsertStoreBranch(sb shopping.StoreBranch) (*shopping.StoreBranch, error) {
+	sb.ID = strconv.Itoa(len(db.branches) + 1)
+	db.branches = append(db.branches, sb)
+	return &sb, nil
+}
+
+func (db *storeDB) UpdateStoreBranch(sb shopping.StoreBranch) error {
+	for i, b := range db.branches {
+		if b.ID == sb.ID {
+			db.branches[i] = sb
+			return nil
+		}
+	}
+	return errors.NewNotFound("store branch not found")
+}
+
+func (db *storeDB) StoreBranchByOSMID(osmID string) (*shopping.StoreBranch, error) {
+	for _, b := range db.branches {
+		if b.OSMID == osmID {
+			return &b, nil
+		}
+	}
+	return nil, errors.NewNotFound("store branch not found")
+}
+
+func (db *storeDB) StoreBranchesWithin(storeID string, sw, ne shopping.Location) ([]shopping.StoreBranch, error) {
+	var sbs []shopping.StoreBranch
+	for _, b := range db.branches {
+		if b.Store.ID == storeID &&
+			b.Location.Latitude >= sw.Latitude && b.Location.Latitude <= ne.Latitude &&
+			b.Location.Longitude >= sw.Longitude && b.Location.Longitude <= ne.Longitude {
+			sbs = append(sbs, b)
+		}
+	}
+	if len(sbs) == 0 {
+		return nil, errors.NewNotFound("no store branches found")
+	}
+	return sbs, nil
+}
+
+func TestImporter_Import(t *testing.T) {
+	westlands := shopping.Location{Latitude: -1.2636, Longitude: 36.8035}
+	nearWestlands := shopping.Location{Latitude: -1.2640, Longitude: 36.8038}
+	farFromWestlands := shopping.Location{Latitude: -1.3000, Longitude: 36.8500}
+	tags := map[string]string{"shop": "supermarket", "name": "Naivas Westlands", "brand": "Naivas"}
+	tt := []struct {
+		name      string
+		existing  []shopping.StoreBranch
+		place     osm.Place
+		expAction osm.Action
+		expBranch int
+	}{
+		{
+			name:      "new branch",
+			place:     osm.Place{ID: "node/1", Tags: tags, Location: westlands},
+			expAction: osm.ActionCreated,
+			expBranch: 1,
+		},
+		{
+			name: "re-import unchanged",
+			existing: []shopping.StoreBranch{
+				{Name: "Naivas Westlands", OSMID: "node/1", Location: westlands},
+			},
+			place:     osm.Place{ID: "node/1", Tags: tags, Location: westlands},
+			expAction: osm.ActionUnchanged,
+			expBranch: 1,
+		},
+		{
+			name: "re-import moved",
+			existing: []shopping.StoreBranch{
+				{Name: "Naivas Westlands", OSMID: "node/1", Location: westlands},
+			},
+			place:     osm.Place{ID: "node/1", Tags: tags, Location: nearWestlands},
+			expAction: osm.ActionUpdated,
+			expBranch: 1,
+		},
+		{
+			name: "manually entered branch nearby",
+			existing: []shopping.StoreBranch{
+				{Name: " naivas  westlands", Location: nearWestlands},
+			},
+			place:     osm.Place{ID: "node/1", Tags: tags, Location: westlands},
+			expAction: osm.ActionUpdated,
+			expBranch: 1,
+		},
+		{
+			name: "same name far away",
+			existing: []shopping.StoreBranch{
+				{Name: "Naivas Westlands", Location: farFromWestlands},
+			},
+			place:     osm.Place{ID: "node/1", Tags: tags, Location: westlands},
+			expAction: osm.ActionCreated,
+			expBranch: 2,
+		},
+		{
+			name: "nearby branch from other OSM element",
+			existing: []shopping.StoreBranch{
+				{Name: "Naivas Westlands", OSMID: "way/9", Location: nearWestlands},
+			},
+			place:     osm.Place{ID: "node/1", Tags: tags, Location: westlands},
+			expAction: osm.ActionCreated,
+			expBranch: 2,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newStoreDB()
+			for _, sb := range tc.existing {
+				store, _ := db.UpsertStore("Naivas")
+				sb.Store = *store
+				db.InsertStoreBranch(sb)
+			}
+			im, err := osm.NewImporter(db)
+			if err != nil {
+				t.Fatalf("Error setting up: new importer: %v", err)
+			}
+			action, err := im.Import(tc.place)
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if action != tc.expAction {
+				t.Errorf("Expected action %s, got %s", tc.expAction, action)
+			}
+			if len(db.branches) != tc.expBranch {
+				t.Errorf("Expected %d branches, got %d", tc.expBranch, len(db.branches))
+			}
+			if len(db.stores) != 1 {
+				t.Errorf("Expected 1 store, got %d", len(db.stores))
+			}
+		})
+	}
+}
+
+func TestReadGeoJSON(t *testing.T) {
+	geoJSON := `{
+		"type": "FeatureCollection",
+		"generator": "osmtogeojson",
+		"features": [
+			{
+				"type": "Feature",
+				"id": "node/1",
+				"properties": {"shop": "supermarket", "name": "Carrefour Junction"},
+				"geometry": {"type": "Point", "coordinates": [36.76, -1.29]}
+			},
+			{
+				"type": "Feature",
+				"properties": {"@type": "way", "@id": 2, "shop": "convenience", "name": "Kiosk"},
+				"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [2, 0], [2, 2], [0, 2], [0, 0]]]}
+			},
+			{
+				"type": "Feature",
+				"id": "node/3",
+				"properties": {"amenity": "bank", "name": "Bank"},
+				"geometry": {"type": "Point", "coordinates": [36.7, -1.2]}
+			},
+			{
+				"type": "Feature",
+				"id": "n4",
+				"properties": {"shop": "grocery", "name": "Mama Mboga"},
+				"geometry": {"type": "Point", "coordinates": [1, 1]}
+			},
+			{
+				"type": "Feature",
+				"id": "a10",
+				"properties": {"shop": "supermarket", "name": "Naivas"},
+				"geometry": {"type": "MultiPolygon", "coordinates": [[[[0, 0], [2, 0], [2, 2], [0, 0]]]]}
+			},
+			{
+				"type": "Feature",
+				"id": 6,
+				"properties": {"shop": "general", "name": "Duka"},
+				"geometry": {"type": "Point", "coordinates": [1, 1]}
+			},
+			{
+				"type": "Feature",
+				"id": "node/7",
+				"properties": {"shop": "supermarket", "name": "No Geometry"},
+				"geometry": null
+			},
+			{
+				"type": "Feature",
+				"id": "w8",
+				"properties": {"shop": "supermarket", "name": "Collection"},
+				"geometry": {"type": "GeometryCollection", "geometries": []}
+			},
+			{
+				"type": "Feature",
+				"id": "r9",
+				"properties": {"shop": "supermarket", "name": "Empty"},
+				"geometry": {"type": "Point", "coordinates": []}
+			}
+		]
+	}`
+	var places []osm.Place
+	var skipped []string
+	err := osm.ReadGeoJSON(strings.NewReader(geoJSON), osm.ShopFilter(), func(p osm.Place) error {
+		places = append(places, p)
+		return nil
+	}, func(ID string, reason error) {
+		if reason == nil {
+			t.Errorf("%s skipped with a nil reason", ID)
+		}
+		skipped = append(skipped, ID)
+	})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(places) != 5 {
+		t.Fatalf("Expected 5 places, got %d: %+v", len(places), places)
+	}
+	if places[0].ID != "node/1" || places[0].Location.Latitude != -1.29 {
+		t.Errorf("Unexpected point place: %+v", places[0])
+	}
+	if places[1].ID != "way/2" || places[1].Location.Latitude != 1 || places[1].Location.Longitude != 1 {
+		t.Errorf("Unexpected polygon place: %+v", places[1])
+	}
+	// osmium export IDs, an area ID is twice that of the way it was built from.
+	if places[2].ID != "node/4" {
+		t.Errorf("Expected osmium node ID node/4, got %+v", places[2])
+	}
+	if places[3].ID != "way/5" {
+		t.Errorf("Expected osmium area ID way/5, got %+v", places[3])
+	}
+	// The element type of a bare number is not known.
+	if places[4].ID != "" {
+		t.Errorf("Expected an empty ID, got %+v", places[4])
+	}
+	expSkipped := []string{"node/7", "way/8", "relation/9"}
+	if !reflect.DeepEqual(skipped, expSkipped) {
+		t.Errorf("Expected skipped %v, got %v", expSkipped, skipped)
+	}
+}
@@ -0,0 +1,130 @@
+package osm
+
+import (
+	"context"
+	"os"
+	"runtime"
+
+	osmLib "github.com/paulmach/osm"
+	"github.com/paulmach/osm/osmpbf"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type pendingWay struct {
+	id      osmLib.WayID
+	tags    map[string]string
+	nodeIDs []osmLib.NodeID
+}
+
+// ReadPBFFile reads the OSM PBF extract at fName and calls fn with every
+// node or way that keep accepts. Ways are reduced to the centroid of their
+// nodes, which requires a second pass over the file to resolve node
+// coordinates. Nodes missing from the extract (e.g. outside the area it was
+// clipped to) are left out of the centroid and ways with none of their
+// nodes in the extract are passed to skip. Place IDs have the same form as
+// those read by ReadGeoJSON e.g. "node/123". Relations are ignored. Reading
+// stops at the first error returned by fn.
+func ReadPBFFile(fName string, keep Filter, fn func(Place) error, skip SkipFunc) error {
+
+	var ways []pendingWay
+	// wayNodes holds nil until the location of the node is read.
+	wayNodes := make(map[osmLib.NodeID]*shopping.Location)
+
+	err := scanPBF(fName, false, func(o osmLib.Object) error {
+		switch el := o.(type) {
+		case *osmLib.Node:
+			tags := el.Tags.Map()
+			if !keep(tags) {
+				return nil
+			}
+			return fn(Place{
+				ID:       el.FeatureID().String(),
+				Tags:     tags,
+				Location: shopping.Location{Latitude: el.Lat, Longitude: el.Lon},
+			})
+		case *osmLib.Way:
+			tags := el.Tags.Map()
+			if !keep(tags) {
+				return nil
+			}
+			w := pendingWay{id: el.ID, tags: tags}
+			for _, wn := range el.Nodes {
+				w.nodeIDs = append(w.nodeIDs, wn.ID)
+				wayNodes[wn.ID] = nil
+			}
+			ways = append(ways, w)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(ways) == 0 {
+		return nil
+	}
+
+	err = scanPBF(fName, true, func(o osmLib.Object) error {
+		if n, ok := o.(*osmLib.Node); ok {
+			if _, wanted := wayNodes[n.ID]; wanted {
+				wayNodes[n.ID] = &shopping.Location{Latitude: n.Lat, Longitude: n.Lon}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, w := range ways {
+		nodeIDs := w.nodeIDs
+		if len(nodeIDs) > 1 && nodeIDs[0] == nodeIDs[len(nodeIDs)-1] {
+			nodeIDs = nodeIDs[:len(nodeIDs)-1]
+		}
+		positions := make([][]float64, 0, len(nodeIDs))
+		for _, nID := range nodeIDs {
+			if loc := wayNodes[nID]; loc != nil {
+				positions = append(positions, []float64{loc.Longitude, loc.Latitude})
+			}
+		}
+		ID := w.id.FeatureID().String()
+		if len(positions) == 0 {
+			skip(ID, errors.New("none of its nodes are in the extract"))
+			continue
+		}
+		loc, err := meanLocation(positions)
+		if err != nil {
+			skip(ID, err)
+			continue
+		}
+		err = fn(Place{ID: ID, Tags: w.tags, Location: loc})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanPBF calls fn with every node and way in the PBF file fName.
+func scanPBF(fName string, nodesOnly bool, fn func(o osmLib.Object) error) error {
+	f, err := os.Open(fName)
+	if err != nil {
+		return errors.Newf("open PBF file: %v", err)
+	}
+	defer f.Close()
+
+	scanner := osmpbf.New(context.Background(), f, runtime.GOMAXPROCS(-1))
+	defer scanner.Close()
+	scanner.SkipWays = nodesOnly
+	scanner.SkipRelations = true
+
+	for scanner.Scan() {
+		if err := fn(scanner.Object()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Newf("scan PBF: %v", err)
+	}
+	return nil
+}
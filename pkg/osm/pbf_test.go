@@ -0,0 +1,149 @@
+package osm_test
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/osm"
+)
+
+func TestReadPBFFile(t *testing.T) {
+	strs := []string{"", "shop", "supermarket", "name", "Carrefour Junction",
+		"convenience", "Kiosk"}
+	type node struct {
+		id, version int64
+		lat, lon    float64
+		keyVals     []int64
+	}
+	nodes := []node{
+		{id: 1, version: 5, lat: -1.29, lon: 36.76, keyVals: []int64{1, 2, 3, 4}},
+		{id: 10, version: 1, lat: 0, lon: 0},
+		{id: 11, version: 1, lat: 0, lon: 2},
+		{id: 12, version: 1, lat: 2, lon: 2},
+		{id: 13, version: 1, lat: 2, lon: 0},
+	}
+	// Node 99 is not in the extract e.g. because it was clipped out.
+	wayRefs := []int64{10, 11, 12, 13, 99, 10}
+
+	var ids, versions, lats, lons, keyVals []uint64
+	var prevID, prevLat, prevLon int64
+	for _, n := range nodes {
+		lat, lon := int64(math.Round(n.lat*1e7)), int64(math.Round(n.lon*1e7))
+		ids = append(ids, zigzag(n.id-prevID))
+		versions = append(versions, uint64(n.version))
+		lats = append(lats, zigzag(lat-prevLat))
+		lons = append(lons, zigzag(lon-prevLon))
+		for _, kv := range n.keyVals {
+			keyVals = append(keyVals, uint64(kv))
+		}
+		keyVals = append(keyVals, 0)
+		prevID, prevLat, prevLon = n.id, lat, lon
+	}
+	dense := pbPacked(nil, 1, ids)
+	dense = pbBytes(dense, 5, pbPacked(nil, 1, versions))
+	dense = pbPacked(dense, 8, lats)
+	dense = pbPacked(dense, 9, lons)
+	dense = pbPacked(dense, 10, keyVals)
+
+	var refs []uint64
+	var prevRef int64
+	for _, ref := range wayRefs {
+		refs = append(refs, zigzag(ref-prevRef))
+		prevRef = ref
+	}
+	way := pbVarint(nil, 1, 2)
+	way = pbPacked(way, 2, []uint64{1, 3})
+	way = pbPacked(way, 3, []uint64{5, 6})
+	way = pbBytes(way, 4, pbVarint(nil, 1, 3)) // version
+	way = pbPacked(way, 8, refs)
+
+	var strTable []byte
+	for _, s := range strs {
+		strTable = pbBytes(strTable, 1, []byte(s))
+	}
+	block := pbBytes(nil, 1, strTable)
+	block = pbBytes(block, 2, pbBytes(nil, 2, dense))
+	block = pbBytes(block, 2, pbBytes(nil, 3, way))
+
+	fName := writePBF(t, block)
+
+	var places []osm.Place
+	err := osm.ReadPBFFile(fName, osm.ShopFilter(), func(p osm.Place) error {
+		places = append(places, p)
+		return nil
+	}, func(ID string, reason error) {
+		t.Errorf("Unexpected skip of %s: %v", ID, reason)
+	})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(places) != 2 {
+		t.Fatalf("Expected 2 places, got %d: %+v", len(places), places)
+	}
+	// IDs must match those read by ReadGeoJSON (see TestReadGeoJSON) for
+	// re-imports to find the branches imported before.
+	if p := places[0]; p.ID != "node/1" || !near(p.Location.Latitude, -1.29) ||
+		!near(p.Location.Longitude, 36.76) || p.Tags["name"] != "Carrefour Junction" {
+		t.Errorf("Unexpected node place: %+v", p)
+	}
+	if p := places[1]; p.ID != "way/2" || !near(p.Location.Latitude, 1) ||
+		!near(p.Location.Longitude, 1) || p.Tags["name"] != "Kiosk" {
+		t.Errorf("Unexpected way place: %+v", p)
+	}
+}
+
+// writePBF writes a PBF file containing the single OSMData block and
+// returns its name.
+func writePBF(t *testing.T, block []byte) string {
+	blob := pbBytes(nil, 1, block)
+	blob = pbVarint(blob, 2, uint64(len(block)))
+	header := pbBytes(nil, 1, []byte("OSMData"))
+	header = pbVarint(header, 3, uint64(len(blob)))
+
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, uint32(len(header)))
+	data = append(data, header...)
+	data = append(data, blob...)
+
+	dir, err := ioutil.TempDir("", "shoppingms-osm")
+	if err != nil {
+		t.Fatalf("Error setting up: temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	fName := filepath.Join(dir, "extract.osm.pbf")
+	if err := ioutil.WriteFile(fName, data, 0600); err != nil {
+		t.Fatalf("Error setting up: write PBF: %v", err)
+	}
+	return fName
+}
+
+func pbVarint(b []byte, field int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3)
+	return binary.AppendUvarint(b, v)
+}
+
+func pbBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func pbPacked(b []byte, field int, vs []uint64) []byte {
+	var packed []byte
+	for _, v := range vs {
+		packed = binary.AppendUvarint(packed, v)
+	}
+	return pbBytes(b, field, packed)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
@@ -0,0 +1,70 @@
+// Package osm reads shop locations from OpenStreetMap extracts and imports
+// them as shopping.Store and shopping.StoreBranch records.
+package osm
+
+import (
+	"strings"
+
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+// Place is a shop extracted from an OpenStreetMap extract.
+type Place struct {
+	// ID is the OSM element reference e.g. "node/123" or "way/456".
+	ID       string
+	Tags     map[string]string
+	Location shopping.Location
+}
+
+// Filter reports whether the element with tags should be read.
+type Filter func(tags map[string]string) bool
+
+// SkipFunc is called with the ID of an element that a Filter kept but that
+// could not be read as a Place e.g. because it has no usable geometry, and
+// the reason why. ID is empty if the element type is not known.
+type SkipFunc func(ID string, reason error)
+
+// DefaultShopTypes are the values of the OSM "shop" tag that are considered
+// places where groceries and household items can be bought.
+var DefaultShopTypes = []string{
+	"supermarket",
+	"convenience",
+	"grocery",
+	"greengrocer",
+	"wholesale",
+	"department_store",
+	"general",
+}
+
+// ShopFilter returns a Filter that keeps named elements whose "shop" tag
+// is one of shopTypes. DefaultShopTypes is used if shopTypes is empty.
+func ShopFilter(shopTypes ...string) Filter {
+	if len(shopTypes) == 0 {
+		shopTypes = DefaultShopTypes
+	}
+	allowed := make(map[string]bool, len(shopTypes))
+	for _, st := range shopTypes {
+		allowed[strings.TrimSpace(st)] = true
+	}
+	return func(tags map[string]string) bool {
+		return allowed[tags["shop"]] && (tags["name"] != "" || tags["brand"] != "")
+	}
+}
+
+// StoreName returns the name of the store (chain) the place belongs to.
+// The brand tag is preferred since branch names often carry a location
+// suffix e.g. brand=Naivas, name=Naivas Westlands.
+func (p Place) StoreName() string {
+	if brand := shopping.CleanName(p.Tags["brand"]); brand != "" {
+		return brand
+	}
+	return shopping.CleanName(p.Tags["name"])
+}
+
+// BranchName returns the name of the store branch represented by the place.
+func (p Place) BranchName() string {
+	if name := shopping.CleanName(p.Tags["name"]); name != "" {
+		return name
+	}
+	return p.StoreName()
+}
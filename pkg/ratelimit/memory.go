@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Memory drops buckets that have refilled.
+const sweepInterval = time.Minute
+
+// Memory is a Store that keeps token buckets in memory, limits therefore
+// only hold per instance of the service.
+// Use NewMemory() to instantiate.
+type Memory struct {
+	mutex     sync.Mutex
+	buckets   map[string]memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	Bucket
+	fullAt time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]memoryBucket), lastSweep: time.Now()}
+}
+
+// TakeRateLimitToken takes a token from the bucket identified by key.
+func (m *Memory) TakeRateLimitToken(ctx context.Context, key string, l Limit) (Result, error) {
+	now := time.Now()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	b, res := m.buckets[key].Take(l, now)
+	m.buckets[key] = memoryBucket{Bucket: b, fullAt: now.Add(res.Reset)}
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+	}
+	return res, nil
+}
+
+// sweep drops full buckets, which are equivalent to missing ones, so that
+// memory use is bounded by the number of recently active keys.
+func (m *Memory) sweep(now time.Time) {
+	for k, b := range m.buckets {
+		if !now.Before(b.fullAt) {
+			delete(m.buckets, k)
+		}
+	}
+	m.lastSweep = now
+}
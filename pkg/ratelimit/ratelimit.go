@@ -0,0 +1,197 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// Limit is a token bucket that holds up to Burst tokens and is refilled at
+// Rate tokens per second. Every request takes a token. A zero Limit does not
+// limit requests.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited returns true if l does not limit requests.
+func (l Limit) Unlimited() bool {
+	return l.Rate == 0
+}
+
+func (l Limit) validate() error {
+	if l.Rate < 0 {
+		return errors.Newf("rate must not be negative, got %f", l.Rate)
+	}
+	if !l.Unlimited() && l.Burst < 1 {
+		return errors.Newf("burst must be at least 1, got %d", l.Burst)
+	}
+	return nil
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed bool
+	// Limit is the Burst of the bucket, 0 if the request was not limited.
+	Limit int
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+	// RetryAfter is how long to wait for a token if the request was not
+	// Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Stricter returns whichever of a and b leaves less room for requests e.g.
+// to report the limit closest to being exceeded.
+func Stricter(a, b Result) Result {
+	switch {
+	case !a.Allowed:
+		return a
+	case !b.Allowed:
+		return b
+	case a.Limit == 0:
+		return b
+	case b.Limit == 0:
+		return a
+	case b.Remaining < a.Remaining:
+		return b
+	}
+	return a
+}
+
+// Bucket is the state of a token bucket. The zero Bucket is full.
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// Take refills b for the time elapsed since it was last Updated and takes
+// a token from it at now if one is available.
+func (b Bucket) Take(l Limit, now time.Time) (Bucket, Result) {
+	burst := float64(l.Burst)
+	tokens := burst
+	if !b.Updated.IsZero() {
+		elapsed := now.Sub(b.Updated)
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		tokens = math.Min(burst, b.Tokens+elapsed.Seconds()*l.Rate)
+	}
+	res := Result{Limit: l.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / l.Rate)
+	}
+	res.Remaining = int(tokens)
+	res.Reset = seconds((burst - tokens) / l.Rate)
+	return Bucket{Tokens: tokens, Updated: now}, res
+}
+
+// Store keeps token buckets by key. Use a store shared by all instances of
+// the service e.g. the database for limits to hold across instances.
+type Store interface {
+	TakeRateLimitToken(ctx context.Context, key string, l Limit) (Result, error)
+}
+
+// Limiter limits requests per API key user and per authenticated user.
+// Use NewLimiter() to instantiate.
+type Limiter struct {
+	store Store
+
+	mu     sync.RWMutex
+	apiKey Limit
+	user   Limit
+}
+
+// Option allows extra configuration for instantiating Limiter.
+type Option func(*Limiter)
+
+// WithAPIKeyLimit limits requests made with the API key of each client app
+// user. Requests are not limited per API key by default.
+func WithAPIKeyLimit(l Limit) Option {
+	return func(lm *Limiter) {
+		lm.apiKey = l
+	}
+}
+
+// WithUserLimit limits requests made by each authenticated user. Requests
+// are not limited per user by default.
+func WithUserLimit(l Limit) Option {
+	return func(lm *Limiter) {
+		lm.user = l
+	}
+}
+
+func NewLimiter(s Store, opts ...Option) (*Limiter, error) {
+	if s == nil {
+		return nil, errors.New("Store was nil")
+	}
+	lm := &Limiter{store: s}
+	for _, f := range opts {
+		f(lm)
+	}
+	if err := lm.apiKey.validate(); err != nil {
+		return nil, errors.Newf("API key limit: %v", err)
+	}
+	if err := lm.user.validate(); err != nil {
+		return nil, errors.Newf("user limit: %v", err)
+	}
+	return lm, nil
+}
+
+// SetLimits replaces the per API key and per user limits e.g. when config
+// is reloaded. Buckets keep their tokens and are refilled at the new rates
+// from then on. Neither limit is replaced if either is invalid.
+func (lm *Limiter) SetLimits(apiKey, user Limit) error {
+	if err := apiKey.validate(); err != nil {
+		return errors.Newf("API key limit: %v", err)
+	}
+	if err := user.validate(); err != nil {
+		return errors.Newf("user limit: %v", err)
+	}
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm.apiKey, lm.user = apiKey, user
+	return nil
+}
+
+// AllowAPIKey takes a token for a request made with the API key of the
+// client app user clUsrID.
+func (lm *Limiter) AllowAPIKey(ctx context.Context, clUsrID string) (Result, error) {
+	lm.mu.RLock()
+	l := lm.apiKey
+	lm.mu.RUnlock()
+	return lm.take(ctx, "apiKey:"+clUsrID, l)
+}
+
+// AllowUser takes a token for a request made by the authenticated user
+// usrID.
+func (lm *Limiter) AllowUser(ctx context.Context, usrID string) (Result, error) {
+	lm.mu.RLock()
+	l := lm.user
+	lm.mu.RUnlock()
+	return lm.take(ctx, "user:"+usrID, l)
+}
+
+func (lm *Limiter) take(ctx context.Context, key string, l Limit) (Result, error) {
+	if l.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+	res, err := lm.store.TakeRateLimitToken(ctx, key, l)
+	if err != nil {
+		return Result{}, errors.Newf("take token: %v", err)
+	}
+	return res, nil
+}
+
+// seconds converts s seconds to a time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,202 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+)
+
+type failingStore struct{}
+
+func (failingStore) TakeRateLimitToken(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store down")
+}
+
+func TestBucket_Take(t *testing.T) {
+	now := time.Now()
+	l := ratelimit.Limit{Rate: 2, Burst: 4}
+	tt := []struct {
+		name   string
+		bucket ratelimit.Bucket
+		expRes ratelimit.Result
+		expTkn float64
+	}{
+		{
+			name:   "new bucket",
+			bucket: ratelimit.Bucket{},
+			expRes: ratelimit.Result{Allowed: true, Limit: 4, Remaining: 3, Reset: 500 * time.Millisecond},
+			expTkn: 3,
+		},
+		{
+			name:   "refilled",
+			bucket: ratelimit.Bucket{Tokens: 0, Updated: now.Add(-time.Second)},
+			expRes: ratelimit.Result{Allowed: true, Limit: 4, Remaining: 1, Reset: 1500 * time.Millisecond},
+			expTkn: 1,
+		},
+		{
+			name:   "refill capped at burst",
+			bucket: ratelimit.Bucket{Tokens: 1, Updated: now.Add(-time.Hour)},
+			expRes: ratelimit.Result{Allowed: true, Limit: 4, Remaining: 3, Reset: 500 * time.Millisecond},
+			expTkn: 3,
+		},
+		{
+			name:   "empty",
+			bucket: ratelimit.Bucket{Tokens: 0.5, Updated: now},
+			expRes: ratelimit.Result{Limit: 4, RetryAfter: 250 * time.Millisecond, Reset: 1750 * time.Millisecond},
+			expTkn: 0.5,
+		},
+		{
+			name:   "updated in the future",
+			bucket: ratelimit.Bucket{Tokens: 0, Updated: now.Add(time.Second)},
+			expRes: ratelimit.Result{Limit: 4, RetryAfter: 500 * time.Millisecond, Reset: 2 * time.Second},
+			expTkn: 0,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			b, res := tc.bucket.Take(l, now)
+			if res != tc.expRes {
+				t.Errorf("Expected result %+v, got %+v", tc.expRes, res)
+			}
+			if b.Tokens != tc.expTkn || !b.Updated.Equal(now) {
+				t.Errorf("Expected bucket with %f tokens updated at %v, got %+v",
+					tc.expTkn, now, b)
+			}
+		})
+	}
+}
+
+func TestStricter(t *testing.T) {
+	unlimited := ratelimit.Result{Allowed: true}
+	roomy := ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9}
+	tight := ratelimit.Result{Allowed: true, Limit: 2, Remaining: 1}
+	denied := ratelimit.Result{Limit: 100, RetryAfter: time.Second}
+	tt := []struct {
+		name string
+		a, b ratelimit.Result
+		exp  ratelimit.Result
+	}{
+		{name: "both unlimited", a: unlimited, b: unlimited, exp: unlimited},
+		{name: "one unlimited", a: unlimited, b: roomy, exp: roomy},
+		{name: "fewer remaining", a: roomy, b: tight, exp: tight},
+		{name: "fewer remaining first", a: tight, b: roomy, exp: tight},
+		{name: "denied", a: tight, b: denied, exp: denied},
+		{name: "denied first", a: denied, b: unlimited, exp: denied},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ratelimit.Stricter(tc.a, tc.b); got != tc.exp {
+				t.Errorf("Expected %+v, got %+v", tc.exp, got)
+			}
+		})
+	}
+}
+
+func TestNewLimiter(t *testing.T) {
+	tt := []struct {
+		name   string
+		store  ratelimit.Store
+		opts   []ratelimit.Option
+		expErr bool
+	}{
+		{name: "no limits", store: ratelimit.NewMemory()},
+		{name: "with limits", store: ratelimit.NewMemory(), opts: []ratelimit.Option{
+			ratelimit.WithAPIKeyLimit(ratelimit.Limit{Rate: 10, Burst: 20}),
+			ratelimit.WithUserLimit(ratelimit.Limit{Rate: 1, Burst: 1}),
+		}},
+		{name: "nil store", expErr: true},
+		{name: "negative rate", store: ratelimit.NewMemory(), opts: []ratelimit.Option{
+			ratelimit.WithAPIKeyLimit(ratelimit.Limit{Rate: -1, Burst: 1}),
+		}, expErr: true},
+		{name: "no burst", store: ratelimit.NewMemory(), opts: []ratelimit.Option{
+			ratelimit.WithUserLimit(ratelimit.Limit{Rate: 1}),
+		}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			lm, err := ratelimit.NewLimiter(tc.store, tc.opts...)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if lm == nil {
+				t.Fatalf("Got nil *ratelimit.Limiter")
+			}
+		})
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	lm, err := ratelimit.NewLimiter(ratelimit.NewMemory(),
+		ratelimit.WithAPIKeyLimit(ratelimit.Limit{Rate: 0.001, Burst: 2}))
+	if err != nil {
+		t.Fatalf("Error setting up: new limiter: %v", err)
+	}
+	ctx := context.Background()
+	for i, expAllowed := range []bool{true, true, false} {
+		res, err := lm.AllowAPIKey(ctx, "app1")
+		if err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+		if res.Allowed != expAllowed {
+			t.Errorf("Request %d: expected allowed %t, got %+v", i, expAllowed, res)
+		}
+	}
+	if res, err := lm.AllowAPIKey(ctx, "app2"); err != nil || !res.Allowed {
+		t.Errorf("Expected other API key users not to be limited, got %+v, %v", res, err)
+	}
+	for i := 0; i < 5; i++ {
+		if res, err := lm.AllowUser(ctx, "app1"); err != nil || !res.Allowed || res.Limit != 0 {
+			t.Fatalf("Expected users not to be limited, got %+v, %v", res, err)
+		}
+	}
+
+	lm, err = ratelimit.NewLimiter(failingStore{},
+		ratelimit.WithUserLimit(ratelimit.Limit{Rate: 1, Burst: 1}))
+	if err != nil {
+		t.Fatalf("Error setting up: new limiter: %v", err)
+	}
+	if _, err := lm.AllowUser(ctx, "usr1"); err == nil {
+		t.Errorf("Expected store error, got nil")
+	}
+}
+
+func TestLimiter_SetLimits(t *testing.T) {
+	lm, err := ratelimit.NewLimiter(ratelimit.NewMemory(),
+		ratelimit.WithAPIKeyLimit(ratelimit.Limit{Rate: 0.001, Burst: 1}))
+	if err != nil {
+		t.Fatalf("Error setting up: new limiter: %v", err)
+	}
+	ctx := context.Background()
+	if res, err := lm.AllowAPIKey(ctx, "app1"); err != nil || !res.Allowed {
+		t.Fatalf("Expected first request allowed, got %+v, %v", res, err)
+	}
+
+	err = lm.SetLimits(ratelimit.Limit{}, ratelimit.Limit{Rate: 1})
+	if err == nil {
+		t.Fatalf("Expected an error for a user limit without burst, got nil")
+	}
+	if res, err := lm.AllowAPIKey(ctx, "app1"); err != nil || res.Allowed {
+		t.Errorf("Expected limits unchanged after invalid SetLimits, got %+v, %v", res, err)
+	}
+
+	if err := lm.SetLimits(ratelimit.Limit{}, ratelimit.Limit{Rate: 0.001, Burst: 1}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if res, err := lm.AllowAPIKey(ctx, "app1"); err != nil || !res.Allowed || res.Limit != 0 {
+		t.Errorf("Expected API keys no longer limited, got %+v, %v", res, err)
+	}
+	for i, expAllowed := range []bool{true, false} {
+		if res, err := lm.AllowUser(ctx, "usr1"); err != nil || res.Allowed != expAllowed {
+			t.Errorf("User request %d: expected allowed %t, got %+v, %v", i, expAllowed, res, err)
+		}
+	}
+}
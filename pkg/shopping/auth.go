@@ -0,0 +1,29 @@
+package shopping
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// JWTEr validates JWTs issued by the authentication micro-service.
+type JWTEr interface {
+	Validate(JWT string, claims jwt.Claims) (*jwt.Token, error)
+}
+
+// Claim is the set of JWT claims issued by the authentication micro-service.
+type Claim struct {
+	UsrID string `json:"usrID"`
+	jwt.StandardClaims
+}
+
+// userID returns the ID of the user JWT was issued to.
+func userID(jwter JWTEr, JWT string) (string, error) {
+	clm := new(Claim)
+	if _, err := jwter.Validate(JWT, clm); err != nil {
+		return "", errors.NewUnauthorizedf("invalid token: %v", err)
+	}
+	if clm.UsrID == "" {
+		return "", errors.NewUnauthorized("token does not identify a user")
+	}
+	return clm.UsrID, nil
+}
@@ -0,0 +1,166 @@
+package shopping
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// CatalogDB persists the shared catalog of items and brands.
+type CatalogDB interface {
+	Items(ctx context.Context) ([]Item, error)
+	Brands(ctx context.Context) ([]Brand, error)
+	MergeItems(ctx context.Context, survivorID string, duplicateIDs []string) (*Item, error)
+	MergeBrands(ctx context.Context, survivorID string, duplicateIDs []string) (*Brand, error)
+	UpsertBrands(ctx context.Context, brands []Brand) ([]Brand, error)
+}
+
+// Catalog adds to, detects and merges duplicate entries in the shared
+// catalog.
+// Use NewCatalog() to instantiate.
+type Catalog struct {
+	errors.ErrToHTTP
+
+	db CatalogDB
+}
+
+func NewCatalog(db CatalogDB) (*Catalog, error) {
+	if db == nil {
+		return nil, errors.New("CatalogDB was nil")
+	}
+	return &Catalog{db: db}, nil
+}
+
+// UpsertBrands adds brands, their items and measuring units to the catalog
+// if missing and returns the stored brands in the same order. Names are
+// cleaned and default the same way as in Manager.UpsertShoppingListItem().
+func (c *Catalog) UpsertBrands(ctx context.Context, brands []Brand) ([]Brand, error) {
+	ctx, span := tracer.Start(ctx, "Catalog.UpsertBrands")
+	defer span.End()
+
+	if len(brands) == 0 {
+		return nil, errors.NewClient("no brands provided")
+	}
+	clean := make([]Brand, len(brands))
+	for i, b := range brands {
+		if b.Item.Name = CleanName(b.Item.Name); b.Item.Name == "" {
+			return nil, errors.NewClientf("item name of brand %d was empty", i)
+		}
+		if b.Name = CleanName(b.Name); b.Name == "" {
+			b.Name = DefaultBrandName
+		}
+		if b.MeasuringUnit.Name = CleanName(b.MeasuringUnit.Name); b.MeasuringUnit.Name == "" {
+			b.MeasuringUnit.Name = DefaultMeasuringUnit
+		}
+		var barcodes []string
+		for _, bc := range b.Barcodes {
+			if bc = strings.TrimSpace(bc); bc != "" {
+				barcodes = append(barcodes, bc)
+			}
+		}
+		b.Barcodes = barcodes
+		clean[i] = b
+	}
+	return c.db.UpsertBrands(ctx, clean)
+}
+
+// DuplicateItems returns groups of items whose names have a similarity of
+// at least threshold.
+func (c *Catalog) DuplicateItems(ctx context.Context, threshold float64) ([]DuplicateGroup, error) {
+	ctx, span := tracer.Start(ctx, "Catalog.DuplicateItems")
+	defer span.End()
+
+	if err := validateThreshold(threshold); err != nil {
+		return nil, err
+	}
+	items, err := c.db.Items(ctx)
+	if err != nil {
+		return nil, errors.Newf("get items: %v", err)
+	}
+	entries := make([]CatalogEntry, len(items))
+	for i, item := range items {
+		entries[i] = CatalogEntry{ID: item.ID, Name: item.Name}
+	}
+	return FindDuplicates(entries, threshold), nil
+}
+
+// DuplicateBrands returns groups of brands whose names have a similarity
+// of at least threshold. Only brands of the same item and measuring unit
+// are compared since only those can be merged.
+func (c *Catalog) DuplicateBrands(ctx context.Context, threshold float64) ([]DuplicateGroup, error) {
+	ctx, span := tracer.Start(ctx, "Catalog.DuplicateBrands")
+	defer span.End()
+
+	if err := validateThreshold(threshold); err != nil {
+		return nil, err
+	}
+	brands, err := c.db.Brands(ctx)
+	if err != nil {
+		return nil, errors.Newf("get brands: %v", err)
+	}
+	partitions := make(map[[2]string][]CatalogEntry)
+	for _, b := range brands {
+		k := [2]string{b.Item.ID, b.MeasuringUnit.ID}
+		partitions[k] = append(partitions[k], CatalogEntry{ID: b.ID, Name: b.Name})
+	}
+	var groups []DuplicateGroup
+	for _, entries := range partitions {
+		groups = append(groups, FindDuplicates(entries, threshold)...)
+	}
+	return groups, nil
+}
+
+// MergeItems merges the items with duplicateIDs into the item with
+// survivorID. Brands of the duplicates are moved to the survivor and the
+// duplicates' names are kept as aliases of the survivor for future
+// matching.
+func (c *Catalog) MergeItems(ctx context.Context, survivorID string, duplicateIDs []string) (*Item, error) {
+	ctx, span := tracer.Start(ctx, "Catalog.MergeItems")
+	defer span.End()
+
+	if err := validateMerge(survivorID, duplicateIDs); err != nil {
+		return nil, err
+	}
+	return c.db.MergeItems(ctx, survivorID, duplicateIDs)
+}
+
+// MergeBrands merges the brands with duplicateIDs into the brand with
+// survivorID. Prices, shopping list items and barcodes of the duplicates
+// are moved to the survivor and the duplicates' names are kept as aliases
+// of the survivor for future matching. All duplicates must be of the same
+// item and measuring unit as the survivor.
+func (c *Catalog) MergeBrands(ctx context.Context, survivorID string, duplicateIDs []string) (*Brand, error) {
+	ctx, span := tracer.Start(ctx, "Catalog.MergeBrands")
+	defer span.End()
+
+	if err := validateMerge(survivorID, duplicateIDs); err != nil {
+		return nil, err
+	}
+	return c.db.MergeBrands(ctx, survivorID, duplicateIDs)
+}
+
+func validateThreshold(threshold float64) error {
+	if threshold <= 0 || threshold > 1 {
+		return errors.NewClientf("threshold must be in the range (0, 1], got %f", threshold)
+	}
+	return nil
+}
+
+func validateMerge(survivorID string, duplicateIDs []string) error {
+	if survivorID == "" {
+		return errors.NewClient("survivor ID was empty")
+	}
+	if len(duplicateIDs) == 0 {
+		return errors.NewClient("no duplicate IDs provided")
+	}
+	for _, ID := range duplicateIDs {
+		if ID == "" {
+			return errors.NewClient("duplicate IDs contain an empty ID")
+		}
+		if ID == survivorID {
+			return errors.NewClient("cannot merge an entry into itself")
+		}
+	}
+	return nil
+}
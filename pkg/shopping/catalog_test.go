@@ -0,0 +1,109 @@
+package shopping_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type catalogDB struct {
+	shoppingListDB
+
+	catItems  []shopping.Item
+	catBrands []shopping.Brand
+}
+
+func (db *catalogDB) Items(ctx context.Context) ([]shopping.Item, error) {
+	if len(db.catItems) == 0 {
+		return nil, errors.NewNotFound("no items")
+	}
+	return db.catItems, nil
+}
+
+func (db *catalogDB) Brands(ctx context.Context) ([]shopping.Brand, error) {
+	if len(db.catBrands) == 0 {
+		return nil, errors.NewNotFound("no brands")
+	}
+	return db.catBrands, nil
+}
+
+func (db *catalogDB) MergeItems(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Item, error) {
+	return &shopping.Item{ID: survivorID}, nil
+}
+
+func (db *catalogDB) MergeBrands(ctx context.Context, survivorID string, duplicateIDs []string) (*shopping.Brand, error) {
+	return &shopping.Brand{ID: survivorID}, nil
+}
+
+func TestCatalog_UpsertBrands(t *testing.T) {
+	tt := []struct {
+		name     string
+		brands   []shopping.Brand
+		expBrand shopping.Brand
+		expClErr bool
+	}{
+		{
+			name: "cleaned",
+			brands: []shopping.Brand{{
+				Name:          "  Brookside   Dairy ",
+				Item:          shopping.Item{Name: " Milk"},
+				MeasuringUnit: shopping.MeasuringUnit{Name: "500ml "},
+				Barcodes:      []string{" 6161100530024", ""},
+			}},
+			expBrand: shopping.Brand{
+				ID:            "brand",
+				Name:          "Brookside Dairy",
+				Item:          shopping.Item{Name: "Milk"},
+				MeasuringUnit: shopping.MeasuringUnit{Name: "500ml"},
+				Barcodes:      []string{"6161100530024"},
+			},
+		},
+		{
+			name:   "defaults",
+			brands: []shopping.Brand{{Item: shopping.Item{Name: "Milk"}}},
+			expBrand: shopping.Brand{
+				ID:            "brand",
+				Name:          shopping.DefaultBrandName,
+				Item:          shopping.Item{Name: "Milk"},
+				MeasuringUnit: shopping.MeasuringUnit{Name: shopping.DefaultMeasuringUnit},
+			},
+		},
+		{
+			name:     "empty item name",
+			brands:   []shopping.Brand{{Name: "Brookside", Item: shopping.Item{Name: "  "}}},
+			expClErr: true,
+		},
+		{
+			name:     "no brands",
+			expClErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			db := &catalogDB{}
+			c, err := shopping.NewCatalog(db)
+			if err != nil {
+				t.Fatalf("shopping.NewCatalog(): %v", err)
+			}
+			got, err := c.UpsertBrands(context.TODO(), tc.brands)
+			if tc.expClErr {
+				if !(errors.ClErrCheck{}).IsClientError(err) {
+					t.Errorf("Expected a client error, got %v", err)
+				}
+				if len(db.brands) != 0 {
+					t.Errorf("Expected no brands stored, got %+v", db.brands)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if len(got) != 1 || !reflect.DeepEqual(got[0], tc.expBrand) {
+				t.Errorf("Expected [%+v], got %+v", tc.expBrand, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package shopping
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultSimilarityThreshold is the minimum NameSimilarity at which two
+// catalog entries are reported as probable duplicates.
+const DefaultSimilarityThreshold = 0.85
+
+// companySuffixes are the legal forms dropped when comparing names since
+// free text entries of the same brand commonly differ only by them e.g.
+// "Colgate Ltd". Other words are kept even if common in company names as
+// they may tell products apart e.g. "Special K".
+var companySuffixes = map[string]bool{
+	"ltd": true, "limited": true, "inc": true, "co": true, "company": true,
+	"corp": true, "corporation": true, "plc": true, "llc": true, "gmbh": true,
+	"sa": true, "ag": true,
+}
+
+// CatalogEntry is a catalog entity (Item or Brand) considered for
+// duplicate detection.
+type CatalogEntry struct {
+	ID   string
+	Name string
+}
+
+// DuplicateGroup is a set of catalog entries that probably describe the
+// same entity. Entries are ordered by ID.
+type DuplicateGroup struct {
+	Entries []CatalogEntry
+	// Score is the highest similarity between any two entries in the group.
+	Score float64
+}
+
+// NameSimilarity returns a score between 0 (different) and 1 (same) for
+// how likely names a and b refer to the same thing. Case, punctuation,
+// spacing and company suffixes are ignored.
+func NameSimilarity(a, b string) float64 {
+	ca, cb := comparableName(a), comparableName(b)
+	if ca == "" || cb == "" {
+		return 0
+	}
+	if ca == cb {
+		return 1
+	}
+	return levenshteinRatio(ca, cb)
+}
+
+// blockPrefixLen is the number of leading runes (ignoring spaces) that
+// comparable names must share to be compared by FindDuplicates().
+const blockPrefixLen = 3
+
+// FindDuplicates groups entries whose names have a NameSimilarity of at
+// least threshold. To keep detection fast on large catalogs, only entries
+// whose comparable names share the first blockPrefixLen letters or digits
+// and whose lengths are close enough to reach threshold are compared, so
+// misspellings within the first few letters go undetected. Entries that
+// have no duplicates are not returned.
+func FindDuplicates(entries []CatalogEntry, threshold float64) []DuplicateGroup {
+
+	blocks := make(map[string][]int)
+	names := make([]string, len(entries))
+	lens := make([]int, len(entries))
+	for i, e := range entries {
+		names[i] = comparableName(e.Name)
+		if names[i] == "" {
+			continue
+		}
+		lens[i] = len([]rune(names[i]))
+		k := blockKey(names[i])
+		blocks[k] = append(blocks[k], i)
+	}
+
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	scores := make(map[int]float64)
+	for _, block := range blocks {
+		sort.SliceStable(block, func(x, y int) bool { return lens[block[x]] < lens[block[y]] })
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				i, j := block[x], block[y]
+				// The edit distance is at least the difference in length
+				// so names[j] and all longer names are too different.
+				if float64(lens[i]) < threshold*float64(lens[j]) {
+					break
+				}
+				score := 1.0
+				if names[i] != names[j] {
+					score = levenshteinRatio(names[i], names[j])
+				}
+				if score < threshold {
+					continue
+				}
+				ri, rj := find(i), find(j)
+				if ri != rj {
+					parent[rj] = ri
+				}
+				root := find(i)
+				scores[root] = maxFloat(scores[root], maxFloat(scores[ri], scores[rj]))
+				scores[root] = maxFloat(scores[root], score)
+			}
+		}
+	}
+
+	members := make(map[int][]CatalogEntry)
+	for i, e := range entries {
+		if names[i] == "" {
+			continue
+		}
+		root := find(i)
+		members[root] = append(members[root], e)
+	}
+
+	var groups []DuplicateGroup
+	for root, es := range members {
+		if len(es) < 2 {
+			continue
+		}
+		sort.Slice(es, func(i, j int) bool { return lessID(es[i].ID, es[j].ID) })
+		groups = append(groups, DuplicateGroup{Entries: es, Score: scores[root]})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return lessID(groups[i].Entries[0].ID, groups[j].Entries[0].ID)
+	})
+	return groups
+}
+
+// blockKey returns the first blockPrefixLen runes of the comparable name
+// with spaces removed, or all of it if shorter.
+func blockKey(comparableName string) string {
+	r := []rune(strings.Replace(comparableName, " ", "", -1))
+	if len(r) > blockPrefixLen {
+		r = r[:blockPrefixLen]
+	}
+	return string(r)
+}
+
+// comparableName reduces name to lower case alphanumeric words without
+// company suffixes.
+func comparableName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := words[:0]
+	for i, w := range words {
+		// Never drop the first word, the name may be a suffix e.g. "Co".
+		if i > 0 && companySuffixes[w] {
+			continue
+		}
+		kept = append(kept, w)
+	}
+	return strings.Join(kept, " ")
+}
+
+// levenshteinRatio returns 1 - (edit distance / length of longer string).
+func levenshteinRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// lessID orders numeric IDs numerically and falls back to lexical order.
+func lessID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
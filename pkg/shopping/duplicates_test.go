@@ -0,0 +1,117 @@
+package shopping_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	tt := []struct {
+		a, b     string
+		minScore float64
+		maxScore float64
+	}{
+		{a: "Colgate", b: "colgate ", minScore: 1, maxScore: 1},
+		{a: "Colgate", b: "Colgate Ltd", minScore: 1, maxScore: 1},
+		{a: "Colgate", b: "Colgte", minScore: 0.85, maxScore: 0.99},
+		{a: "Toothpaste", b: "Tooth-paste", minScore: 0.85, maxScore: 0.99},
+		{a: "Colgate", b: "Close Up", minScore: 0, maxScore: 0.5},
+		{a: "Special K", b: "Special", minScore: 0, maxScore: 0.84},
+		{a: "", b: "Colgate", minScore: 0, maxScore: 0},
+	}
+	for _, tc := range tt {
+		t.Run(tc.a+"|"+tc.b, func(t *testing.T) {
+			score := shopping.NameSimilarity(tc.a, tc.b)
+			if score < tc.minScore || score > tc.maxScore {
+				t.Errorf("Expected score in [%f, %f], got %f",
+					tc.minScore, tc.maxScore, score)
+			}
+		})
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	entries := []shopping.CatalogEntry{
+		{ID: "10", Name: "Colgate Ltd"},
+		{ID: "2", Name: "Close Up"},
+		{ID: "3", Name: "colgate "},
+		{ID: "4", Name: "Sensodyne"},
+		{ID: "5", Name: "Colgate"},
+		{ID: "6", Name: "Sensodyn"},
+	}
+	expGroups := []shopping.DuplicateGroup{
+		{
+			Entries: []shopping.CatalogEntry{
+				{ID: "3", Name: "colgate "},
+				{ID: "5", Name: "Colgate"},
+				{ID: "10", Name: "Colgate Ltd"},
+			},
+			Score: 1,
+		},
+		{
+			Entries: []shopping.CatalogEntry{
+				{ID: "4", Name: "Sensodyne"},
+				{ID: "6", Name: "Sensodyn"},
+			},
+			Score: shopping.NameSimilarity("Sensodyne", "Sensodyn"),
+		},
+	}
+	groups := shopping.FindDuplicates(entries, shopping.DefaultSimilarityThreshold)
+	if !reflect.DeepEqual(groups, expGroups) {
+		t.Errorf("Duplicate groups mismatch:\nExpect:\t%+v\nGot:\t%+v", expGroups, groups)
+	}
+}
+
+func TestFindDuplicates_productWords(t *testing.T) {
+	entries := []shopping.CatalogEntry{
+		{ID: "1", Name: "Special"},
+		{ID: "2", Name: "Special K"},
+		{ID: "3", Name: "Special K Ltd"},
+	}
+	expGroups := []shopping.DuplicateGroup{{
+		Entries: []shopping.CatalogEntry{
+			{ID: "2", Name: "Special K"},
+			{ID: "3", Name: "Special K Ltd"},
+		},
+		Score: 1,
+	}}
+	groups := shopping.FindDuplicates(entries, shopping.DefaultSimilarityThreshold)
+	if !reflect.DeepEqual(groups, expGroups) {
+		t.Errorf("Duplicate groups mismatch:\nExpect:\t%+v\nGot:\t%+v", expGroups, groups)
+	}
+}
+
+func TestFindDuplicates_blocking(t *testing.T) {
+	entries := []shopping.CatalogEntry{
+		{ID: "1", Name: "Toothpaste"},
+		{ID: "2", Name: "Tooth paste"},
+		{ID: "3", Name: "Omo"},
+		{ID: "4", Name: "OMO Ltd"},
+		// Same prefix but too long to reach the threshold.
+		{ID: "5", Name: "Toothpaste Whitening Extra"},
+		// Misspelt within the prefix so never compared.
+		{ID: "6", Name: "Tiothpaste"},
+	}
+	expGroups := []shopping.DuplicateGroup{
+		{
+			Entries: []shopping.CatalogEntry{
+				{ID: "1", Name: "Toothpaste"},
+				{ID: "2", Name: "Tooth paste"},
+			},
+			Score: shopping.NameSimilarity("Toothpaste", "Tooth paste"),
+		},
+		{
+			Entries: []shopping.CatalogEntry{
+				{ID: "3", Name: "Omo"},
+				{ID: "4", Name: "OMO Ltd"},
+			},
+			Score: 1,
+		},
+	}
+	groups := shopping.FindDuplicates(entries, shopping.DefaultSimilarityThreshold)
+	if !reflect.DeepEqual(groups, expGroups) {
+		t.Errorf("Duplicate groups mismatch:\nExpect:\t%+v\nGot:\t%+v", expGroups, groups)
+	}
+}
@@ -1,5 +1,17 @@
 package shopping
 
+import "time"
+
+// Price statuses. Prices that are outliers stay pending until moderated
+// (see Prices.Submit()). Only approved prices count towards
+// Prices.CurrentPrice(), outlier scores and contributor trust; a shopping
+// list item shows the price its user observed whatever its status.
+const (
+	PriceStatusApproved = "APPROVED"
+	PriceStatusPending  = "PENDING"
+	PriceStatusRejected = "REJECTED"
+)
+
 type ShoppingList struct {
 	ID          string
 	UserID      string
@@ -24,6 +36,8 @@ type Brand struct {
 	Name          string
 	MeasuringUnit MeasuringUnit
 	Item          Item
+	// Barcodes are the GTIN/EAN codes printed on the brand's packaging.
+	Barcodes []string
 }
 
 type Store struct {
@@ -31,10 +45,20 @@ type Store struct {
 	Name string
 }
 
+// Location is a point on the earth's surface in decimal degrees (WGS84).
+type Location struct {
+	Latitude  float64
+	Longitude float64
+}
+
 type StoreBranch struct {
-	ID    string
-	Name  string
-	Store Store
+	ID       string
+	Name     string
+	Store    Store
+	Location Location
+	// OSMID is the OpenStreetMap element ID e.g. "node/123456" from which
+	// the branch was imported, empty if the branch was not imported.
+	OSMID string
 }
 
 type Price struct {
@@ -43,6 +67,22 @@ type Price struct {
 	Currency      string
 	Brand         Brand
 	AtStoreBranch StoreBranch
+	// SubmittedBy is the ID of the user who observed the price.
+	SubmittedBy string
+	// Status is one of PriceStatusApproved, PriceStatusPending or
+	// PriceStatusRejected.
+	Status string
+	// OutlierScore is how far the price was from recent history when it was
+	// submitted, see OutlierScore(). It is 0 if there was too little
+	// history to judge.
+	OutlierScore float64
+	// Confirmations is the number of later observations by other users
+	// that agreed with this price.
+	Confirmations int64
+	// Contradictions is the number of later observations by other users
+	// that disagreed with this price.
+	Contradictions int64
+	Created        time.Time
 }
 
 type ShoppingListItem struct {
@@ -0,0 +1,44 @@
+package shopping
+
+import "math"
+
+const earthRadiusMeters = 6371000
+
+// Distance returns the great-circle distance in meters between a and b
+// using the haversine formula.
+func Distance(a, b Location) float64 {
+	lat1 := degToRad(a.Latitude)
+	lat2 := degToRad(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := degToRad(b.Longitude - a.Longitude)
+	h := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox returns the south-west and north-east corners of a box that
+// contains every point within radiusM meters of center.
+func BoundingBox(center Location, radiusM float64) (sw, ne Location) {
+	dLat := radToDeg(radiusM / earthRadiusMeters)
+	dLon := 180.0
+	if cosLat := math.Cos(degToRad(center.Latitude)); cosLat > 1e-9 {
+		dLon = math.Min(180, radToDeg(radiusM/(earthRadiusMeters*cosLat)))
+	}
+	sw = Location{
+		Latitude:  math.Max(-90, center.Latitude-dLat),
+		Longitude: math.Max(-180, center.Longitude-dLon),
+	}
+	ne = Location{
+		Latitude:  math.Min(90, center.Latitude+dLat),
+		Longitude: math.Min(180, center.Longitude+dLon),
+	}
+	return sw, ne
+}
+
+func degToRad(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+func radToDeg(r float64) float64 {
+	return r * 180 / math.Pi
+}
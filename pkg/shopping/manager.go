@@ -0,0 +1,307 @@
+package shopping
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// Shopping list modes as set by client apps.
+const (
+	ModePreparation = "PREPARATION"
+	ModeShopping    = "SHOPPING"
+)
+
+const (
+	// DefaultBrandName is used for shopping list items whose brand is not
+	// specified.
+	DefaultBrandName = "Generic"
+
+	// DefaultMeasuringUnit is used for shopping list items whose measuring
+	// unit is not specified.
+	DefaultMeasuringUnit = "unit"
+)
+
+// ShoppingListItemUpsert describes the values of a shopping list item to
+// insert or update. Items are identified within a shopping list by their
+// brand i.e. the combination of ItemName, BrandName and MeasuringUnit.
+type ShoppingListItemUpsert struct {
+	ShoppingListID string
+	ItemName       string
+	// BrandName defaults to DefaultBrandName.
+	BrandName string
+	// MeasuringUnit defaults to DefaultMeasuringUnit.
+	MeasuringUnit string
+	Quantity      int
+	InList        bool
+	// InCart implies InList.
+	InCart bool
+	// UnitPrice is submitted as an observed price of the brand if greater
+	// than 0.
+	UnitPrice float32
+	// Currency of UnitPrice, defaults to DefaultCurrency.
+	Currency string
+	// StoreBranchID is where UnitPrice was observed, if known.
+	StoreBranchID string
+}
+
+// ItemSearch filters shopping list items by case-insensitive partial
+// matches. Empty fields match everything.
+type ItemSearch struct {
+	ItemName      string
+	BrandName     string
+	MeasuringUnit string
+}
+
+// ShoppingListDB persists users' shopping lists and their items.
+type ShoppingListDB interface {
+	IsNotFoundError(error) bool
+	// UpsertShoppingList inserts sl if the user has no list of the same name
+	// and returns the stored list.
+	UpsertShoppingList(ctx context.Context, sl ShoppingList) (*ShoppingList, error)
+	UpdateShoppingList(ctx context.Context, userID, ID string, name, mode crdb.StringUpdate) (*ShoppingList, error)
+	ShoppingLists(ctx context.Context, userID string, offset, count int64) ([]ShoppingList, error)
+	// ShoppingList returns the shopping list with ID owned by userID.
+	ShoppingList(ctx context.Context, userID, ID string) (*ShoppingList, error)
+	UpsertBrands(ctx context.Context, brands []Brand) ([]Brand, error)
+	// UpsertShoppingListItem inserts or updates the item with the same
+	// shopping list and brand as item in a shopping list owned by userID.
+	UpsertShoppingListItem(ctx context.Context, userID string, item ShoppingListItem) (*ShoppingListItem, error)
+	DeleteShoppingListItem(ctx context.Context, userID, ID string) error
+	ShoppingListItems(ctx context.Context, userID, shoppingListID string, offset, count int64) ([]ShoppingListItem, error)
+	SearchShoppingListItems(ctx context.Context, userID string, q ItemSearch, offset, count int64) ([]ShoppingListItem, error)
+}
+
+// PriceSubmitter records observed prices.
+type PriceSubmitter interface {
+	Submit(ctx context.Context, JWT string, price Price) (*Price, error)
+}
+
+// Manager manages users' shopping lists and their items.
+// Use NewManager() to instantiate.
+type Manager struct {
+	errors.ErrToHTTP
+
+	db     ShoppingListDB
+	jwter  JWTEr
+	prices PriceSubmitter
+}
+
+func NewManager(db ShoppingListDB, jwter JWTEr, prices PriceSubmitter) (*Manager, error) {
+	if db == nil {
+		return nil, errors.New("ShoppingListDB was nil")
+	}
+	if jwter == nil {
+		return nil, errors.New("JWTEr was nil")
+	}
+	if prices == nil {
+		return nil, errors.New("PriceSubmitter was nil")
+	}
+	return &Manager{db: db, jwter: jwter, prices: prices}, nil
+}
+
+// UserID returns the ID of the user JWT was issued to e.g. to rate limit
+// the user's requests.
+func (m *Manager) UserID(JWT string) (string, error) {
+	return userID(m.jwter, JWT)
+}
+
+// InsertShoppingList inserts a shopping list with name for the user owning
+// JWT if they do not already have one with that name. mode defaults to
+// ModePreparation.
+func (m *Manager) InsertShoppingList(ctx context.Context, JWT, name, mode string) (*ShoppingList, error) {
+	ctx, span := tracer.Start(ctx, "Manager.InsertShoppingList")
+	defer span.End()
+
+	usrID, err := userID(m.jwter, JWT)
+	if err != nil {
+		return nil, err
+	}
+	if name = CleanName(name); name == "" {
+		return nil, errors.NewClient("name was empty")
+	}
+	if mode == "" {
+		mode = ModePreparation
+	}
+	if mode, err = validMode(mode); err != nil {
+		return nil, err
+	}
+	return m.db.UpsertShoppingList(ctx, ShoppingList{UserID: usrID, Name: name, Mode: mode})
+}
+
+// UpdateShoppingList updates the name and/or mode of the shopping list
+// with shoppingListID owned by the user owning JWT.
+func (m *Manager) UpdateShoppingList(ctx context.Context, JWT, shoppingListID string, name, mode crdb.StringUpdate) (*ShoppingList, error) {
+	ctx, span := tracer.Start(ctx, "Manager.UpdateShoppingList")
+	defer span.End()
+
+	usrID, err := userID(m.jwter, JWT)
+	if err != nil {
+		return nil, err
+	}
+	if shoppingListID == "" {
+		return nil, errors.NewClient("shopping list ID was empty")
+	}
+	if !name.Updating && !mode.Updating {
+		return nil, errors.NewClient("nothing to update")
+	}
+	if name.Updating {
+		if name.NewVal = CleanName(name.NewVal); name.NewVal == "" {
+			return nil, errors.NewClient("name was empty")
+		}
+	}
+	if mode.Updating {
+		if mode.NewVal, err = validMode(mode.NewVal); err != nil {
+			return nil, err
+		}
+	}
+	return m.db.UpdateShoppingList(ctx, usrID, shoppingListID, name, mode)
+}
+
+// ShoppingLists returns the shopping lists of the user owning JWT.
+func (m *Manager) ShoppingLists(ctx context.Context, JWT string, offset, count int64) ([]ShoppingList, error) {
+	ctx, span := tracer.Start(ctx, "Manager.ShoppingLists")
+	defer span.End()
+
+	usrID, err := userID(m.jwter, JWT)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePaging(offset, count); err != nil {
+		return nil, err
+	}
+	return m.db.ShoppingLists(ctx, usrID, offset, count)
+}
+
+// UpsertShoppingListItem inserts or updates an item in a shopping list
+// owned by the user owning JWT. The item's brand is added to the shared
+// catalog if missing and a UnitPrice is submitted as a price observation
+// (see Prices.Submit()) that the item then refers to. Nothing is added to
+// the catalog or submitted if the user does not own the shopping list.
+func (m *Manager) UpsertShoppingListItem(ctx context.Context, JWT string, u ShoppingListItemUpsert) (*ShoppingListItem, error) {
+	ctx, span := tracer.Start(ctx, "Manager.UpsertShoppingListItem")
+	defer span.End()
+
+	usrID, err := userID(m.jwter, JWT)
+	if err != nil {
+		return nil, err
+	}
+	if u.ShoppingListID == "" {
+		return nil, errors.NewClient("shopping list ID was empty")
+	}
+	if u.ItemName = CleanName(u.ItemName); u.ItemName == "" {
+		return nil, errors.NewClient("item name was empty")
+	}
+	if u.Quantity < 0 {
+		return nil, errors.NewClient("quantity must not be less than 0")
+	}
+	if u.UnitPrice < 0 {
+		return nil, errors.NewClient("unit price must not be less than 0")
+	}
+	if u.BrandName = CleanName(u.BrandName); u.BrandName == "" {
+		u.BrandName = DefaultBrandName
+	}
+	if u.MeasuringUnit = CleanName(u.MeasuringUnit); u.MeasuringUnit == "" {
+		u.MeasuringUnit = DefaultMeasuringUnit
+	}
+	if _, err := m.db.ShoppingList(ctx, usrID, u.ShoppingListID); err != nil {
+		return nil, err
+	}
+
+	brands, err := m.db.UpsertBrands(ctx, []Brand{{
+		Name:          u.BrandName,
+		Item:          Item{Name: u.ItemName},
+		MeasuringUnit: MeasuringUnit{Name: u.MeasuringUnit},
+	}})
+	if err != nil {
+		return nil, errors.Newf("upsert brand: %v", err)
+	}
+
+	item := ShoppingListItem{
+		Quantity:     u.Quantity,
+		InList:       u.InList || u.InCart,
+		InCart:       u.InCart,
+		ShoppingList: ShoppingList{ID: u.ShoppingListID},
+		Price:        Price{Brand: brands[0]},
+	}
+	if u.UnitPrice > 0 {
+		p, err := m.prices.Submit(ctx, JWT, Price{
+			Value:         u.UnitPrice,
+			Currency:      u.Currency,
+			Brand:         brands[0],
+			AtStoreBranch: StoreBranch{ID: u.StoreBranchID},
+		})
+		if err != nil {
+			return nil, err
+		}
+		item.Price = *p
+	}
+	return m.db.UpsertShoppingListItem(ctx, usrID, item)
+}
+
+// DeleteShoppingListItem deletes the shopping list item with ID from a
+// shopping list owned by the user owning JWT. Prices are shared and remain
+// intact.
+func (m *Manager) DeleteShoppingListItem(ctx context.Context, JWT, ID string) error {
+	ctx, span := tracer.Start(ctx, "Manager.DeleteShoppingListItem")
+	defer span.End()
+
+	usrID, err := userID(m.jwter, JWT)
+	if err != nil {
+		return err
+	}
+	if ID == "" {
+		return errors.NewClient("shopping list item ID was empty")
+	}
+	return m.db.DeleteShoppingListItem(ctx, usrID, ID)
+}
+
+// ShoppingListItems returns the items in the shopping list with
+// shoppingListID owned by the user owning JWT.
+func (m *Manager) ShoppingListItems(ctx context.Context, JWT, shoppingListID string, offset, count int64) ([]ShoppingListItem, error) {
+	ctx, span := tracer.Start(ctx, "Manager.ShoppingListItems")
+	defer span.End()
+
+	usrID, err := userID(m.jwter, JWT)
+	if err != nil {
+		return nil, err
+	}
+	if shoppingListID == "" {
+		return nil, errors.NewClient("shopping list ID was empty")
+	}
+	if err := validatePaging(offset, count); err != nil {
+		return nil, err
+	}
+	return m.db.ShoppingListItems(ctx, usrID, shoppingListID, offset, count)
+}
+
+// SearchShoppingItems returns items in any of the shopping lists owned by
+// the user owning JWT that match q.
+func (m *Manager) SearchShoppingItems(ctx context.Context, JWT string, q ItemSearch, offset, count int64) ([]ShoppingListItem, error) {
+	ctx, span := tracer.Start(ctx, "Manager.SearchShoppingItems")
+	defer span.End()
+
+	usrID, err := userID(m.jwter, JWT)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePaging(offset, count); err != nil {
+		return nil, err
+	}
+	q.ItemName = strings.TrimSpace(q.ItemName)
+	q.BrandName = strings.TrimSpace(q.BrandName)
+	q.MeasuringUnit = strings.TrimSpace(q.MeasuringUnit)
+	return m.db.SearchShoppingListItems(ctx, usrID, q, offset, count)
+}
+
+func validMode(mode string) (string, error) {
+	switch m := strings.ToUpper(strings.TrimSpace(mode)); m {
+	case ModePreparation, ModeShopping:
+		return m, nil
+	default:
+		return "", errors.NewClientf("mode must be one of %s or %s, got '%s'",
+			ModePreparation, ModeShopping, mode)
+	}
+}
@@ -0,0 +1,302 @@
+package shopping_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tomogoma/crdb"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/mocks"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type shoppingListDB struct {
+	errors.NotFoundErrCheck
+
+	lists  []shopping.ShoppingList
+	brands []shopping.Brand
+	items  []shopping.ShoppingListItem
+}
+
+func (db *shoppingListDB) UpsertShoppingList(ctx context.Context, sl shopping.ShoppingList) (*shopping.ShoppingList, error) {
+	sl.ID = "list"
+	db.lists = append(db.lists, sl)
+	return &sl, nil
+}
+
+func (db *shoppingListDB) UpdateShoppingList(ctx context.Context, userID, ID string, name, mode crdb.StringUpdate) (*shopping.ShoppingList, error) {
+	sl := shopping.ShoppingList{ID: ID, UserID: userID}
+	if name.Updating {
+		sl.Name = name.NewVal
+	}
+	if mode.Updating {
+		sl.Mode = mode.NewVal
+	}
+	return &sl, nil
+}
+
+func (db *shoppingListDB) ShoppingLists(ctx context.Context, userID string, offset, count int64) ([]shopping.ShoppingList, error) {
+	return db.lists, nil
+}
+
+// ShoppingList only finds the shopping list with ID "list".
+func (db *shoppingListDB) ShoppingList(ctx context.Context, userID, ID string) (*shopping.ShoppingList, error) {
+	if ID != "list" {
+		return nil, errors.NewNotFoundf("shopping list %s not found", ID)
+	}
+	return &shopping.ShoppingList{ID: ID, UserID: userID}, nil
+}
+
+func (db *shoppingListDB) UpsertBrands(ctx context.Context, brands []shopping.Brand) ([]shopping.Brand, error) {
+	for i := range brands {
+		brands[i].ID = "brand"
+	}
+	db.brands = append(db.brands, brands...)
+	return brands, nil
+}
+
+func (db *shoppingListDB) UpsertShoppingListItem(ctx context.Context, userID string, item shopping.ShoppingListItem) (*shopping.ShoppingListItem, error) {
+	item.ID = "item"
+	db.items = append(db.items, item)
+	return &item, nil
+}
+
+func (db *shoppingListDB) DeleteShoppingListItem(ctx context.Context, userID, ID string) error {
+	return nil
+}
+
+func (db *shoppingListDB) ShoppingListItems(ctx context.Context, userID, shoppingListID string, offset, count int64) ([]shopping.ShoppingListItem, error) {
+	return db.items, nil
+}
+
+func (db *shoppingListDB) SearchShoppingListItems(ctx context.Context, userID string, q shopping.ItemSearch, offset, count int64) ([]shopping.ShoppingListItem, error) {
+	return db.items, nil
+}
+
+func TestNewManager(t *testing.T) {
+	prices := newPrices(t, &priceDB{}, &mocks.JWTEr{})
+	tt := []struct {
+		name   string
+		db     shopping.ShoppingListDB
+		jwter  shopping.JWTEr
+		prices shopping.PriceSubmitter
+		expErr bool
+	}{
+		{name: "valid", db: &shoppingListDB{}, jwter: &mocks.JWTEr{}, prices: prices},
+		{name: "nil db", jwter: &mocks.JWTEr{}, prices: prices, expErr: true},
+		{name: "nil jwter", db: &shoppingListDB{}, prices: prices, expErr: true},
+		{name: "nil prices", db: &shoppingListDB{}, jwter: &mocks.JWTEr{}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := shopping.NewManager(tc.db, tc.jwter, tc.prices)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if m == nil {
+				t.Fatalf("Got nil *shopping.Manager")
+			}
+		})
+	}
+}
+
+func TestManager_UserID(t *testing.T) {
+	tt := []struct {
+		name     string
+		jwter    *mocks.JWTEr
+		expUsrID string
+		expErr   bool
+	}{
+		{name: "valid", jwter: &mocks.JWTEr{ExpValidateUsrID: "usr1"}, expUsrID: "usr1"},
+		{name: "no user", jwter: &mocks.JWTEr{}, expErr: true},
+		{name: "bad JWT", jwter: &mocks.JWTEr{ExpValidateErr: errors.New("bad token")}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newManager(t, &shoppingListDB{}, tc.jwter, &priceDB{})
+			usrID, err := m.UserID("jwt")
+			if tc.expErr {
+				if !(errors.AuthErrCheck{}).IsUnauthorizedError(err) {
+					t.Fatalf("Expected an unauthorized error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if usrID != tc.expUsrID {
+				t.Errorf("Expected user ID %s, got %s", tc.expUsrID, usrID)
+			}
+		})
+	}
+}
+
+func TestManager_InsertShoppingList(t *testing.T) {
+	tt := []struct {
+		name    string
+		jwter   *mocks.JWTEr
+		listNm  string
+		mode    string
+		expMode string
+		expErr  bool
+	}{
+		{name: "default mode", jwter: &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			listNm: " Groceries ", expMode: shopping.ModePreparation},
+		{name: "mode normalized", jwter: &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			listNm: "Groceries", mode: "shopping", expMode: shopping.ModeShopping},
+		{name: "bad mode", jwter: &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			listNm: "Groceries", mode: "cooking", expErr: true},
+		{name: "empty name", jwter: &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			listNm: "  ", expErr: true},
+		{name: "bad JWT", jwter: &mocks.JWTEr{ExpValidateErr: errors.New("bad token")},
+			listNm: "Groceries", expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newManager(t, &shoppingListDB{}, tc.jwter, &priceDB{})
+			sl, err := m.InsertShoppingList(context.TODO(), "jwt", tc.listNm, tc.mode)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if sl.UserID != "usr1" || sl.Name != "Groceries" || sl.Mode != tc.expMode {
+				t.Errorf("Expected usr1's Groceries list in mode %s, got %+v",
+					tc.expMode, sl)
+			}
+		})
+	}
+}
+
+func TestManager_UpsertShoppingListItem(t *testing.T) {
+	tt := []struct {
+		name        string
+		upsert      shopping.ShoppingListItemUpsert
+		expBrand    shopping.Brand
+		expInList   bool
+		expPriceSub bool
+		expErr      bool
+		expNotFound bool
+	}{
+		{
+			name:   "defaults",
+			upsert: shopping.ShoppingListItemUpsert{ShoppingListID: "list", ItemName: "Salt", Quantity: 1},
+			expBrand: shopping.Brand{
+				ID:            "brand",
+				Name:          shopping.DefaultBrandName,
+				Item:          shopping.Item{Name: "Salt"},
+				MeasuringUnit: shopping.MeasuringUnit{Name: shopping.DefaultMeasuringUnit},
+			},
+		},
+		{
+			name: "in cart with price",
+			upsert: shopping.ShoppingListItemUpsert{
+				ShoppingListID: "list",
+				ItemName:       "Toothpaste",
+				BrandName:      "Colgate",
+				MeasuringUnit:  "100 ml",
+				Quantity:       2,
+				InCart:         true,
+				UnitPrice:      200,
+			},
+			expBrand: shopping.Brand{
+				ID:            "brand",
+				Name:          "Colgate",
+				Item:          shopping.Item{Name: "Toothpaste"},
+				MeasuringUnit: shopping.MeasuringUnit{Name: "100 ml"},
+			},
+			expInList:   true,
+			expPriceSub: true,
+		},
+		{
+			name:   "missing shopping list",
+			upsert: shopping.ShoppingListItemUpsert{ItemName: "Salt"},
+			expErr: true,
+		},
+		{
+			name:   "missing item name",
+			upsert: shopping.ShoppingListItemUpsert{ShoppingListID: "list"},
+			expErr: true,
+		},
+		{
+			name: "another user's shopping list",
+			upsert: shopping.ShoppingListItemUpsert{ShoppingListID: "others",
+				ItemName: "Salt", Quantity: 1, UnitPrice: 50},
+			expErr:      true,
+			expNotFound: true,
+		},
+		{
+			name:   "negative quantity",
+			upsert: shopping.ShoppingListItemUpsert{ShoppingListID: "list", ItemName: "Salt", Quantity: -1},
+			expErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			db := &shoppingListDB{}
+			pdb := &priceDB{}
+			m := newManager(t, db, &mocks.JWTEr{ExpValidateUsrID: "usr1"}, pdb)
+			item, err := m.UpsertShoppingListItem(context.TODO(), "jwt", tc.upsert)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				if tc.expNotFound && !db.IsNotFoundError(err) {
+					t.Errorf("Expected a not found error, got %v", err)
+				}
+				if !tc.expNotFound && !(errors.ClErrCheck{}).IsClientError(err) {
+					t.Errorf("Expected a client error, got %v", err)
+				}
+				if len(db.brands) > 0 || len(pdb.inserted) > 0 {
+					t.Errorf("Expected nothing stored, got brands %+v and prices %+v",
+						db.brands, pdb.inserted)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if item.Price.Brand.ID != tc.expBrand.ID || item.Price.Brand.Name != tc.expBrand.Name ||
+				item.Price.Brand.Item != tc.expBrand.Item ||
+				item.Price.Brand.MeasuringUnit != tc.expBrand.MeasuringUnit {
+				t.Errorf("Expected brand %+v, got %+v", tc.expBrand, item.Price.Brand)
+			}
+			if item.InList != tc.expInList {
+				t.Errorf("Expected inList %t, got %t", tc.expInList, item.InList)
+			}
+			if subm := len(pdb.inserted) > 0; subm != tc.expPriceSub {
+				t.Fatalf("Expected price submitted %t, got %t", tc.expPriceSub, subm)
+			}
+			if tc.expPriceSub && (item.Price.ID != "new" || pdb.inserted[0].SubmittedBy != "usr1") {
+				t.Errorf("Expected item to refer to price submitted by usr1, got %+v",
+					item.Price)
+			}
+		})
+	}
+}
+
+func newManager(t *testing.T, db shopping.ShoppingListDB, jwter shopping.JWTEr, pdb shopping.PriceDB) *shopping.Manager {
+	m, err := shopping.NewManager(db, jwter, newPrices(t, pdb, jwter))
+	if err != nil {
+		t.Fatalf("Error setting up: new manager: %v", err)
+	}
+	return m
+}
+
+func newPrices(t *testing.T, db shopping.PriceDB, jwter shopping.JWTEr) *shopping.Prices {
+	p, err := shopping.NewPrices(db, jwter)
+	if err != nil {
+		t.Fatalf("Error setting up: new prices: %v", err)
+	}
+	return p
+}
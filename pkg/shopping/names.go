@@ -0,0 +1,23 @@
+package shopping
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeName returns name in a canonical form suitable for comparing
+// free text names of catalog entities e.g. " Colgate  Total" and
+// "colgate total" both yield "colgate total".
+func NormalizeName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), isNameSeparator), " ")
+}
+
+// CleanName trims name and collapses repeated white space without changing
+// case, it is used to tidy user or import supplied names before storage.
+func CleanName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func isNameSeparator(r rune) bool {
+	return unicode.IsSpace(r) || r == '_'
+}
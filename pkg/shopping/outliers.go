@@ -0,0 +1,66 @@
+package shopping
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultOutlierThreshold is the OutlierScore above which a submitted
+	// price is held for moderation.
+	DefaultOutlierThreshold = 3.5
+
+	// MinOutlierHistory is the minimum number of approved observations
+	// needed to score a price. Prices with less history are not scored.
+	MinOutlierHistory = 3
+
+	// minRelativeSpread is the smallest spread, as a fraction of the median,
+	// assumed of price history. It keeps a history of identical prices
+	// from flagging every small change.
+	minRelativeSpread = 0.05
+
+	// madToStdDev scales the median absolute deviation to be comparable
+	// with the standard deviation of normally distributed values.
+	madToStdDev = 1.4826
+)
+
+// OutlierScore returns how many (robust) standard deviations value is
+// from the median of history using the modified z-score
+// |value - median| / (1.4826 * MAD) where MAD is the median absolute
+// deviation of history. The median and MAD are not skewed by the very
+// outliers being detected, unlike the mean and standard deviation.
+// ok is false if history has fewer than MinOutlierHistory values, in
+// which case value cannot be judged.
+func OutlierScore(value float64, history []float64) (score float64, ok bool) {
+	if len(history) < MinOutlierHistory {
+		return 0, false
+	}
+	med := median(history)
+	deviations := make([]float64, len(history))
+	for i, h := range history {
+		deviations[i] = math.Abs(h - med)
+	}
+	spread := madToStdDev * median(deviations)
+	if minSpread := minRelativeSpread * math.Abs(med); spread < minSpread {
+		spread = minSpread
+	}
+	if spread == 0 {
+		// All history is 0 (free), any non-zero price is suspicious.
+		if value == 0 {
+			return 0, true
+		}
+		return math.MaxFloat64, true
+	}
+	return math.Abs(value-med) / spread, true
+}
+
+func median(vals []float64) float64 {
+	sorted := make([]float64, len(vals))
+	copy(sorted, vals)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
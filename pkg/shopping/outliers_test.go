@@ -0,0 +1,39 @@
+package shopping_test
+
+import (
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestOutlierScore(t *testing.T) {
+	tt := []struct {
+		name      string
+		value     float64
+		history   []float64
+		expOK     bool
+		isOutlier bool
+	}{
+		{name: "too little history", value: 20000, history: []float64{200, 210}, expOK: false},
+		{name: "typical", value: 205, history: []float64{200, 210, 195, 205, 199}, expOK: true},
+		{name: "decimal slip", value: 20000, history: []float64{200, 210, 195, 205, 199}, expOK: true, isOutlier: true},
+		{name: "too cheap", value: 20, history: []float64{200, 210, 195, 205, 199}, expOK: true, isOutlier: true},
+		{name: "outlier in history", value: 205, history: []float64{200, 210, 20000, 205, 199}, expOK: true},
+		{name: "identical history small change", value: 210, history: []float64{200, 200, 200}, expOK: true},
+		{name: "identical history big change", value: 400, history: []float64{200, 200, 200}, expOK: true, isOutlier: true},
+		{name: "free history", value: 50, history: []float64{0, 0, 0}, expOK: true, isOutlier: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			score, ok := shopping.OutlierScore(tc.value, tc.history)
+			if ok != tc.expOK {
+				t.Fatalf("Expected ok %t, got %t", tc.expOK, ok)
+			}
+			isOutlier := score > shopping.DefaultOutlierThreshold
+			if isOutlier != tc.isOutlier {
+				t.Errorf("Expected outlier %t, got %t (score %f)",
+					tc.isOutlier, isOutlier, score)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package shopping
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+const (
+	// DefaultCurrency is assumed for submitted prices that have none.
+	DefaultCurrency = "KES"
+
+	// DefaultHistoryWindow is how far back approved prices are considered
+	// when scoring a submitted price.
+	DefaultHistoryWindow = 90 * 24 * time.Hour
+
+	// DefaultHistoryLimit is the maximum number of the most recent approved
+	// prices considered when scoring a submitted price.
+	DefaultHistoryLimit = 50
+
+	// DefaultAgreementWindow is how far back prices of other users are
+	// confirmed or contradicted by a new observation. It is shorter than
+	// DefaultHistoryWindow since prices legitimately change over time.
+	DefaultAgreementWindow = 14 * 24 * time.Hour
+)
+
+// PriceDB persists price observations.
+type PriceDB interface {
+	IsNotFoundError(error) bool
+	// InsertPrice inserts p and records v in one transaction.
+	InsertPrice(ctx context.Context, p Price, v Verdicts) (*Price, error)
+	PriceByID(ctx context.Context, ID string) (*Price, error)
+	// RecentApprovedPrices returns the latest (up to limit) approved prices
+	// of brandID in currency that were observed since. Prices observed at
+	// any store branch are included if storeBranchID is empty.
+	RecentApprovedPrices(ctx context.Context, brandID, storeBranchID, currency string, since time.Time, limit int) ([]Price, error)
+	PricesByStatus(ctx context.Context, status string, offset, count int64) ([]Price, error)
+	// UpdatePriceStatus sets the status of the price with priceID,
+	// reverses the verdicts it previously cast and records v in one
+	// transaction. A price that already has status is returned unchanged
+	// without recording v.
+	UpdatePriceStatus(ctx context.Context, priceID, status string, v Verdicts) (*Price, error)
+	Contributors(ctx context.Context, userIDs []string) ([]Contributor, error)
+	// ContributorsByTrust returns contributors ordered by TrustScore,
+	// most trusted first.
+	ContributorsByTrust(ctx context.Context, offset, count int64) ([]Contributor, error)
+}
+
+// Prices accepts shared price observations from users, holding those that
+// are suspiciously far from recent history for moderation. Each approved
+// observation confirms or contradicts recent observations of other users
+// at the same store branch, building each contributor's trust score.
+// Use NewPrices() to instantiate.
+type Prices struct {
+	errors.ErrToHTTP
+
+	db               PriceDB
+	jwter            JWTEr
+	outlierThreshold float64
+	historyWindow    time.Duration
+	historyLimit     int
+	tolerance        float64
+	agreementWindow  time.Duration
+}
+
+// PricesOption configures Prices during instantiation.
+type PricesOption func(*Prices)
+
+// WithOutlierThreshold sets the OutlierScore above which submitted prices
+// are held for moderation. Defaults to DefaultOutlierThreshold.
+func WithOutlierThreshold(threshold float64) PricesOption {
+	return func(p *Prices) {
+		p.outlierThreshold = threshold
+	}
+}
+
+// WithHistoryWindow sets how far back approved prices are considered when
+// scoring a submitted price. Defaults to DefaultHistoryWindow.
+func WithHistoryWindow(window time.Duration) PricesOption {
+	return func(p *Prices) {
+		p.historyWindow = window
+	}
+}
+
+// WithHistoryLimit sets the maximum number of recent approved prices
+// considered when scoring a submitted price. Defaults to
+// DefaultHistoryLimit.
+func WithHistoryLimit(limit int) PricesOption {
+	return func(p *Prices) {
+		p.historyLimit = limit
+	}
+}
+
+// WithAgreementTolerance sets the maximum relative difference between two
+// observations for one to confirm the other. Defaults to
+// DefaultAgreementTolerance.
+func WithAgreementTolerance(tolerance float64) PricesOption {
+	return func(p *Prices) {
+		p.tolerance = tolerance
+	}
+}
+
+// WithAgreementWindow sets how far back prices of other users are
+// confirmed or contradicted by a new observation. Defaults to
+// DefaultAgreementWindow.
+func WithAgreementWindow(window time.Duration) PricesOption {
+	return func(p *Prices) {
+		p.agreementWindow = window
+	}
+}
+
+func NewPrices(db PriceDB, jwter JWTEr, opts ...PricesOption) (*Prices, error) {
+	if db == nil {
+		return nil, errors.New("PriceDB was nil")
+	}
+	if jwter == nil {
+		return nil, errors.New("JWTEr was nil")
+	}
+	p := &Prices{
+		db:               db,
+		jwter:            jwter,
+		outlierThreshold: DefaultOutlierThreshold,
+		historyWindow:    DefaultHistoryWindow,
+		historyLimit:     DefaultHistoryLimit,
+		tolerance:        DefaultAgreementTolerance,
+		agreementWindow:  DefaultAgreementWindow,
+	}
+	for _, f := range opts {
+		f(p)
+	}
+	if p.outlierThreshold <= 0 {
+		return nil, errors.New("outlier threshold must be greater than 0")
+	}
+	if p.historyLimit < MinOutlierHistory {
+		return nil, errors.Newf("history limit must be at least %d", MinOutlierHistory)
+	}
+	if p.tolerance < 0 || p.tolerance >= 1 {
+		return nil, errors.New("agreement tolerance must be in the range [0, 1)")
+	}
+	return p, nil
+}
+
+// Submit records price as observed by the user owning JWT. price.Brand.ID
+// is required, price.AtStoreBranch.ID is optional.
+// The price is scored against recent approved prices of the same brand at
+// the same store branch, or at any store branch if the store branch has
+// too little history. Prices whose score exceeds the outlier threshold are
+// saved with PriceStatusPending and left out of price calculations until
+// approved, all others are saved with PriceStatusApproved and immediately
+// confirm or contradict recent prices of other users at the same store
+// branch.
+func (p *Prices) Submit(ctx context.Context, JWT string, price Price) (*Price, error) {
+	ctx, span := tracer.Start(ctx, "Prices.Submit")
+	defer span.End()
+
+	usrID, err := userID(p.jwter, JWT)
+	if err != nil {
+		return nil, err
+	}
+	if price.Brand.ID == "" {
+		return nil, errors.NewClient("brand ID was empty")
+	}
+	value := float64(price.Value)
+	if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+		return nil, errors.NewClient("price value must be a number not less than 0")
+	}
+	if price.Currency, err = validCurrency(price.Currency); err != nil {
+		return nil, err
+	}
+
+	score, err := p.outlierScore(ctx, value, price.Brand.ID,
+		price.AtStoreBranch.ID, price.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	price.SubmittedBy = usrID
+	price.OutlierScore = score
+	price.Status = PriceStatusApproved
+	if score > p.outlierThreshold {
+		price.Status = PriceStatusPending
+	}
+	var v Verdicts
+	if price.Status == PriceStatusApproved {
+		if v, err = p.judgePrior(ctx, price); err != nil {
+			return nil, err
+		}
+	}
+	saved, err := p.db.InsertPrice(ctx, price, v)
+	if err != nil {
+		return nil, errors.Newf("insert price: %v", err)
+	}
+	return saved, nil
+}
+
+// ModerationQueue returns prices pending moderation, oldest first.
+func (p *Prices) ModerationQueue(ctx context.Context, offset, count int64) ([]Price, error) {
+	ctx, span := tracer.Start(ctx, "Prices.ModerationQueue")
+	defer span.End()
+
+	if err := validatePaging(offset, count); err != nil {
+		return nil, err
+	}
+	return p.db.PricesByStatus(ctx, PriceStatusPending, offset, count)
+}
+
+// Approve marks the price with priceID as approved so that it is used in
+// price calculations. A price that was not already approved then confirms
+// or contradicts recent prices of other users as if it was just submitted.
+func (p *Prices) Approve(ctx context.Context, priceID string) (*Price, error) {
+	ctx, span := tracer.Start(ctx, "Prices.Approve")
+	defer span.End()
+
+	if priceID == "" {
+		return nil, errors.NewClient("price ID was empty")
+	}
+	price, err := p.db.PriceByID(ctx, priceID)
+	if err != nil {
+		return nil, err
+	}
+	if price.Status == PriceStatusApproved {
+		return price, nil
+	}
+	v, err := p.judgePrior(ctx, *price)
+	if err != nil {
+		return nil, err
+	}
+	return p.db.UpdatePriceStatus(ctx, priceID, PriceStatusApproved, v)
+}
+
+// Reject marks the price with priceID as rejected so that it is never used
+// in price calculations. The confirmations and contradictions an approved
+// price cast on earlier prices are reversed.
+func (p *Prices) Reject(ctx context.Context, priceID string) (*Price, error) {
+	ctx, span := tracer.Start(ctx, "Prices.Reject")
+	defer span.End()
+
+	if priceID == "" {
+		return nil, errors.NewClient("price ID was empty")
+	}
+	return p.db.UpdatePriceStatus(ctx, priceID, PriceStatusRejected, Verdicts{})
+}
+
+// CurrentPrice returns the trust-weighted median of the recent approved
+// prices of the brand with brandID in currency at the store branch with
+// storeBranchID (or at any store branch if storeBranchID is empty) so that
+// prices from trusted contributors count more. The returned price is one
+// of the recent observations.
+func (p *Prices) CurrentPrice(ctx context.Context, brandID, storeBranchID, currency string) (*Price, error) {
+	ctx, span := tracer.Start(ctx, "Prices.CurrentPrice")
+	defer span.End()
+
+	if brandID == "" {
+		return nil, errors.NewClient("brand ID was empty")
+	}
+	currency, err := validCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := p.db.RecentApprovedPrices(ctx, brandID, storeBranchID, currency,
+		time.Now().Add(-p.historyWindow), p.historyLimit)
+	if err != nil {
+		return nil, err
+	}
+	trust, err := p.trust(ctx, recent)
+	if err != nil {
+		return nil, err
+	}
+	current := trustWeightedMedian(recent, trust)
+	return &current, nil
+}
+
+// Contributors returns contributors ordered by trust, most trusted first.
+func (p *Prices) Contributors(ctx context.Context, offset, count int64) ([]Contributor, error) {
+	ctx, span := tracer.Start(ctx, "Prices.Contributors")
+	defer span.End()
+
+	if err := validatePaging(offset, count); err != nil {
+		return nil, err
+	}
+	return p.db.ContributorsByTrust(ctx, offset, count)
+}
+
+// judgePrior returns whether observation, once approved, confirms or
+// contradicts recent approved prices of other users at the same store
+// branch. Prices at different store branches legitimately differ so
+// observations without a store branch judge nothing.
+func (p *Prices) judgePrior(ctx context.Context, observation Price) (Verdicts, error) {
+	if observation.AtStoreBranch.ID == "" {
+		return Verdicts{}, nil
+	}
+	prior, err := p.db.RecentApprovedPrices(ctx, observation.Brand.ID,
+		observation.AtStoreBranch.ID, observation.Currency,
+		time.Now().Add(-p.agreementWindow), p.historyLimit)
+	if err != nil {
+		if p.db.IsNotFoundError(err) {
+			return Verdicts{}, nil
+		}
+		return Verdicts{}, errors.Newf("get prior prices: %v", err)
+	}
+	return verdicts(observation, prior, p.tolerance), nil
+}
+
+// trust returns the trust scores of the submitters of prices mapped by
+// user ID.
+func (p *Prices) trust(ctx context.Context, prices []Price) (map[string]float64, error) {
+	var usrIDs []string
+	seen := make(map[string]bool)
+	for _, price := range prices {
+		if !seen[price.SubmittedBy] {
+			seen[price.SubmittedBy] = true
+			usrIDs = append(usrIDs, price.SubmittedBy)
+		}
+	}
+	cs, err := p.db.Contributors(ctx, usrIDs)
+	if err != nil && !p.db.IsNotFoundError(err) {
+		return nil, errors.Newf("get contributors: %v", err)
+	}
+	trust := make(map[string]float64)
+	for _, c := range cs {
+		trust[c.UserID] = c.Trust
+	}
+	return trust, nil
+}
+
+func (p *Prices) outlierScore(ctx context.Context, value float64, brandID, storeBranchID, currency string) (float64, error) {
+	since := time.Now().Add(-p.historyWindow)
+	storeBranchIDs := []string{""}
+	if storeBranchID != "" {
+		storeBranchIDs = []string{storeBranchID, ""}
+	}
+	for _, sbID := range storeBranchIDs {
+		recent, err := p.db.RecentApprovedPrices(ctx, brandID, sbID, currency,
+			since, p.historyLimit)
+		if err != nil && !p.db.IsNotFoundError(err) {
+			return 0, errors.Newf("get price history: %v", err)
+		}
+		history := make([]float64, len(recent))
+		for i, r := range recent {
+			history[i] = float64(r.Value)
+		}
+		if score, ok := OutlierScore(value, history); ok {
+			return score, nil
+		}
+	}
+	return 0, nil
+}
+
+func validCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency, nil
+	}
+	if len(currency) != 3 {
+		return "", errors.NewClientf("invalid ISO 4217 currency code '%s'", currency)
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return "", errors.NewClientf("invalid ISO 4217 currency code '%s'", currency)
+		}
+	}
+	return currency, nil
+}
+
+func validatePaging(offset, count int64) error {
+	if offset < 0 {
+		return errors.NewClient("offset must not be less than 0")
+	}
+	if count < 1 {
+		return errors.NewClient("count must be greater than 0")
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+package shopping_test
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/mocks"
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+type priceDB struct {
+	errors.NotFoundErrCheck
+
+	// history is keyed by store branch ID, "" holds brand-wide history.
+	history      map[string][]shopping.Price
+	trust        map[string]float64
+	inserted     []shopping.Price
+	statuses     map[string]string
+	confirmed    []string
+	contradicted []string
+	// cast holds the verdicts recorded by each price ID.
+	cast map[string]shopping.Verdicts
+}
+
+func (db *priceDB) InsertPrice(ctx context.Context, p shopping.Price, v shopping.Verdicts) (*shopping.Price, error) {
+	p.ID = "new"
+	p.Created = time.Now()
+	db.inserted = append(db.inserted, p)
+	db.recordVerdicts(p.ID, v)
+	return &p, nil
+}
+
+func (db *priceDB) PriceByID(ctx context.Context, ID string) (*shopping.Price, error) {
+	for _, p := range db.history[""] {
+		if p.ID == ID {
+			return &p, nil
+		}
+	}
+	return nil, errors.NewNotFound("price not found")
+}
+
+func (db *priceDB) RecentApprovedPrices(ctx context.Context, brandID, storeBranchID, currency string, since time.Time, limit int) ([]shopping.Price, error) {
+	h, ok := db.history[storeBranchID]
+	if !ok {
+		return nil, errors.NewNotFound("no prices")
+	}
+	return h, nil
+}
+
+func (db *priceDB) PricesByStatus(ctx context.Context, status string, offset, count int64) ([]shopping.Price, error) {
+	return nil, errors.NewNotFound("no prices")
+}
+
+func (db *priceDB) UpdatePriceStatus(ctx context.Context, priceID, status string, v shopping.Verdicts) (*shopping.Price, error) {
+	if db.statuses == nil {
+		db.statuses = make(map[string]string)
+	}
+	p, err := db.PriceByID(ctx, priceID)
+	if err != nil {
+		p = &shopping.Price{ID: priceID}
+	}
+	if db.statuses[priceID] == status {
+		p.Status = status
+		return p, nil
+	}
+	db.statuses[priceID] = status
+	p.Status = status
+	db.revokeVerdicts(priceID)
+	db.recordVerdicts(priceID, v)
+	return p, nil
+}
+
+func (db *priceDB) recordVerdicts(priceID string, v shopping.Verdicts) {
+	if db.cast == nil {
+		db.cast = make(map[string]shopping.Verdicts)
+	}
+	db.cast[priceID] = v
+	db.confirmed = append(db.confirmed, v.Confirmed...)
+	db.contradicted = append(db.contradicted, v.Contradicted...)
+}
+
+func (db *priceDB) revokeVerdicts(priceID string) {
+	v := db.cast[priceID]
+	db.confirmed = removeEach(db.confirmed, v.Confirmed)
+	db.contradicted = removeEach(db.contradicted, v.Contradicted)
+	delete(db.cast, priceID)
+}
+
+// removeEach removes one occurrence of each of rm from IDs.
+func removeEach(IDs, rm []string) []string {
+	for _, r := range rm {
+		for i, ID := range IDs {
+			if ID == r {
+				IDs = append(IDs[:i:i], IDs[i+1:]...)
+				break
+			}
+		}
+	}
+	return IDs
+}
+
+func (db *priceDB) Contributors(ctx context.Context, userIDs []string) ([]shopping.Contributor, error) {
+	var cs []shopping.Contributor
+	for _, usrID := range userIDs {
+		if trust, ok := db.trust[usrID]; ok {
+			cs = append(cs, shopping.Contributor{UserID: usrID, Trust: trust})
+		}
+	}
+	if len(cs) == 0 {
+		return nil, errors.NewNotFound("no contributors")
+	}
+	return cs, nil
+}
+
+func (db *priceDB) ContributorsByTrust(ctx context.Context, offset, count int64) ([]shopping.Contributor, error) {
+	return nil, errors.NewNotFound("no contributors")
+}
+
+func TestNewPrices(t *testing.T) {
+	tt := []struct {
+		name   string
+		db     shopping.PriceDB
+		jwter  shopping.JWTEr
+		opts   []shopping.PricesOption
+		expErr bool
+	}{
+		{name: "valid", db: &priceDB{}, jwter: &mocks.JWTEr{}},
+		{name: "nil db", jwter: &mocks.JWTEr{}, expErr: true},
+		{name: "nil jwter", db: &priceDB{}, expErr: true},
+		{
+			name: "bad threshold", db: &priceDB{}, jwter: &mocks.JWTEr{},
+			opts:   []shopping.PricesOption{shopping.WithOutlierThreshold(0)},
+			expErr: true,
+		},
+		{
+			name: "bad history limit", db: &priceDB{}, jwter: &mocks.JWTEr{},
+			opts:   []shopping.PricesOption{shopping.WithHistoryLimit(1)},
+			expErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := shopping.NewPrices(tc.db, tc.jwter, tc.opts...)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if p == nil {
+				t.Fatalf("Got nil *shopping.Prices")
+			}
+		})
+	}
+}
+
+func TestPrices_Submit(t *testing.T) {
+	history := priceHistory("usr2", 200, 210, 195, 205, 199)
+	tt := []struct {
+		name      string
+		history   map[string][]shopping.Price
+		jwter     *mocks.JWTEr
+		price     shopping.Price
+		expStatus string
+		expCur    string
+		expErr    bool
+		expClErr  bool
+		expAthErr bool
+	}{
+		{
+			name:      "typical at branch",
+			history:   map[string][]shopping.Price{"sb1": history},
+			jwter:     &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			price:     newPrice(205, "kes", "sb1"),
+			expStatus: shopping.PriceStatusApproved,
+			expCur:    "KES",
+		},
+		{
+			name:      "outlier at branch",
+			history:   map[string][]shopping.Price{"sb1": history},
+			jwter:     &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			price:     newPrice(20000, "KES", "sb1"),
+			expStatus: shopping.PriceStatusPending,
+			expCur:    "KES",
+		},
+		{
+			name:      "outlier against brand-wide history",
+			history:   map[string][]shopping.Price{"": history},
+			jwter:     &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			price:     newPrice(20000, "", "sb1"),
+			expStatus: shopping.PriceStatusPending,
+			expCur:    shopping.DefaultCurrency,
+		},
+		{
+			name:      "no history",
+			jwter:     &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			price:     newPrice(20000, "KES", ""),
+			expStatus: shopping.PriceStatusApproved,
+			expCur:    "KES",
+		},
+		{
+			name:      "invalid JWT",
+			jwter:     &mocks.JWTEr{ExpValidateErr: errors.New("expired")},
+			price:     newPrice(200, "KES", ""),
+			expErr:    true,
+			expAthErr: true,
+		},
+		{
+			name:     "missing brand",
+			jwter:    &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			price:    shopping.Price{Value: 200},
+			expErr:   true,
+			expClErr: true,
+		},
+		{
+			name:     "negative value",
+			jwter:    &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			price:    newPrice(-1, "KES", ""),
+			expErr:   true,
+			expClErr: true,
+		},
+		{
+			name:     "bad currency",
+			jwter:    &mocks.JWTEr{ExpValidateUsrID: "usr1"},
+			price:    newPrice(200, "shilling", ""),
+			expErr:   true,
+			expClErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			db := &priceDB{history: tc.history}
+			p, err := shopping.NewPrices(db, tc.jwter)
+			if err != nil {
+				t.Fatalf("shopping.NewPrices(): %v", err)
+			}
+			got, err := p.Submit(context.TODO(), "a.jwt", tc.price)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				if tc.expClErr && !(errors.ClErrCheck{}).IsClientError(err) {
+					t.Errorf("Expected a client error, got %v", err)
+				}
+				if tc.expAthErr && !(errors.AuthErrCheck{}).IsUnauthorizedError(err) {
+					t.Errorf("Expected an unauthorized error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			if got.Status != tc.expStatus {
+				t.Errorf("Expected status %s, got %s", tc.expStatus, got.Status)
+			}
+			if got.Currency != tc.expCur {
+				t.Errorf("Expected currency %s, got %s", tc.expCur, got.Currency)
+			}
+			if got.SubmittedBy != tc.jwter.ExpValidateUsrID {
+				t.Errorf("Expected submitted by %s, got %s",
+					tc.jwter.ExpValidateUsrID, got.SubmittedBy)
+			}
+			if len(db.inserted) != 1 {
+				t.Errorf("Expected 1 inserted price, got %d", len(db.inserted))
+			}
+		})
+	}
+}
+
+func TestPrices_moderate(t *testing.T) {
+	db := &priceDB{history: map[string][]shopping.Price{"": {
+		{ID: "1", Status: shopping.PriceStatusPending},
+	}}}
+	p, err := shopping.NewPrices(db, &mocks.JWTEr{})
+	if err != nil {
+		t.Fatalf("shopping.NewPrices(): %v", err)
+	}
+	if _, err := p.Approve(context.TODO(), "1"); err != nil {
+		t.Fatalf("Approve(): %v", err)
+	}
+	if _, err := p.Reject(context.TODO(), "2"); err != nil {
+		t.Fatalf("Reject(): %v", err)
+	}
+	if _, err := p.Approve(context.TODO(), ""); !(errors.ClErrCheck{}).IsClientError(err) {
+		t.Errorf("Expected a client error for empty ID, got %v", err)
+	}
+	if db.statuses["1"] != shopping.PriceStatusApproved {
+		t.Errorf("Expected price 1 approved, got %s", db.statuses["1"])
+	}
+	if db.statuses["2"] != shopping.PriceStatusRejected {
+		t.Errorf("Expected price 2 rejected, got %s", db.statuses["2"])
+	}
+}
+
+func TestPrices_Approve_twice(t *testing.T) {
+	now := time.Now()
+	pending := shopping.Price{ID: "5", Value: 200, SubmittedBy: "usr1",
+		Status: shopping.PriceStatusPending, AtStoreBranch: shopping.StoreBranch{ID: "sb1"}}
+	db := &priceDB{history: map[string][]shopping.Price{
+		"":    {pending},
+		"sb1": {{ID: "1", Value: 200, SubmittedBy: "usr2", Created: now.Add(-time.Hour)}},
+	}}
+	p, err := shopping.NewPrices(db, &mocks.JWTEr{})
+	if err != nil {
+		t.Fatalf("shopping.NewPrices(): %v", err)
+	}
+	// PriceByID keeps reporting the price as pending, as it would to
+	// concurrent moderators, so both approvals get past the status check.
+	for i := 0; i < 2; i++ {
+		if _, err := p.Approve(context.TODO(), pending.ID); err != nil {
+			t.Fatalf("Approve() #%d: %v", i, err)
+		}
+	}
+	if !reflect.DeepEqual(db.confirmed, []string{"1"}) {
+		t.Errorf("Expected price 1 confirmed once, got %v", db.confirmed)
+	}
+}
+
+func TestPrices_Reject_approved(t *testing.T) {
+	now := time.Now()
+	pending := shopping.Price{ID: "5", Value: 200, SubmittedBy: "usr1",
+		Status: shopping.PriceStatusPending, AtStoreBranch: shopping.StoreBranch{ID: "sb1"}}
+	db := &priceDB{history: map[string][]shopping.Price{
+		"": {pending},
+		"sb1": {
+			{ID: "1", Value: 200, SubmittedBy: "usr2", Created: now.Add(-time.Hour)},
+			{ID: "3", Value: 300, SubmittedBy: "usr3", Created: now.Add(-time.Hour)},
+		},
+	}}
+	p, err := shopping.NewPrices(db, &mocks.JWTEr{})
+	if err != nil {
+		t.Fatalf("shopping.NewPrices(): %v", err)
+	}
+	if _, err := p.Approve(context.TODO(), pending.ID); err != nil {
+		t.Fatalf("Approve(): %v", err)
+	}
+	if !reflect.DeepEqual(db.confirmed, []string{"1"}) ||
+		!reflect.DeepEqual(db.contradicted, []string{"3"}) {
+		t.Fatalf("Error setting up: expected [1] confirmed and [3] contradicted, got %v and %v",
+			db.confirmed, db.contradicted)
+	}
+	rejected, err := p.Reject(context.TODO(), pending.ID)
+	if err != nil {
+		t.Fatalf("Reject(): %v", err)
+	}
+	if rejected.Status != shopping.PriceStatusRejected {
+		t.Errorf("Expected status %s, got %s", shopping.PriceStatusRejected, rejected.Status)
+	}
+	if len(db.confirmed) != 0 || len(db.contradicted) != 0 {
+		t.Errorf("Expected the verdicts of the rejected price reversed, got %v confirmed and %v contradicted",
+			db.confirmed, db.contradicted)
+	}
+}
+
+func TestPrices_Submit_verdicts(t *testing.T) {
+	now := time.Now()
+	db := &priceDB{history: map[string][]shopping.Price{"sb1": {
+		{ID: "1", Value: 200, SubmittedBy: "usr2", Created: now.Add(-time.Hour)},
+		{ID: "2", Value: 150, SubmittedBy: "usr2", Created: now.Add(-2 * time.Hour)},
+		{ID: "3", Value: 300, SubmittedBy: "usr3", Created: now.Add(-time.Hour)},
+		{ID: "4", Value: 205, SubmittedBy: "usr1", Created: now.Add(-time.Hour)},
+	}}}
+	p, err := shopping.NewPrices(db, &mocks.JWTEr{ExpValidateUsrID: "usr1"})
+	if err != nil {
+		t.Fatalf("shopping.NewPrices(): %v", err)
+	}
+	if _, err := p.Submit(context.TODO(), "a.jwt", newPrice(210, "KES", "sb1")); err != nil {
+		t.Fatalf("Submit(): %v", err)
+	}
+	// Only the latest price of each other user is judged.
+	if !reflect.DeepEqual(db.confirmed, []string{"1"}) {
+		t.Errorf("Expected confirmed [1], got %v", db.confirmed)
+	}
+	if !reflect.DeepEqual(db.contradicted, []string{"3"}) {
+		t.Errorf("Expected contradicted [3], got %v", db.contradicted)
+	}
+}
+
+func TestPrices_CurrentPrice(t *testing.T) {
+	db := &priceDB{
+		history: map[string][]shopping.Price{"sb1": {
+			{ID: "1", Value: 100, SubmittedBy: "troll"},
+			{ID: "2", Value: 200, SubmittedBy: "trusted"},
+			{ID: "3", Value: 110, SubmittedBy: "troll2"},
+		}},
+		trust: map[string]float64{"troll": 0.1, "troll2": 0.1, "trusted": 0.9},
+	}
+	p, err := shopping.NewPrices(db, &mocks.JWTEr{})
+	if err != nil {
+		t.Fatalf("shopping.NewPrices(): %v", err)
+	}
+	got, err := p.CurrentPrice(context.TODO(), "b1", "sb1", "")
+	if err != nil {
+		t.Fatalf("CurrentPrice(): %v", err)
+	}
+	if got.ID != "2" {
+		t.Errorf("Expected the trusted contributor's price 2, got %+v", got)
+	}
+}
+
+func priceHistory(usrID string, values ...float32) []shopping.Price {
+	ps := make([]shopping.Price, len(values))
+	for i, v := range values {
+		ps[i] = shopping.Price{ID: strconv.Itoa(i + 1), Value: v, SubmittedBy: usrID}
+	}
+	return ps
+}
+
+func newPrice(value float32, currency, storeBranchID string) shopping.Price {
+	return shopping.Price{
+		Value:         value,
+		Currency:      currency,
+		Brand:         shopping.Brand{ID: "b1"},
+		AtStoreBranch: shopping.StoreBranch{ID: storeBranchID},
+	}
+}
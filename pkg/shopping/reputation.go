@@ -0,0 +1,104 @@
+package shopping
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultAgreementTolerance is the maximum relative difference between two
+// observations of the same price for one to confirm the other.
+const DefaultAgreementTolerance = 0.1
+
+// Contributor is a user who submits shared prices.
+type Contributor struct {
+	UserID      string
+	Submissions int64
+	// Confirmations is the number of times the contributor's prices were
+	// confirmed by later observations of other users.
+	Confirmations int64
+	// Contradictions is the number of times the contributor's prices were
+	// contradicted by later observations of other users.
+	Contradictions int64
+	// Trust is the TrustScore of the contributor.
+	Trust float64
+}
+
+// TrustScore returns the likelihood, between 0 and 1, that a contributor's
+// next price is accurate given how often their prices were confirmed and
+// contradicted. It is the mean of a Beta(1, 1) prior updated with the
+// outcomes so contributors without history score 0.5 and a handful of
+// outcomes cannot push the score to either extreme.
+func TrustScore(confirmations, contradictions int64) float64 {
+	return float64(confirmations+1) / float64(confirmations+contradictions+2)
+}
+
+// Verdicts are the IDs of the prices an observation confirms and
+// contradicts.
+type Verdicts struct {
+	Confirmed    []string
+	Contradicted []string
+}
+
+// verdicts returns the IDs of the prices in prior that observation confirms
+// and contradicts. Only the latest price of each user other than the
+// observation's submitter is judged so that neither a user's own repeat
+// submissions nor a single observation can sway a reputation much.
+func verdicts(observation Price, prior []Price, tolerance float64) Verdicts {
+	var v Verdicts
+	judged := map[string]bool{observation.SubmittedBy: true}
+	latestFirst := make([]Price, len(prior))
+	copy(latestFirst, prior)
+	sort.SliceStable(latestFirst, func(i, j int) bool {
+		return latestFirst[i].Created.After(latestFirst[j].Created)
+	})
+	for _, p := range latestFirst {
+		if judged[p.SubmittedBy] || p.ID == observation.ID {
+			continue
+		}
+		judged[p.SubmittedBy] = true
+		if agree(float64(observation.Value), float64(p.Value), tolerance) {
+			v.Confirmed = append(v.Confirmed, p.ID)
+		} else {
+			v.Contradicted = append(v.Contradicted, p.ID)
+		}
+	}
+	return v
+}
+
+func agree(a, b, tolerance float64) bool {
+	larger := math.Max(math.Abs(a), math.Abs(b))
+	if larger == 0 {
+		return true
+	}
+	return math.Abs(a-b)/larger <= tolerance
+}
+
+// trustWeightedMedian returns the price in prices at the median of the
+// distribution of values weighted by the trust of each price's submitter.
+// Submitters missing from trust are weighted by TrustScore(0, 0).
+// prices must not be empty.
+func trustWeightedMedian(prices []Price, trust map[string]float64) Price {
+	sorted := make([]Price, len(prices))
+	copy(sorted, prices)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Value < sorted[j].Value
+	})
+	weights := make([]float64, len(sorted))
+	total := 0.0
+	for i, p := range sorted {
+		w, ok := trust[p.SubmittedBy]
+		if !ok {
+			w = TrustScore(0, 0)
+		}
+		weights[i] = w
+		total += w
+	}
+	cumulative := 0.0
+	for i, w := range weights {
+		cumulative += w
+		if cumulative >= total/2 {
+			return sorted[i]
+		}
+	}
+	return sorted[len(sorted)-1]
+}
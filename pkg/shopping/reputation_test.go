@@ -0,0 +1,28 @@
+package shopping_test
+
+import (
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/shopping"
+)
+
+func TestTrustScore(t *testing.T) {
+	tt := []struct {
+		name                          string
+		confirmations, contradictions int64
+		exp                           float64
+	}{
+		{name: "no history", exp: 0.5},
+		{name: "one confirmation", confirmations: 1, exp: 2.0 / 3},
+		{name: "one contradiction", contradictions: 1, exp: 1.0 / 3},
+		{name: "mostly confirmed", confirmations: 98, contradictions: 0, exp: 0.99},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := shopping.TrustScore(tc.confirmations, tc.contradictions)
+			if got != tc.exp {
+				t.Errorf("Expected %f, got %f", tc.exp, got)
+			}
+		})
+	}
+}
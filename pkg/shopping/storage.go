@@ -0,0 +1,45 @@
+package shopping
+
+import (
+	"context"
+	"time"
+
+	apiG "github.com/tomogoma/go-api-guard"
+	"github.com/tomogoma/shoppingms/pkg/api"
+	"github.com/tomogoma/shoppingms/pkg/apikeys"
+	"github.com/tomogoma/shoppingms/pkg/ratelimit"
+)
+
+// StoreDB persists stores and their branches.
+type StoreDB interface {
+	IsNotFoundError(error) bool
+	UpsertStore(name string) (*Store, error)
+	InsertStoreBranch(sb StoreBranch) (*StoreBranch, error)
+	UpdateStoreBranch(sb StoreBranch) error
+	StoreBranchByOSMID(osmID string) (*StoreBranch, error)
+	StoreBranchesWithin(storeID string, sw, ne Location) ([]StoreBranch, error)
+}
+
+// Storage is a storage backend for everything the service persists:
+// shopping lists and their items, the catalog, prices, stores, API keys
+// and rate limit buckets. Each method call is applied atomically; it
+// either succeeds in full or leaves the Storage unchanged.
+type Storage interface {
+	ShoppingListDB
+	CatalogDB
+	PriceDB
+	StoreDB
+	apikeys.DB
+	ratelimit.Store
+	// InsertAPIKey stores a new API key for userID, see
+	// go-api-guard's KeyStore.
+	InsertAPIKey(userID string, key []byte) (apiG.Key, error)
+	// UpdateAPIKeyPolicy replaces the scopes, origins and expiry of the
+	// API key with ID.
+	UpdateAPIKeyPolicy(ctx context.Context, ID string, scopes, origins []string, expires time.Time) (*api.Key, error)
+	// Ping checks that the Storage can be reached.
+	Ping(ctx context.Context) error
+	// Close releases the resources held by the Storage, which must not be
+	// used afterwards.
+	Close() error
+}
@@ -0,0 +1,8 @@
+package shopping
+
+import "go.opentelemetry.io/otel"
+
+// tracer starts spans around the operations of Manager, Prices and Catalog.
+// It uses the global TracerProvider so spans are dropped unless one is set
+// e.g. using tracing.NewProvider().
+var tracer = otel.Tracer("github.com/tomogoma/shoppingms/pkg/shopping")
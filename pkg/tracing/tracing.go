@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/pborman/uuid"
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultSampleRatio is the fraction of new traces sampled when
+// config.Tracing.SampleRatio is not set.
+const DefaultSampleRatio = 1.0
+
+// NewProvider creates a TracerProvider that exports spans to the OTLP/HTTP
+// collector at conf.OTLPEndpoint. Spans are sampled but not exported if no
+// endpoint is configured so that trace IDs are still available e.g. for logs.
+// Call Shutdown() on the returned provider to flush pending spans.
+func NewProvider(conf config.Tracing) (*sdktrace.TracerProvider, error) {
+	ratio := DefaultSampleRatio
+	if conf.SampleRatio != nil {
+		ratio = *conf.SampleRatio
+	}
+	if ratio < 0 || ratio > 1 {
+		return nil, errors.Newf("sample ratio must be within [0, 1], got %f", ratio)
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(config.CanonicalName()),
+			semconv.ServiceVersionKey.String(config.VersionFull),
+		)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	}
+	if conf.OTLPEndpoint != "" {
+		expOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(conf.OTLPEndpoint)}
+		if conf.Insecure {
+			expOpts = append(expOpts, otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(context.Background(), expOpts...)
+		if err != nil {
+			return nil, errors.Newf("new OTLP exporter: %v", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+// TransID returns the ID of the trace in ctx for use as the log transaction
+// ID so that logs and traces can be joined. A random ID is returned if ctx
+// carries no valid span context.
+func TransID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return uuid.New()
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,74 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/config"
+	"github.com/tomogoma/shoppingms/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewProvider(t *testing.T) {
+	ratio := func(r float64) *float64 { return &r }
+	tt := []struct {
+		name       string
+		conf       config.Tracing
+		expSampled bool
+		expErr     bool
+	}{
+		{name: "no exporter", conf: config.Tracing{}, expSampled: true},
+		{name: "with exporter", conf: config.Tracing{
+			OTLPEndpoint: "localhost:4318", Insecure: true, SampleRatio: ratio(1),
+		}, expSampled: true},
+		{name: "sampling off", conf: config.Tracing{SampleRatio: ratio(0)}},
+		{name: "ratio too big", conf: config.Tracing{SampleRatio: ratio(1.5)}, expErr: true},
+		{name: "negative ratio", conf: config.Tracing{SampleRatio: ratio(-0.1)}, expErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tp, err := tracing.NewProvider(tc.conf)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got error: %v", err)
+			}
+			defer tp.Shutdown(context.Background())
+			_, span := tp.Tracer("test").Start(context.Background(), "test")
+			defer span.End()
+			if !span.SpanContext().IsValid() {
+				t.Errorf("Expected a valid span context")
+			}
+			if sampled := span.SpanContext().IsSampled(); sampled != tc.expSampled {
+				t.Errorf("Expected sampled %t, got %t", tc.expSampled, sampled)
+			}
+		})
+	}
+}
+
+func TestTransID(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	if err != nil {
+		t.Fatalf("Error setting up: trace ID from hex: %v", err)
+	}
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	if err != nil {
+		t.Fatalf("Error setting up: span ID from hex: %v", err)
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	if got := tracing.TransID(ctx); got != traceID.String() {
+		t.Errorf("Expected trans ID %s, got %s", traceID, got)
+	}
+
+	first := tracing.TransID(context.Background())
+	second := tracing.TransID(context.Background())
+	if first == "" || first == second {
+		t.Errorf("Expected unique random trans IDs without a trace, got %q and %q",
+			first, second)
+	}
+}
@@ -0,0 +1,238 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tomogoma/go-typed-errors"
+)
+
+// TagName is the struct tag declaring the rules a field must satisfy
+// e.g.
+//
+//	Name string `json:"name" validate:"required,maxlen=256"`
+const TagName = "validate"
+
+// Rules that may be listed, comma separated, in a TagName struct tag.
+// Rules other than RuleRequired are not applied to empty strings. Pointer
+// fields are optional: no rule is applied to nil pointers, otherwise the
+// rules apply to the value pointed to.
+const (
+	// RuleRequired rejects empty (or blank) strings, empty slices and maps,
+	// and zero numbers.
+	RuleRequired = "required"
+	// RuleMin e.g. "min=0" rejects numbers less than its value.
+	RuleMin = "min"
+	// RuleMax e.g. "max=100" rejects numbers greater than its value.
+	RuleMax = "max"
+	// RuleGT e.g. "gt=0" rejects numbers not greater than its value.
+	RuleGT = "gt"
+	// RuleLen e.g. "len=3" rejects strings that are not exactly its value
+	// characters long.
+	RuleLen = "len"
+	// RuleMaxLen e.g. "maxlen=256" rejects strings longer than its value
+	// characters and slices with more than its value elements.
+	RuleMaxLen = "maxlen"
+	// RuleOneOf e.g. "oneof=PREPARATION SHOPPING" rejects strings that are
+	// not, ignoring case and surrounding space, one of its space separated
+	// values.
+	RuleOneOf = "oneof"
+)
+
+// Struct checks the fields of the struct (or pointer to struct) v against
+// the rules in their TagName tags, descending into nested structs. It
+// returns an *Error listing every violation, or nil if there is none.
+// Fields are named after their json tag, or their name with the first
+// letter in lower case if they have none; fields of nested structs are
+// prefixed by the name of the struct field followed by a dot unless the
+// struct is embedded.
+// Struct panics if v is not a struct and returns an internal error if a
+// tag is malformed.
+func Struct(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation.Struct() called with a %s", rv.Kind()))
+	}
+	e := &Error{}
+	if err := checkStruct(e, rv, ""); err != nil {
+		return err
+	}
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+func checkStruct(e *Error, rv reflect.Value, prefix string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue // unexported
+		}
+		fv := rv.Field(i)
+		tag, hasTag := sf.Tag.Lookup(TagName)
+		if !hasTag {
+			if fv.Kind() == reflect.Struct {
+				nestedPrefix := prefix
+				if !sf.Anonymous {
+					nestedPrefix = prefix + fieldName(sf) + "."
+				}
+				if err := checkStruct(e, fv, nestedPrefix); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		name := prefix + fieldName(sf)
+		for _, rule := range strings.Split(tag, ",") {
+			reason, err := check(fv, rule)
+			if err != nil {
+				return errors.Newf("invalid %s tag on %s.%s: %v",
+					TagName, rt.Name(), sf.Name, err)
+			}
+			if reason != "" {
+				e.Violations = append(e.Violations, Violation{Field: name, Reason: reason})
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// fieldName returns the name of sf as known to clients.
+func fieldName(sf reflect.StructField) string {
+	if name := strings.Split(sf.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	r, size := utf8.DecodeRuneInString(sf.Name)
+	return string(unicode.ToLower(r)) + sf.Name[size:]
+}
+
+// check returns the reason fv violates rule, or an empty string if it
+// does not.
+func check(fv reflect.Value, rule string) (string, error) {
+	name, arg := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+	name = strings.TrimSpace(name)
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return "", nil
+		}
+		fv = fv.Elem()
+	}
+	switch name {
+	case RuleRequired:
+		if isEmpty(fv) {
+			return "is required", nil
+		}
+		return "", nil
+	case RuleMin, RuleMax, RuleGT:
+		return checkNumber(fv, name, arg)
+	case RuleLen, RuleMaxLen:
+		return checkLen(fv, name, arg)
+	case RuleOneOf:
+		if fv.Kind() != reflect.String {
+			return "", errors.Newf("%s applied to a %s", name, fv.Kind())
+		}
+		return checkOneOf(fv.String(), strings.Fields(arg)), nil
+	default:
+		return "", errors.Newf("unknown rule %q", name)
+	}
+}
+
+func isEmpty(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(fv.String()) == ""
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return fv.Len() == 0
+	case reflect.Interface:
+		return fv.IsNil()
+	default:
+		return fv.IsZero()
+	}
+}
+
+func checkNumber(fv reflect.Value, rule, arg string) (string, error) {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return "", errors.Newf("%s: %v", rule, err)
+	}
+	var val float64
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		val = fv.Float()
+	default:
+		return "", errors.Newf("%s applied to a %s", rule, fv.Kind())
+	}
+	switch {
+	case rule == RuleMin && !(val >= limit):
+		return "must not be less than " + arg, nil
+	case rule == RuleMax && !(val <= limit):
+		return "must not be greater than " + arg, nil
+	case rule == RuleGT && !(val > limit):
+		return "must be greater than " + arg, nil
+	}
+	return "", nil
+}
+
+func checkLen(fv reflect.Value, rule, arg string) (string, error) {
+	limit, err := strconv.Atoi(arg)
+	if err != nil {
+		return "", errors.Newf("%s: %v", rule, err)
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		if fv.Len() == 0 {
+			return "", nil
+		}
+		n := utf8.RuneCountInString(fv.String())
+		if rule == RuleLen && n != limit {
+			return fmt.Sprintf("must be %d characters long", limit), nil
+		}
+		if rule == RuleMaxLen && n > limit {
+			return fmt.Sprintf("must not be longer than %d characters", limit), nil
+		}
+	case reflect.Slice, reflect.Array:
+		if rule == RuleLen && fv.Len() != limit {
+			return fmt.Sprintf("must have %d elements", limit), nil
+		}
+		if rule == RuleMaxLen && fv.Len() > limit {
+			return fmt.Sprintf("must not have more than %d elements", limit), nil
+		}
+	default:
+		return "", errors.Newf("%s applied to a %s", rule, fv.Kind())
+	}
+	return "", nil
+}
+
+func checkOneOf(val string, allowed []string) string {
+	if val == "" {
+		return ""
+	}
+	val = strings.TrimSpace(val)
+	for _, a := range allowed {
+		if strings.EqualFold(val, a) {
+			return ""
+		}
+	}
+	switch len(allowed) {
+	case 0:
+		return "must be empty"
+	case 1:
+		return "must be " + allowed[0]
+	}
+	return "must be one of " + strings.Join(allowed[:len(allowed)-1], ", ") +
+		" or " + allowed[len(allowed)-1]
+}
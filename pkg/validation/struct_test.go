@@ -0,0 +1,123 @@
+package validation_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tomogoma/shoppingms/pkg/validation"
+)
+
+type page struct {
+	Offset int64 `json:"offset" validate:"min=0"`
+	Count  int64 `json:"count" validate:"min=1,max=100"`
+}
+
+type listRequest struct {
+	JWT  string
+	Name *string  `json:"name" validate:"required,maxlen=5"`
+	Mode string   `json:"mode" validate:"oneof=PREPARATION SHOPPING"`
+	Cur  string   `validate:"len=3"`
+	IDs  []string `json:"IDs" validate:"required,maxlen=2"`
+	Rate float64  `json:"rate" validate:"gt=0"`
+	page
+	Next page `json:"next"`
+}
+
+func TestStruct(t *testing.T) {
+	name := func(n string) *string { return &n }
+	valid := func() listRequest {
+		return listRequest{
+			Name: name("list"),
+			Mode: "shopping",
+			Cur:  "KES",
+			IDs:  []string{"1"},
+			Rate: 0.5,
+			page: page{Count: 10},
+			Next: page{Offset: 10, Count: 10},
+		}
+	}
+	tt := []struct {
+		name          string
+		req           func() listRequest
+		expViolations []validation.Violation
+	}{
+		{name: "valid", req: valid},
+		{
+			name: "optional fields omitted",
+			req: func() listRequest {
+				r := valid()
+				r.Name, r.Mode, r.Cur = nil, "", ""
+				return r
+			},
+		},
+		{
+			name: "all invalid",
+			req: func() listRequest {
+				return listRequest{
+					Name: name("  "),
+					Mode: "cooking",
+					Cur:  "KSHS",
+					Rate: -1,
+					page: page{Offset: -1, Count: 101},
+					Next: page{},
+				}
+			},
+			expViolations: []validation.Violation{
+				{Field: "name", Reason: "is required"},
+				{Field: "mode", Reason: "must be one of PREPARATION or SHOPPING"},
+				{Field: "cur", Reason: "must be 3 characters long"},
+				{Field: "IDs", Reason: "is required"},
+				{Field: "rate", Reason: "must be greater than 0"},
+				{Field: "offset", Reason: "must not be less than 0"},
+				{Field: "count", Reason: "must not be greater than 100"},
+				{Field: "next.count", Reason: "must not be less than 1"},
+			},
+		},
+		{
+			name: "too long",
+			req: func() listRequest {
+				r := valid()
+				r.Name = name("groceries")
+				r.IDs = []string{"1", "2", "3"}
+				return r
+			},
+			expViolations: []validation.Violation{
+				{Field: "name", Reason: "must not be longer than 5 characters"},
+				{Field: "IDs", Reason: "must not have more than 2 elements"},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.req()
+			err := validation.Struct(&req)
+			if tc.expViolations == nil {
+				if err != nil {
+					t.Fatalf("Got error: %v", err)
+				}
+				return
+			}
+			vs, ok := validation.Violations(err)
+			if !ok {
+				t.Fatalf("Expected a validation error, got %v", err)
+			}
+			if !reflect.DeepEqual(vs, tc.expViolations) {
+				t.Errorf("Violations mismatch:\nExpect:\t%+v\nGot:\t%+v", tc.expViolations, vs)
+			}
+		})
+	}
+}
+
+func TestStruct_malformedTag(t *testing.T) {
+	req := struct {
+		Count int `validate:"max=many"`
+	}{}
+	err := validation.Struct(req)
+	if err == nil || !strings.Contains(err.Error(), "max") {
+		t.Fatalf("Expected a malformed tag error, got %v", err)
+	}
+	if _, ok := validation.Violations(err); ok {
+		t.Errorf("Expected an internal error, got validation error %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Violation describes why the value of a request field is invalid.
+type Violation struct {
+	// Field is the name of the field as sent by clients e.g. "count".
+	Field  string
+	Reason string
+}
+
+// Error is a client error listing every invalid field of a request.
+type Error struct {
+	Violations []Violation
+}
+
+// NewError returns an *Error with a single violation of field.
+func NewError(field, reason string) error {
+	return &Error{Violations: []Violation{{Field: field, Reason: reason}}}
+}
+
+// NewErrorf is NewError() with the reason formatted as in fmt.Sprintf().
+func NewErrorf(field, format string, args ...interface{}) error {
+	return NewError(field, fmt.Sprintf(format, args...))
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = "invalid " + v.Field + ": " + v.Reason
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Violations returns the violations listed by err and true if err is an
+// *Error, otherwise it returns nil and false.
+func Violations(err error) ([]Violation, bool) {
+	e, ok := err.(*Error)
+	if !ok {
+		return nil, false
+	}
+	return e.Violations, true
+}
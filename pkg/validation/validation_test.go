@@ -0,0 +1,54 @@
+package validation_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tomogoma/go-typed-errors"
+	"github.com/tomogoma/shoppingms/pkg/validation"
+)
+
+func TestViolations(t *testing.T) {
+	tt := []struct {
+		name          string
+		err           error
+		expViolations []validation.Violation
+		expOK         bool
+	}{
+		{
+			name:          "validation error",
+			err:           validation.NewErrorf("count", "must be at most %d", 100),
+			expViolations: []validation.Violation{{Field: "count", Reason: "must be at most 100"}},
+			expOK:         true,
+		},
+		{
+			name: "other error",
+			err:  errors.NewClient("bad request"),
+		},
+		{
+			name: "nil error",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			vs, ok := validation.Violations(tc.err)
+			if ok != tc.expOK {
+				t.Fatalf("Expected ok %t, got %t", tc.expOK, ok)
+			}
+			if !reflect.DeepEqual(vs, tc.expViolations) {
+				t.Errorf("Expected violations %+v, got %+v", tc.expViolations, vs)
+			}
+		})
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	err := &validation.Error{Violations: []validation.Violation{
+		{Field: "name", Reason: "is required"},
+		{Field: "count", Reason: "must be at most 100"},
+	}}
+	exp := "invalid name: is required; invalid count: must be at most 100"
+	if got := err.Error(); got != exp {
+		t.Errorf("Expected %q, got %q", exp, got)
+	}
+}